@@ -0,0 +1,272 @@
+// Package catalog exports the catalog of a product to a portable archive and
+// imports such an archive into the same or another product, e.g. to promote
+// content from staging to production.
+//
+// An archive is a zip file. Its first entry, manifest.ndjson, holds one JSON
+// record per line: a header naming the format version and the source product,
+// then the custom field definitions, tags, stored files and resources. The
+// stored files follow under files/, by object name. Resources refer to their
+// stored files by archive path rather than URL, so an archive does not depend
+// on the bucket it was exported from. Links to external files are kept as
+// they are.
+//
+// Copy runs a single resource through the same import to copy it to another
+// product, its files being copied within the bucket rather than archived.
+package catalog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"learninghub/constants"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+// Entries of an archive
+const (
+	ManifestName = "manifest.ndjson"
+	FilesDir     = "files/" // Stored files, by object name
+)
+
+// Kinds of manifest records
+const (
+	KindHeader   = "header"
+	KindField    = "field"
+	KindTag      = "tag"
+	KindFile     = "file"
+	KindResource = "resource"
+)
+
+// ErrInvalidArchive reports an archive that cannot be imported
+var ErrInvalidArchive = errors.New("invalid catalog archive")
+
+// Strategies lists how an import may treat resources whose ID exists in the
+// target product
+var Strategies = []string{constants.ImportStrategySkip, constants.ImportStrategyOverwrite, constants.ImportStrategyDuplicate}
+
+// Record is a line of the manifest. Kind tells which of the other fields is
+// set.
+type Record struct {
+	Kind     string                  `json:"kind"`
+	Header   *Header                 `json:"header,omitempty"`
+	Field    *models.FieldDefinition `json:"field,omitempty"`
+	Tag      *models.Tag             `json:"tag,omitempty"`
+	File     *File                   `json:"file,omitempty"`
+	Resource *Resource               `json:"resource,omitempty"`
+}
+
+// Header describes an archive
+type Header struct {
+	Version    int       `json:"version"` // constants.CatalogFormatVersion
+	Product    string    `json:"product"` // Exported product, the prefix of every object name
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// File is a stored file of an archive
+type File struct {
+	Path        string `json:"path"` // FilesDir followed by the object name
+	Size        int64  `json:"size"` // bytes
+	ContentType string `json:"contentType"`
+}
+
+// Resource is a resource of an archive. Unlike in API responses, the storage
+// state of its background jobs' outputs is kept. URLs of stored files are
+// archive paths.
+type Resource struct {
+	ID                string            `json:"id"`
+	Title             string            `json:"title"`
+	Description       string            `json:"description"`
+	Type              string            `json:"type"`
+	URL               string            `json:"url"`
+	ThumbnailURL      string            `json:"thumbnailUrl,omitempty"`
+	Thumbnails        map[string]string `json:"thumbnails,omitempty"`
+	Transcode         *JobOutput        `json:"transcode,omitempty"`
+	Conversion        *JobOutput        `json:"conversion,omitempty"`
+	Embed             *models.Embed     `json:"embed,omitempty"`
+	Metadata          *models.Metadata  `json:"metadata,omitempty"`
+	ThumbnailMetadata *models.Metadata  `json:"thumbnailMetadata,omitempty"`
+	Tags              []string          `json:"tags"`
+	CustomFields      map[string]any    `json:"customFields,omitempty"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	UpdatedAt         time.Time         `json:"updatedAt"`
+}
+
+// JobOutput is the state of a transcoding or a conversion
+type JobOutput struct {
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+	Prefix    string    `json:"prefix,omitempty"` // Archive path of the outputs, ending with a slash
+	URL       string    `json:"url,omitempty"`    // Converted PDF
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Manifest is the content of an archive's manifest
+type Manifest struct {
+	Header    Header
+	Fields    []models.FieldDefinition
+	Tags      []models.Tag
+	Files     map[string]File // By path
+	Resources []Resource
+}
+
+// writeManifest writes the records of a manifest, files in path order
+func writeManifest(w io.Writer, manifest *Manifest) error {
+	encoder := json.NewEncoder(w)
+
+	records := []Record{{Kind: KindHeader, Header: &manifest.Header}}
+	for i := range manifest.Fields {
+		records = append(records, Record{Kind: KindField, Field: &manifest.Fields[i]})
+	}
+	for i := range manifest.Tags {
+		records = append(records, Record{Kind: KindTag, Tag: &manifest.Tags[i]})
+	}
+	for _, filePath := range slices.Sorted(maps.Keys(manifest.Files)) {
+		file := manifest.Files[filePath]
+		records = append(records, Record{Kind: KindFile, File: &file})
+	}
+	for i := range manifest.Resources {
+		records = append(records, Record{Kind: KindResource, Resource: &manifest.Resources[i]})
+	}
+
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+// readManifest reads a manifest and checks its header. Errors in the manifest
+// wrap ErrInvalidArchive.
+func readManifest(r io.Reader) (*Manifest, error) {
+	invalid := func(line int, format string, args ...any) error {
+		return fmt.Errorf("%w: line %d of %s: %s", ErrInvalidArchive, line, ManifestName, fmt.Sprintf(format, args...))
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), constants.MaxCatalogManifestRecord)
+
+	manifest := &Manifest{Files: map[string]File{}}
+	line, headerRead := 0, false
+	ids := map[string]bool{}
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, invalid(line, "%v", err)
+		}
+
+		if !headerRead {
+			if record.Kind != KindHeader || record.Header == nil {
+				return nil, invalid(line, "the manifest must start with a header")
+			}
+			if record.Header.Version != constants.CatalogFormatVersion {
+				return nil, invalid(line, "unsupported format version %d, expected %d", record.Header.Version, constants.CatalogFormatVersion)
+			}
+			if !validName(record.Header.Product) {
+				return nil, invalid(line, "invalid product %q", record.Header.Product)
+			}
+			manifest.Header = *record.Header
+			headerRead = true
+			continue
+		}
+
+		switch {
+		case record.Kind == KindField && record.Field != nil:
+			manifest.Fields = append(manifest.Fields, *record.Field)
+		case record.Kind == KindTag && record.Tag != nil:
+			manifest.Tags = append(manifest.Tags, *record.Tag)
+		case record.Kind == KindFile && record.File != nil:
+			if _, ok := objectOf(record.File.Path); !ok {
+				return nil, invalid(line, "file path %q is not under %s", record.File.Path, FilesDir)
+			}
+			manifest.Files[record.File.Path] = *record.File
+		case record.Kind == KindResource && record.Resource != nil:
+			if !validName(record.Resource.ID) {
+				return nil, invalid(line, "invalid resource ID %q", record.Resource.ID)
+			}
+			if ids[record.Resource.ID] {
+				return nil, invalid(line, "resource %s is listed twice", record.Resource.ID)
+			}
+			ids[record.Resource.ID] = true
+			manifest.Resources = append(manifest.Resources, *record.Resource)
+		default:
+			return nil, invalid(line, "unexpected %q record", record.Kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %v", ErrInvalidArchive, ManifestName, err)
+	}
+	if !headerRead {
+		return nil, fmt.Errorf("%w: %s is empty", ErrInvalidArchive, ManifestName)
+	}
+
+	return manifest, nil
+}
+
+// validName reports whether s can name a product or a document: not empty,
+// no slash, not a relative segment
+func validName(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.Contains(s, "/") && len(s) <= 1500
+}
+
+// filePath returns the archive path of a stored object
+func filePath(objectName string) string {
+	return FilesDir + objectName
+}
+
+// objectOf returns the object an archive path names. It returns false for
+// other values, e.g. links to external files.
+func objectOf(value string) (string, bool) {
+	objectName, ok := strings.CutPrefix(value, FilesDir)
+	return objectName, ok && objectName != ""
+}
+
+// relocation places the stored files of an archived resource in the target
+// product
+type relocation struct {
+	from, to  string // Source and target products
+	id, newID string // Resource IDs, which differ for duplicates
+}
+
+// object returns the target object of a source object.
+//
+// Content-addressed uploads are shared, whichever resource they back. A
+// duplicate gets its own copy of other files: outputs of background jobs are
+// stored under the resource ID, which is replaced, and uploads get the new ID
+// as a prefix of their name. Objects outside the source product, or with
+// relative segments, are rejected.
+func (r relocation) object(objectName string) (string, error) {
+	rest, ok := strings.CutPrefix(objectName, r.from+"/")
+	segments := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if !ok || slices.ContainsFunc(segments, func(s string) bool { return s == "" || s == "." || s == ".." }) {
+		return "", fmt.Errorf("%w: file %s is not an object of product %s", ErrInvalidArchive, objectName, r.from)
+	}
+
+	target := r.to + "/" + rest
+	if r.newID == r.id {
+		return target, nil
+	}
+	if _, _, _, content := utils.ParseContentObject(target); content {
+		return target, nil
+	}
+	if segment := "/" + r.id + "/"; strings.Contains(target, segment) {
+		return strings.Replace(target, segment, "/"+r.newID+"/", 1), nil
+	}
+	dir, name := path.Split(target)
+	return dir + r.newID + "_" + name, nil
+}
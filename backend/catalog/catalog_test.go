@@ -0,0 +1,394 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+const testBucket = "test-bucket.firebasestorage.app"
+
+func storageURL(objectName string) string {
+	return "https://firebasestorage.googleapis.com/v0/b/" + testBucket + "/o/" + url.PathEscape(objectName) + "?alt=media"
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	exportedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	manifest := &Manifest{
+		Header: Header{Version: constants.CatalogFormatVersion, Product: "ecomm", ExportedAt: exportedAt},
+		Fields: []models.FieldDefinition{{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"beginner", "advanced"}}},
+		Tags:   []models.Tag{{Name: "onboarding", UsageCount: 2}},
+		Files: map[string]File{
+			"files/ecomm/pdf/b.pdf": {Path: "files/ecomm/pdf/b.pdf", Size: 20, ContentType: "application/pdf"},
+			"files/ecomm/pdf/a.pdf": {Path: "files/ecomm/pdf/a.pdf", Size: 10, ContentType: "application/pdf"},
+		},
+		Resources: []Resource{{
+			ID:           "r1",
+			Title:        "Intro",
+			Description:  "Getting started",
+			Type:         constants.ResourceTypePDF,
+			URL:          "files/ecomm/pdf/a.pdf",
+			Tags:         []string{"onboarding"},
+			CustomFields: map[string]any{"difficulty": "beginner"},
+			CreatedAt:    exportedAt,
+			UpdatedAt:    exportedAt,
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeManifest(&buf, manifest))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.Contains(t, lines[0], `"kind":"header"`)
+	assert.Contains(t, lines[3], `"path":"files/ecomm/pdf/a.pdf"`, "files are written in path order")
+
+	read, err := readManifest(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, read)
+}
+
+func TestReadManifestRejectsInvalidManifests(t *testing.T) {
+	header := `{"kind":"header","header":{"version":1,"product":"ecomm"}}` + "\n"
+
+	tests := []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{name: "empty", manifest: "", expected: "is empty"},
+		{name: "no header", manifest: `{"kind":"tag","tag":{"name":"a"}}`, expected: "must start with a header"},
+		{name: "unsupported version", manifest: `{"kind":"header","header":{"version":2,"product":"ecomm"}}`, expected: "unsupported format version 2"},
+		{name: "invalid product", manifest: `{"kind":"header","header":{"version":1,"product":"../ecomm"}}`, expected: "invalid product"},
+		{name: "not JSON", manifest: header + "{", expected: "line 2"},
+		{name: "unknown kind", manifest: header + `{"kind":"collection"}`, expected: `unexpected "collection" record`},
+		{name: "file outside files", manifest: header + `{"kind":"file","file":{"path":"manifest.ndjson"}}`, expected: "is not under files/"},
+		{name: "invalid resource ID", manifest: header + `{"kind":"resource","resource":{"id":"a/b"}}`, expected: "invalid resource ID"},
+		{name: "duplicate resource", manifest: header + `{"kind":"resource","resource":{"id":"a"}}` + "\n" + `{"kind":"resource","resource":{"id":"a"}}`, expected: "listed twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readManifest(strings.NewReader(tt.manifest))
+			require.ErrorIs(t, err, ErrInvalidArchive)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestRelocationObject(t *testing.T) {
+	sha := strings.Repeat("a", 64)
+
+	tests := []struct {
+		name       string
+		relocation relocation
+		object     string
+		expected   string
+		invalid    bool
+	}{
+		{name: "same product", relocation: relocation{from: "ecomm", to: "ecomm", id: "r1", newID: "r1"}, object: "ecomm/pdf/1759318704_notes.pdf", expected: "ecomm/pdf/1759318704_notes.pdf"},
+		{name: "other product", relocation: relocation{from: "staging", to: "ecomm", id: "r1", newID: "r1"}, object: "staging/image/" + sha + ".png", expected: "ecomm/image/" + sha + ".png"},
+		{name: "duplicate shares content", relocation: relocation{from: "ecomm", to: "ecomm", id: "r1", newID: "r2"}, object: "ecomm/image/" + sha + "_320w.webp", expected: "ecomm/image/" + sha + "_320w.webp"},
+		{name: "duplicate job output", relocation: relocation{from: "ecomm", to: "ecomm", id: "r1", newID: "r2"}, object: "ecomm/hls/r1/job1/master.m3u8", expected: "ecomm/hls/r2/job1/master.m3u8"},
+		{name: "duplicate output prefix", relocation: relocation{from: "ecomm", to: "ecomm", id: "r1", newID: "r2"}, object: "ecomm/converted/r1/job1/", expected: "ecomm/converted/r2/job1/"},
+		{name: "duplicate upload", relocation: relocation{from: "ecomm", to: "ecomm", id: "r1", newID: "r2"}, object: "ecomm/pdf/1759318704_notes.pdf", expected: "ecomm/pdf/r2_1759318704_notes.pdf"},
+		{name: "other source product", relocation: relocation{from: "staging", to: "ecomm", id: "r1", newID: "r1"}, object: "ecomm/pdf/notes.pdf", invalid: true},
+		{name: "relative segment", relocation: relocation{from: "ecomm", to: "ecomm", id: "r1", newID: "r1"}, object: "ecomm/../other/notes.pdf", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := tt.relocation.object(tt.object)
+			if tt.invalid {
+				assert.ErrorIs(t, err, ErrInvalidArchive)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
+
+func TestExportResource(t *testing.T) {
+	originalBucket := firebase.StorageBucket
+	firebase.StorageBucket = testBucket
+	defer func() {
+		firebase.StorageBucket = originalBucket
+	}()
+
+	sha := strings.Repeat("a", 64)
+	record, objects, prefixes := exportResource(models.Resource{
+		ID:           "r1",
+		Title:        "Talk",
+		Type:         constants.ResourceTypeVideo,
+		URL:          storageURL("ecomm/video/" + sha + ".mp4"),
+		ThumbnailURL: "https://example.com/thumbnail.png",
+		Thumbnails:   map[string]string{"320.webp": storageURL("ecomm/image/" + sha + "_320w.webp")},
+		Transcode: &models.Transcode{
+			Status: constants.TranscodeStatusReady,
+			Source: storageURL("ecomm/video/" + sha + ".mp4"),
+			Prefix: "ecomm/hls/r1/job1",
+		},
+	})
+
+	assert.Equal(t, "files/ecomm/video/"+sha+".mp4", record.URL)
+	assert.Equal(t, "https://example.com/thumbnail.png", record.ThumbnailURL, "external links are kept")
+	assert.Equal(t, map[string]string{"320.webp": "files/ecomm/image/" + sha + "_320w.webp"}, record.Thumbnails)
+	require.NotNil(t, record.Transcode)
+	assert.Equal(t, "files/ecomm/video/"+sha+".mp4", record.Transcode.Source)
+	assert.Equal(t, "files/ecomm/hls/r1/job1/", record.Transcode.Prefix)
+
+	assert.ElementsMatch(t, []string{"ecomm/video/" + sha + ".mp4", "ecomm/image/" + sha + "_320w.webp", "ecomm/video/" + sha + ".mp4"}, objects)
+	assert.Equal(t, []string{"ecomm/hls/r1/job1/"}, prefixes)
+}
+
+func TestResolveFileTypes(t *testing.T) {
+	originalBucket, originalConfig := firebase.StorageBucket, config.AppConfig
+	firebase.StorageBucket, config.AppConfig = testBucket, &config.EnvConfig{ENV_MODE: constants.EnvModeProd}
+	defer func() {
+		firebase.StorageBucket, config.AppConfig = originalBucket, originalConfig
+	}()
+
+	sha := strings.Repeat("a", 64)
+	im := &importer{manifest: &Manifest{Files: map[string]File{}}}
+	for _, path := range []string{
+		"files/staging/slides/" + sha + ".pptx",
+		"files/staging/image/" + sha + ".png",
+		"files/staging/image/" + sha + "_320w.webp",
+		"files/staging/converted/r1/job1/slides.pdf",
+		"files/staging/hls/r2/job2/master.m3u8",
+		"files/staging/hls/r2/job2/720p/segment_000.ts",
+	} {
+		im.manifest.Files[path] = File{Path: path}
+	}
+
+	_, files, _, err := im.resolve(Resource{
+		ID:           "r1",
+		Type:         constants.ResourceTypeSlides,
+		URL:          "files/staging/slides/" + sha + ".pptx",
+		ThumbnailURL: "files/staging/image/" + sha + ".png",
+		Thumbnails:   map[string]string{"320.webp": "files/staging/image/" + sha + "_320w.webp"},
+		Conversion: &JobOutput{
+			Status: constants.ConversionStatusReady,
+			Source: "files/staging/slides/" + sha + ".pptx",
+			Prefix: "files/staging/converted/r1/job1/",
+			URL:    "files/staging/converted/r1/job1/slides.pdf",
+		},
+	}, relocation{from: "staging", to: "ecomm", id: "r1", newID: "r1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]archivedFile{
+		"files/staging/slides/" + sha + ".pptx":      {object: "ecomm/slides/" + sha + ".pptx", fileType: constants.ResourceTypeSlides},
+		"files/staging/image/" + sha + ".png":        {object: "ecomm/image/" + sha + ".png", fileType: constants.ResourceTypeImage},
+		"files/staging/image/" + sha + "_320w.webp":  {object: "ecomm/image/" + sha + "_320w.webp", fileType: constants.ResourceTypeImage},
+		"files/staging/converted/r1/job1/slides.pdf": {object: "ecomm/converted/r1/job1/slides.pdf", fileType: constants.ResourceTypePDF},
+	}, files)
+
+	_, files, _, err = im.resolve(Resource{
+		ID:   "r2",
+		Type: constants.ResourceTypeVideo,
+		URL:  "https://example.com/talk.mp4",
+		Transcode: &JobOutput{
+			Status: constants.TranscodeStatusReady,
+			Source: "https://example.com/talk.mp4",
+			Prefix: "files/staging/hls/r2/job2/",
+		},
+	}, relocation{from: "staging", to: "ecomm", id: "r2", newID: "r2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]archivedFile{
+		"files/staging/hls/r2/job2/master.m3u8":         {object: "ecomm/hls/r2/job2/master.m3u8", fileType: constants.FileTypeJobOutput},
+		"files/staging/hls/r2/job2/720p/segment_000.ts": {object: "ecomm/hls/r2/job2/720p/segment_000.ts", fileType: constants.FileTypeJobOutput},
+	}, files)
+}
+
+func TestActionFor(t *testing.T) {
+	tests := []struct {
+		strategy string
+		exists   bool
+		expected string
+	}{
+		{constants.ImportStrategySkip, false, ActionCreated},
+		{constants.ImportStrategySkip, true, ActionSkipped},
+		{constants.ImportStrategyOverwrite, false, ActionCreated},
+		{constants.ImportStrategyOverwrite, true, ActionOverwritten},
+		{constants.ImportStrategyDuplicate, false, ActionCreated},
+		{constants.ImportStrategyDuplicate, true, ActionDuplicated},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, actionFor(tt.strategy, tt.exists), "%s, exists: %v", tt.strategy, tt.exists)
+	}
+}
+
+func TestCheckResource(t *testing.T) {
+	definitions := []models.FieldDefinition{{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"beginner"}, Required: true}}
+
+	resource := models.Resource{Title: "Intro", Description: "Getting started", Type: constants.ResourceTypeArticle, Tags: []string{" Basics ", "basics"}, CustomFields: map[string]any{"difficulty": "beginner"}}
+	require.NoError(t, checkResource(&resource, definitions))
+	assert.Equal(t, []string{"basics"}, resource.Tags)
+
+	unsupported := models.Resource{Title: "Intro", Description: "Getting started", Type: "podcast"}
+	assert.ErrorContains(t, checkResource(&unsupported, definitions), `type "podcast" is not supported`)
+
+	invalid := models.Resource{Description: "Getting started", Type: constants.ResourceTypeArticle, URL: "ftp://example.com/a"}
+	assert.ErrorContains(t, checkResource(&invalid, definitions), "title is required; url must be an http or https URL")
+
+	missingField := models.Resource{Title: "Intro", Description: "Getting started", Type: constants.ResourceTypeArticle}
+	assert.ErrorContains(t, checkResource(&missingField, definitions), "custom field 'difficulty' is required")
+}
+
+func TestObsoleteFiles(t *testing.T) {
+	previous := models.Resource{
+		URL:          storageURL("ecomm/pdf/old.pdf"),
+		ThumbnailURL: storageURL("ecomm/image/cover.png"),
+		Thumbnails:   map[string]string{"320.webp": storageURL("ecomm/image/cover_320w.webp")},
+		Conversion:   &models.Conversion{Prefix: "ecomm/converted/r1/job1"},
+	}
+	resource := models.Resource{
+		URL:          storageURL("ecomm/pdf/new.pdf"),
+		ThumbnailURL: storageURL("ecomm/image/cover.png"),
+		Conversion:   &models.Conversion{Prefix: "ecomm/converted/r1/job2"},
+	}
+
+	fileURLs, prefixes := obsoleteFiles(previous, resource)
+	assert.Equal(t, []string{storageURL("ecomm/pdf/old.pdf"), storageURL("ecomm/image/cover_320w.webp")}, fileURLs)
+	assert.Equal(t, []string{"ecomm/converted/r1/job1"}, prefixes)
+}
+
+// memoryRegistry is an objectRegistry keeping registrations in memory
+type memoryRegistry map[string]*models.StoredObject
+
+func (r memoryRegistry) Acquire(_ context.Context, _, sha256 string) (*models.StoredObject, error) {
+	object, registered := r[sha256]
+	if !registered || object.Refs <= 0 {
+		return nil, nil
+	}
+	object.Refs++
+	acquired := *object
+	return &acquired, nil
+}
+
+func (r memoryRegistry) Register(_ context.Context, _, sha256 string, object models.StoredObject) (models.StoredObject, bool, error) {
+	object.Refs = 1
+	r[sha256] = &object
+	return object, false, nil
+}
+
+func (r memoryRegistry) Release(_ context.Context, _, sha256, releaseID string) (*models.StoredObject, error) {
+	object, registered := r[sha256]
+	if !registered || !object.Release(releaseID, time.Now()) || object.Refs > 0 {
+		return nil, nil
+	}
+	delete(r, sha256)
+	return object, nil
+}
+
+func TestOverwriteKeepsReferences(t *testing.T) {
+	originalBucket := firebase.StorageBucket
+	firebase.StorageBucket = testBucket
+	defer func() {
+		firebase.StorageBucket = originalBucket
+	}()
+
+	video, cover := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	videoObject, coverObject := "ecomm/video/"+video+".mp4", "ecomm/image/"+cover+".png"
+	// Both are uploads of another resource of the product
+	registry := memoryRegistry{
+		video: {Object: videoObject, Refs: 1},
+		cover: {Object: coverObject, Refs: 1},
+	}
+	im := &importer{product: "ecomm", options: ImportOptions{Strategy: constants.ImportStrategyOverwrite}, objectService: registry, present: map[string]bool{}}
+
+	resource := models.Resource{URL: storageURL(videoObject), ThumbnailURL: storageURL(coverObject)}
+	files := map[string]archivedFile{
+		"files/staging/video/" + video + ".mp4": {object: videoObject, fileType: constants.ResourceTypeVideo},
+		"files/staging/image/" + cover + ".png": {object: coverObject, fileType: constants.ResourceTypeImage},
+	}
+
+	// importArchive stores the files of the resource over previous, and
+	// releases the references of previous that are obsolete like
+	// utils.DeleteObjects does
+	importArchive := func(previous *models.Resource) {
+		acquired, err := im.storeFiles(context.Background(), resource, previous, files)
+		require.NoError(t, err)
+		if previous == nil {
+			assert.Len(t, acquired, 2)
+			return
+		}
+		assert.Empty(t, acquired, "references of the overwritten version are kept")
+
+		fileURLs, _ := obsoleteFiles(*previous, resource)
+		for _, fileURL := range fileURLs {
+			objectName, _ := contentObject(fileURL)
+			_, sha256, _, _ := utils.ParseContentObject(objectName)
+			_, err := registry.Release(context.Background(), "ecomm", sha256, "")
+			require.NoError(t, err)
+		}
+	}
+
+	importArchive(nil)
+	for range 2 {
+		previous := resource
+		importArchive(&previous)
+		assert.Equal(t, 2, registry[video].Refs, "one reference of the resource and one of the upload")
+		assert.Equal(t, 2, registry[cover].Refs)
+	}
+
+	// A new version on another video releases the previous one
+	previous := resource
+	resource.URL = "https://example.com/video.mp4"
+	fileURLs, _ := obsoleteFiles(previous, resource)
+	assert.Equal(t, []string{storageURL(videoObject)}, fileURLs)
+}
+
+func TestCheckPolicy(t *testing.T) {
+	noLinks := false
+	policy := config.UploadPolicy{
+		MaxFileSizeMB:     map[string]int64{constants.ResourceTypePDF: 1},
+		AllowedMIMETypes:  map[string][]string{constants.ResourceTypeImage: {"image/webp"}},
+		AllowExternalURLs: &noLinks,
+	}
+	files := map[string]File{
+		"files/ecomm/pdf/small.pdf": {Path: "files/ecomm/pdf/small.pdf", Size: 1 << 10, ContentType: "application/pdf"},
+		"files/ecomm/pdf/large.pdf": {Path: "files/ecomm/pdf/large.pdf", Size: 2 << 20, ContentType: "application/pdf"},
+		"files/ecomm/image/a.webp":  {Path: "files/ecomm/image/a.webp", Size: 1 << 10, ContentType: "image/webp"},
+		"files/ecomm/image/b.png":   {Path: "files/ecomm/image/b.png", Size: 1 << 10, ContentType: "image/png"},
+	}
+
+	tests := []struct {
+		name     string
+		record   Resource
+		expected string
+	}{
+		{name: "allowed", record: Resource{Type: constants.ResourceTypePDF, URL: "files/ecomm/pdf/small.pdf", ThumbnailURL: "files/ecomm/image/a.webp"}},
+		{name: "article link", record: Resource{Type: constants.ResourceTypeArticle, URL: "https://example.com/article"}},
+		{name: "too large", record: Resource{Type: constants.ResourceTypePDF, URL: "files/ecomm/pdf/large.pdf"}, expected: "its file is larger than the 1 MB the product allows for pdf files"},
+		{name: "MIME type", record: Resource{Type: constants.ResourceTypePDF, URL: "files/ecomm/pdf/small.pdf", ThumbnailURL: "files/ecomm/image/b.png"}, expected: "its thumbnail is a image/png file"},
+		{name: "external file", record: Resource{Type: constants.ResourceTypePDF, URL: "https://example.com/notes.pdf"}, expected: "its file links a file stored elsewhere"},
+		{name: "external thumbnail", record: Resource{Type: constants.ResourceTypeArticle, URL: "https://example.com/article", ThumbnailURL: "https://example.com/cover.png"}, expected: "its thumbnail links a file stored elsewhere"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPolicy(policy, tt.record, files)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	assert.NoError(t, checkPolicy(config.UploadPolicy{}, Resource{Type: constants.ResourceTypePDF, URL: "https://example.com/notes.pdf"}, files), "links are allowed by default")
+}
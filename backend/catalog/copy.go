@@ -0,0 +1,173 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+// Errors of copies between products
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrRejected         = errors.New("resource rejected by the target product")
+)
+
+// CopyReport reports the copy of a resource to another product
+type CopyReport struct {
+	Source   string   `json:"source"` // Product copied from
+	SourceID string   `json:"sourceId"`
+	Product  string   `json:"product"` // Product copied to
+	ID       string   `json:"id"`      // Of the copy, the source ID unless the target product uses it
+	Files    int      `json:"files"`   // Stored files copied, the target product may share some already
+	Bytes    int64    `json:"bytes"`
+	Warnings []string `json:"warnings,omitempty"` // What was left out, e.g. unfinished job state
+}
+
+// Copy copies a resource to another product, with its stored files, which
+// are copied within the bucket to the target product's prefix like an
+// imported archive's: content-addressed uploads the target product stores
+// already are shared. The copy keeps the ID of the resource unless the
+// target product uses it, and adds to the target product's tag usage counts.
+//
+// The copy is checked like an imported resource, against the target
+// product's custom fields, and against its upload policy: file sizes, MIME
+// types and links to external files. A resource the target product does not
+// accept fails with an error wrapping ErrRejected; a missing one with
+// ErrResourceNotFound.
+func Copy(ctx context.Context, from, id, to string) (*CopyReport, error) {
+	if from == to {
+		return nil, fmt.Errorf("%w: it is in product %s already", ErrRejected, to)
+	}
+
+	database := db.New()
+	resourceService := db.NewResourceService(database)
+
+	doc, err := resourceService.GetByID(ctx, from, id)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource: %w", err)
+	}
+	var resource models.Resource
+	if err := doc.DataTo(&resource); err != nil {
+		return nil, fmt.Errorf("failed to read resource: %w", err)
+	}
+	resource.ID = id
+
+	// The resource as a one-resource archive whose files are in the bucket
+	record, objects, prefixes := exportResource(resource)
+	manifest := &Manifest{
+		Header:    Header{Version: constants.CatalogFormatVersion, Product: from},
+		Files:     map[string]File{},
+		Resources: []Resource{record},
+	}
+	bucket := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	missing, err := listFiles(ctx, bucket, slices.Compact(slices.Sorted(slices.Values(objects))), prefixes, manifest.Files)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: its stored files %s no longer exist", ErrRejected, strings.Join(missing, ", "))
+	}
+
+	definitions, err := db.NewFieldService(database).List(ctx, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom fields: %w", err)
+	}
+
+	im := &importer{
+		product:     to,
+		options:     ImportOptions{Strategy: constants.ImportStrategyDuplicate},
+		manifest:    manifest,
+		definitions: definitions,
+		report:      &ImportReport{Product: to, Source: from, Strategy: constants.ImportStrategyDuplicate},
+
+		resourceService: resourceService,
+		objectService:   db.NewObjectService(database),
+		bucket:          bucket,
+
+		present:   map[string]bool{},
+		tagDeltas: map[string]int{},
+	}
+
+	// Check the copy before writing anything
+	checked, _, _, err := im.resolve(record, relocation{from: from, to: to, id: id, newID: id})
+	if err == nil {
+		err = checkResource(&checked, definitions)
+	}
+	if err == nil {
+		err = checkPolicy(config.UploadPolicyFor(to), record, manifest.Files)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	existing, err := resourceService.GetByID(ctx, to, id)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, fmt.Errorf("failed to read resources of %s: %w", to, err)
+	}
+	// The duplicate strategy only needs to know whether the ID is taken
+	var taken *models.Resource
+	if err == nil && existing.Exists() {
+		taken = &models.Resource{}
+	}
+
+	result := im.importResource(ctx, record, taken)
+	utils.AdjustTagUsage(ctx, to, im.tagDeltas)
+	if result.Action == ActionFailed {
+		return nil, errors.New(result.Error)
+	}
+
+	report := &CopyReport{Source: from, SourceID: id, Product: to, ID: id, Files: im.report.Files, Bytes: im.report.Bytes, Warnings: result.Warnings}
+	if result.NewID != "" {
+		report.ID = result.NewID
+	}
+	return report, nil
+}
+
+// checkPolicy checks the file and thumbnail of a resource against the upload
+// policy of the product it is copied to, files being the stored files listed
+// for it
+func checkPolicy(policy config.UploadPolicy, record Resource, files map[string]File) error {
+	var errs []error
+	check := func(value, resourceType, subject string) {
+		if value == "" {
+			return
+		}
+		file, stored := files[value]
+		if !stored {
+			if !policy.ExternalURLsAllowed() {
+				errs = append(errs, fmt.Errorf("%s links a file stored elsewhere, which the product does not allow", subject))
+			}
+			return
+		}
+
+		if maxSize := policy.MaxFileSize(resourceType); file.Size > maxSize {
+			errs = append(errs, fmt.Errorf("%s is larger than the %d MB the product allows for %s files", subject, maxSize>>20, resourceType))
+		}
+		if mimeType, _, _ := strings.Cut(file.ContentType, ";"); !policy.AllowsMIMEType(resourceType, strings.TrimSpace(mimeType)) {
+			errs = append(errs, fmt.Errorf("%s is a %s file, which the product does not allow for %s files", subject, mimeType, resourceType))
+		}
+	}
+
+	// Articles and embeds link pages rather than files
+	if resourceType, _ := utils.LookupResourceType(record.Type); resourceType.Source == utils.SourceFile {
+		check(record.URL, record.Type, "its file")
+	}
+	check(record.ThumbnailURL, constants.ResourceTypeImage, "its thumbnail")
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,231 @@
+package catalog
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+// ExportReport summarizes an export
+type ExportReport struct {
+	Product   string   `json:"product"`
+	Resources int      `json:"resources"`
+	Fields    int      `json:"fields"`
+	Tags      int      `json:"tags"`
+	Files     int      `json:"files"`
+	Bytes     int64    `json:"bytes"`             // Of the stored files
+	Missing   []string `json:"missing,omitempty"` // Referenced objects that no longer exist, left out of the archive
+}
+
+// Export writes the catalog of a product to w as a zip archive: the manifest,
+// then every stored file its resources reference. Files are streamed from the
+// bucket, never held in memory.
+//
+// Resources are read first, so resources saved during the export are left
+// out. An error after the manifest was written leaves a truncated archive.
+func Export(ctx context.Context, product string, w io.Writer) (*ExportReport, error) {
+	database := db.New()
+	manifest := &Manifest{
+		Header: Header{Version: constants.CatalogFormatVersion, Product: product, ExportedAt: time.Now().UTC()},
+		Files:  map[string]File{},
+	}
+
+	objects := map[string]bool{}
+	var prefixes []string
+	err := db.NewResourceService(database).ForEach(ctx, product, func(resource models.Resource) error {
+		record, resourceObjects, resourcePrefixes := exportResource(resource)
+		manifest.Resources = append(manifest.Resources, record)
+		for _, objectName := range resourceObjects {
+			objects[objectName] = true
+		}
+		prefixes = append(prefixes, resourcePrefixes...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+
+	manifest.Fields, err = db.NewFieldService(database).List(ctx, product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom fields: %w", err)
+	}
+
+	tagDocs, err := db.NewTagService(database).List(ctx, product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	for _, doc := range tagDocs {
+		var tag models.Tag
+		if err := doc.DataTo(&tag); err != nil {
+			return nil, fmt.Errorf("failed to read tag %s: %w", doc.Ref.ID, err)
+		}
+		manifest.Tags = append(manifest.Tags, tag)
+	}
+
+	report := &ExportReport{Product: product, Resources: len(manifest.Resources), Fields: len(manifest.Fields), Tags: len(manifest.Tags)}
+
+	bucket := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	report.Missing, err = listFiles(ctx, bucket, slices.Sorted(maps.Keys(objects)), prefixes, manifest.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := zip.NewWriter(w)
+	manifestWriter, err := archive.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: manifest.Header.ExportedAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := writeManifest(manifestWriter, manifest); err != nil {
+		return nil, err
+	}
+
+	// Media files are compressed already
+	for _, path := range slices.Sorted(maps.Keys(manifest.Files)) {
+		objectName, _ := objectOf(path)
+		size, err := copyObject(ctx, archive, bucket, objectName, path, manifest.Header.ExportedAt)
+		if err != nil {
+			return nil, err
+		}
+		report.Files++
+		report.Bytes += size
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return report, nil
+}
+
+// listFiles adds the stored objects and the objects under the prefixes to
+// the files of a manifest. It returns the objects that no longer exist.
+func listFiles(ctx context.Context, bucket *storage.BucketHandle, objects, prefixes []string, files map[string]File) ([]string, error) {
+	var missing []string
+	for _, objectName := range objects {
+		attrs, err := bucket.Object(objectName).Attrs(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			missing = append(missing, objectName)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object %s: %w", objectName, err)
+		}
+		files[filePath(objectName)] = File{Path: filePath(objectName), Size: attrs.Size, ContentType: attrs.ContentType}
+	}
+
+	for _, prefix := range prefixes {
+		it := bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+		for {
+			attrs, err := it.Next()
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+			}
+			files[filePath(attrs.Name)] = File{Path: filePath(attrs.Name), Size: attrs.Size, ContentType: attrs.ContentType}
+		}
+	}
+	return missing, nil
+}
+
+// copyObject streams a stored object into an archive entry
+func copyObject(ctx context.Context, archive *zip.Writer, bucket *storage.BucketHandle, objectName, path string, modified time.Time) (int64, error) {
+	reader, err := bucket.Object(objectName).NewReader(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read object %s: %w", objectName, err)
+	}
+	defer reader.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: path, Method: zip.Store, Modified: modified})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	size, err := io.Copy(entry, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy object %s: %w", objectName, err)
+	}
+	return size, nil
+}
+
+// exportResource returns the archive record of a resource, with the stored
+// objects it references and the prefixes of its jobs' outputs
+func exportResource(resource models.Resource) (Resource, []string, []string) {
+	var objects, prefixes []string
+
+	// archived returns the archive path of a stored file, or an external link
+	// as it is
+	archived := func(fileURL string) string {
+		objectName, ok := utils.StorageObjectName(fileURL)
+		if !ok {
+			return fileURL
+		}
+		objects = append(objects, objectName)
+		return filePath(objectName)
+	}
+	archivedPrefix := func(prefix string) string {
+		if prefix == "" {
+			return ""
+		}
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+		prefixes = append(prefixes, prefix)
+		return filePath(prefix)
+	}
+
+	record := Resource{
+		ID:                resource.ID,
+		Title:             resource.Title,
+		Description:       resource.Description,
+		Type:              resource.Type,
+		URL:               archived(resource.URL),
+		ThumbnailURL:      archived(resource.ThumbnailURL),
+		Embed:             resource.Embed,
+		Metadata:          resource.Metadata,
+		ThumbnailMetadata: resource.ThumbnailMetadata,
+		Tags:              resource.Tags,
+		CustomFields:      resource.CustomFields,
+		CreatedAt:         resource.CreatedAt,
+		UpdatedAt:         resource.UpdatedAt,
+	}
+	if len(resource.Thumbnails) > 0 {
+		record.Thumbnails = make(map[string]string, len(resource.Thumbnails))
+		for key, variantURL := range resource.Thumbnails {
+			record.Thumbnails[key] = archived(variantURL)
+		}
+	}
+	if transcode := resource.Transcode; transcode != nil {
+		record.Transcode = &JobOutput{
+			Status:    transcode.Status,
+			Source:    archived(transcode.Source),
+			Prefix:    archivedPrefix(transcode.Prefix),
+			Error:     transcode.Error,
+			UpdatedAt: transcode.UpdatedAt,
+		}
+	}
+	if conversion := resource.Conversion; conversion != nil {
+		record.Conversion = &JobOutput{
+			Status:    conversion.Status,
+			Source:    archived(conversion.Source),
+			Prefix:    archivedPrefix(conversion.Prefix),
+			URL:       archived(conversion.URL),
+			Error:     conversion.Error,
+			UpdatedAt: conversion.UpdatedAt,
+		}
+	}
+
+	return record, objects, prefixes
+}
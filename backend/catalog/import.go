@@ -0,0 +1,771 @@
+package catalog
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// ImportOptions tunes an import
+type ImportOptions struct {
+	// Strategy tells what to do with resources whose ID exists in the target
+	// product, one of Strategies
+	Strategy string
+
+	// DryRun reports what would be imported without writing anything
+	DryRun bool
+}
+
+// What an import does with an archived resource
+const (
+	ActionCreated     = "created"     // Under its ID, which the target product did not use
+	ActionOverwritten = "overwritten" // Replacing the resource with its ID
+	ActionDuplicated  = "duplicated"  // Under a new ID
+	ActionSkipped     = "skipped"     // Its ID is taken
+	ActionFailed      = "failed"
+)
+
+// ImportedResource reports what an import did with an archived resource
+type ImportedResource struct {
+	ID       string   `json:"id"`              // In the archive
+	NewID    string   `json:"newId,omitempty"` // Of a duplicate, unless in a dry run
+	Action   string   `json:"action"`
+	Error    string   `json:"error,omitempty"`    // Why the resource could not be imported
+	Warnings []string `json:"warnings,omitempty"` // What was left out, e.g. unfinished job state
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	Product     string             `json:"product"`
+	Source      string             `json:"source"` // Product the archive was exported from
+	Strategy    string             `json:"strategy"`
+	DryRun      bool               `json:"dryRun"`
+	Resources   []ImportedResource `json:"resources"`
+	Created     int                `json:"created"`
+	Overwritten int                `json:"overwritten"`
+	Duplicated  int                `json:"duplicated"`
+	Skipped     int                `json:"skipped"`
+	Failed      int                `json:"failed"`
+	Fields      int                `json:"fields"` // Custom field definitions created or replaced
+	Files       int                `json:"files"`  // Stored files written, or missing from the bucket in a dry run
+	Bytes       int64              `json:"bytes"`
+}
+
+// add records what was done with a resource
+func (r *ImportReport) add(result ImportedResource) {
+	r.Resources = append(r.Resources, result)
+	switch result.Action {
+	case ActionCreated:
+		r.Created++
+	case ActionOverwritten:
+		r.Overwritten++
+	case ActionDuplicated:
+		r.Duplicated++
+	case ActionSkipped:
+		r.Skipped++
+	case ActionFailed:
+		r.Failed++
+	}
+}
+
+// archivedFile is where an archived file of a resource is stored, and the
+// kind of file it must be: a file resource type, image, or
+// constants.FileTypeJobOutput, see utils.RestoreFile
+type archivedFile struct {
+	object   string
+	fileType string
+}
+
+// objectRegistry registers content-addressed uploads, see db.ObjectService.
+// Replaced in tests.
+type objectRegistry interface {
+	Acquire(ctx context.Context, product, sha256 string) (*models.StoredObject, error)
+	Register(ctx context.Context, product, sha256 string, object models.StoredObject) (models.StoredObject, bool, error)
+	Release(ctx context.Context, product, sha256, releaseID string) (*models.StoredObject, error)
+}
+
+// importer imports the content of an archive into a product
+type importer struct {
+	product     string
+	options     ImportOptions
+	manifest    *Manifest
+	entries     map[string]*zip.File // By path, nil when copying from the bucket
+	definitions []models.FieldDefinition
+	report      *ImportReport
+
+	resourceService *db.ResourceService
+	objectService   objectRegistry
+	bucket          *storage.BucketHandle
+
+	present   map[string]bool // Objects known to exist in the bucket
+	tagDeltas map[string]int
+}
+
+// Import imports a catalog archive into a product. Custom field definitions
+// are imported first, then resources in batches, each with its stored files.
+// Every resource is checked like a created one, and one that fails is
+// reported without stopping the import.
+//
+// Content-addressed uploads the product already stores are referenced rather
+// than written again, as are other files whose object exists. Archived files
+// are checked like uploads before they are written, see utils.RestoreFile.
+//
+// Errors are returned for archives that cannot be read, wrapping
+// ErrInvalidArchive, and for failures to read or write the product's
+// catalog, after which the import may be partial.
+func Import(ctx context.Context, product string, archive io.ReaderAt, size int64, options ImportOptions) (*ImportReport, error) {
+	if !slices.Contains(Strategies, options.Strategy) {
+		return nil, fmt.Errorf("unknown import strategy %q", options.Strategy)
+	}
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	entries := make(map[string]*zip.File, len(reader.File))
+	for _, entry := range reader.File {
+		entries[entry.Name] = entry
+	}
+
+	manifestEntry, ok := entries[ManifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidArchive, ManifestName)
+	}
+	manifestReader, err := manifestEntry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	manifest, err := readManifest(manifestReader)
+	manifestReader.Close()
+	if err != nil {
+		return nil, err
+	}
+	for path := range manifest.Files {
+		if _, ok := entries[path]; !ok {
+			return nil, fmt.Errorf("%w: %s is listed in the manifest but missing", ErrInvalidArchive, path)
+		}
+	}
+
+	database := db.New()
+	im := &importer{
+		product:  product,
+		options:  options,
+		manifest: manifest,
+		entries:  entries,
+		report: &ImportReport{
+			Product:   product,
+			Source:    manifest.Header.Product,
+			Strategy:  options.Strategy,
+			DryRun:    options.DryRun,
+			Resources: []ImportedResource{},
+		},
+		resourceService: db.NewResourceService(database),
+		objectService:   db.NewObjectService(database),
+		bucket:          firebase.StorageClient.Bucket(firebase.StorageBucket),
+		present:         map[string]bool{},
+		tagDeltas:       map[string]int{},
+	}
+
+	if err := im.importFields(ctx, db.NewFieldService(database)); err != nil {
+		return nil, err
+	}
+
+	err = im.importResources(ctx)
+
+	// Whatever was saved counts, even if the import stopped
+	if !options.DryRun {
+		utils.AdjustTagUsage(ctx, product, im.tagDeltas)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return im.report, nil
+}
+
+// importFields creates the archive's custom field definitions missing from
+// the product and, with the overwrite strategy, replaces those that differ.
+// Resources are checked against the resulting definitions.
+func (im *importer) importFields(ctx context.Context, fieldService *db.FieldService) error {
+	current, err := fieldService.List(ctx, im.product)
+	if err != nil {
+		return fmt.Errorf("failed to read custom fields: %w", err)
+	}
+
+	definitions := slices.Clone(current)
+	for _, definition := range im.manifest.Fields {
+		if err := utils.ValidateFieldDefinition(&definition); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		index := slices.IndexFunc(definitions, func(d models.FieldDefinition) bool { return d.Name == definition.Name })
+		switch {
+		case index < 0:
+			if len(definitions) >= constants.MaxCustomFields {
+				return fmt.Errorf("%w: a product has at most %d custom fields", ErrInvalidArchive, constants.MaxCustomFields)
+			}
+			definitions = append(definitions, definition)
+		case im.options.Strategy == constants.ImportStrategyOverwrite && !reflect.DeepEqual(definitions[index], definition):
+			definitions[index] = definition
+		default:
+			continue
+		}
+
+		im.report.Fields++
+		if im.options.DryRun {
+			continue
+		}
+		if err := fieldService.Set(ctx, im.product, definition); err != nil {
+			return fmt.Errorf("failed to save custom field %s: %w", definition.Name, err)
+		}
+	}
+
+	im.definitions = definitions
+	return nil
+}
+
+// importResources imports the archived resources in batches, looking up
+// which of their IDs the product uses a batch at a time
+func (im *importer) importResources(ctx context.Context) error {
+	records := im.manifest.Resources
+	for start := 0; start < len(records); start += constants.BulkBatchSize {
+		batch := records[start:min(start+constants.BulkBatchSize, len(records))]
+
+		ids := make([]string, len(batch))
+		for i, record := range batch {
+			ids[i] = record.ID
+		}
+		docs, err := im.resourceService.GetAll(ctx, im.product, ids)
+		if err != nil {
+			return fmt.Errorf("failed to read resources: %w", err)
+		}
+
+		for i, record := range batch {
+			var existing *models.Resource
+			if docs[i].Exists() {
+				existing = &models.Resource{}
+				if err := docs[i].DataTo(existing); err != nil {
+					im.report.add(ImportedResource{ID: record.ID, Action: ActionFailed, Error: fmt.Sprintf("failed to read the existing resource: %v", err)})
+					continue
+				}
+			}
+			im.report.add(im.importResource(ctx, record, existing))
+		}
+	}
+	return nil
+}
+
+// actionFor returns what a strategy does with an archived resource, given
+// whether its ID exists in the target product
+func actionFor(strategy string, exists bool) string {
+	if !exists {
+		return ActionCreated
+	}
+	switch strategy {
+	case constants.ImportStrategyOverwrite:
+		return ActionOverwritten
+	case constants.ImportStrategyDuplicate:
+		return ActionDuplicated
+	default:
+		return ActionSkipped
+	}
+}
+
+// importResource imports an archived resource, existing being the resource
+// with its ID in the product, if any
+func (im *importer) importResource(ctx context.Context, record Resource, existing *models.Resource) ImportedResource {
+	result := ImportedResource{ID: record.ID, Action: actionFor(im.options.Strategy, existing != nil)}
+	if result.Action == ActionSkipped {
+		return result
+	}
+	fail := func(err error) ImportedResource {
+		result.Action, result.Error = ActionFailed, err.Error()
+		return result
+	}
+
+	id := record.ID
+	if result.Action == ActionDuplicated {
+		id = im.resourceService.NewID(im.product)
+		if !im.options.DryRun {
+			result.NewID = id
+		}
+	}
+
+	resource, files, warnings, err := im.resolve(record, relocation{from: im.manifest.Header.Product, to: im.product, id: record.ID, newID: id})
+	if err != nil {
+		return fail(err)
+	}
+	result.Warnings = warnings
+
+	if err := checkResource(&resource, im.definitions); err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+	resource.UpdatedAt = now
+	if result.Action == ActionOverwritten {
+		resource.CreatedAt = existing.CreatedAt
+	}
+	if resource.CreatedAt.IsZero() {
+		resource.CreatedAt = now
+	}
+
+	acquired, err := im.storeFiles(ctx, resource, existing, files)
+	if err != nil {
+		im.release(ctx, acquired)
+		return fail(err)
+	}
+	if im.options.DryRun {
+		return result
+	}
+
+	if result.Action == ActionOverwritten {
+		err = im.resourceService.Replace(ctx, im.product, id, resource)
+	} else {
+		err = im.resourceService.CreateWithID(ctx, im.product, id, resource)
+		if status.Code(err) == codes.AlreadyExists {
+			err = fmt.Errorf("resource %s was created meanwhile", id)
+		}
+	}
+	if err != nil {
+		im.release(ctx, acquired)
+		return fail(fmt.Errorf("failed to save resource: %w", err))
+	}
+
+	for _, tag := range resource.Tags {
+		im.tagDeltas[tag]++
+	}
+	if result.Action == ActionOverwritten {
+		for _, tag := range existing.Tags {
+			im.tagDeltas[tag]--
+		}
+
+		// The references both versions hold stay with the new one
+		fileURLs, prefixes := obsoleteFiles(*existing, resource)
+		if err := utils.DeleteObjects(ctx, "", fileURLs, prefixes); err != nil {
+			logger.Errorf("Failed to delete files of overwritten resource %s: %v", id, err)
+		}
+	}
+
+	return result
+}
+
+// resolve returns the resource to save for an archived one, with each
+// archived file it uses, by path. State of unfinished background jobs is
+// dropped: the archive holds none of their outputs.
+func (im *importer) resolve(record Resource, reloc relocation) (models.Resource, map[string]archivedFile, []string, error) {
+	files := map[string]archivedFile{}
+	var errs []error
+
+	// storedURL returns the URL of the target object of an archived file of
+	// a type, or an external link as it is
+	storedURL := func(value, fileType string) string {
+		objectName, stored := objectOf(value)
+		if !stored {
+			return value
+		}
+		if _, listed := im.manifest.Files[value]; !listed {
+			errs = append(errs, fmt.Errorf("%s is missing from the archive", value))
+			return ""
+		}
+
+		target, err := reloc.object(objectName)
+		if err != nil {
+			errs = append(errs, err)
+			return ""
+		}
+		files[value] = archivedFile{object: target, fileType: fileType}
+
+		publicURL, err := utils.StorageURL(target)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return publicURL
+	}
+
+	// storedPrefix returns the target prefix of archived job outputs, whose
+	// files are all imported. Files with a type of their own, e.g. converted
+	// PDFs, keep the type storedURL resolves them with, before or after.
+	storedPrefix := func(value string) string {
+		prefix, stored := objectOf(value)
+		if !stored {
+			return ""
+		}
+
+		target, err := reloc.object(prefix)
+		if err != nil {
+			errs = append(errs, err)
+			return ""
+		}
+		for path := range im.manifest.Files {
+			if _, resolved := files[path]; resolved || !strings.HasPrefix(path, value) {
+				continue
+			}
+			objectName, _ := objectOf(path)
+			output, err := reloc.object(objectName)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			files[path] = archivedFile{object: output, fileType: constants.FileTypeJobOutput}
+		}
+		return strings.TrimSuffix(target, "/")
+	}
+
+	resource := models.Resource{
+		Title:             record.Title,
+		Description:       record.Description,
+		Type:              record.Type,
+		URL:               storedURL(record.URL, record.Type),
+		ThumbnailURL:      storedURL(record.ThumbnailURL, constants.ResourceTypeImage),
+		Embed:             record.Embed,
+		Metadata:          record.Metadata,
+		ThumbnailMetadata: record.ThumbnailMetadata,
+		Tags:              record.Tags,
+		CustomFields:      record.CustomFields,
+		CreatedAt:         record.CreatedAt,
+		UpdatedAt:         record.UpdatedAt,
+	}
+	if len(record.Thumbnails) > 0 {
+		resource.Thumbnails = make(map[string]string, len(record.Thumbnails))
+		for key, variant := range record.Thumbnails {
+			resource.Thumbnails[key] = storedURL(variant, constants.ResourceTypeImage)
+		}
+	}
+
+	var warnings []string
+	if transcode := record.Transcode; transcode != nil {
+		switch transcode.Status {
+		case constants.TranscodeStatusReady, constants.TranscodeStatusFailed:
+			resource.Transcode = &models.Transcode{
+				Status:    transcode.Status,
+				Source:    storedURL(transcode.Source, record.Type),
+				Prefix:    storedPrefix(transcode.Prefix),
+				Error:     transcode.Error,
+				UpdatedAt: transcode.UpdatedAt,
+			}
+		default:
+			warnings = append(warnings, "Transcoding was not finished, the video is imported without its stream")
+		}
+	}
+	if conversion := record.Conversion; conversion != nil {
+		switch conversion.Status {
+		case constants.ConversionStatusReady, constants.ConversionStatusFailed:
+			resource.Conversion = &models.Conversion{
+				Status:    conversion.Status,
+				Source:    storedURL(conversion.Source, record.Type),
+				Prefix:    storedPrefix(conversion.Prefix),
+				URL:       storedURL(conversion.URL, constants.ResourceTypePDF),
+				Error:     conversion.Error,
+				UpdatedAt: conversion.UpdatedAt,
+			}
+		default:
+			warnings = append(warnings, "Conversion was not finished, the deck is imported without its PDF preview")
+		}
+	}
+
+	return resource, files, warnings, errors.Join(errs...)
+}
+
+// checkResource checks an imported resource like a created one, against the
+// type registry, the validation rules and the product's custom fields. Tags
+// are normalized.
+func checkResource(resource *models.Resource, definitions []models.FieldDefinition) error {
+	if !utils.IsValidResourceType(resource.Type) {
+		return fmt.Errorf("type %q is not supported", resource.Type)
+	}
+
+	resource.Tags = utils.NormalizeTags(resource.Tags)
+	if fieldErrors := validation.Resource(*resource); len(fieldErrors) > 0 {
+		messages := make([]string, len(fieldErrors))
+		for i, fieldErr := range fieldErrors {
+			messages[i] = fieldErr.Message
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+
+	customFields, err := utils.MergeCustomFields(definitions, nil, resource.CustomFields)
+	if err != nil {
+		return err
+	}
+	resource.CustomFields = customFields
+	return nil
+}
+
+// storeFiles writes the archived files of a resource the bucket lacks, and
+// takes the references the resource holds to content-addressed uploads: one
+// for its file and one for its thumbnail. The references of the version it
+// overwrites, if any, are kept for the uploads both use, see obsoleteFiles.
+// It returns the content hashes referenced, to release if the resource is
+// not saved. A dry run only counts the missing files.
+func (im *importer) storeFiles(ctx context.Context, resource models.Resource, previous *models.Resource, files map[string]archivedFile) ([]string, error) {
+	references := contentReferences(resource)
+	held := map[string]int{}
+	if previous != nil {
+		held = contentReferences(*previous)
+	}
+
+	var acquired []string
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		file := files[path]
+		missing := references[file.object] - held[file.object]
+		switch {
+		case references[file.object] > 0 && missing <= 0:
+			im.present[file.object] = true
+			continue
+		case references[file.object] == 0 || im.options.DryRun:
+			if err := im.placeObject(ctx, path, file); err != nil {
+				return acquired, err
+			}
+			continue
+		}
+
+		_, sha256, _, _ := utils.ParseContentObject(file.object)
+		for range missing {
+			if err := im.referenceObject(ctx, path, file, sha256); err != nil {
+				return acquired, err
+			}
+			acquired = append(acquired, sha256)
+		}
+	}
+	return acquired, nil
+}
+
+// placeObject writes an archived file to its object unless the object exists
+func (im *importer) placeObject(ctx context.Context, path string, file archivedFile) error {
+	if im.present[file.object] {
+		return nil
+	}
+
+	_, err := im.bucket.Object(file.object).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		_, err = im.writeObject(ctx, path, file)
+	} else if err != nil {
+		err = fmt.Errorf("failed to read object %s: %w", file.object, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	im.present[file.object] = true
+	return nil
+}
+
+// referenceObject takes a reference to a content-addressed upload. The
+// archived file is written and registered if the product registers no
+// reference to it, like an upload. Content the product stores under another
+// type is written to the archived file's object too, which the resource links
+// to; garbage collection removes it once the content is no longer used.
+func (im *importer) referenceObject(ctx context.Context, path string, file archivedFile, sha256 string) error {
+	acquired, err := im.objectService.Acquire(ctx, im.product, sha256)
+	if err != nil {
+		return fmt.Errorf("failed to look up stored object: %w", err)
+	}
+	if acquired != nil {
+		if acquired.Object != file.object {
+			if err := im.placeObject(ctx, path, file); err != nil {
+				im.release(ctx, []string{sha256})
+				return err
+			}
+		}
+		im.present[file.object] = true
+		return nil
+	}
+
+	object, err := im.writeObject(ctx, path, file)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := im.objectService.Register(ctx, im.product, sha256, object); err != nil {
+		// Left for garbage collection, like a failed upload
+		return fmt.Errorf("failed to register stored object: %w", err)
+	}
+
+	im.present[file.object] = true
+	return nil
+}
+
+// writeObject writes an archived file to its object, checked like an upload,
+// and returns the stored object. A dry run only counts it.
+func (im *importer) writeObject(ctx context.Context, path string, file archivedFile) (models.StoredObject, error) {
+	listed := im.manifest.Files[path]
+	if im.options.DryRun {
+		im.report.Files++
+		im.report.Bytes += listed.Size
+		return models.StoredObject{Object: file.object, Size: listed.Size, ContentType: listed.ContentType}, nil
+	}
+
+	if im.entries == nil {
+		return im.copyStoredObject(ctx, path, file.object)
+	}
+
+	spooled, err := im.spool(path)
+	if err != nil {
+		return models.StoredObject{}, err
+	}
+	defer func() {
+		spooled.Close()
+		os.Remove(spooled.Name())
+	}()
+
+	restored, err := utils.RestoreFile(ctx, spooled, im.product, file.fileType, file.object)
+	if err != nil {
+		return models.StoredObject{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	im.report.Files++
+	im.report.Bytes += restored.Size
+	return models.StoredObject{Object: file.object, Generation: restored.Generation, Size: restored.Size, ContentType: restored.ContentType}, nil
+}
+
+// spool copies an archived file to a temporary file, since the checks of
+// uploads read it more than once. Files larger than any upload are cut short,
+// which the checks reject.
+func (im *importer) spool(path string) (*os.File, error) {
+	reader, err := im.entries[path].Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer reader.Close()
+
+	spooled, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := io.Copy(spooled, io.LimitReader(reader, constants.MaxFileSize+1)); err != nil {
+		spooled.Close()
+		os.Remove(spooled.Name())
+		return nil, fmt.Errorf("%w: failed to read %s: %v", ErrInvalidArchive, path, err)
+	}
+	if _, err := spooled.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		os.Remove(spooled.Name())
+		return nil, fmt.Errorf("failed to rewind temporary file: %w", err)
+	}
+	return spooled, nil
+}
+
+// copyStoredObject copies the stored object a file was listed from to an
+// object, within the bucket, and returns it. The file was checked when it
+// was uploaded.
+func (im *importer) copyStoredObject(ctx context.Context, path, objectName string) (models.StoredObject, error) {
+	file := im.manifest.Files[path]
+	source, _ := objectOf(path)
+
+	copier := im.bucket.Object(objectName).CopierFrom(im.bucket.Object(source))
+	copier.ContentType = file.ContentType
+	copier.ContentDisposition = "inline"
+	attrs, err := copier.Run(ctx)
+	if err != nil {
+		return models.StoredObject{}, fmt.Errorf("failed to copy object %s to %s: %w", source, objectName, err)
+	}
+
+	im.report.Files++
+	im.report.Bytes += attrs.Size
+	return models.StoredObject{Object: objectName, Generation: attrs.Generation, Size: attrs.Size, ContentType: file.ContentType}, nil
+}
+
+// release drops the references taken for a resource that was not saved.
+// Objects left unreferenced are left to garbage collection.
+func (im *importer) release(ctx context.Context, acquired []string) {
+	for _, sha256 := range acquired {
+		if _, err := im.objectService.Release(context.WithoutCancel(ctx), im.product, sha256, ""); err != nil {
+			logger.Errorf("Failed to release stored object %s: %v", sha256, err)
+		}
+	}
+}
+
+// obsoleteFiles returns the stored files and job output prefixes of a
+// resource's previous version that its new version no longer uses. The
+// previous version's references to content-addressed uploads are released
+// beyond those the new version holds, see storeFiles.
+func obsoleteFiles(previous, resource models.Resource) ([]string, []string) {
+	used := map[string]bool{}
+	for _, fileURL := range storedFiles(resource) {
+		used[fileURL] = true
+	}
+	kept := contentReferences(resource)
+
+	var fileURLs []string
+	for _, fileURL := range []string{previous.URL, previous.ThumbnailURL} {
+		if objectName, ok := contentObject(fileURL); ok {
+			if kept[objectName] > 0 {
+				kept[objectName]--
+			} else {
+				fileURLs = append(fileURLs, fileURL)
+			}
+		}
+	}
+	for _, fileURL := range storedFiles(previous) {
+		if _, content := contentObject(fileURL); !content && !used[fileURL] {
+			fileURLs = append(fileURLs, fileURL)
+		}
+	}
+
+	usedPrefixes := resource.OutputPrefixes()
+	var prefixes []string
+	for _, prefix := range previous.OutputPrefixes() {
+		if !slices.Contains(usedPrefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return fileURLs, prefixes
+}
+
+// contentReferences counts the references a resource holds to
+// content-addressed uploads by object: one for its file and one for its
+// thumbnail, which may be the same upload
+func contentReferences(resource models.Resource) map[string]int {
+	references := map[string]int{}
+	for _, fileURL := range []string{resource.URL, resource.ThumbnailURL} {
+		if objectName, ok := contentObject(fileURL); ok {
+			references[objectName]++
+		}
+	}
+	return references
+}
+
+// contentObject returns the object of a URL if it is a content-addressed
+// upload, not one of its variants
+func contentObject(fileURL string) (string, bool) {
+	objectName, ok := utils.StorageObjectName(fileURL)
+	if !ok {
+		return "", false
+	}
+	_, _, variant, ok := utils.ParseContentObject(objectName)
+	return objectName, ok && !variant
+}
+
+// storedFiles returns the URLs of a resource's file, thumbnail and thumbnail
+// variants. Job outputs are under their prefixes.
+func storedFiles(resource models.Resource) []string {
+	var fileURLs []string
+	for _, fileURL := range append([]string{resource.URL, resource.ThumbnailURL}, slices.Sorted(maps.Values(resource.Thumbnails))...) {
+		if fileURL != "" && !slices.Contains(fileURLs, fileURL) {
+			fileURLs = append(fileURLs, fileURL)
+		}
+	}
+	return fileURLs
+}
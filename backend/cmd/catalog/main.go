@@ -0,0 +1,194 @@
+// Command catalog exports the catalog of a product to a zip archive, or
+// imports such an archive into a product, e.g. to promote content from
+// staging to production. It runs the same export and import as the
+// GET /export and POST /import endpoints, with the configuration of the
+// server:
+//
+//	go run ./cmd/catalog export -product ecomm -o ecomm.zip
+//	go run ./cmd/catalog import -product ecomm -dry-run ecomm.zip
+//	go run ./cmd/catalog import -product ecomm -strategy overwrite -json ecomm.zip
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"learninghub/catalog"
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	logger "learninghub/pkg/logger"
+	"learninghub/products"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "export" && os.Args[1] != "import") {
+		fmt.Fprintln(os.Stderr, "usage: catalog export|import [flags]")
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	product := flags.String("product", "", "Product to export or import into")
+	output := flags.String("o", "", "Archive to write, <product>-catalog.zip by default (export)")
+	strategy := flags.String("strategy", constants.ImportStrategySkip, "What to do with resources whose ID exists: "+strings.Join(catalog.Strategies, ", ")+" (import)")
+	dryRun := flags.Bool("dry-run", false, "Report what would be imported without writing anything (import)")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Parse(os.Args[2:])
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.InitGlobal(
+		logger.WithServiceName("learninghub-catalog"),
+		logger.WithDefaultDestinations(logger.ConsoleLogger),
+		logger.WithConsoleDestination(),
+	)
+	defer logger.CloseGlobal()
+
+	if err := config.LoadConfig(); err != nil {
+		logger.Fatalf("Error loading configuration: %v", err)
+	}
+
+	if command == "import" && flags.NArg() != 1 {
+		logger.Fatalf("Expected the archive to import")
+	}
+	if !slices.Contains(catalog.Strategies, *strategy) {
+		logger.Fatalf("Invalid strategy %q, valid strategies: %v", *strategy, catalog.Strategies)
+	}
+
+	if err := firebase.InitializeFirebase(); err != nil {
+		logger.Fatalf("Failed to initialize Firebase: %v", err)
+	}
+	defer firebase.CloseFirebase()
+
+	// Archived products can still be exported and imported into
+	if _, err := products.Initialize(ctx, db.NewProductService(db.New()), config.AppConfig.VALID_PRODUCTS); err != nil {
+		logger.Fatalf("Failed to load products: %v", err)
+	}
+	if _, exists := products.Lookup(*product); !exists {
+		logger.Fatalf("Invalid product %q, valid products: %v", *product, products.Names(true))
+	}
+
+	var report any
+	var err error
+	if command == "export" {
+		path := *output
+		if path == "" {
+			path = *product + "-catalog.zip"
+		}
+		report, err = export(ctx, *product, path)
+	} else {
+		report, err = importArchive(ctx, *product, flags.Arg(0), catalog.ImportOptions{Strategy: *strategy, DryRun: *dryRun})
+	}
+	if err != nil {
+		logger.Errorf("Failed to %s catalog of %s: %v", command, *product, err)
+		logger.CloseGlobal()
+		os.Exit(1)
+	}
+
+	failed := false
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			logger.Errorf("Failed to print report: %v", err)
+			failed = true
+		}
+	} else if exportReport, ok := report.(*catalog.ExportReport); ok {
+		printExportReport(exportReport)
+	} else if importReport, ok := report.(*catalog.ImportReport); ok {
+		printImportReport(importReport)
+	}
+
+	if importReport, ok := report.(*catalog.ImportReport); ok && importReport.Failed > 0 {
+		failed = true
+	}
+	if failed {
+		logger.CloseGlobal()
+		os.Exit(1)
+	}
+}
+
+// export writes the catalog of a product to an archive file. An incomplete
+// archive is removed.
+func export(ctx context.Context, product, path string) (*catalog.ExportReport, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := catalog.Export(ctx, product, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return report, nil
+}
+
+// importArchive imports an archive file into a product
+func importArchive(ctx context.Context, product, path string, options catalog.ImportOptions) (*catalog.ImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Import(ctx, product, file, info.Size(), options)
+}
+
+// printExportReport prints an export report for humans
+func printExportReport(report *catalog.ExportReport) {
+	for _, objectName := range report.Missing {
+		fmt.Printf("missing\t%s\n", objectName)
+	}
+	fmt.Printf("%s: %d resources, %d custom fields, %d tags, %d files (%d bytes) exported", report.Product, report.Resources, report.Fields, report.Tags, report.Files, report.Bytes)
+	if len(report.Missing) > 0 {
+		fmt.Printf(", %d missing files left out", len(report.Missing))
+	}
+	fmt.Println()
+}
+
+// printImportReport prints an import report for humans
+func printImportReport(report *catalog.ImportReport) {
+	prefix := ""
+	if report.DryRun {
+		prefix = "would be "
+	}
+
+	for _, resource := range report.Resources {
+		action := resource.Action
+		if resource.Action != catalog.ActionFailed {
+			action = prefix + action
+		}
+		line := fmt.Sprintf("%s\t%s", action, resource.ID)
+		if resource.NewID != "" {
+			line += " -> " + resource.NewID
+		}
+		if resource.Error != "" {
+			line += "\t" + resource.Error
+		}
+		fmt.Println(line)
+		for _, warning := range resource.Warnings {
+			fmt.Printf("\twarning: %s\n", warning)
+		}
+	}
+
+	fmt.Printf("%s from %s (%s): %d created, %d overwritten, %d duplicated, %d skipped, %d failed; %d custom fields, %d files (%d bytes) %swritten\n",
+		report.Product, report.Source, report.Strategy, report.Created, report.Overwritten, report.Duplicated, report.Skipped, report.Failed, report.Fields, report.Files, report.Bytes, prefix)
+}
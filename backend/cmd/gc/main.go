@@ -0,0 +1,121 @@
+// Command gc deletes storage objects no resource references anymore.
+//
+// It runs the same collection as the scheduled gc job (see STORAGE_GC_INTERVAL)
+// on demand, with the configuration of the server:
+//
+//	go run ./cmd/gc -dry-run            # report the orphans of every product
+//	go run ./cmd/gc -product ecomm      # delete the orphans of one product
+//	go run ./cmd/gc -grace 72h -json    # keep three days of orphans, print JSON
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/gc"
+	logger "learninghub/pkg/logger"
+	"learninghub/products"
+)
+
+func main() {
+	product := flag.String("product", "", "Product to collect, all products by default, archived ones included")
+	dryRun := flag.Bool("dry-run", false, "Report orphaned objects without deleting them")
+	grace := flag.Duration("grace", constants.StorageGCGracePeriod*time.Hour, "Keep orphaned objects created more recently than this")
+	asJSON := flag.Bool("json", false, "Print the reports as JSON")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.InitGlobal(
+		logger.WithServiceName("learninghub-gc"),
+		logger.WithDefaultDestinations(logger.ConsoleLogger),
+		logger.WithConsoleDestination(),
+	)
+	defer logger.CloseGlobal()
+
+	if err := config.LoadConfig(); err != nil {
+		logger.Fatalf("Error loading configuration: %v", err)
+	}
+	if err := firebase.InitializeFirebase(); err != nil {
+		logger.Fatalf("Failed to initialize Firebase: %v", err)
+	}
+	defer firebase.CloseFirebase()
+
+	if _, err := products.Initialize(ctx, db.NewProductService(db.New()), config.AppConfig.VALID_PRODUCTS); err != nil {
+		logger.Fatalf("Failed to load products: %v", err)
+	}
+
+	// Archived products still have storage to collect
+	collected := products.Names(true)
+	if *product != "" {
+		if !slices.Contains(collected, *product) {
+			logger.Fatalf("Invalid product %q, valid products: %v", *product, collected)
+		}
+		collected = []string{*product}
+	}
+
+	var reports []*gc.Report
+	failed := false
+	for _, p := range collected {
+		report, err := gc.Collect(ctx, p, gc.Options{GracePeriod: *grace, DryRun: *dryRun})
+		if err != nil {
+			logger.Errorf("Failed to collect %s: %v", p, err)
+			failed = true
+			continue
+		}
+		if report.Failures > 0 {
+			failed = true
+		}
+		reports = append(reports, report)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			logger.Errorf("Failed to print reports: %v", err)
+			failed = true
+		}
+	} else {
+		for _, report := range reports {
+			printReport(report)
+		}
+	}
+
+	if failed {
+		logger.CloseGlobal()
+		os.Exit(1)
+	}
+}
+
+// printReport prints a report for humans
+func printReport(report *gc.Report) {
+	action := "deleted"
+	if report.DryRun {
+		action = "would delete"
+	}
+
+	var orphaned int64
+	for _, orphan := range report.Orphans {
+		fmt.Printf("%s\t%s\t%d bytes\tcreated %s\n", action, orphan.Name, orphan.Size, orphan.Created.Format(time.RFC3339))
+		orphaned += orphan.Size
+	}
+
+	fmt.Printf("%s: %d objects scanned, %d orphaned (%d bytes)", report.Product, report.Scanned, len(report.Orphans), orphaned)
+	if !report.DryRun {
+		fmt.Printf(", %d deleted (%d bytes), %d skipped, %d failed", report.Deleted, report.Freed, report.Skipped, report.Failures)
+	}
+	fmt.Println()
+}
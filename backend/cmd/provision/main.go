@@ -0,0 +1,154 @@
+// Command provision prepares the database of a new product: it generates the
+// composite indexes of <product>_resources, optionally creates them, and
+// seeds the product with starter tags and sample resources.
+//
+// Index definitions are printed unless -indexes names the file to merge them
+// into. With the configuration of the server:
+//
+//	go run ./cmd/provision -product hr                                   # print the indexes of hr
+//	go run ./cmd/provision -product hr -indexes firestore.indexes.json
+//	go run ./cmd/provision -product hr -custom-fields -apply            # create them in FIRESTORE_DB_ID
+//	go run ./cmd/provision -product hr -display-name "Human Resources" -seed
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	logger "learninghub/pkg/logger"
+	"learninghub/provision"
+	"learninghub/validation"
+)
+
+func main() {
+	product := flag.String("product", "", "Product to provision (required)")
+	displayName := flag.String("display-name", "", "Display name of the product when it is registered, its name by default")
+	indexFile := flag.String("indexes", "", "Merge the indexes into this firestore.indexes.json instead of printing them")
+	customFields := flag.Bool("custom-fields", false, "Also index the custom fields defined for the product")
+	apply := flag.Bool("apply", false, "Create the indexes through the Firestore admin API")
+	seed := flag.Bool("seed", false, "Register the product and create starter tags and sample resources")
+	asJSON := flag.Bool("json", false, "Print the seed report as JSON")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.InitGlobal(
+		logger.WithServiceName("learninghub-provision"),
+		logger.WithDefaultDestinations(logger.ConsoleLogger),
+		logger.WithConsoleDestination(),
+	)
+	defer logger.CloseGlobal()
+
+	if err := config.LoadConfig(); err != nil {
+		logger.Fatalf("Error loading configuration: %v", err)
+	}
+
+	candidate := models.Product{Name: *product, DisplayName: *displayName}
+	if candidate.DisplayName == "" {
+		candidate.DisplayName = candidate.Name
+	}
+	if fieldErrors := validation.Product(candidate); len(fieldErrors) > 0 {
+		for _, fieldErr := range fieldErrors {
+			logger.Errorf("Invalid %s: %s", fieldErr.Field, fieldErr.Message)
+		}
+		logger.Fatalf("Usage: provision -product <name> [-indexes <file>] [-custom-fields] [-apply] [-seed]")
+	}
+
+	if *customFields || *seed {
+		if err := firebase.InitializeFirebase(); err != nil {
+			logger.Fatalf("Failed to initialize Firebase: %v", err)
+		}
+		defer firebase.CloseFirebase()
+	}
+
+	var fields []models.FieldDefinition
+	if *customFields {
+		var err error
+		if fields, err = db.NewFieldService(db.New()).List(ctx, candidate.Name); err != nil {
+			logger.Fatalf("Failed to read custom fields: %v", err)
+		}
+	}
+	indexes := provision.Indexes(candidate.Name, fields)
+
+	failed := false
+	if *indexFile != "" {
+		added, err := provision.MergeIndexFile(*indexFile, indexes)
+		if err != nil {
+			logger.Fatalf("Failed to update %s: %v", *indexFile, err)
+		}
+		logger.Infof("Added %d of %d indexes to %s", len(added), len(indexes), *indexFile)
+	} else {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(map[string][]provision.Index{"indexes": indexes}); err != nil {
+			logger.Errorf("Failed to print indexes: %v", err)
+			failed = true
+		}
+	}
+
+	if *apply {
+		if config.AppConfig.ENV_MODE == constants.EnvModeDev {
+			// The emulator serves every query without composite indexes
+			logger.Infof("Not applying indexes: the Firestore emulator does not need them")
+		} else {
+			created, err := provision.ApplyIndexes(ctx, config.AppConfig.FIREBASE_PROJECT_ID, config.AppConfig.FIRESTORE_DB_ID, indexes)
+			if err != nil {
+				logger.Errorf("Failed to apply indexes: %v", err)
+				failed = true
+			} else {
+				logger.Infof("Started building %d indexes, %d existed already", len(created), len(indexes)-len(created))
+			}
+		}
+	}
+
+	if *seed {
+		report, err := provision.Seed(ctx, candidate)
+		if err != nil {
+			logger.Errorf("Failed to seed %s: %v", candidate.Name, err)
+			failed = true
+		}
+		if report != nil {
+			if *asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					logger.Errorf("Failed to print report: %v", err)
+					failed = true
+				}
+			} else {
+				printReport(report)
+			}
+		}
+	}
+
+	if failed {
+		logger.CloseGlobal()
+		os.Exit(1)
+	}
+}
+
+// printReport prints a seed report for humans
+func printReport(report *provision.SeedReport) {
+	registered := "already registered"
+	if report.Registered {
+		registered = "registered"
+	}
+	fmt.Printf("%s: %s", report.Product, registered)
+	if report.Skipped != "" {
+		fmt.Printf(", no sample resources: %s\n", report.Skipped)
+		return
+	}
+	fmt.Printf(", %d sample resources (%s), tags %s\n", len(report.Resources), strings.Join(report.Resources, ", "), strings.Join(report.Tags, ", "))
+}
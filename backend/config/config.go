@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 
 	"learninghub/constants"
@@ -14,7 +15,7 @@ type EnvConfig struct {
 	ENV_MODE string `env:"ENV_MODE"`
 	PORT     string `env:"PORT"`
 
-	VALID_PRODUCTS []string `env:"VALID_PRODUCTS"`
+	VALID_PRODUCTS []string `env:"VALID_PRODUCTS"` // Seeds the product registry, see the products package
 
 	CORS_ORIGINS string `env:"CORS_ORIGINS"` // Comma-separated
 
@@ -22,9 +23,34 @@ type EnvConfig struct {
 
 	FIRESTORE_EMULATOR_HOST        string `env:"FIRESTORE_EMULATOR_HOST"`
 	FIREBASE_STORAGE_EMULATOR_HOST string `env:"FIREBASE_STORAGE_EMULATOR_HOST"`
+	FIREBASE_AUTH_EMULATOR_HOST    string `env:"FIREBASE_AUTH_EMULATOR_HOST"` // Verifies the unsigned ID tokens of the emulator in dev mode
 
 	FIRESTORE_DB_ID         string `env:"FIRESTORE_DB_ID"`
 	FIREBASE_STORAGE_BUCKET string `env:"FIREBASE_STORAGE_BUCKET"`
+
+	PDF_ACTIVE_CONTENT_MODE string `env:"PDF_ACTIVE_CONTENT_MODE"` // "reject" | "sanitize"
+
+	UPLOAD_POLICY_FILE    string                  `env:"UPLOAD_POLICY_FILE"` // JSON file of per-product upload policies, see UploadPolicy
+	UPLOAD_POLICIES       map[string]UploadPolicy // Keyed by product, loaded from UPLOAD_POLICY_FILE
+	UPLOAD_POLICY_DEFAULT UploadPolicy            // Of products UPLOAD_POLICY_FILE does not configure
+
+	MALWARE_SCANNER string `env:"MALWARE_SCANNER"` // "none" | "clamav"
+	CLAMAV_ADDRESS  string `env:"CLAMAV_ADDRESS"`  // "tcp://host:port" | "unix:///path/to/clamd.sock"
+
+	FFMPEG_PATH   string `env:"FFMPEG_PATH"`
+	FFPROBE_PATH  string `env:"FFPROBE_PATH"`
+	PDFTOPPM_PATH string `env:"PDFTOPPM_PATH"`
+	SOFFICE_PATH  string `env:"SOFFICE_PATH"`
+
+	MEDIA_TOKEN_SECRET string `env:"MEDIA_TOKEN_SECRET"` // HMAC key for HLS playlist tokens
+
+	ADMIN_API_KEY string `env:"ADMIN_API_KEY"` // Key of the admin endpoints, which are disabled without one
+
+	PRODUCT_REGISTRY_TTL int `env:"PRODUCT_REGISTRY_TTL"` // Seconds products are cached before they are read again
+
+	JOB_WORKERS int `env:"JOB_WORKERS"` // Background job workers of this instance, 0 disables processing
+
+	STORAGE_GC_INTERVAL int `env:"STORAGE_GC_INTERVAL"` // Hours between orphaned storage object collections, 0 disables them
 }
 
 func parseProductList(value string) []string {
@@ -57,6 +83,22 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getIntEnvOrDefault retrieves a non-negative integer environment variable or
+// returns a default value if it is unset or invalid.
+func getIntEnvOrDefault(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || parsed < 0 {
+		logger.Warnf("Invalid value %q for %s, using %d", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
 // LoadConfig loads environment variables into an EnvConfig struct.
 func LoadConfig() error {
 	config := &EnvConfig{}
@@ -74,14 +116,54 @@ func LoadConfig() error {
 
 	config.FIRESTORE_EMULATOR_HOST = getEnvOrDefault("FIRESTORE_EMULATOR_HOST", "127.0.0.1:8080")
 	config.FIREBASE_STORAGE_EMULATOR_HOST = getEnvOrDefault("FIREBASE_STORAGE_EMULATOR_HOST", "127.0.0.1:9199")
+	config.FIREBASE_AUTH_EMULATOR_HOST = getEnvOrDefault("FIREBASE_AUTH_EMULATOR_HOST", "127.0.0.1:9099")
 
 	config.FIRESTORE_DB_ID = getEnvOrDefault("FIRESTORE_DB_ID", "learninghub")
 
 	config.FIREBASE_STORAGE_BUCKET = getEnvOrDefault("FIREBASE_STORAGE_BUCKET", config.FIREBASE_PROJECT_ID+".firebasestorage.app")
 
+	config.PDF_ACTIVE_CONTENT_MODE = getEnvOrDefault("PDF_ACTIVE_CONTENT_MODE", constants.PDFActiveContentReject)
+
+	config.UPLOAD_POLICY_FILE = getEnvOrDefault("UPLOAD_POLICY_FILE", "")
+	defaultPolicy, policies, err := loadUploadPolicies(config.UPLOAD_POLICY_FILE, config.VALID_PRODUCTS)
+	if err != nil {
+		return err
+	}
+	config.UPLOAD_POLICY_DEFAULT = defaultPolicy
+	config.UPLOAD_POLICIES = policies
+
+	config.MALWARE_SCANNER = getEnvOrDefault("MALWARE_SCANNER", constants.ScannerNone)
+	config.CLAMAV_ADDRESS = getEnvOrDefault("CLAMAV_ADDRESS", "tcp://127.0.0.1:3310")
+
+	// External tools used to render thumbnails, read media metadata and
+	// convert slide decks, looked up in PATH by default
+	config.FFMPEG_PATH = getEnvOrDefault("FFMPEG_PATH", "ffmpeg")
+	config.FFPROBE_PATH = getEnvOrDefault("FFPROBE_PATH", "ffprobe")
+	config.PDFTOPPM_PATH = getEnvOrDefault("PDFTOPPM_PATH", "pdftoppm")
+	config.SOFFICE_PATH = getEnvOrDefault("SOFFICE_PATH", "soffice")
+
+	config.MEDIA_TOKEN_SECRET = getEnvOrDefault("MEDIA_TOKEN_SECRET", "")
+
+	config.ADMIN_API_KEY = getEnvOrDefault("ADMIN_API_KEY", "")
+
+	config.PRODUCT_REGISTRY_TTL = getIntEnvOrDefault("PRODUCT_REGISTRY_TTL", constants.ProductRegistryTTL)
+
+	config.JOB_WORKERS = getIntEnvOrDefault("JOB_WORKERS", 2)
+
+	config.STORAGE_GC_INTERVAL = getIntEnvOrDefault("STORAGE_GC_INTERVAL", 24)
+
 	AppConfig = config
 
-	logger.Infof("Loaded configuration: %+v", AppConfig)
+	// Keep secrets out of the logs
+	redacted := *config
+	if redacted.MEDIA_TOKEN_SECRET != "" {
+		redacted.MEDIA_TOKEN_SECRET = "<redacted>"
+	}
+	if redacted.ADMIN_API_KEY != "" {
+		redacted.ADMIN_API_KEY = "<redacted>"
+	}
+
+	logger.Infof("Loaded configuration: %+v", redacted)
 
 	return nil
 }
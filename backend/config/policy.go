@@ -0,0 +1,202 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"learninghub/constants"
+)
+
+// UploadPolicy restricts what a product accepts in uploads and links, on top
+// of the built-in file validation. Absent settings restrict nothing.
+//
+// Policies are read from the JSON file UPLOAD_POLICY_FILE points to:
+//
+//	{
+//	  "default": {"maxFileSizeMB": {"image": 10}},
+//	  "products": {
+//	    "ecomm": {
+//	      "maxFileSizeMB": {"video": 200, "pdf": 50},
+//	      "allowedMimeTypes": {"video": ["video/mp4"]},
+//	      "allowExternalUrls": false,
+//	      "pdfActiveContent": "sanitize"
+//	    }
+//	  }
+//	}
+//
+// A product's policy is merged over the default one, setting by setting and
+// resource type by resource type. The policy stored with a product in the
+// registry, see ProductUploadPolicy, is in turn merged over the file's.
+type UploadPolicy struct {
+	// MaxFileSizeMB caps uploads per resource type, "image" for thumbnails.
+	// Values above constants.MaxFileSize are rejected.
+	MaxFileSizeMB map[string]int64 `json:"maxFileSizeMB,omitempty" firestore:"maxFileSizeMB,omitempty"`
+
+	// AllowedMIMETypes narrows the MIME types accepted per resource type. It
+	// cannot widen them: blocked types and types that do not match the
+	// resource type are always rejected.
+	AllowedMIMETypes map[string][]string `json:"allowedMimeTypes,omitempty" firestore:"allowedMimeTypes,omitempty"`
+
+	// AllowExternalURLs is whether video and PDF resources and thumbnails may
+	// link files stored elsewhere rather than uploading them. Articles are
+	// links by nature and always allowed.
+	AllowExternalURLs *bool `json:"allowExternalUrls,omitempty" firestore:"allowExternalUrls,omitempty"`
+
+	// PDFActiveContent overrides PDF_ACTIVE_CONTENT_MODE, "reject" | "sanitize"
+	PDFActiveContent string `json:"pdfActiveContent,omitempty" firestore:"pdfActiveContent,omitempty"`
+}
+
+// ProductUploadPolicy returns the upload policy stored with a product, or nil.
+// It is set by the product registry, see the products package.
+var ProductUploadPolicy func(product string) *UploadPolicy
+
+// uploadPolicyFile is the layout of UPLOAD_POLICY_FILE
+type uploadPolicyFile struct {
+	Default  UploadPolicy            `json:"default"`
+	Products map[string]UploadPolicy `json:"products"`
+}
+
+// MaxFileSize returns the largest upload accepted for a resource type, in bytes
+func (p UploadPolicy) MaxFileSize(resourceType string) int64 {
+	if size, ok := p.MaxFileSizeMB[resourceType]; ok {
+		return size << 20
+	}
+	return constants.MaxFileSize
+}
+
+// AllowsMIMEType reports whether the policy accepts a detected MIME type for
+// a resource type
+func (p UploadPolicy) AllowsMIMEType(resourceType, mimeType string) bool {
+	allowed, ok := p.AllowedMIMETypes[resourceType]
+	return !ok || slices.Contains(allowed, mimeType)
+}
+
+// ExternalURLsAllowed reports whether files may be linked rather than uploaded
+func (p UploadPolicy) ExternalURLsAllowed() bool {
+	return p.AllowExternalURLs == nil || *p.AllowExternalURLs
+}
+
+// String formats the policy for the configuration log
+func (p UploadPolicy) String() string {
+	encoded, _ := json.Marshal(p)
+	return string(encoded)
+}
+
+// merge returns the policy with the settings of override applied
+func (p UploadPolicy) merge(override UploadPolicy) UploadPolicy {
+	merged := UploadPolicy{
+		MaxFileSizeMB:     make(map[string]int64),
+		AllowedMIMETypes:  make(map[string][]string),
+		AllowExternalURLs: p.AllowExternalURLs,
+		PDFActiveContent:  p.PDFActiveContent,
+	}
+
+	for _, policy := range []UploadPolicy{p, override} {
+		for resourceType, size := range policy.MaxFileSizeMB {
+			merged.MaxFileSizeMB[resourceType] = size
+		}
+		for resourceType, mimeTypes := range policy.AllowedMIMETypes {
+			merged.AllowedMIMETypes[resourceType] = mimeTypes
+		}
+	}
+	if override.AllowExternalURLs != nil {
+		merged.AllowExternalURLs = override.AllowExternalURLs
+	}
+	if override.PDFActiveContent != "" {
+		merged.PDFActiveContent = override.PDFActiveContent
+	}
+
+	return merged
+}
+
+// Validate rejects settings that cannot be enforced
+func (p UploadPolicy) Validate() error {
+	uploadTypes := append(slices.Clone(constants.ResourceTypes), constants.ResourceTypeImage)
+
+	for resourceType, size := range p.MaxFileSizeMB {
+		if !slices.Contains(uploadTypes, resourceType) {
+			return fmt.Errorf("maxFileSizeMB: unknown resource type %q", resourceType)
+		}
+		if size <= 0 || size<<20 > constants.MaxFileSize {
+			return fmt.Errorf("maxFileSizeMB: %s must be between 1 and %d", resourceType, constants.MaxFileSize>>20)
+		}
+	}
+
+	for resourceType := range p.AllowedMIMETypes {
+		if !slices.Contains(uploadTypes, resourceType) {
+			return fmt.Errorf("allowedMimeTypes: unknown resource type %q", resourceType)
+		}
+	}
+
+	switch p.PDFActiveContent {
+	case "", constants.PDFActiveContentReject, constants.PDFActiveContentSanitize:
+	default:
+		return fmt.Errorf("pdfActiveContent: must be %q or %q", constants.PDFActiveContentReject, constants.PDFActiveContentSanitize)
+	}
+
+	return nil
+}
+
+// loadUploadPolicies reads UPLOAD_POLICY_FILE and returns its default policy,
+// which applies to products it does not configure, and the policy of every
+// product it configures or that is given. Without a file, no product is
+// restricted.
+func loadUploadPolicies(path string, products []string) (UploadPolicy, map[string]UploadPolicy, error) {
+	policies := make(map[string]UploadPolicy, len(products))
+	if path == "" {
+		return UploadPolicy{}, policies, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return UploadPolicy{}, nil, fmt.Errorf("failed to open upload policy file: %w", err)
+	}
+	defer file.Close()
+
+	// Unknown settings are most likely typos, which must not silently lift a
+	// restriction
+	var config uploadPolicyFile
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return UploadPolicy{}, nil, fmt.Errorf("failed to parse upload policy file %s: %w", path, err)
+	}
+
+	if err := config.Default.Validate(); err != nil {
+		return UploadPolicy{}, nil, fmt.Errorf("invalid default upload policy: %w", err)
+	}
+	// Products may be created after the file is written, see the products
+	// package: it cannot tell which products are unknown
+	for product, policy := range config.Products {
+		if err := policy.Validate(); err != nil {
+			return UploadPolicy{}, nil, fmt.Errorf("invalid upload policy for %s: %w", product, err)
+		}
+		policies[product] = config.Default.merge(policy)
+	}
+
+	for _, product := range products {
+		policies[product] = config.Default.merge(config.Products[product])
+	}
+	return config.Default, policies, nil
+}
+
+// UploadPolicyFor returns the upload policy of a product: the one stored with
+// it in the registry merged over the one of UPLOAD_POLICY_FILE
+func UploadPolicyFor(product string) UploadPolicy {
+	if AppConfig == nil {
+		return UploadPolicy{}
+	}
+
+	policy, ok := AppConfig.UPLOAD_POLICIES[product]
+	if !ok {
+		policy = AppConfig.UPLOAD_POLICY_DEFAULT
+	}
+	if ProductUploadPolicy != nil {
+		if stored := ProductUploadPolicy(product); stored != nil {
+			policy = policy.merge(*stored)
+		}
+	}
+	return policy
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+)
+
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "upload-policies.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadUploadPolicies(t *testing.T) {
+	path := writePolicyFile(t, `{
+		"default": {
+			"maxFileSizeMB": {"image": 10, "pdf": 100},
+			"allowExternalUrls": false
+		},
+		"products": {
+			"ecomm": {
+				"maxFileSizeMB": {"pdf": 50},
+				"allowedMimeTypes": {"video": ["video/mp4"]},
+				"allowExternalUrls": true,
+				"pdfActiveContent": "sanitize"
+			}
+		}
+	}`)
+
+	defaultPolicy, policies, err := loadUploadPolicies(path, []string{"ecomm", "docs"})
+	require.NoError(t, err)
+
+	ecomm := policies["ecomm"]
+	assert.Equal(t, int64(10<<20), ecomm.MaxFileSize(constants.ResourceTypeImage))
+	assert.Equal(t, int64(50<<20), ecomm.MaxFileSize(constants.ResourceTypePDF))
+	assert.Equal(t, int64(constants.MaxFileSize), ecomm.MaxFileSize(constants.ResourceTypeVideo))
+	assert.True(t, ecomm.AllowsMIMEType(constants.ResourceTypeVideo, "video/mp4"))
+	assert.False(t, ecomm.AllowsMIMEType(constants.ResourceTypeVideo, "video/webm"))
+	assert.True(t, ecomm.AllowsMIMEType(constants.ResourceTypeImage, "image/png"))
+	assert.True(t, ecomm.ExternalURLsAllowed())
+	assert.Equal(t, constants.PDFActiveContentSanitize, ecomm.PDFActiveContent)
+
+	docs := policies["docs"]
+	assert.Equal(t, int64(100<<20), docs.MaxFileSize(constants.ResourceTypePDF))
+	assert.True(t, docs.AllowsMIMEType(constants.ResourceTypeVideo, "video/webm"))
+	assert.False(t, docs.ExternalURLsAllowed())
+	assert.Empty(t, docs.PDFActiveContent)
+
+	assert.Equal(t, int64(100<<20), defaultPolicy.MaxFileSize(constants.ResourceTypePDF))
+	assert.False(t, defaultPolicy.ExternalURLsAllowed())
+}
+
+func TestLoadUploadPoliciesWithoutFile(t *testing.T) {
+	_, policies, err := loadUploadPolicies("", []string{"ecomm"})
+	require.NoError(t, err)
+
+	policy := policies["ecomm"]
+	assert.Equal(t, int64(constants.MaxFileSize), policy.MaxFileSize(constants.ResourceTypeVideo))
+	assert.True(t, policy.AllowsMIMEType(constants.ResourceTypeVideo, "video/webm"))
+	assert.True(t, policy.ExternalURLsAllowed())
+}
+
+func TestLoadUploadPoliciesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown setting", content: `{"default": {"allowExternalUrl": false}}`},
+		{name: "unknown resource type", content: `{"products": {"ecomm": {"maxFileSizeMB": {"podcast": 10}}}}`},
+		{name: "size above the global limit", content: `{"default": {"maxFileSizeMB": {"video": 501}}}`},
+		{name: "zero size", content: `{"default": {"maxFileSizeMB": {"pdf": 0}}}`},
+		{name: "unknown PDF mode", content: `{"products": {"ecomm": {"pdfActiveContent": "strip"}}}`},
+		{name: "malformed JSON", content: `{"default": `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := loadUploadPolicies(writePolicyFile(t, tt.content), []string{"ecomm"})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUploadPolicyFor(t *testing.T) {
+	t.Cleanup(func() {
+		AppConfig = nil
+		ProductUploadPolicy = nil
+	})
+
+	external := true
+	AppConfig = &EnvConfig{
+		UPLOAD_POLICIES: map[string]UploadPolicy{
+			"ecomm": {MaxFileSizeMB: map[string]int64{constants.ResourceTypePDF: 50}},
+		},
+		UPLOAD_POLICY_DEFAULT: UploadPolicy{MaxFileSizeMB: map[string]int64{constants.ResourceTypePDF: 100}, AllowExternalURLs: new(bool)},
+	}
+	ProductUploadPolicy = func(product string) *UploadPolicy {
+		if product != "docs" {
+			return nil
+		}
+		return &UploadPolicy{MaxFileSizeMB: map[string]int64{constants.ResourceTypeVideo: 200}, AllowExternalURLs: &external}
+	}
+
+	ecomm := UploadPolicyFor("ecomm")
+	assert.Equal(t, int64(50<<20), ecomm.MaxFileSize(constants.ResourceTypePDF))
+	assert.True(t, ecomm.ExternalURLsAllowed())
+
+	// Products the file does not configure get its default, under their stored policy
+	docs := UploadPolicyFor("docs")
+	assert.Equal(t, int64(100<<20), docs.MaxFileSize(constants.ResourceTypePDF))
+	assert.Equal(t, int64(200<<20), docs.MaxFileSize(constants.ResourceTypeVideo))
+	assert.True(t, docs.ExternalURLsAllowed())
+
+	assert.False(t, UploadPolicyFor("crm").ExternalURLsAllowed())
+}
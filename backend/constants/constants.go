@@ -7,8 +7,19 @@ const (
 	EnvModeProd = "prod"
 
 	// Collection name suffixes - will be prefixed with product name
-	CollectionSuffixResources = "_resources"
-	CollectionSuffixTags      = "_tags"
+	CollectionSuffixResources   = "_resources"
+	CollectionSuffixTags        = "_tags"
+	CollectionSuffixObjects     = "_objects"     // Reference counts of content-addressed uploads
+	CollectionSuffixFields      = "_fields"      // Custom field definitions of resources
+	CollectionSuffixCollections = "_collections" // Learning paths of resources
+	CollectionSuffixProgress    = "_progress"    // Progress of users through resources
+	CollectionSuffixBookmarks   = "_bookmarks"   // Resources saved by users for later
+
+	// Background jobs of all products, see the jobs package
+	CollectionJobs = "jobs"
+
+	// Products and their metadata, see the products package
+	CollectionProducts = "products"
 
 	DefaultPageSize = 20
 	MaxPageSize     = 100
@@ -17,11 +28,30 @@ const (
 	ProductContextKey = "product"
 	ProductParamKey   = "product"
 
+	// Product registry
+	MaxProductNameLength = 32 // Characters, names prefix collections and storage paths
+	MaxProductOwners     = 20
+	ProductRegistryTTL   = 60 // seconds the registry is used before it is read again
+
+	// Admin endpoints
+	HeaderAdminKey = "X-Admin-Key" // Compared to ADMIN_API_KEY
+
+	// User authentication, with Firebase ID tokens
+	HeaderAuthorization = "Authorization"
+	BearerPrefix        = "Bearer "
+	UserContextKey      = "userId"
+	UserEmailContextKey = "userEmail"
+
 	// Resource Types
 	ResourceTypeVideo   = "video"
 	ResourceTypePDF     = "pdf"
 	ResourceTypeArticle = "article"
 	ResourceTypeImage   = "image"
+	ResourceTypeAudio   = "audio"  // mp3, ogg, m4a
+	ResourceTypeSlides  = "slides" // pptx, odp, converted to PDF for previews
+	ResourceTypeCode    = "code"   // zip archives of code samples
+	ResourceTypeEmbed   = "embed"  // YouTube, Vimeo or Loom URLs, resolved with oEmbed
+	FileTypeJobOutput   = "output" // HLS playlists and segments of transcoded videos, not a resource type
 
 	// Query Parameter Names
 	QueryParamType   = "type"
@@ -29,6 +59,12 @@ const (
 	QueryParamSearch = "search"
 	QueryParamCursor = "cursor"
 	QueryParamLimit  = "limit"
+	QueryParamWidth  = "w"
+	QueryParamFormat = "format"
+
+	// Query parameters of catalog and CSV imports
+	QueryParamStrategy = "strategy"
+	QueryParamDryRun   = "dryRun"
 
 	// Form Field Names
 	FormFieldTitle        = "title"
@@ -39,6 +75,81 @@ const (
 	FormFieldTags         = "tags"
 	FormFieldFile         = "file"
 	FormFieldThumbnail    = "thumbnail"
+	FormFieldCustomFields = "customFields" // JSON object of custom field values
+	FormFieldArchive      = "archive"      // Catalog archive to import
+	FormFieldProduct      = "product"      // Product a resource is copied or moved to
+
+	// Collection fields
+	FormFieldItems         = "items"
+	FormFieldCoverImageURL = "coverImageUrl"
+	FormFieldMoves         = "moves" // Reorder operations of a collection's items
+
+	// Product fields
+	FormFieldName         = "name"
+	FormFieldDisplayName  = "displayName"
+	FormFieldOwners       = "owners"
+	FormFieldDefaultTags  = "defaultTags"
+	FormFieldUploadPolicy = "uploadPolicy"
+
+	// Request content types
+	ContentTypeMultipart  = "multipart/form-data"
+	ContentTypeJSON       = "application/json"
+	ContentTypeMergePatch = "application/merge-patch+json" // RFC 7396
+	ContentTypeJSONPatch  = "application/json-patch+json"  // RFC 6902
+	ContentTypeCSV        = "text/csv"
+	MaxJSONBodySize       = 1 << 20 // 1MB
+
+	// Custom field types
+	FieldTypeString  = "string"
+	FieldTypeNumber  = "number"
+	FieldTypeBoolean = "boolean"
+	FieldTypeEnum    = "enum" // One of the values listed in the definition
+
+	MaxCustomFields      = 50   // Custom fields defined per product
+	MaxFieldEnumValues   = 100  // Values of an enum field
+	MaxFieldStringLength = 1000 // Characters of string values, enum values, labels and descriptions
+
+	// Resource limits, in characters
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 50000
+	MaxURLLength         = 2048
+	MaxTags              = 20 // Tags per resource
+	MaxTagLength         = 50
+
+	// Bulk operations on resources
+	BulkOpAddTags       = "addTags"
+	BulkOpRemoveTags    = "removeTags"
+	BulkOpUpdate        = "update" // Title, description and custom fields
+	BulkOpDelete        = "delete"
+	BulkStatusSucceeded = "succeeded"
+	BulkStatusFailed    = "failed"
+	MaxBulkOperations   = 500 // Operations per request
+	BulkBatchSize       = 100 // Operations whose resources are read and written together
+
+	// Collections of resources
+	MaxCollectionItems      = 500 // Resources and section headings of a collection
+	MaxCollectionMoves      = 100 // Moves per reorder request
+	MaxCollectionReferences = 30  // Resources whose references are removed per query, Firestore's limit of array-contains-any
+
+	// Learning progress of users
+	ProgressStatusStarted   = "started"
+	ProgressStatusCompleted = "completed"
+	FormFieldStatus         = "status"
+	FormFieldPosition       = "position" // Playback position of videos and audio, in seconds
+	FormFieldPage           = "page"     // Last page read of PDFs and slide decks
+
+	// CSV imports of link resources
+	MaxCSVSize = 5 << 20 // 5MB
+	MaxCSVRows = 5000    // Resources per import
+
+	// Catalog archives, see package catalog
+	CatalogFormatVersion     = 1
+	ImportStrategySkip       = "skip"      // Keep existing resources
+	ImportStrategyOverwrite  = "overwrite" // Replace existing resources
+	ImportStrategyDuplicate  = "duplicate" // Import conflicting resources under new IDs
+	MaxImportSize            = 20 << 30    // 20GB
+	MaxCatalogManifestRecord = 16 << 20    // 16MB, bytes of a manifest line
+	ImportMemoryLimit        = 32 << 20    // 32MB of an uploaded archive held in memory, the rest is spooled to disk
 
 	// Default Values
 	DefaultLimitValue = "20"
@@ -48,13 +159,128 @@ const (
 
 	// Error message prefixes
 	ErrFileValidationFailed = "file validation failed"
+	ErrMalwareDetected      = "malware detected"
+	ErrScanSizeLimit        = "file exceeds the malware scanner's size limit"
+
+	// PDF active content handling modes
+	PDFActiveContentReject   = "reject"   // reject PDFs containing active content
+	PDFActiveContentSanitize = "sanitize" // strip active content and store the cleaned PDF
+
+	// Malware scanners
+	ScannerNone   = "none"
+	ScannerClamAV = "clamav"
+
+	// Storage prefix that infected uploads are moved to
+	QuarantinePrefix = "quarantine/"
+
+	// Generated thumbnails
+	ThumbnailMaxWidth    = 640 // pixels, smaller images are not upscaled
+	ThumbnailJPEGQuality = 85
+	ThumbnailTimeout     = 60 // seconds allowed for rendering one thumbnail
+
+	// Warnings returned with a successful response
+	WarningDuplicateFile = "DUPLICATE_FILE" // the uploaded file already backs another resource
+
+	// Media metadata extraction
+	MetadataTimeout = 30 // seconds allowed for probing one upload
+
+	// Archive safety checks, for code archives and slide decks
+	ArchiveMaxEntries          = 10000
+	ArchiveMaxUncompressedSize = 2 << 30 // 2GB once extracted
+	ArchiveMaxCompressionRatio = 100     // per entry, above this it is likely a zip bomb
+	ArchiveRatioMinSize        = 1 << 20 // bytes, smaller entries are not checked for their ratio
+	ArchiveListingLimit        = 200     // entries listed in the metadata of a code archive
+
+	// oEmbed resolution of embeds
+	EmbedTimeout         = 10      // seconds allowed for a provider to answer
+	EmbedMaxResponseSize = 1 << 20 // 1MB
+
+	// Slide deck conversion statuses
+	ConversionStatusPending    = "pending"
+	ConversionStatusProcessing = "processing"
+	ConversionStatusReady      = "ready"
+	ConversionStatusFailed     = "failed"
+
+	// Slide deck conversion output
+	ConvertedPathSegment = "converted" // <product>/converted/<resource id>/<job>/
+	ConvertedPDFName     = "slides.pdf"
+	ConvertTimeout       = 10 // minutes allowed for converting one deck
+
+	// Video transcoding statuses
+	TranscodeStatusPending    = "pending"
+	TranscodeStatusProcessing = "processing"
+	TranscodeStatusReady      = "ready"
+	TranscodeStatusFailed     = "failed"
+
+	// HLS output
+	HLSPathSegment       = "hls"         // <product>/hls/<resource id>/<job>/
+	HLSMasterPlaylist    = "master.m3u8" // Master playlist, next to one directory per rendition
+	HLSSegmentSeconds    = 6
+	HLSContentType       = "application/vnd.apple.mpegurl"
+	TranscodeTimeout     = 120 // minutes allowed for transcoding one video
+	MediaTokenParam      = "token"
+	MediaTokenExpiration = DefaultSignedURLExpiration // minutes
+	HLSSegmentExpiration = 360                        // minutes segment URLs stay valid, long enough to watch a whole video
+
+	// Job statuses
+	JobStatusQueued    = "queued"    // waiting for a worker, possibly to be retried
+	JobStatusRunning   = "running"   // claimed by a worker
+	JobStatusSucceeded = "succeeded" // done
+	JobStatusDead      = "dead"      // failed permanently or ran out of attempts
+
+	// Job types
+	JobTypeTranscode = "transcode" // transcode an uploaded video to HLS
+	JobTypeThumbnail = "thumbnail" // generate a thumbnail, or the variants of an uploaded one
+	JobTypePurge     = "purge"     // delete storage objects that are no longer referenced
+	JobTypeConvert   = "convert"   // convert an uploaded slide deck to PDF
+	JobTypeGC        = "gc"        // delete orphaned storage objects of a product, see the gc package
+
+	// Job queue tuning
+	JobPollInterval       = 5    // seconds an idle worker waits before looking for due jobs again
+	JobLeaseDuration      = 300  // seconds a claimed job is reserved for its worker, renewed while it runs
+	JobRetryBaseDelay     = 30   // seconds before the first retry, doubled for every further attempt
+	JobRetryMaxDelay      = 3600 // seconds
+	JobDefaultMaxAttempts = 5
+	JobDefaultTimeout     = 600 // seconds allowed for one attempt
+	JobRetention          = 7   // days finished jobs are kept before Firestore's TTL policy deletes them
+
+	// Orphaned storage object collection
+	StorageGCGracePeriod = 24  // hours an unreferenced object is kept, longer than any upload or job
+	StorageGCTimeout     = 60  // minutes allowed for collecting one product
+	MaxObjectReleases    = 100 // release IDs recorded per stored object, more than the purges of it that may still be retried
+
+	// Image variant formats
+	ImageFormatJPEG = "jpeg"
+	ImageFormatWebP = "webp"
+	ImageFormatAVIF = "avif"
 )
 
-// ResourceTypes ...
+// ImageVariantWidths are the widths, in pixels, responsive image variants are
+// generated at. Requested widths are snapped to one of them, so arbitrary
+// widths cannot fill the bucket with derivatives.
+var ImageVariantWidths = []int{160, 320, ThumbnailMaxWidth}
+
+// ImageVariantFormats are the formats responsive image variants are generated in
+var ImageVariantFormats = []string{
+	ImageFormatJPEG,
+	ImageFormatWebP,
+	ImageFormatAVIF,
+}
+
+// ReservedProductNames cannot name a product, as they are the first path
+// segment of routes that are not product routes
+var ReservedProductNames = []string{"admin", "products"}
+
+// ResourceTypes are the built-in resource types. Handlers and validation use
+// the types registered with utils.RegisterResourceType, which include these.
 var ResourceTypes = []string{
 	ResourceTypeVideo,
 	ResourceTypePDF,
 	ResourceTypeArticle,
+	ResourceTypeAudio,
+	ResourceTypeSlides,
+	ResourceTypeCode,
+	ResourceTypeEmbed,
 }
 
 // GetResourcesCollectionName returns the collection name for resources for a given productMore actions
@@ -68,3 +294,33 @@ func GetResourcesCollectionName(product string) string {
 func GetTagsCollectionName(product string) string {
 	return product + CollectionSuffixTags
 }
+
+// GetObjectsCollectionName returns the collection name for stored objects for a given product
+// product_name + "_objects"
+func GetObjectsCollectionName(product string) string {
+	return product + CollectionSuffixObjects
+}
+
+// GetFieldsCollectionName returns the collection name for custom field definitions for a given product
+// product_name + "_fields"
+func GetFieldsCollectionName(product string) string {
+	return product + CollectionSuffixFields
+}
+
+// GetCollectionsCollectionName returns the collection name for the collections of resources of a given product
+// product_name + "_collections"
+func GetCollectionsCollectionName(product string) string {
+	return product + CollectionSuffixCollections
+}
+
+// GetProgressCollectionName returns the collection name for the progress of users through the resources of a given product
+// product_name + "_progress"
+func GetProgressCollectionName(product string) string {
+	return product + CollectionSuffixProgress
+}
+
+// GetBookmarksCollectionName returns the collection name for the bookmarks of users of a given product
+// product_name + "_bookmarks"
+func GetBookmarksCollectionName(product string) string {
+	return product + CollectionSuffixBookmarks
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// BookmarkService stores the resources users of a product saved for later,
+// one document per user and resource
+type BookmarkService struct {
+	db *DB
+}
+
+// NewBookmarkService creates a new bookmark service
+func NewBookmarkService(db *DB) *BookmarkService {
+	return &BookmarkService{db: db}
+}
+
+// ListByUser retrieves the bookmarks of a user
+func (bs *BookmarkService) ListByUser(ctx context.Context, product, userID string) ([]models.Bookmark, error) {
+	collectionName := constants.GetBookmarksCollectionName(product)
+	docs, err := bs.db.client.Collection(collectionName).Where("userId", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]models.Bookmark, 0, len(docs))
+	for _, doc := range docs {
+		var bookmark models.Bookmark
+		if err := doc.DataTo(&bookmark); err != nil {
+			return nil, fmt.Errorf("failed to read bookmark %s: %w", doc.Ref.ID, err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, nil
+}
+
+// Bookmarked returns which of resourceIDs a user bookmarked
+func (bs *BookmarkService) Bookmarked(ctx context.Context, product, userID string, resourceIDs []string) (map[string]bool, error) {
+	bookmarked := make(map[string]bool, len(resourceIDs))
+	if len(resourceIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	collectionName := constants.GetBookmarksCollectionName(product)
+	refs := make([]*firestore.DocumentRef, len(resourceIDs))
+	for i, id := range resourceIDs {
+		refs[i] = bs.db.client.Collection(collectionName).Doc(userResourceID(userID, id))
+	}
+
+	docs, err := bs.db.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		bookmarked[resourceIDs[i]] = doc.Exists()
+	}
+	return bookmarked, nil
+}
+
+// Add bookmarks a resource for a user, and returns the bookmark and whether
+// it was created. Bookmarking a resource twice keeps the first bookmark.
+func (bs *BookmarkService) Add(ctx context.Context, product string, bookmark models.Bookmark) (*models.Bookmark, bool, error) {
+	collectionName := constants.GetBookmarksCollectionName(product)
+	docRef := bs.db.client.Collection(collectionName).Doc(userResourceID(bookmark.UserID, bookmark.ResourceID))
+
+	_, err := docRef.Create(ctx, bookmark)
+	if status.Code(err) != codes.AlreadyExists {
+		if err != nil {
+			return nil, false, err
+		}
+		return &bookmark, true, nil
+	}
+
+	doc, err := docRef.Get(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var existing models.Bookmark
+	if err := doc.DataTo(&existing); err != nil {
+		return nil, false, fmt.Errorf("failed to read bookmark %s: %w", doc.Ref.ID, err)
+	}
+	return &existing, false, nil
+}
+
+// Remove removes the bookmark of a user on a resource, if any
+func (bs *BookmarkService) Remove(ctx context.Context, product, userID, resourceID string) error {
+	collectionName := constants.GetBookmarksCollectionName(product)
+	_, err := bs.db.client.Collection(collectionName).Doc(userResourceID(userID, resourceID)).Delete(ctx)
+	return err
+}
+
+// DeleteResources deletes the bookmarks of every user on deleted resources,
+// and returns the number of bookmarks deleted
+func (bs *BookmarkService) DeleteResources(ctx context.Context, product string, resourceIDs []string) (int, error) {
+	return bs.db.deleteByResources(ctx, constants.GetBookmarksCollectionName(product), resourceIDs)
+}
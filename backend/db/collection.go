@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// CollectionService stores the collections of a product's resources
+type CollectionService struct {
+	db *DB
+}
+
+// NewCollectionService creates a new collection service
+func NewCollectionService(db *DB) *CollectionService {
+	return &CollectionService{db: db}
+}
+
+// List retrieves the collections of a product, newest first
+func (cs *CollectionService) List(ctx context.Context, product string) ([]models.Collection, error) {
+	collectionName := constants.GetCollectionsCollectionName(product)
+	docs, err := cs.db.client.Collection(collectionName).OrderBy("createdAt", firestore.Desc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]models.Collection, 0, len(docs))
+	for _, doc := range docs {
+		collection, err := collectionOf(doc)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	return collections, nil
+}
+
+// Get retrieves a collection. It fails with codes.NotFound if the collection
+// does not exist.
+func (cs *CollectionService) Get(ctx context.Context, product, id string) (*models.Collection, error) {
+	collectionName := constants.GetCollectionsCollectionName(product)
+	doc, err := cs.db.client.Collection(collectionName).Doc(id).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return collectionOf(doc)
+}
+
+// Create creates a collection and returns its ID
+func (cs *CollectionService) Create(ctx context.Context, product string, collection models.Collection) (string, error) {
+	collectionName := constants.GetCollectionsCollectionName(product)
+	collection.IndexResources()
+	docRef, _, err := cs.db.client.Collection(collectionName).Add(ctx, collection)
+	if err != nil {
+		return "", err
+	}
+	return docRef.ID, nil
+}
+
+// Update applies update to a collection in a transaction and returns the
+// collection saved. It fails with codes.NotFound if the collection does not
+// exist, and with the error of update, which may run more than once.
+func (cs *CollectionService) Update(ctx context.Context, product, id string, update func(collection *models.Collection) error) (*models.Collection, error) {
+	collectionName := constants.GetCollectionsCollectionName(product)
+	docRef := cs.db.client.Collection(collectionName).Doc(id)
+
+	var collection *models.Collection
+	err := cs.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+		if collection, err = collectionOf(doc); err != nil {
+			return err
+		}
+		if err := update(collection); err != nil {
+			return err
+		}
+
+		collection.IndexResources()
+		return tx.Set(docRef, collection)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// Delete deletes a collection. The resources it lists are kept.
+func (cs *CollectionService) Delete(ctx context.Context, product, id string) error {
+	collectionName := constants.GetCollectionsCollectionName(product)
+	_, err := cs.db.client.Collection(collectionName).Doc(id).Delete(ctx)
+	return err
+}
+
+// RemoveResources removes deleted resources from the collections listing
+// them, and returns the number of collections changed. Section headings are
+// kept, even when no resource is left under them.
+func (cs *CollectionService) RemoveResources(ctx context.Context, product string, resourceIDs []string) (int, error) {
+	collectionName := constants.GetCollectionsCollectionName(product)
+
+	changed := 0
+	for chunk := range slices.Chunk(resourceIDs, constants.MaxCollectionReferences) {
+		docs, err := cs.db.client.Collection(collectionName).Where("resourceIds", "array-contains-any", chunk).Documents(ctx).GetAll()
+		if err != nil {
+			return changed, err
+		}
+
+		for _, doc := range docs {
+			_, err := cs.Update(ctx, product, doc.Ref.ID, func(collection *models.Collection) error {
+				collection.Items = slices.DeleteFunc(collection.Items, func(item models.CollectionItem) bool {
+					return item.ResourceID != "" && slices.Contains(chunk, item.ResourceID)
+				})
+				return nil
+			})
+			// Deleted meanwhile
+			if status.Code(err) == codes.NotFound {
+				continue
+			}
+			if err != nil {
+				return changed, fmt.Errorf("failed to update collection %s: %w", doc.Ref.ID, err)
+			}
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// collectionOf reads a collection document
+func collectionOf(doc *firestore.DocumentSnapshot) (*models.Collection, error) {
+	var collection models.Collection
+	if err := doc.DataTo(&collection); err != nil {
+		return nil, fmt.Errorf("failed to read collection %s: %w", doc.Ref.ID, err)
+	}
+	collection.ID = doc.Ref.ID
+	return &collection, nil
+}
@@ -2,9 +2,13 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
 
 	"cloud.google.com/go/firestore"
 
+	"learninghub/constants"
 	"learninghub/firebase"
 )
 
@@ -25,3 +29,46 @@ func (db *DB) Client() *firestore.Client {
 func (db *DB) RunTransaction(ctx context.Context, fn func(context.Context, *firestore.Transaction) error) error {
 	return db.client.RunTransaction(ctx, fn)
 }
+
+// deleteByResources deletes the documents of a collection whose resourceId
+// is one of resourceIDs, and returns the number of documents deleted
+func (db *DB) deleteByResources(ctx context.Context, collectionName string, resourceIDs []string) (int, error) {
+	deleted := 0
+	for chunk := range slices.Chunk(resourceIDs, constants.MaxCollectionReferences) {
+		docs, err := db.client.Collection(collectionName).Where("resourceId", "in", chunk).Documents(ctx).GetAll()
+		if err != nil {
+			return deleted, err
+		}
+		if len(docs) == 0 {
+			continue
+		}
+
+		bulkWriter := db.client.BulkWriter(ctx)
+		jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+		for _, doc := range docs {
+			job, err := bulkWriter.Delete(doc.Ref)
+			if err != nil {
+				bulkWriter.End()
+				return deleted, err
+			}
+			jobs = append(jobs, job)
+		}
+		bulkWriter.End()
+
+		for _, job := range jobs {
+			if _, err := job.Results(); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// userResourceID returns the ID of the document of a user and resource, e.g.
+// their progress through it. User IDs are hashed, as they may contain
+// characters document IDs cannot.
+func userResourceID(userID, resourceID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(sum[:16]) + "_" + resourceID
+}
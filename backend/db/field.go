@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// FieldService stores the custom field definitions of products, one document
+// per field keyed by its name
+type FieldService struct {
+	db *DB
+}
+
+// NewFieldService creates a new field service
+func NewFieldService(db *DB) *FieldService {
+	return &FieldService{db: db}
+}
+
+// List retrieves the field definitions of a product ordered by name
+func (fs *FieldService) List(ctx context.Context, product string) ([]models.FieldDefinition, error) {
+	collectionName := constants.GetFieldsCollectionName(product)
+	docs, err := fs.db.client.Collection(collectionName).OrderBy("name", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make([]models.FieldDefinition, 0, len(docs))
+	for _, doc := range docs {
+		var definition models.FieldDefinition
+		if err := doc.DataTo(&definition); err != nil {
+			return nil, fmt.Errorf("failed to read field %s: %w", doc.Ref.ID, err)
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, nil
+}
+
+// Set creates or replaces a field definition
+func (fs *FieldService) Set(ctx context.Context, product string, definition models.FieldDefinition) error {
+	collectionName := constants.GetFieldsCollectionName(product)
+	_, err := fs.db.client.Collection(collectionName).Doc(definition.Name).Set(ctx, definition)
+	return err
+}
+
+// Delete deletes a field definition. Values already stored on resources are
+// kept, but no longer validated nor filterable.
+func (fs *FieldService) Delete(ctx context.Context, product, name string) error {
+	collectionName := constants.GetFieldsCollectionName(product)
+	_, err := fs.db.client.Collection(collectionName).Doc(name).Delete(ctx)
+	return err
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// JobService stores background jobs. It implements jobs.Store.
+type JobService struct {
+	db *DB
+}
+
+// NewJobService creates a new job service
+func NewJobService(db *DB) *JobService {
+	return &JobService{db: db}
+}
+
+// Create creates a new job
+func (js *JobService) Create(ctx context.Context, job models.Job) (string, error) {
+	docRef, _, err := js.db.client.Collection(constants.CollectionJobs).Add(ctx, job)
+	if err != nil {
+		return "", err
+	}
+	return docRef.ID, nil
+}
+
+// CreateWithID creates a new job under the given ID, unless one exists
+func (js *JobService) CreateWithID(ctx context.Context, id string, job models.Job) (bool, error) {
+	_, err := js.db.client.Collection(constants.CollectionJobs).Doc(id).Create(ctx, job)
+	if status.Code(err) == codes.AlreadyExists {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get retrieves a single job by ID
+func (js *JobService) Get(ctx context.Context, id string) (*models.Job, error) {
+	doc, err := js.db.client.Collection(constants.CollectionJobs).Doc(id).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return jobFromDoc(doc)
+}
+
+// Due lists queued and running jobs available at now, oldest first.
+// Requires the (status, availableAt) composite index.
+func (js *JobService) Due(ctx context.Context, now time.Time, limit int) ([]models.Job, error) {
+	docs, err := js.db.client.Collection(constants.CollectionJobs).
+		Where("status", "in", []string{constants.JobStatusQueued, constants.JobStatusRunning}).
+		Where("availableAt", "<=", now).
+		OrderBy("availableAt", firestore.Asc).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]models.Job, 0, len(docs))
+	for _, doc := range docs {
+		job, err := jobFromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// Update applies fn to a job in a transaction
+func (js *JobService) Update(ctx context.Context, id string, fn func(job *models.Job) error) error {
+	docRef := js.db.client.Collection(constants.CollectionJobs).Doc(id)
+
+	return js.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		job, err := jobFromDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(job); err != nil {
+			return err
+		}
+		return tx.Set(docRef, job)
+	})
+}
+
+// jobFromDoc converts a job document
+func jobFromDoc(doc *firestore.DocumentSnapshot) (*models.Job, error) {
+	var job models.Job
+	if err := doc.DataTo(&job); err != nil {
+		return nil, err
+	}
+	job.ID = doc.Ref.ID
+	return &job, nil
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// ObjectService counts the references to content-addressed uploads
+type ObjectService struct {
+	db *DB
+}
+
+// NewObjectService creates a new object service
+func NewObjectService(db *DB) *ObjectService {
+	return &ObjectService{db: db}
+}
+
+// Acquire adds a reference to the object stored for a content hash and
+// returns its registration, whose object may be stored under another type
+// than the caller's. It returns nil, adding nothing, when no object is
+// registered for the hash.
+func (obs *ObjectService) Acquire(ctx context.Context, product, sha256 string) (*models.StoredObject, error) {
+	docRef := obs.db.client.Collection(constants.GetObjectsCollectionName(product)).Doc(sha256)
+
+	var acquired *models.StoredObject
+	err := obs.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		acquired = nil
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var object models.StoredObject
+		if err := doc.DataTo(&object); err != nil {
+			return err
+		}
+		if object.Refs <= 0 {
+			return nil
+		}
+
+		object.Refs++
+		object.UpdatedAt = time.Now()
+		acquired = &object
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "refs", Value: firestore.Increment(1)},
+			{Path: "updatedAt", Value: object.UpdatedAt},
+		})
+	})
+
+	return acquired, err
+}
+
+// Register records a newly written object with one reference and returns the
+// registration. If another upload registered the same content meanwhile, a
+// reference is added to it instead, its registration is returned and existed
+// is true. Generations of the same object only grow, so the latest write
+// wins; an object written under another name than the registered one is left
+// for garbage collection.
+func (obs *ObjectService) Register(ctx context.Context, product, sha256 string, object models.StoredObject) (registered models.StoredObject, existed bool, err error) {
+	docRef := obs.db.client.Collection(constants.GetObjectsCollectionName(product)).Doc(sha256)
+
+	err = obs.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		existed = false
+		now := time.Now()
+
+		doc, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if err == nil {
+			var current models.StoredObject
+			if err := doc.DataTo(&current); err != nil {
+				return err
+			}
+			if current.Refs > 0 {
+				existed = true
+				current.Refs++
+				if current.Object == object.Object && object.Generation > current.Generation {
+					current.Generation = object.Generation
+				}
+				current.UpdatedAt = now
+				registered = current
+				return tx.Set(docRef, current)
+			}
+		}
+
+		object.Refs = 1
+		object.CreatedAt, object.UpdatedAt = now, now
+		registered = object
+		return tx.Set(docRef, object)
+	})
+
+	return registered, existed, err
+}
+
+// Release removes a reference to the object stored for a content hash. When
+// the last one goes, the registration is deleted and returned so the caller
+// can delete the object. Nil is returned while references remain, or when the
+// hash is not registered.
+//
+// A release ID is recorded on the registration, and releasing with it again
+// removes nothing, see models.StoredObject.Release.
+func (obs *ObjectService) Release(ctx context.Context, product, sha256, releaseID string) (*models.StoredObject, error) {
+	docRef := obs.db.client.Collection(constants.GetObjectsCollectionName(product)).Doc(sha256)
+
+	var released *models.StoredObject
+	err := obs.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		released = nil
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var object models.StoredObject
+		if err := doc.DataTo(&object); err != nil {
+			return err
+		}
+
+		if !object.Release(releaseID, time.Now()) {
+			return nil // Released by an earlier attempt
+		}
+		if object.Refs > 0 {
+			return tx.Update(docRef, []firestore.Update{
+				{Path: "refs", Value: object.Refs},
+				{Path: "releases", Value: object.Releases},
+				{Path: "updatedAt", Value: object.UpdatedAt},
+			})
+		}
+
+		released = &object
+		return tx.Delete(docRef)
+	})
+
+	return released, err
+}
+
+// List returns the registered objects of a product keyed by content hash
+func (obs *ObjectService) List(ctx context.Context, product string) (map[string]models.StoredObject, error) {
+	docs, err := obs.db.client.Collection(constants.GetObjectsCollectionName(product)).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]models.StoredObject, len(docs))
+	for _, doc := range docs {
+		var object models.StoredObject
+		if err := doc.DataTo(&object); err != nil {
+			return nil, err
+		}
+		objects[doc.Ref.ID] = object
+	}
+	return objects, nil
+}
+
+// Remove deletes the registration of an object whatever its references,
+// unless it was updated after updatedBefore. It returns whether the
+// registration is gone.
+func (obs *ObjectService) Remove(ctx context.Context, product, sha256 string, updatedBefore time.Time) (bool, error) {
+	docRef := obs.db.client.Collection(constants.GetObjectsCollectionName(product)).Doc(sha256)
+
+	removed := false
+	err := obs.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		removed = false
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			removed = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var object models.StoredObject
+		if err := doc.DataTo(&object); err != nil {
+			return err
+		}
+		if object.UpdatedAt.After(updatedBefore) {
+			return nil
+		}
+
+		removed = true
+		return tx.Delete(docRef)
+	})
+
+	return removed, err
+}
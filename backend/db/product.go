@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// ProductService stores the products of the registry, one document per
+// product keyed by its name
+type ProductService struct {
+	db *DB
+}
+
+// NewProductService creates a new product service
+func NewProductService(db *DB) *ProductService {
+	return &ProductService{db: db}
+}
+
+// List retrieves every product, archived ones included, ordered by name
+func (ps *ProductService) List(ctx context.Context) ([]models.Product, error) {
+	docs, err := ps.db.client.Collection(constants.CollectionProducts).OrderBy("name", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]models.Product, 0, len(docs))
+	for _, doc := range docs {
+		var product models.Product
+		if err := doc.DataTo(&product); err != nil {
+			return nil, fmt.Errorf("failed to read product %s: %w", doc.Ref.ID, err)
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// Get retrieves a product, archived or not. It fails with codes.NotFound if
+// there is no such product.
+func (ps *ProductService) Get(ctx context.Context, name string) (*models.Product, error) {
+	doc, err := ps.db.client.Collection(constants.CollectionProducts).Doc(name).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var product models.Product
+	if err := doc.DataTo(&product); err != nil {
+		return nil, fmt.Errorf("failed to read product %s: %w", name, err)
+	}
+	return &product, nil
+}
+
+// Create stores a new product. It fails with codes.AlreadyExists if the name
+// is taken, by an archived product too.
+func (ps *ProductService) Create(ctx context.Context, product models.Product) error {
+	_, err := ps.db.client.Collection(constants.CollectionProducts).Doc(product.Name).Create(ctx, product)
+	return err
+}
+
+// Archive marks a product archived and returns it. Archiving an archived
+// product changes nothing. It fails with codes.NotFound if there is no such
+// product.
+func (ps *ProductService) Archive(ctx context.Context, name string, now time.Time) (*models.Product, error) {
+	docRef := ps.db.client.Collection(constants.CollectionProducts).Doc(name)
+
+	var product models.Product
+	err := ps.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		product = models.Product{}
+
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+		if err := doc.DataTo(&product); err != nil {
+			return err
+		}
+		if product.Archived {
+			return nil
+		}
+
+		product.Archived = true
+		product.ArchivedAt = &now
+		product.UpdatedAt = now
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "archived", Value: true},
+			{Path: "archivedAt", Value: now},
+			{Path: "updatedAt", Value: now},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
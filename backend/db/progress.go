@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// ProgressService stores the progress of users through a product's
+// resources, one document per user and resource
+type ProgressService struct {
+	db *DB
+}
+
+// NewProgressService creates a new progress service
+func NewProgressService(db *DB) *ProgressService {
+	return &ProgressService{db: db}
+}
+
+// ListByUser retrieves the progress of a user through the resources of a
+// product
+func (ps *ProgressService) ListByUser(ctx context.Context, product, userID string) ([]models.Progress, error) {
+	collectionName := constants.GetProgressCollectionName(product)
+	docs, err := ps.db.client.Collection(collectionName).Where("userId", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]models.Progress, 0, len(docs))
+	for _, doc := range docs {
+		var progress models.Progress
+		if err := doc.DataTo(&progress); err != nil {
+			return nil, fmt.Errorf("failed to read progress %s: %w", doc.Ref.ID, err)
+		}
+		list = append(list, progress)
+	}
+	return list, nil
+}
+
+// Update applies update to the progress of a user through a resource in a
+// transaction, and returns the progress saved. Progress that does not exist
+// is passed empty, with exists unset. update may run more than once.
+func (ps *ProgressService) Update(ctx context.Context, product, userID, resourceID string, update func(progress *models.Progress, exists bool) error) (*models.Progress, error) {
+	collectionName := constants.GetProgressCollectionName(product)
+	docRef := ps.db.client.Collection(collectionName).Doc(userResourceID(userID, resourceID))
+
+	var progress models.Progress
+	err := ps.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		progress = models.Progress{}
+		doc, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		exists := err == nil
+		if exists {
+			if err := doc.DataTo(&progress); err != nil {
+				return err
+			}
+		}
+		if err := update(&progress, exists); err != nil {
+			return err
+		}
+
+		progress.UserID, progress.ResourceID = userID, resourceID
+		return tx.Set(docRef, progress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// DeleteResources deletes the progress of every user through deleted
+// resources, and returns the number of documents deleted
+func (ps *ProgressService) DeleteResources(ctx context.Context, product string, resourceIDs []string) (int, error) {
+	return ps.db.deleteByResources(ctx, constants.GetProgressCollectionName(product), resourceIDs)
+}
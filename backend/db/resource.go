@@ -2,12 +2,18 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"learninghub/constants"
 	"learninghub/models"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ResourceQuery represents query parameters for listing resources
@@ -18,6 +24,21 @@ type ResourceQuery struct {
 	Search  string
 	Cursor  string
 	Limit   int
+	Fields  map[string]any // Custom field values to match, keyed by field name
+}
+
+// ErrResourceChanged is returned for a bulk write to a resource that changed
+// since it was read
+var ErrResourceChanged = errors.New("resource changed since it was read")
+
+// ResourceWrite is a change to a resource of a bulk write: field updates, or
+// its deletion. It fails if the resource changed since it was read at
+// UpdateTime.
+type ResourceWrite struct {
+	ID         string
+	Updates    map[string]any // Values by field path, nil deletes a field
+	Delete     bool
+	UpdateTime time.Time
 }
 
 // ResourceService handles resource database operations
@@ -45,6 +66,12 @@ func (rs *ResourceService) List(ctx context.Context, query ResourceQuery) ([]*fi
 		firestoreQuery = firestoreQuery.Where("tags", "array-contains-any", query.Tags)
 	}
 
+	// Apply custom field filters. Each field needs its own composite index
+	// with createdAt.
+	for name, value := range query.Fields {
+		firestoreQuery = firestoreQuery.Where("customFields."+name, "==", value)
+	}
+
 	// Apply cursor for pagination
 	if query.Cursor != "" {
 		if offset, err := strconv.Atoi(query.Cursor); err == nil && offset >= 0 {
@@ -62,6 +89,58 @@ func (rs *ResourceService) GetByID(ctx context.Context, product, id string) (*fi
 	return rs.db.client.Collection(collectionName).Doc(id).Get(ctx)
 }
 
+// GetAll retrieves resources by ID, in order. Snapshots of missing
+// resources do not exist.
+func (rs *ResourceService) GetAll(ctx context.Context, product string, ids []string) ([]*firestore.DocumentSnapshot, error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = rs.db.client.Collection(collectionName).Doc(id)
+	}
+	return rs.db.client.GetAll(ctx, refs)
+}
+
+// BulkWrite applies writes to distinct resources independently, and returns
+// the error of each write, in order. A resource that changed since it was
+// read fails with ErrResourceChanged.
+func (rs *ResourceService) BulkWrite(ctx context.Context, product string, writes []ResourceWrite) []error {
+	collectionName := constants.GetResourcesCollectionName(product)
+	results := make([]error, len(writes))
+
+	bulkWriter := rs.db.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, len(writes))
+	for i, write := range writes {
+		docRef := rs.db.client.Collection(collectionName).Doc(write.ID)
+		precondition := firestore.LastUpdateTime(write.UpdateTime)
+		if write.Delete {
+			jobs[i], results[i] = bulkWriter.Delete(docRef, precondition)
+			continue
+		}
+
+		updates := make([]firestore.Update, 0, len(write.Updates))
+		for path, value := range write.Updates {
+			if value == nil {
+				value = firestore.Delete
+			}
+			updates = append(updates, firestore.Update{Path: path, Value: value})
+		}
+		jobs[i], results[i] = bulkWriter.Update(docRef, updates, precondition)
+	}
+	bulkWriter.End()
+
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if _, err := job.Results(); status.Code(err) == codes.FailedPrecondition {
+			results[i] = ErrResourceChanged
+		} else {
+			results[i] = err
+		}
+	}
+	return results
+}
+
 // Create creates a new resource
 func (rs *ResourceService) Create(ctx context.Context, product string, resource models.Resource) (*firestore.DocumentRef, error) {
 	collectionName := constants.GetResourcesCollectionName(product)
@@ -69,13 +148,297 @@ func (rs *ResourceService) Create(ctx context.Context, product string, resource
 	return docRef, err
 }
 
-// Update updates an existing resource
-func (rs *ResourceService) Update(ctx context.Context, product, id string, resource models.Resource) error {
+// CreateAll creates resources independently, and returns the ID and error of
+// each, in order
+func (rs *ResourceService) CreateAll(ctx context.Context, product string, resources []models.Resource) ([]string, []error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	ids := make([]string, len(resources))
+	results := make([]error, len(resources))
+
+	bulkWriter := rs.db.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, len(resources))
+	for i, resource := range resources {
+		docRef := rs.db.client.Collection(collectionName).NewDoc()
+		ids[i] = docRef.ID
+		jobs[i], results[i] = bulkWriter.Create(docRef, resource)
+	}
+	bulkWriter.End()
+
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		_, results[i] = job.Results()
+	}
+	return ids, results
+}
+
+// CreateWithID creates a resource with a given ID. It fails with
+// codes.AlreadyExists if the ID is taken.
+func (rs *ResourceService) CreateWithID(ctx context.Context, product, id string, resource models.Resource) error {
+	collectionName := constants.GetResourcesCollectionName(product)
+	_, err := rs.db.client.Collection(collectionName).Doc(id).Create(ctx, resource)
+	return err
+}
+
+// NewID returns a new random resource ID, without writing anything
+func (rs *ResourceService) NewID(product string) string {
+	collectionName := constants.GetResourcesCollectionName(product)
+	return rs.db.client.Collection(collectionName).NewDoc().ID
+}
+
+// Replace overwrites a resource with another version as it is, the state of
+// its background jobs included, e.g. an imported one
+func (rs *ResourceService) Replace(ctx context.Context, product, id string, resource models.Resource) error {
 	collectionName := constants.GetResourcesCollectionName(product)
 	_, err := rs.db.client.Collection(collectionName).Doc(id).Set(ctx, resource)
 	return err
 }
 
+// Update updates an existing resource.
+//
+// Background jobs update the resource's transcode, conversion and thumbnail
+// state while it may be edited, so the stored state is kept when it belongs to
+// the same job: an edit read before the job finished does not roll its results
+// back.
+func (rs *ResourceService) Update(ctx context.Context, product, id string, resource models.Resource) error {
+	collectionName := constants.GetResourcesCollectionName(product)
+	docRef := rs.db.client.Collection(collectionName).Doc(id)
+
+	return rs.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+		if resource.Transcode != nil || resource.Conversion != nil || resource.ThumbnailJob != nil {
+			doc, err := tx.Get(docRef)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+
+			if err == nil {
+				var current models.Resource
+				if err := doc.DataTo(&current); err != nil {
+					return err
+				}
+				if resource.Transcode != nil && current.Transcode != nil && current.Transcode.Source == resource.Transcode.Source {
+					resource.Transcode = current.Transcode
+				}
+				if resource.Conversion != nil && current.Conversion != nil && current.Conversion.Source == resource.Conversion.Source {
+					resource.Conversion = current.Conversion
+					if resource.Metadata != nil && current.Metadata != nil {
+						resource.Metadata.Pages = current.Metadata.Pages
+					}
+				}
+				if resource.ThumbnailJob != nil && current.ThumbnailJob != nil && current.ThumbnailJob.Source == resource.ThumbnailJob.Source {
+					resource.ThumbnailJob = current.ThumbnailJob
+					resource.ThumbnailURL = current.ThumbnailURL
+					resource.Thumbnails = current.Thumbnails
+					resource.ThumbnailMetadata = current.ThumbnailMetadata
+				}
+			}
+		}
+
+		return tx.Set(docRef, resource)
+	})
+}
+
+// ForEach calls fn with every resource of a product, stopping at the first error
+func (rs *ResourceService) ForEach(ctx context.Context, product string, fn func(resource models.Resource) error) error {
+	collectionName := constants.GetResourcesCollectionName(product)
+	docs := rs.db.client.Collection(collectionName).Documents(ctx)
+	defer docs.Stop()
+
+	for {
+		doc, err := docs.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var resource models.Resource
+		if err := doc.DataTo(&resource); err != nil {
+			return fmt.Errorf("failed to read resource %s: %w", doc.Ref.ID, err)
+		}
+		resource.ID = doc.Ref.ID
+
+		if err := fn(resource); err != nil {
+			return err
+		}
+	}
+}
+
+// FindBySHA256 lists up to limit resources whose uploaded file has the given
+// SHA-256
+func (rs *ResourceService) FindBySHA256(ctx context.Context, product, sha256 string, limit int) ([]*firestore.DocumentSnapshot, error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	return rs.db.client.Collection(collectionName).Where("metadata.sha256", "==", sha256).Limit(limit).Documents(ctx).GetAll()
+}
+
+// SetThumbnailVariant records the URL of a single thumbnail variant without
+// touching the rest of the resource
+func (rs *ResourceService) SetThumbnailVariant(ctx context.Context, product, id, key, variantURL string) error {
+	collectionName := constants.GetResourcesCollectionName(product)
+	_, err := rs.db.client.Collection(collectionName).Doc(id).Update(ctx, []firestore.Update{
+		// A FieldPath, since variant keys contain dots
+		{FieldPath: firestore.FieldPath{"thumbnails", key}, Value: variantURL},
+	})
+	return err
+}
+
+// UpdateTranscode records the state of a video's transcoding job. The update is
+// skipped, returning false, when the resource is gone or its video was replaced
+// since the job started, so a stale job never overwrites a newer one.
+func (rs *ResourceService) UpdateTranscode(ctx context.Context, product, id string, transcode models.Transcode) (bool, error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	docRef := rs.db.client.Collection(collectionName).Doc(id)
+
+	updated := false
+	err := rs.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		updated = false
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var resource models.Resource
+		if err := doc.DataTo(&resource); err != nil {
+			return err
+		}
+		if resource.URL != transcode.Source {
+			return nil
+		}
+
+		updated = true
+		return tx.Update(docRef, []firestore.Update{{Path: "transcode", Value: transcode}})
+	})
+
+	return updated, err
+}
+
+// UpdateConversion records the state of a slide deck's PDF conversion job,
+// and the page count of the PDF when it is known. Like UpdateTranscode, the
+// update is skipped, returning false, when the resource is gone or its deck
+// was replaced since the job started.
+func (rs *ResourceService) UpdateConversion(ctx context.Context, product, id string, conversion models.Conversion, pages int) (bool, error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	docRef := rs.db.client.Collection(collectionName).Doc(id)
+
+	updated := false
+	err := rs.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		updated = false
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var resource models.Resource
+		if err := doc.DataTo(&resource); err != nil {
+			return err
+		}
+		if resource.URL != conversion.Source {
+			return nil
+		}
+
+		updates := []firestore.Update{{Path: "conversion", Value: conversion}}
+		if pages > 0 && resource.Metadata != nil {
+			updates = append(updates, firestore.Update{Path: "metadata.pages", Value: pages})
+		}
+
+		updated = true
+		return tx.Update(docRef, updates)
+	})
+
+	return updated, err
+}
+
+// StartThumbnailJob records a thumbnail job for source, unless the resource
+// already has a thumbnail or a thumbnail job. Returns whether the job was
+// recorded and must be enqueued.
+func (rs *ResourceService) StartThumbnailJob(ctx context.Context, product, id, source string) (bool, error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	docRef := rs.db.client.Collection(collectionName).Doc(id)
+
+	started := false
+	err := rs.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		started = false
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var resource models.Resource
+		if err := doc.DataTo(&resource); err != nil {
+			return err
+		}
+		if resource.ThumbnailURL != "" || resource.ThumbnailJob != nil {
+			return nil
+		}
+
+		started = true
+		return tx.Update(docRef, []firestore.Update{{Path: "thumbnailJob", Value: models.ThumbnailJob{Source: source}}})
+	})
+
+	return started, err
+}
+
+// CompleteThumbnail records the outcome of a thumbnail job started for source:
+// a generated thumbnail with its metadata and variants, or only the variants
+// when source is the resource's own thumbnail. Empty results record a failed
+// job. Nothing is written, returning false, when the resource is gone or its
+// thumbnail job was superseded since the job started.
+func (rs *ResourceService) CompleteThumbnail(ctx context.Context, product, id, source, thumbnailURL string, metadata *models.Metadata, variants map[string]string) (bool, error) {
+	collectionName := constants.GetResourcesCollectionName(product)
+	docRef := rs.db.client.Collection(collectionName).Doc(id)
+
+	updated := false
+	err := rs.db.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		updated = false
+
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var resource models.Resource
+		if err := doc.DataTo(&resource); err != nil {
+			return err
+		}
+		if resource.ThumbnailJob == nil || resource.ThumbnailJob.Source != source || resource.ThumbnailJob.Done {
+			return nil
+		}
+
+		updates := []firestore.Update{{Path: "thumbnailJob", Value: models.ThumbnailJob{Source: source, Done: true}}}
+		if thumbnailURL != "" && thumbnailURL != resource.ThumbnailURL {
+			updates = append(updates,
+				firestore.Update{Path: "thumbnailUrl", Value: thumbnailURL},
+				firestore.Update{Path: "thumbnailMetadata", Value: metadata},
+			)
+		}
+		if len(variants) > 0 {
+			updates = append(updates, firestore.Update{Path: "thumbnails", Value: variants})
+		}
+
+		updated = true
+		return tx.Update(docRef, updates)
+	})
+
+	return updated, err
+}
+
 // Delete deletes a resource by ID
 func (rs *ResourceService) Delete(ctx context.Context, product, id string) error {
 	collectionName := constants.GetResourcesCollectionName(product)
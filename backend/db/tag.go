@@ -37,6 +37,69 @@ func (ts *TagService) UpdateUsage(ctx context.Context, product string, tags []st
 	return nil
 }
 
+// maxTagsPerTransaction keeps AdjustUsage transactions within Firestore's
+// limit of 500 writes
+const maxTagsPerTransaction = 500
+
+// AdjustUsage applies usage count deltas to tags, in as few transactions as
+// possible rather than one per tag. Tags whose count reaches 0 are deleted.
+func (ts *TagService) AdjustUsage(ctx context.Context, product string, deltas map[string]int) error {
+	collectionName := constants.GetTagsCollectionName(product)
+
+	var tags []string
+	for tag, delta := range deltas {
+		if tag != "" && delta != 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	for len(tags) > 0 {
+		chunk := tags[:min(len(tags), maxTagsPerTransaction)]
+		tags = tags[len(chunk):]
+
+		refs := make([]*firestore.DocumentRef, len(chunk))
+		for i, tag := range chunk {
+			refs[i] = ts.db.client.Collection(collectionName).Doc(tag)
+		}
+
+		err := ts.db.RunTransaction(ctx, func(_ context.Context, tx *firestore.Transaction) error {
+			docs, err := tx.GetAll(refs)
+			if err != nil {
+				return err
+			}
+
+			for i, doc := range docs {
+				var existingTag models.Tag
+				if doc.Exists() {
+					if err := doc.DataTo(&existingTag); err != nil {
+						return err
+					}
+				}
+
+				newCount := max(0, existingTag.UsageCount+deltas[chunk[i]])
+				switch {
+				case newCount == 0 && doc.Exists():
+					err = tx.Delete(refs[i])
+				case newCount == 0:
+					continue
+				case doc.Exists():
+					err = tx.Update(refs[i], []firestore.Update{{Path: "usageCount", Value: newCount}})
+				default:
+					err = tx.Set(refs[i], models.Tag{Name: chunk[i], UsageCount: newCount})
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // updateSingleTagUsage updates the usage count for a single tag using a transaction
 func (ts *TagService) updateSingleTagUsage(ctx context.Context, product, tag string, delta int) error {
 	collectionName := constants.GetTagsCollectionName(product)
@@ -18,6 +18,7 @@ const (
 	ErrUnsupportedType    ErrorCode = "UNSUPPORTED_TYPE"
 	ErrMissingRequired    ErrorCode = "MISSING_REQUIRED"
 	ErrInvalidFileType    ErrorCode = "INVALID_FILE_TYPE"
+	ErrMalwareDetected    ErrorCode = "MALWARE_DETECTED"
 
 	// Authentication errors (4xx)
 	ErrUnauthorized      ErrorCode = "UNAUTHORIZED"
@@ -26,9 +27,16 @@ const (
 	ErrRateLimitExceeded ErrorCode = "RATE_LIMIT_EXCEEDED"
 
 	// Resource errors (4xx)
-	ErrResourceNotFound ErrorCode = "RESOURCE_NOT_FOUND"
-	ErrResourceExists   ErrorCode = "RESOURCE_EXISTS"
-	ErrTagNotFound      ErrorCode = "TAG_NOT_FOUND"
+	ErrResourceNotFound   ErrorCode = "RESOURCE_NOT_FOUND"
+	ErrResourceExists     ErrorCode = "RESOURCE_EXISTS"
+	ErrTagNotFound        ErrorCode = "TAG_NOT_FOUND"
+	ErrJobNotFound        ErrorCode = "JOB_NOT_FOUND"
+	ErrFieldNotFound      ErrorCode = "FIELD_NOT_FOUND"
+	ErrPatchTestFailed    ErrorCode = "PATCH_TEST_FAILED"
+	ErrResourceConflict   ErrorCode = "RESOURCE_CONFLICT"
+	ErrProductNotFound    ErrorCode = "PRODUCT_NOT_FOUND"
+	ErrProductExists      ErrorCode = "PRODUCT_EXISTS"
+	ErrCollectionNotFound ErrorCode = "COLLECTION_NOT_FOUND"
 
 	// Database errors (5xx)
 	ErrQueryFailed          ErrorCode = "QUERY_FAILED"
@@ -48,6 +56,16 @@ type ErrorResponse struct {
 	Error   ErrorCode `json:"error"`             // Error code for frontend translation
 	Message string    `json:"message,omitempty"` // Optional fallback message
 	Details string    `json:"details,omitempty"` // Optional additional details
+
+	FieldErrors []FieldError `json:"fieldErrors,omitempty"` // Rejected request fields, see the validation package
+}
+
+// FieldError describes why a request field was rejected
+type FieldError struct {
+	Field   string         `json:"field"`            // Request field, e.g. "title", "tags.2" or "customFields.difficulty"
+	Code    string         `json:"code"`             // Reason for frontend translation, e.g. "required" or "too_long"
+	Message string         `json:"message"`          // Fallback message
+	Params  map[string]any `json:"params,omitempty"` // Values the message refers to, e.g. {"max": 200}
 }
 
 // errorMetadata maps error codes to HTTP status codes
@@ -60,6 +78,7 @@ var errorMetadata = map[ErrorCode]int{
 	ErrUnsupportedType:    http.StatusBadRequest,
 	ErrMissingRequired:    http.StatusBadRequest,
 	ErrInvalidFileType:    http.StatusBadRequest,
+	ErrMalwareDetected:    http.StatusUnprocessableEntity,
 
 	// Authentication errors (4xx)
 	ErrUnauthorized:      http.StatusUnauthorized,
@@ -68,9 +87,16 @@ var errorMetadata = map[ErrorCode]int{
 	ErrRateLimitExceeded: http.StatusTooManyRequests,
 
 	// Resource errors (4xx)
-	ErrResourceNotFound: http.StatusNotFound,
-	ErrResourceExists:   http.StatusConflict,
-	ErrTagNotFound:      http.StatusNotFound,
+	ErrResourceNotFound:   http.StatusNotFound,
+	ErrResourceExists:     http.StatusConflict,
+	ErrTagNotFound:        http.StatusNotFound,
+	ErrJobNotFound:        http.StatusNotFound,
+	ErrFieldNotFound:      http.StatusNotFound,
+	ErrPatchTestFailed:    http.StatusConflict,
+	ErrResourceConflict:   http.StatusConflict,
+	ErrProductNotFound:    http.StatusNotFound,
+	ErrProductExists:      http.StatusConflict,
+	ErrCollectionNotFound: http.StatusNotFound,
 
 	// Database errors (5xx)
 	ErrQueryFailed:          http.StatusInternalServerError,
@@ -114,6 +140,23 @@ func RespondWithErrorDetails(c *gin.Context, code ErrorCode, message, details st
 	c.JSON(status, response)
 }
 
+// RespondWithFieldError sends an error response about a single request field,
+// with the message of the field error
+func RespondWithFieldError(c *gin.Context, code ErrorCode, fieldError FieldError) {
+	RespondWithFieldErrors(c, code, fieldError.Message, fieldError)
+}
+
+// RespondWithFieldErrors sends an error response listing the rejected request fields
+func RespondWithFieldErrors(c *gin.Context, code ErrorCode, message string, fieldErrors ...FieldError) {
+	status := GetHTTPStatus(code)
+	response := ErrorResponse{
+		Error:       code,
+		Message:     message,
+		FieldErrors: fieldErrors,
+	}
+	c.JSON(status, response)
+}
+
 // AbortWithError aborts the request with a standardized error response
 func AbortWithError(c *gin.Context, code ErrorCode, message string) {
 	status := GetHTTPStatus(code)
@@ -136,6 +136,32 @@ func TestRespondWithErrorDetails(t *testing.T) {
 	assert.Equal(t, details, response.Details)
 }
 
+func TestRespondWithFieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	fieldErrors := []FieldError{
+		{Field: "title", Code: "required", Message: "title is required"},
+		{Field: "tags.0", Code: "too_long", Message: "tags.0 must be at most 50 characters", Params: map[string]any{"max": 50}},
+	}
+	RespondWithFieldErrors(c, ErrInvalidParam, "Invalid resource", fieldErrors...)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]any
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "INVALID_PARAM", response["error"])
+	assert.Equal(t, "Invalid resource", response["message"])
+	assert.Equal(t, []any{
+		map[string]any{"field": "title", "code": "required", "message": "title is required"},
+		map[string]any{"field": "tags.0", "code": "too_long", "message": "tags.0 must be at most 50 characters", "params": map[string]any{"max": 50.0}},
+	}, response["fieldErrors"])
+}
+
 func TestAbortWithError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -7,6 +7,8 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"cloud.google.com/go/storage"
+	firebaseAdmin "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
 
 	"google.golang.org/api/option"
 
@@ -18,6 +20,7 @@ import (
 var (
 	FirestoreClient *firestore.Client
 	StorageClient   *storage.Client
+	AuthClient      *auth.Client // Verifies the ID tokens of users
 
 	StorageBucket string
 
@@ -52,6 +55,18 @@ func InitializeFirebase() error {
 
 	// opts = append([]option.ClientOption{option.WithScopes()}, opts...)
 
+	// Initialize Firebase Authentication, for the ID tokens of users
+	app, err := firebaseAdmin.NewApp(ctx, &firebaseAdmin.Config{ProjectID: config.AppConfig.FIREBASE_PROJECT_ID}, opts...)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("error initializing firebase app: %w", err)
+	}
+	AuthClient, err = app.Auth(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("error initializing auth: %w", err)
+	}
+
 	// Initialize Cloud Storage
 	StorageClient, err = storage.NewClient(ctx, opts...)
 	if err != nil {
@@ -89,6 +104,9 @@ func setEmulatorHosts() {
 
 	// need to set because we are using "cloud.google.com/go/storage" to create new storage client - https://github.com/firebase/firebase-admin-go/blob/570427a0f270b9adb061f54187a2b033548c3c9e/storage/storage.go#L38
 	os.Setenv("STORAGE_EMULATOR_HOST", firebaseStorageEmulatorHost)
+
+	// the auth client accepts the unsigned ID tokens of the emulator once set
+	os.Setenv("FIREBASE_AUTH_EMULATOR_HOST", config.AppConfig.FIREBASE_AUTH_EMULATOR_HOST)
 }
 
 func CloseFirebase() {
@@ -0,0 +1,244 @@
+// Package gc deletes storage objects no resource references anymore.
+//
+// Files are normally deleted when a resource releases them, but a failed
+// deletion is only logged, and a failed Firestore write after an upload leaves
+// a file no resource points to. The collector lists every object under a
+// product prefix, cross-references them with the product's resources and
+// deletes the orphans.
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/utils"
+)
+
+// Options tunes a collection
+type Options struct {
+	// GracePeriod protects recent objects, which may belong to an upload or a
+	// background job still in progress
+	GracePeriod time.Duration
+
+	// DryRun reports orphans without deleting them
+	DryRun bool
+}
+
+// Orphan is a stored object no resource references
+type Orphan struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"` // bytes
+	Created time.Time `json:"created"`
+}
+
+// Report summarizes the collection of a product
+type Report struct {
+	Product  string   `json:"product"`
+	DryRun   bool     `json:"dryRun"`
+	Scanned  int      `json:"scanned"`  // Objects listed under the product prefix
+	Orphans  []Orphan `json:"orphans"`  // Unreferenced objects older than the grace period
+	Deleted  int      `json:"deleted"`  // Orphans deleted, always 0 in a dry run
+	Freed    int64    `json:"freed"`    // bytes
+	Skipped  int      `json:"skipped"`  // Orphans referenced again or rewritten meanwhile
+	Failures int      `json:"failures"` // Orphans that could not be deleted
+}
+
+// storedObject is the part of a listed object the collector needs
+type storedObject struct {
+	Name       string
+	Size       int64
+	Generation int64
+	Created    time.Time
+}
+
+// references are the objects the resources of a product use
+type references struct {
+	objects  map[string]bool // Object names
+	prefixes []string        // HLS outputs, everything under them is used
+	content  map[string]bool // Content hashes of content-addressed uploads, covering their variants
+}
+
+// add records the object a URL points to. External links are ignored.
+func (r *references) add(fileURL string) {
+	objectName, ok := utils.StorageObjectName(fileURL)
+	if !ok {
+		return
+	}
+
+	r.objects[objectName] = true
+	if _, sha256, _, ok := utils.ParseContentObject(objectName); ok {
+		r.content[sha256] = true
+	}
+}
+
+// addResource records everything a resource references, including the
+// sources of its background jobs
+func (r *references) addResource(resource models.Resource) {
+	r.add(resource.URL)
+	r.add(resource.ThumbnailURL)
+	for _, variantURL := range resource.Thumbnails {
+		r.add(variantURL)
+	}
+	if resource.ThumbnailJob != nil {
+		r.add(resource.ThumbnailJob.Source)
+	}
+	if resource.Transcode != nil {
+		r.add(resource.Transcode.Source)
+	}
+	if resource.Conversion != nil {
+		r.add(resource.Conversion.Source)
+		r.add(resource.Conversion.URL)
+	}
+	for _, prefix := range resource.OutputPrefixes() {
+		r.prefixes = append(r.prefixes, strings.TrimSuffix(prefix, "/")+"/")
+	}
+}
+
+// used reports whether an object is referenced
+func (r *references) used(objectName string) bool {
+	if r.objects[objectName] {
+		return true
+	}
+	if _, sha256, _, ok := utils.ParseContentObject(objectName); ok && r.content[sha256] {
+		return true
+	}
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(objectName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findOrphans returns the objects that are neither referenced nor created
+// after cutoff. A content-addressed object whose registration was updated
+// after cutoff is kept too: an upload is about to reference it.
+func findOrphans(objects []storedObject, refs *references, registrations map[string]models.StoredObject, cutoff time.Time) []storedObject {
+	var orphans []storedObject
+	for _, object := range objects {
+		if object.Created.After(cutoff) || refs.used(object.Name) {
+			continue
+		}
+		if _, sha256, _, ok := utils.ParseContentObject(object.Name); ok {
+			if registration, registered := registrations[sha256]; registered && registration.UpdatedAt.After(cutoff) {
+				continue
+			}
+		}
+		orphans = append(orphans, object)
+	}
+	return orphans
+}
+
+// Collect finds the orphaned objects of a product and, unless in a dry run,
+// deletes them.
+//
+// Resources are read before objects are listed, so an object uploaded for a
+// resource saved in between is only protected by the grace period, which must
+// exceed the longest upload and background job.
+func Collect(ctx context.Context, product string, options Options) (*Report, error) {
+	cutoff := time.Now().Add(-options.GracePeriod)
+	database := db.New()
+
+	refs := &references{objects: map[string]bool{}, content: map[string]bool{}}
+	err := db.NewResourceService(database).ForEach(ctx, product, func(resource models.Resource) error {
+		refs.addResource(resource)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+
+	objectService := db.NewObjectService(database)
+	registrations, err := objectService.List(ctx, product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored objects: %w", err)
+	}
+
+	bucket := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	objects, err := listObjects(ctx, bucket, product+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Product: product, DryRun: options.DryRun, Scanned: len(objects), Orphans: []Orphan{}}
+	for _, object := range findOrphans(objects, refs, registrations, cutoff) {
+		report.Orphans = append(report.Orphans, Orphan{Name: object.Name, Size: object.Size, Created: object.Created})
+		if options.DryRun {
+			continue
+		}
+
+		deleted, err := deleteOrphan(ctx, bucket, objectService, product, object, cutoff)
+		switch {
+		case err != nil:
+			logger.Errorf("Failed to delete orphaned object %s: %v", object.Name, err)
+			report.Failures++
+		case deleted:
+			report.Deleted++
+			report.Freed += object.Size
+		default:
+			report.Skipped++
+		}
+	}
+
+	return report, nil
+}
+
+// listObjects lists the objects under a prefix
+func listObjects(ctx context.Context, bucket *storage.BucketHandle, prefix string) ([]storedObject, error) {
+	var objects []storedObject
+
+	it := bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+
+		objects = append(objects, storedObject{
+			Name:       attrs.Name,
+			Size:       attrs.Size,
+			Generation: attrs.Generation,
+			Created:    attrs.Created,
+		})
+	}
+}
+
+// deleteOrphan deletes an orphaned object, and the registration of a
+// content-addressed upload first. Nothing is deleted, returning false, if the
+// registration was updated or the object rewritten since it was listed.
+func deleteOrphan(ctx context.Context, bucket *storage.BucketHandle, objectService *db.ObjectService, product string, object storedObject, cutoff time.Time) (bool, error) {
+	if _, sha256, variant, ok := utils.ParseContentObject(object.Name); ok && !variant {
+		removed, err := objectService.Remove(ctx, product, sha256, cutoff)
+		if err != nil {
+			return false, fmt.Errorf("failed to remove registration: %w", err)
+		}
+		if !removed {
+			return false, nil
+		}
+	}
+
+	err := bucket.Object(object.Name).If(storage.Conditions{GenerationMatch: object.Generation}).Delete(ctx)
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return false, nil
+	}
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,86 @@
+package gc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"learninghub/firebase"
+	"learninghub/models"
+)
+
+const testBucket = "test-bucket.firebasestorage.app"
+
+func storageURL(objectName string) string {
+	return "https://firebasestorage.googleapis.com/v0/b/" + testBucket + "/o/" + url.PathEscape(objectName) + "?alt=media"
+}
+
+func TestFindOrphans(t *testing.T) {
+	originalBucket := firebase.StorageBucket
+	firebase.StorageBucket = testBucket
+	defer func() {
+		firebase.StorageBucket = originalBucket
+	}()
+
+	now := time.Now()
+	cutoff := now.Add(-24 * time.Hour)
+	old := now.Add(-48 * time.Hour)
+
+	sha := strings.Repeat("a", 64)
+	otherSha := strings.Repeat("b", 64)
+	recentSha := strings.Repeat("c", 64)
+
+	refs := &references{objects: map[string]bool{}, content: map[string]bool{}}
+	refs.addResource(models.Resource{
+		URL:          storageURL("ecomm/image/" + sha + ".png"),
+		ThumbnailURL: "https://example.com/thumbnail.png",
+		Transcode: &models.Transcode{
+			Source: storageURL("ecomm/video/1759318704_talk.mp4"),
+			Prefix: "ecomm/hls/1759318704",
+		},
+	})
+	refs.addResource(models.Resource{
+		URL:          storageURL("ecomm/pdf/1759318704_notes.pdf"),
+		ThumbnailJob: &models.ThumbnailJob{Source: storageURL("ecomm/thumbnail/1759318704_cover.png")},
+	})
+
+	registrations := map[string]models.StoredObject{
+		otherSha:  {Object: "ecomm/image/" + otherSha + ".png", Refs: 1, UpdatedAt: old},
+		recentSha: {Object: "ecomm/image/" + recentSha + ".png", Refs: 1, UpdatedAt: now},
+	}
+
+	tests := []struct {
+		name     string
+		object   string
+		created  time.Time
+		orphaned bool
+	}{
+		{name: "referenced upload", object: "ecomm/image/" + sha + ".png", created: old},
+		{name: "variant of a referenced upload", object: "ecomm/image/" + sha + "_480w.webp", created: old},
+		{name: "transcoding source", object: "ecomm/video/1759318704_talk.mp4", created: old},
+		{name: "HLS rendition", object: "ecomm/hls/1759318704/720p/segment_001.ts", created: old},
+		{name: "thumbnail job source", object: "ecomm/thumbnail/1759318704_cover.png", created: old},
+		{name: "legacy upload", object: "ecomm/pdf/1759318704_notes.pdf", created: old},
+		{name: "unreferenced upload", object: "ecomm/image/" + otherSha + ".png", created: old, orphaned: true},
+		{name: "variant of an unreferenced upload", object: "ecomm/image/" + otherSha + "_480w.webp", created: old, orphaned: true},
+		{name: "unreferenced legacy upload", object: "ecomm/pdf/1700000000_old.pdf", created: old, orphaned: true},
+		{name: "HLS rendition of another video", object: "ecomm/hls/17593187040/720p/segment_001.ts", created: old, orphaned: true},
+		{name: "unreferenced upload within grace period", object: "ecomm/pdf/1760000000_new.pdf", created: now},
+		{name: "unreferenced upload registered recently", object: "ecomm/image/" + recentSha + ".png", created: old},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := storedObject{Name: tt.object, Size: 10, Generation: 1, Created: tt.created}
+			orphans := findOrphans([]storedObject{object}, refs, registrations, cutoff)
+			if tt.orphaned {
+				assert.Equal(t, []storedObject{object}, orphans)
+			} else {
+				assert.Empty(t, orphans)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+)
+
+// GetMyBookmarks handles GET /me/bookmarks
+//   - Lists the resources the signed in user bookmarked, most recently
+//     bookmarked first, signed like those of GET /resources.
+//
+// Query Params:
+//   - cursor: Offset for pagination (as stringified int)
+//   - limit: Number of items per page (default 20, max 100)
+func GetMyBookmarks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	userID, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrUnauthorized, "Missing ID token")
+		return
+	}
+
+	// set to default page size if error in conversion or limit <= 0 or greater than max page size
+	limit, err := strconv.Atoi(c.DefaultQuery(constants.QueryParamLimit, constants.DefaultLimitValue))
+	if err != nil || limit <= 0 || limit > constants.MaxPageSize {
+		limit = constants.DefaultPageSize
+	}
+	offset, err := strconv.Atoi(c.Query(constants.QueryParamCursor))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	database := db.New()
+	bookmarks, err := db.NewBookmarkService(database).ListByUser(ctx, product, userID)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch bookmarks", err.Error())
+		return
+	}
+
+	page := bookmarkPage(bookmarks, offset, limit)
+	resources, err := bookmarkedResources(ctx, db.NewResourceService(database), product, page)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch resources", err.Error())
+		return
+	}
+
+	response := models.PaginatedResponse{
+		Data:    resources,
+		HasMore: offset+limit < len(bookmarks),
+		Total:   len(bookmarks),
+	}
+	if response.HasMore {
+		response.NextCursor = strconv.Itoa(offset + limit)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddBookmark handles POST /me/bookmarks/:resourceId
+//   - Bookmarks a resource for the signed in user. Bookmarking it again
+//     keeps the first bookmark.
+func AddBookmark(c *gin.Context) {
+	ctx := c.Request.Context()
+	resourceID := c.Param("resourceId")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	userID, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrUnauthorized, "Missing ID token")
+		return
+	}
+
+	database := db.New()
+	_, err := db.NewResourceService(database).GetByID(ctx, product, resourceID)
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrResourceNotFound, "Resource not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch resource", err.Error())
+		return
+	}
+
+	bookmark, created, err := db.NewBookmarkService(database).Add(ctx, product, models.Bookmark{
+		UserID:     userID,
+		ResourceID: resourceID,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to save bookmark", err.Error())
+		return
+	}
+
+	if created {
+		c.JSON(http.StatusCreated, bookmark)
+		return
+	}
+	c.JSON(http.StatusOK, bookmark)
+}
+
+// RemoveBookmark handles DELETE /me/bookmarks/:resourceId
+//   - Removing a bookmark the signed in user does not have succeeds too.
+func RemoveBookmark(c *gin.Context) {
+	ctx := c.Request.Context()
+	resourceID := c.Param("resourceId")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	userID, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrUnauthorized, "Missing ID token")
+		return
+	}
+
+	if err := db.NewBookmarkService(db.New()).Remove(ctx, product, userID, resourceID); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to remove bookmark", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed successfully"})
+}
+
+// bookmarkPage returns a page of bookmarks, most recently bookmarked first.
+// Bookmarks are sorted here rather than in the query, which would need a
+// composite index.
+func bookmarkPage(bookmarks []models.Bookmark, offset, limit int) []models.Bookmark {
+	sorted := slices.SortedFunc(slices.Values(bookmarks), func(a, b models.Bookmark) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ResourceID, b.ResourceID)
+	})
+	if offset >= len(sorted) {
+		return nil
+	}
+	return sorted[offset:min(offset+limit, len(sorted))]
+}
+
+// bookmarkedResources returns the resources of bookmarks in order, with
+// signed URLs. Resources deleted meanwhile are left out.
+func bookmarkedResources(ctx context.Context, resourceService *db.ResourceService, product string, bookmarks []models.Bookmark) ([]models.Resource, error) {
+	resources := make([]models.Resource, 0, len(bookmarks))
+	if len(bookmarks) == 0 {
+		return resources, nil
+	}
+
+	ids := make([]string, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.ResourceID
+	}
+	docs, err := resourceService.GetAll(ctx, product, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarked := true
+	for _, doc := range docs {
+		var resource models.Resource
+		if !doc.Exists() {
+			continue
+		}
+		if err := doc.DataTo(&resource); err != nil {
+			logger.Infof("Error converting document %s: %v", doc.Ref.ID, err)
+			continue
+		}
+		resource.ID = doc.Ref.ID
+		resource.Bookmarked = &bookmarked
+
+		signResource(ctx, &resource)
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// markBookmarked sets whether the signed in user bookmarked each of
+// resources. Resources of anonymous requests are left unset, and so are all
+// of them if bookmarks cannot be read: failures are only logged.
+func markBookmarked(c *gin.Context, product string, resources []models.Resource) {
+	userID, signedIn := middleware.GetUserFromContext(c)
+	if !signedIn || len(resources) == 0 {
+		return
+	}
+
+	ids := make([]string, len(resources))
+	for i, resource := range resources {
+		ids[i] = resource.ID
+	}
+	bookmarked, err := db.NewBookmarkService(db.New()).Bookmarked(c.Request.Context(), product, userID, ids)
+	if err != nil {
+		logger.Errorf("Failed to read bookmarks of %s: %v", product, err)
+		return
+	}
+
+	for i := range resources {
+		flag := bookmarked[resources[i].ID]
+		resources[i].Bookmarked = &flag
+	}
+}
+
+// deleteBookmarks deletes the bookmarks of every user on deleted resources.
+// The resources are gone already, so failures are only logged.
+func deleteBookmarks(ctx context.Context, product string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	deleted, err := db.NewBookmarkService(db.New()).DeleteResources(ctx, product, ids)
+	if err != nil {
+		logger.Errorf("Failed to delete bookmarks of deleted resources of %s: %v", product, err)
+		return
+	}
+	if deleted > 0 {
+		logger.Infof("Deleted %d bookmarks of deleted resources of %s", deleted, product)
+	}
+}
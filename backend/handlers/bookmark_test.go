@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestBookmarksRequireSignedInUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		method  string
+		handler gin.HandlerFunc
+	}{
+		{name: "list", method: http.MethodGet, handler: GetMyBookmarks},
+		{name: "add", method: http.MethodPost, handler: AddBookmark},
+		{name: "remove", method: http.MethodDelete, handler: RemoveBookmark},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/api/v1/ecomm/me/bookmarks/intro", nil)
+			c.Params = gin.Params{{Key: "resourceId", Value: "intro"}}
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			tt.handler(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, errors.ErrUnauthorized, response.Error)
+		})
+	}
+}
+
+func TestBookmarkPage(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	bookmarks := []models.Bookmark{
+		{ResourceID: "a", CreatedAt: monday},
+		{ResourceID: "c", CreatedAt: monday.Add(48 * time.Hour)},
+		{ResourceID: "b", CreatedAt: monday},
+		{ResourceID: "d", CreatedAt: monday.Add(24 * time.Hour)},
+	}
+	ids := func(bookmarks []models.Bookmark) []string {
+		var ids []string
+		for _, bookmark := range bookmarks {
+			ids = append(ids, bookmark.ResourceID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"c", "d", "a", "b"}, ids(bookmarkPage(bookmarks, 0, 20)))
+	assert.Equal(t, []string{"c", "d"}, ids(bookmarkPage(bookmarks, 0, 2)))
+	assert.Equal(t, []string{"a", "b"}, ids(bookmarkPage(bookmarks, 2, 2)))
+	assert.Equal(t, []string{"b"}, ids(bookmarkPage(bookmarks, 3, 2)))
+	assert.Empty(t, bookmarkPage(bookmarks, 4, 2))
+	assert.Equal(t, "a", bookmarks[0].ResourceID, "bookmarks are not sorted in place")
+}
+
+func TestMarkBookmarkedLeavesAnonymousRequestsUnset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/ecomm/resources", nil)
+	resources := []models.Resource{{ID: "a"}, {ID: "b"}}
+
+	markBookmarked(c, "ecomm", resources)
+
+	for _, resource := range resources {
+		assert.Nil(t, resource.Bookmarked)
+	}
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// bulkOperations are the operations of bulk requests
+var bulkOperations = []string{
+	constants.BulkOpAddTags,
+	constants.BulkOpRemoveTags,
+	constants.BulkOpUpdate,
+	constants.BulkOpDelete,
+}
+
+// bulkRequest is the body of POST /resources/bulk
+type bulkRequest struct {
+	Operations []bulkOperation `json:"operations"`
+}
+
+// bulkOperation is an operation of a bulk request on a resource
+type bulkOperation struct {
+	Op     string      `json:"op"`
+	ID     string      `json:"id"`
+	Tags   []string    `json:"tags,omitempty"`   // addTags and removeTags
+	Fields *bulkFields `json:"fields,omitempty"` // update
+}
+
+// bulkFields are the fields an update operation sets. Custom field values
+// are merged into the existing ones, null unsets a field.
+type bulkFields struct {
+	Title        *string        `json:"title,omitempty"`
+	Description  *string        `json:"description,omitempty"`
+	CustomFields map[string]any `json:"customFields,omitempty"`
+}
+
+// bulkResult is the outcome of an operation of a bulk request
+type bulkResult struct {
+	Index  int                   `json:"index"` // Of the operation in the request
+	ID     string                `json:"id"`
+	Op     string                `json:"op"`
+	Status string                `json:"status"`          // succeeded or failed
+	Error  *errors.ErrorResponse `json:"error,omitempty"` // Fields are those of the resource
+}
+
+// bulkResponse is the response of POST /resources/bulk
+type bulkResponse struct {
+	Results   []bulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+// bulkTarget is a resource the operations of a batch change
+type bulkTarget struct {
+	original   models.Resource
+	resource   models.Resource // With the operations applied
+	updateTime time.Time
+	changed    []string // Fields the operations changed
+	deleted    bool
+	operations []int // Indexes in the batch of the operations applied
+}
+
+// BulkResources handles POST /resources/bulk
+//   - Runs a list of operations on resources: addTags, removeTags, update
+//     (title, description and custom fields) and delete.
+//   - Operations run in batches of constants.BulkBatchSize: the resources of
+//     a batch are read and written once, and tag usage counts adjusted once.
+//   - Operations succeed or fail on their own, see bulkResult. A resource
+//     that changed while its batch ran is not written.
+func BulkResources(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	if c.ContentType() != constants.ContentTypeJSON {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be application/json")
+		return
+	}
+
+	body, ok := readJSONBody(c)
+	if !ok {
+		return
+	}
+
+	var request bulkRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid bulk request", err.Error())
+		return
+	}
+
+	if fieldErrors := checkBulkOperations(request.Operations); len(fieldErrors) > 0 {
+		response := validationError(fieldErrors)
+		errors.RespondWithFieldErrors(c, response.Error, response.Message, fieldErrors...)
+		return
+	}
+
+	// Custom field definitions, read once if an update sets custom fields
+	var definitions []models.FieldDefinition
+	if slices.ContainsFunc(request.Operations, func(operation bulkOperation) bool {
+		return operation.Fields != nil && operation.Fields.CustomFields != nil
+	}) {
+		var err error
+		definitions, err = db.NewFieldService(db.New()).List(ctx, product)
+		if err != nil {
+			errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+			return
+		}
+	}
+
+	resourceService := db.NewResourceService(db.New())
+
+	response := bulkResponse{Results: make([]bulkResult, 0, len(request.Operations))}
+	for start := 0; start < len(request.Operations); start += constants.BulkBatchSize {
+		batch := request.Operations[start:min(start+constants.BulkBatchSize, len(request.Operations))]
+		response.Results = append(response.Results, runBulkBatch(ctx, resourceService, product, start, batch, definitions)...)
+	}
+
+	for _, result := range response.Results {
+		if result.Status == constants.BulkStatusSucceeded {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// checkBulkOperations checks the operations of a bulk request, before any
+// runs, and returns the errors of every rejected field
+func checkBulkOperations(operations []bulkOperation) []errors.FieldError {
+	const field = "operations"
+
+	if len(operations) == 0 {
+		return []errors.FieldError{{Field: field, Code: validation.CodeRequired, Message: field + " is required"}}
+	}
+	if len(operations) > constants.MaxBulkOperations {
+		return []errors.FieldError{{
+			Field:   field,
+			Code:    validation.CodeTooMany,
+			Message: fmt.Sprintf("%s must have at most %d items", field, constants.MaxBulkOperations),
+			Params:  map[string]any{"max": constants.MaxBulkOperations},
+		}}
+	}
+
+	var fieldErrors []errors.FieldError
+	for i, operation := range operations {
+		prefix := field + "." + strconv.Itoa(i) + "."
+		reject := func(name, code, message string, params map[string]any) {
+			fieldErrors = append(fieldErrors, errors.FieldError{Field: prefix + name, Code: code, Message: prefix + name + " " + message, Params: params})
+		}
+
+		if operation.ID == "" {
+			reject("id", validation.CodeRequired, "is required", nil)
+		}
+
+		switch operation.Op {
+		case constants.BulkOpAddTags, constants.BulkOpRemoveTags:
+			if len(utils.NormalizeTags(operation.Tags)) == 0 {
+				reject("tags", validation.CodeRequired, "is required", nil)
+			}
+		case constants.BulkOpUpdate:
+			if operation.Fields == nil || (operation.Fields.Title == nil && operation.Fields.Description == nil && operation.Fields.CustomFields == nil) {
+				reject("fields", validation.CodeRequired, "is required", nil)
+			}
+		case constants.BulkOpDelete:
+		case "":
+			reject("op", validation.CodeRequired, "is required", nil)
+		default:
+			reject("op", validation.CodeInvalidChoice, "must be one of: addTags, removeTags, update, delete",
+				map[string]any{"values": bulkOperations})
+		}
+	}
+	return fieldErrors
+}
+
+// runBulkBatch runs a batch of operations, the one at offset in the request
+// first, and returns their results
+func runBulkBatch(ctx context.Context, resourceService *db.ResourceService, product string, offset int, batch []bulkOperation, definitions []models.FieldDefinition) []bulkResult {
+	results := make([]bulkResult, len(batch))
+	for i, operation := range batch {
+		results[i] = bulkResult{Index: offset + i, ID: operation.ID, Op: operation.Op, Status: constants.BulkStatusSucceeded}
+	}
+	fail := func(i int, response errors.ErrorResponse) {
+		results[i].Status = constants.BulkStatusFailed
+		results[i].Error = &response
+	}
+
+	// Read the resources of the batch at once
+	var ids []string
+	for _, operation := range batch {
+		if !slices.Contains(ids, operation.ID) {
+			ids = append(ids, operation.ID)
+		}
+	}
+
+	docs, err := resourceService.GetAll(ctx, product, ids)
+	if err != nil {
+		logger.Infof("Failed to fetch resources of bulk operations: %v", err)
+		for i := range batch {
+			fail(i, errors.ErrorResponse{Error: errors.ErrQueryFailed, Message: "Failed to fetch resource"})
+		}
+		return results
+	}
+
+	targets := make(map[string]*bulkTarget, len(docs))
+	for _, doc := range docs {
+		var resource models.Resource
+		if !doc.Exists() || doc.DataTo(&resource) != nil {
+			continue
+		}
+		targets[doc.Ref.ID] = &bulkTarget{original: resource, resource: resource, updateTime: doc.UpdateTime}
+	}
+
+	// Apply the operations in memory, in order
+	for i, operation := range batch {
+		target := targets[operation.ID]
+		if target == nil || target.deleted {
+			fail(i, errors.ErrorResponse{Error: errors.ErrResourceNotFound, Message: "Resource not found"})
+			continue
+		}
+
+		if operation.Op == constants.BulkOpDelete {
+			target.deleted = true
+			target.operations = append(target.operations, i)
+			continue
+		}
+
+		resource := target.resource
+		changed, errResponse := applyBulkOperation(&resource, operation, definitions)
+		if errResponse != nil {
+			fail(i, *errResponse)
+			continue
+		}
+
+		target.resource = resource
+		for _, field := range changed {
+			if !slices.Contains(target.changed, field) {
+				target.changed = append(target.changed, field)
+			}
+		}
+		target.operations = append(target.operations, i)
+	}
+
+	// Write each resource once
+	var writes []db.ResourceWrite
+	var written []*bulkTarget
+	for _, id := range ids {
+		target := targets[id]
+		if target == nil || (!target.deleted && len(target.changed) == 0) {
+			continue
+		}
+
+		write := db.ResourceWrite{ID: id, Delete: target.deleted, UpdateTime: target.updateTime}
+		if !target.deleted {
+			write.Updates = resourceUpdates(target.resource, target.changed)
+		}
+		writes = append(writes, write)
+		written = append(written, target)
+	}
+
+	writeErrs := resourceService.BulkWrite(ctx, product, writes)
+
+	tagDeltas := make(map[string]int)
+	var deletedIDs, obsoleteURLs, obsoletePrefixes []string
+	for i, target := range written {
+		if err := writeErrs[i]; err != nil {
+			response := errors.ErrorResponse{Error: errors.ErrMutationFailed, Message: "Failed to save resource"}
+			if stdErrors.Is(err, db.ErrResourceChanged) {
+				response = errors.ErrorResponse{Error: errors.ErrResourceConflict, Message: "Resource changed during the bulk operation, retry"}
+			} else {
+				logger.Infof("Failed to save resource %s of bulk operations: %v", writes[i].ID, err)
+			}
+			for _, j := range target.operations {
+				fail(j, response)
+			}
+			continue
+		}
+
+		if target.deleted {
+			deletedIDs = append(deletedIDs, writes[i].ID)
+			for _, tag := range target.original.Tags {
+				tagDeltas[tag]--
+			}
+			obsoleteURLs = append(obsoleteURLs, target.original.URL)
+			obsoleteURLs = append(obsoleteURLs, thumbnailObjects(target.original)...)
+			obsoletePrefixes = append(obsoletePrefixes, target.original.OutputPrefixes()...)
+			continue
+		}
+
+		for _, tag := range target.original.Tags {
+			if !slices.Contains(target.resource.Tags, tag) {
+				tagDeltas[tag]--
+			}
+		}
+		for _, tag := range target.resource.Tags {
+			if !slices.Contains(target.original.Tags, tag) {
+				tagDeltas[tag]++
+			}
+		}
+	}
+
+	// Once per batch rather than per resource
+	utils.AdjustTagUsage(ctx, product, tagDeltas)
+	forgetResources(ctx, product, deletedIDs)
+	purgeObjects(ctx, product, obsoleteURLs, obsoletePrefixes)
+
+	return results
+}
+
+// applyBulkOperation applies a tag or update operation to a resource and
+// returns the fields it changed. The resource is left as it was if the
+// operation is rejected.
+func applyBulkOperation(resource *models.Resource, operation bulkOperation, definitions []models.FieldDefinition) ([]string, *errors.ErrorResponse) {
+	updated := *resource
+	var changed []string
+
+	switch operation.Op {
+	case constants.BulkOpAddTags:
+		updated.Tags = utils.NormalizeTags(append(slices.Clone(resource.Tags), operation.Tags...))
+	case constants.BulkOpRemoveTags:
+		removed := utils.NormalizeTags(operation.Tags)
+		updated.Tags = slices.DeleteFunc(slices.Clone(resource.Tags), func(tag string) bool {
+			return slices.Contains(removed, tag)
+		})
+	case constants.BulkOpUpdate:
+		fields := operation.Fields
+		if fields.Title != nil && *fields.Title != resource.Title {
+			updated.Title = *fields.Title
+			changed = append(changed, constants.FormFieldTitle)
+		}
+		if fields.Description != nil && *fields.Description != resource.Description {
+			updated.Description = *fields.Description
+			changed = append(changed, constants.FormFieldDescription)
+		}
+		if fields.CustomFields != nil {
+			merged, err := utils.MergeCustomFields(definitions, resource.CustomFields, fields.CustomFields)
+			if fieldErr := (*utils.CustomFieldError)(nil); stdErrors.As(err, &fieldErr) {
+				response := customFieldError(fieldErr)
+				return nil, &response
+			}
+			if err != nil {
+				return nil, &errors.ErrorResponse{Error: errors.ErrInvalidParam, Message: "Invalid custom fields", Details: err.Error()}
+			}
+			if !reflect.DeepEqual(merged, resource.CustomFields) {
+				updated.CustomFields = merged
+				changed = append(changed, constants.FormFieldCustomFields)
+			}
+		}
+	}
+
+	if operation.Op == constants.BulkOpAddTags || operation.Op == constants.BulkOpRemoveTags {
+		if !slices.Equal(updated.Tags, resource.Tags) {
+			changed = append(changed, constants.FormFieldTags)
+		}
+	}
+
+	// Custom fields have no rules, and were checked when merged
+	if len(changed) > 0 {
+		if fieldErrors := validation.Resource(updated, changed...); len(fieldErrors) > 0 {
+			response := validationError(fieldErrors)
+			return nil, &response
+		}
+	}
+
+	*resource = updated
+	return changed, nil
+}
+
+// resourceUpdates returns the values of the changed fields of a resource, by
+// Firestore path
+func resourceUpdates(resource models.Resource, changed []string) map[string]any {
+	updates := map[string]any{"updatedAt": time.Now()}
+	for _, field := range changed {
+		switch field {
+		case constants.FormFieldTitle:
+			updates["title"] = resource.Title
+		case constants.FormFieldDescription:
+			updates["description"] = resource.Description
+		case constants.FormFieldTags:
+			updates["tags"] = resource.Tags
+		case constants.FormFieldCustomFields:
+			if len(resource.CustomFields) == 0 {
+				updates["customFields"] = nil
+			} else {
+				updates["customFields"] = resource.CustomFields
+			}
+		}
+	}
+	return updates
+}
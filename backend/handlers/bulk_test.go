@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestBulkResourcesRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tooMany := `{"operations":[` + strings.Repeat(`{"op":"delete","id":"a"},`, constants.MaxBulkOperations) + `{"op":"delete","id":"a"}]}`
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "not JSON", contentType: constants.ContentTypeMultipart, body: "", expectedError: errors.ErrInvalidContentType},
+		{name: "unknown field", contentType: constants.ContentTypeJSON, body: `{"operations":[{"op":"delete","id":"a","force":true}]}`, expectedError: errors.ErrInvalidPayload},
+		{name: "no operations", contentType: constants.ContentTypeJSON, body: `{"operations":[]}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"operations"}},
+		{name: "too many operations", contentType: constants.ContentTypeJSON, body: tooMany, expectedError: errors.ErrInvalidParam, expectedFields: []string{"operations"}},
+		{
+			name:           "invalid operations",
+			contentType:    constants.ContentTypeJSON,
+			body:           `{"operations":[{"op":"addTags","id":"a","tags":[" "]},{"op":"archive","id":"b"},{"op":"update"},{"op":"delete","id":"c"}]}`,
+			expectedError:  errors.ErrInvalidParam,
+			expectedFields: []string{"operations.0.tags", "operations.1.op", "operations.2.id", "operations.2.fields"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/resources/bulk", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			BulkResources(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
+
+func TestApplyBulkOperation(t *testing.T) {
+	definitions := []models.FieldDefinition{
+		{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"beginner", "advanced"}},
+	}
+
+	stringPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name            string
+		operation       bulkOperation
+		expectedChanged []string
+		expectedTags    []string
+		expectedTitle   string
+		expectedFields  map[string]any
+		expectedError   errors.ErrorCode
+		expectedField   string
+	}{
+		{
+			name:            "add tags",
+			operation:       bulkOperation{Op: constants.BulkOpAddTags, Tags: []string{" Basics ", "onboarding"}},
+			expectedChanged: []string{"tags"},
+			expectedTags:    []string{"onboarding", "video", "basics"},
+		},
+		{
+			name:         "add existing tags",
+			operation:    bulkOperation{Op: constants.BulkOpAddTags, Tags: []string{"Onboarding"}},
+			expectedTags: []string{"onboarding", "video"},
+		},
+		{
+			name:            "remove tags",
+			operation:       bulkOperation{Op: constants.BulkOpRemoveTags, Tags: []string{"VIDEO", "missing"}},
+			expectedChanged: []string{"tags"},
+			expectedTags:    []string{"onboarding"},
+		},
+		{
+			name:            "update fields",
+			operation:       bulkOperation{Op: constants.BulkOpUpdate, Fields: &bulkFields{Title: stringPtr("Introduction"), CustomFields: map[string]any{"difficulty": "advanced"}}},
+			expectedChanged: []string{"title", "customFields"},
+			expectedTitle:   "Introduction",
+			expectedFields:  map[string]any{"difficulty": "advanced"},
+		},
+		{
+			name:          "too many tags",
+			operation:     bulkOperation{Op: constants.BulkOpAddTags, Tags: strings.Split("a,b,c,d,e,f,g,h,i,j,k,l,m,n,o,p,q,r,s", ",")},
+			expectedError: errors.ErrInvalidParam,
+			expectedField: "tags",
+		},
+		{
+			name:          "cleared title",
+			operation:     bulkOperation{Op: constants.BulkOpUpdate, Fields: &bulkFields{Title: stringPtr("")}},
+			expectedError: errors.ErrMissingRequired,
+			expectedField: "title",
+		},
+		{
+			name:          "invalid custom field",
+			operation:     bulkOperation{Op: constants.BulkOpUpdate, Fields: &bulkFields{Title: stringPtr("Introduction"), CustomFields: map[string]any{"difficulty": "expert"}}},
+			expectedError: errors.ErrInvalidParam,
+			expectedField: "customFields.difficulty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := models.Resource{
+				Title:        "Intro",
+				Description:  "Getting started",
+				Type:         constants.ResourceTypeArticle,
+				Tags:         []string{"onboarding", "video"},
+				CustomFields: map[string]any{"difficulty": "beginner"},
+			}
+			original := resource
+
+			changed, response := applyBulkOperation(&resource, tt.operation, definitions)
+			if tt.expectedError != "" {
+				require.NotNil(t, response)
+				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, []string{tt.expectedField}, fieldsOf(*response))
+				assert.Equal(t, original, resource)
+				return
+			}
+
+			require.Nil(t, response)
+			assert.Equal(t, tt.expectedChanged, changed)
+			if tt.expectedTags != nil {
+				assert.Equal(t, tt.expectedTags, resource.Tags)
+			}
+			if tt.expectedTitle != "" {
+				assert.Equal(t, tt.expectedTitle, resource.Title)
+			}
+			if tt.expectedFields != nil {
+				assert.Equal(t, tt.expectedFields, resource.CustomFields)
+			}
+		})
+	}
+}
+
+func TestResourceUpdates(t *testing.T) {
+	resource := models.Resource{Title: "Intro", Tags: []string{"basics"}}
+
+	updates := resourceUpdates(resource, []string{"title", "tags", "customFields"})
+
+	assert.Equal(t, "Intro", updates["title"])
+	assert.Equal(t, []string{"basics"}, updates["tags"])
+	assert.Contains(t, updates, "customFields")
+	assert.Nil(t, updates["customFields"])
+	assert.Contains(t, updates, "updatedAt")
+	assert.NotContains(t, updates, "description")
+}
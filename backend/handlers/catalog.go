@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/catalog"
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/pkg/logger"
+	"learninghub/validation"
+)
+
+// ExportCatalog handles GET /export, for admins
+//   - Streams the product's catalog as a zip archive: the manifest of its
+//     resources, tags and custom fields, then their stored files.
+//   - Errors are only reported as JSON before the archive starts; a failure
+//     later truncates it.
+func ExportCatalog(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	filename := fmt.Sprintf("%s-catalog-%s.zip", product, time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	report, err := catalog.Export(ctx, product, c.Writer)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to export catalog", err.Error())
+			return
+		}
+		logger.Errorf("Failed to export catalog of %s: %v", product, err)
+		c.Abort()
+		return
+	}
+
+	if len(report.Missing) > 0 {
+		logger.Warnf("Export of %s left out %d missing files: %v", product, len(report.Missing), report.Missing)
+	}
+	logger.Infof("Exported %d resources and %d files (%d bytes) of %s", report.Resources, report.Files, report.Bytes, product)
+}
+
+// ImportCatalog handles POST /import, for admins
+//   - Imports a catalog archive, sent as the multipart field "archive", into
+//     the product. The archive may come from another product.
+//   - Query parameter "strategy" tells what to do with resources whose ID
+//     exists: skip (default), overwrite or duplicate.
+//   - With "dryRun=true" nothing is written, the report tells what would be.
+//   - Resources that cannot be imported are reported without failing the
+//     request.
+func ImportCatalog(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	options, ok := importOptions(c)
+	if !ok {
+		return
+	}
+
+	if c.ContentType() != constants.ContentTypeMultipart {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Content-Type must be multipart/form-data")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.MaxImportSize)
+	if err := c.Request.ParseMultipartForm(constants.ImportMemoryLimit); err != nil {
+		if maxBytesErr := (*http.MaxBytesError)(nil); stdErrors.As(err, &maxBytesErr) {
+			errors.RespondWithError(c, errors.ErrFileTooLarge, fmt.Sprintf("Archive too large. Maximum size is %d GB", constants.MaxImportSize/(1<<30)))
+			return
+		}
+		handleMultipartFormError(c, err)
+		return
+	}
+
+	archive, header, err := c.Request.FormFile(constants.FormFieldArchive)
+	if err != nil {
+		errors.RespondWithFieldError(c, errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldArchive, Code: validation.CodeRequired, Message: "archive is required"})
+		return
+	}
+	defer archive.Close()
+
+	report, err := catalog.Import(ctx, product, archive, header.Size, options)
+	if stdErrors.Is(err, catalog.ErrInvalidArchive) {
+		errors.RespondWithFieldError(c, errors.ErrInvalidPayload, errors.FieldError{Field: constants.FormFieldArchive, Code: validation.CodeInvalid, Message: err.Error()})
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to import catalog", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// importOptions reads the options of an import from the query. It responds
+// with an error and returns false if one is invalid.
+func importOptions(c *gin.Context) (catalog.ImportOptions, bool) {
+	options := catalog.ImportOptions{Strategy: constants.ImportStrategySkip}
+
+	if strategy := c.Query(constants.QueryParamStrategy); strategy != "" {
+		if !slices.Contains(catalog.Strategies, strategy) {
+			errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{
+				Field:   constants.QueryParamStrategy,
+				Code:    validation.CodeInvalidChoice,
+				Message: "strategy must be one of: " + strings.Join(catalog.Strategies, ", "),
+				Params:  map[string]any{"values": catalog.Strategies},
+			})
+			return options, false
+		}
+		options.Strategy = strategy
+	}
+
+	dryRun, ok := dryRunOption(c)
+	options.DryRun = dryRun
+	return options, ok
+}
+
+// dryRunOption reads the "dryRun" query parameter of an import. It responds
+// with an error and returns false if it is not a boolean.
+func dryRunOption(c *gin.Context) (bool, bool) {
+	raw := c.Query(constants.QueryParamDryRun)
+	if raw == "" {
+		return false, true
+	}
+
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.QueryParamDryRun, Code: validation.CodeInvalidType, Message: "dryRun must be true or false"})
+		return false, false
+	}
+	return dryRun, true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+)
+
+func TestImportCatalogRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	multipartBody := func(archive []byte) (string, *bytes.Buffer) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		if archive != nil {
+			part, err := writer.CreateFormFile(constants.FormFieldArchive, "catalog.zip")
+			require.NoError(t, err)
+			_, err = part.Write(archive)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+		return writer.FormDataContentType(), body
+	}
+
+	var emptyZip bytes.Buffer
+	require.NoError(t, zip.NewWriter(&emptyZip).Close())
+
+	tests := []struct {
+		name           string
+		query          string
+		archive        []byte
+		json           bool
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "invalid strategy", query: "?strategy=merge", archive: emptyZip.Bytes(), expectedError: errors.ErrInvalidParam, expectedFields: []string{"strategy"}},
+		{name: "invalid dry run", query: "?dryRun=maybe", archive: emptyZip.Bytes(), expectedError: errors.ErrInvalidParam, expectedFields: []string{"dryRun"}},
+		{name: "not multipart", json: true, expectedError: errors.ErrInvalidContentType},
+		{name: "no archive", expectedError: errors.ErrMissingRequired, expectedFields: []string{"archive"}},
+		{name: "not a zip archive", archive: []byte("not a zip"), expectedError: errors.ErrInvalidPayload, expectedFields: []string{"archive"}},
+		{name: "no manifest", query: "?strategy=overwrite&dryRun=true", archive: emptyZip.Bytes(), expectedError: errors.ErrInvalidPayload, expectedFields: []string{"archive"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body := multipartBody(tt.archive)
+			if tt.json {
+				contentType, body = constants.ContentTypeJSON, bytes.NewBufferString("{}")
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/import"+tt.query, body)
+			c.Request.Header.Set("Content-Type", contentType)
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			ImportCatalog(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/validation"
+)
+
+// collectionRequest is the body of POST /collections and PUT /collections/:id
+type collectionRequest struct {
+	Title         string                  `json:"title"`
+	Description   string                  `json:"description"`
+	CoverImageURL string                  `json:"coverImageUrl"`
+	Items         []collectionItemRequest `json:"items"`
+}
+
+// collectionItemRequest is an item of a collection request, a resource or a
+// section heading
+type collectionItemRequest struct {
+	ResourceID string `json:"resourceId"`
+	Heading    string `json:"heading"`
+}
+
+// reorderRequest is the body of POST /collections/:id/reorder
+type reorderRequest struct {
+	Moves []collectionMove `json:"moves"`
+}
+
+// collectionMove moves the item at From to To, shifting the items between
+type collectionMove struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// rejectedFields is the error of a collection update whose request does not
+// fit the stored collection
+type rejectedFields []errors.FieldError
+
+func (r rejectedFields) Error() string {
+	return validationError(r).Message
+}
+
+// GetCollections handles GET /collections
+//   - Lists the collections of the product, newest first, with the IDs of
+//     their resources.
+func GetCollections(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	database := db.New()
+	collectionService := db.NewCollectionService(database)
+
+	collections, err := collectionService.List(ctx, product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch collections", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+// GetCollection handles GET /collections/:id
+//   - Returns a collection with the resources of its items, signed like
+//     those of GET /resources.
+func GetCollection(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	database := db.New()
+	collectionService := db.NewCollectionService(database)
+
+	collection, err := collectionService.Get(ctx, product, id)
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrCollectionNotFound, "Collection not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch collection", err.Error())
+		return
+	}
+
+	if err := expandCollection(ctx, db.NewResourceService(database), product, collection); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch resources", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// CreateCollection handles POST /collections
+//   - Creates a collection from a JSON body. Its items list resources of the
+//     product, each once, and section headings.
+func CreateCollection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	request, ok := decodeCollectionRequest(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	collection := newCollection(request)
+	collection.CreatedAt, collection.UpdatedAt = now, now
+
+	database := db.New()
+	if !checkCollection(c, db.NewResourceService(database), product, collection) {
+		return
+	}
+
+	collectionService := db.NewCollectionService(database)
+	id, err := collectionService.Create(ctx, product, collection)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to create collection", err.Error())
+		return
+	}
+	collection.ID = id
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// UpdateCollection handles PUT /collections/:id
+//   - Replaces the title, description, cover image and items of a collection
+//     with those of a JSON body, like POST /collections.
+func UpdateCollection(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	request, ok := decodeCollectionRequest(c)
+	if !ok {
+		return
+	}
+
+	replacement := newCollection(request)
+	database := db.New()
+	if !checkCollection(c, db.NewResourceService(database), product, replacement) {
+		return
+	}
+
+	collectionService := db.NewCollectionService(database)
+	collection, err := collectionService.Update(ctx, product, id, func(collection *models.Collection) error {
+		replacement.CreatedAt = collection.CreatedAt
+		replacement.UpdatedAt = time.Now()
+		*collection = replacement
+		return nil
+	})
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrCollectionNotFound, "Collection not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to save collection", err.Error())
+		return
+	}
+	collection.ID = id
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// ReorderCollection handles POST /collections/:id/reorder
+//   - Applies moves to the items of a collection, in order: each takes the
+//     item at from out of the list and inserts it at to. Headings move like
+//     resources, the resources following them stay in place.
+//   - Moves refer to the stored items, which change meanwhile: all of them
+//     are applied, or none.
+func ReorderCollection(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	if c.ContentType() != constants.ContentTypeJSON {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be application/json")
+		return
+	}
+
+	body, ok := readJSONBody(c)
+	if !ok {
+		return
+	}
+
+	var request reorderRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid reorder request", err.Error())
+		return
+	}
+
+	switch {
+	case len(request.Moves) == 0:
+		respondWith(c, validationError([]errors.FieldError{{Field: constants.FormFieldMoves, Code: validation.CodeRequired, Message: "moves is required"}}))
+		return
+	case len(request.Moves) > constants.MaxCollectionMoves:
+		respondWith(c, validationError([]errors.FieldError{{
+			Field:   constants.FormFieldMoves,
+			Code:    validation.CodeTooMany,
+			Message: fmt.Sprintf("moves must have at most %d items", constants.MaxCollectionMoves),
+			Params:  map[string]any{"max": constants.MaxCollectionMoves},
+		}}))
+		return
+	}
+
+	collectionService := db.NewCollectionService(db.New())
+	collection, err := collectionService.Update(ctx, product, id, func(collection *models.Collection) error {
+		items, fieldErrors := moveItems(collection.Items, request.Moves)
+		if len(fieldErrors) > 0 {
+			return rejectedFields(fieldErrors)
+		}
+		collection.Items = items
+		collection.UpdatedAt = time.Now()
+		return nil
+	})
+	if fieldErrors := rejectedFields(nil); stdErrors.As(err, &fieldErrors) {
+		respondWith(c, validationError(fieldErrors))
+		return
+	}
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrCollectionNotFound, "Collection not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to save collection", err.Error())
+		return
+	}
+	collection.ID = id
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection handles DELETE /collections/:id
+//   - The resources of the collection are kept.
+func DeleteCollection(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	database := db.New()
+	collectionService := db.NewCollectionService(database)
+
+	_, err := collectionService.Get(ctx, product, id)
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrCollectionNotFound, "Collection not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch collection", err.Error())
+		return
+	}
+
+	if err := collectionService.Delete(ctx, product, id); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to delete collection", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+// decodeCollectionRequest decodes the JSON body of a collection request. It
+// responds with an error and returns false if the body is not one.
+func decodeCollectionRequest(c *gin.Context) (collectionRequest, bool) {
+	var request collectionRequest
+	if c.ContentType() != constants.ContentTypeJSON {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be application/json")
+		return request, false
+	}
+
+	body, ok := readJSONBody(c)
+	if !ok {
+		return request, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid collection", err.Error())
+		return request, false
+	}
+	return request, true
+}
+
+// newCollection returns the collection a request describes, trimmed
+func newCollection(request collectionRequest) models.Collection {
+	collection := models.Collection{
+		Title:         strings.TrimSpace(request.Title),
+		Description:   strings.TrimSpace(request.Description),
+		CoverImageURL: strings.TrimSpace(request.CoverImageURL),
+		Items:         make([]models.CollectionItem, len(request.Items)),
+	}
+	for i, item := range request.Items {
+		collection.Items[i] = models.CollectionItem{
+			ResourceID: strings.TrimSpace(item.ResourceID),
+			Heading:    strings.TrimSpace(item.Heading),
+		}
+	}
+	return collection
+}
+
+// checkCollection checks the fields of a collection, then that its resources
+// exist. It responds with the errors of every rejected field and returns
+// false otherwise.
+func checkCollection(c *gin.Context, resourceService *db.ResourceService, product string, collection models.Collection) bool {
+	if fieldErrors := validation.Collection(collection); len(fieldErrors) > 0 {
+		respondWith(c, validationError(fieldErrors))
+		return false
+	}
+
+	var ids []string
+	var indexes []int
+	for i, item := range collection.Items {
+		if item.ResourceID != "" {
+			ids = append(ids, item.ResourceID)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(ids) == 0 {
+		return true
+	}
+
+	docs, err := resourceService.GetAll(c.Request.Context(), product, ids)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch resources", err.Error())
+		return false
+	}
+
+	var fieldErrors []errors.FieldError
+	for i, doc := range docs {
+		if !doc.Exists() {
+			field := constants.FormFieldItems + "." + strconv.Itoa(indexes[i]) + ".resourceId"
+			fieldErrors = append(fieldErrors, errors.FieldError{Field: field, Code: validation.CodeNotFound, Message: field + " is not a resource of the product"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		respondWith(c, validationError(fieldErrors))
+		return false
+	}
+	return true
+}
+
+// moveItems applies moves to a copy of items, see ReorderCollection. It
+// returns the errors of moves out of the list instead.
+func moveItems(items []models.CollectionItem, moves []collectionMove) ([]models.CollectionItem, []errors.FieldError) {
+	var fieldErrors []errors.FieldError
+	for i, move := range moves {
+		for _, position := range []struct {
+			name  string
+			index int
+		}{{"from", move.From}, {"to", move.To}} {
+			if position.index >= 0 && position.index < len(items) {
+				continue
+			}
+			field := constants.FormFieldMoves + "." + strconv.Itoa(i) + "." + position.name
+			fieldErrors = append(fieldErrors, errors.FieldError{
+				Field:   field,
+				Code:    validation.CodeInvalid,
+				Message: fmt.Sprintf("%s must be between 0 and %d", field, len(items)-1),
+				Params:  map[string]any{"max": len(items) - 1},
+			})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	moved := slices.Clone(items)
+	for _, move := range moves {
+		item := moved[move.From]
+		moved = slices.Insert(slices.Delete(moved, move.From, move.From+1), move.To, item)
+	}
+	return moved, nil
+}
+
+// expandCollection sets the resources of a collection's items, with signed
+// URLs. Items of resources deleted meanwhile are left out.
+func expandCollection(ctx context.Context, resourceService *db.ResourceService, product string, collection *models.Collection) error {
+	if len(collection.ResourceIDs) == 0 {
+		return nil
+	}
+
+	docs, err := resourceService.GetAll(ctx, product, collection.ResourceIDs)
+	if err != nil {
+		return err
+	}
+
+	resources := make(map[string]*models.Resource, len(docs))
+	for _, doc := range docs {
+		var resource models.Resource
+		if !doc.Exists() {
+			continue
+		}
+		if err := doc.DataTo(&resource); err != nil {
+			logger.Infof("Error converting document %s: %v", doc.Ref.ID, err)
+			continue
+		}
+		resource.ID = doc.Ref.ID
+
+		signResource(ctx, &resource)
+		resources[resource.ID] = &resource
+	}
+
+	collection.Items = slices.DeleteFunc(collection.Items, func(item models.CollectionItem) bool {
+		return item.ResourceID != "" && resources[item.ResourceID] == nil
+	})
+	for i, item := range collection.Items {
+		if item.ResourceID != "" {
+			collection.Items[i].Resource = resources[item.ResourceID]
+		}
+	}
+	return nil
+}
+
+// removeFromCollections removes deleted resources from the collections of a
+// product. The resources are gone already, so failures are only logged.
+func removeFromCollections(ctx context.Context, product string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	changed, err := db.NewCollectionService(db.New()).RemoveResources(ctx, product, ids)
+	if err != nil {
+		logger.Errorf("Failed to remove deleted resources of %s from collections: %v", product, err)
+		return
+	}
+	if changed > 0 {
+		logger.Infof("Removed %d deleted resources of %s from %d collections", len(ids), product, changed)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestCreateCollectionRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "not JSON", contentType: constants.ContentTypeMultipart, body: "", expectedError: errors.ErrInvalidContentType},
+		{name: "invalid JSON", contentType: constants.ContentTypeJSON, body: "{", expectedError: errors.ErrInvalidPayload},
+		{name: "unknown member", contentType: constants.ContentTypeJSON, body: `{"title":"Onboarding","resourceIds":["a"]}`, expectedError: errors.ErrInvalidPayload},
+		{name: "resources are not expanded from requests", contentType: constants.ContentTypeJSON, body: `{"title":"Onboarding","items":[{"resource":{"id":"a"}}]}`, expectedError: errors.ErrInvalidPayload},
+		{name: "no title", contentType: constants.ContentTypeJSON, body: `{"title":"  "}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"title"}},
+		{
+			name:           "invalid fields",
+			contentType:    constants.ContentTypeJSON,
+			body:           `{"title":"Onboarding","coverImageUrl":"cover.png","items":[{"heading":"Week 1"},{"resourceId":"a","heading":"Week 2"}]}`,
+			expectedError:  errors.ErrInvalidParam,
+			expectedFields: []string{"coverImageUrl", "items.1"},
+		},
+		{
+			name:           "resource listed twice",
+			contentType:    constants.ContentTypeJSON,
+			body:           `{"title":"Onboarding","items":[{"resourceId":"a"},{"resourceId":" a "}]}`,
+			expectedError:  errors.ErrInvalidParam,
+			expectedFields: []string{"items.1.resourceId"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/collections", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			CreateCollection(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
+
+func TestReorderCollectionRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "unknown member", body: `{"moves":[{"from":0,"to":1,"count":2}]}`, expectedError: errors.ErrInvalidPayload},
+		{name: "no moves", body: `{"moves":[]}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"moves"}},
+		{name: "too many moves", body: `{"moves":[` + strings.Repeat(`{"from":0,"to":1},`, constants.MaxCollectionMoves) + `{"from":0,"to":1}]}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"moves"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/collections/onboarding/reorder", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", constants.ContentTypeJSON)
+			c.Params = gin.Params{{Key: "id", Value: "onboarding"}}
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			ReorderCollection(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
+
+func TestNewCollection(t *testing.T) {
+	collection := newCollection(collectionRequest{
+		Title: " Onboarding ",
+		Items: []collectionItemRequest{{Heading: " Week 1 "}, {ResourceID: " intro "}},
+	})
+
+	assert.Equal(t, "Onboarding", collection.Title)
+	assert.Equal(t, []models.CollectionItem{{Heading: "Week 1"}, {ResourceID: "intro"}}, collection.Items)
+
+	collection.IndexResources()
+	assert.Equal(t, []string{"intro"}, collection.ResourceIDs)
+
+	empty := newCollection(collectionRequest{Title: "Empty"})
+	assert.NotNil(t, empty.Items, "items are stored as an empty list")
+}
+
+func TestMoveItems(t *testing.T) {
+	items := []models.CollectionItem{{Heading: "Basics"}, {ResourceID: "a"}, {ResourceID: "b"}, {ResourceID: "c"}}
+	ids := func(items []models.CollectionItem) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ResourceID+item.Heading)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name           string
+		moves          []collectionMove
+		expected       []string
+		expectedFields []string
+	}{
+		{name: "forward", moves: []collectionMove{{From: 1, To: 3}}, expected: []string{"Basics", "b", "c", "a"}},
+		{name: "backward", moves: []collectionMove{{From: 3, To: 0}}, expected: []string{"c", "Basics", "a", "b"}},
+		{name: "in place", moves: []collectionMove{{From: 2, To: 2}}, expected: []string{"Basics", "a", "b", "c"}},
+		{name: "in order", moves: []collectionMove{{From: 1, To: 3}, {From: 1, To: 2}}, expected: []string{"Basics", "c", "b", "a"}},
+		{
+			name:           "out of the list",
+			moves:          []collectionMove{{From: 0, To: 1}, {From: 4, To: -1}},
+			expectedFields: []string{"moves.1.from", "moves.1.to"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moved, fieldErrors := moveItems(items, tt.moves)
+			assert.Equal(t, tt.expectedFields, fieldsOf(errors.ErrorResponse{FieldErrors: fieldErrors}))
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, ids(moved))
+			}
+			assert.Equal(t, []string{"Basics", "a", "b", "c"}, ids(items), "items are not changed")
+		})
+	}
+}
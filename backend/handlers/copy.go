@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/catalog"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/products"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// copyRequest is the body of POST /resources/:id/copy and /move
+type copyRequest struct {
+	Product string `json:"product"` // Target product
+}
+
+// CopyResource handles POST /resources/:id/copy
+//   - Copies a resource to the product named in the body, with its stored
+//     files, see catalog.Copy. The copy keeps the resource's ID unless the
+//     target product uses it.
+//   - The target product's custom fields and upload policy apply: a resource
+//     it does not accept is rejected before anything is written.
+//   - Only admins, sending the admin key, and the owners of the target
+//     product, sending an ID token with their verified email, may copy to it.
+func CopyResource(c *gin.Context) {
+	transferResource(c, false)
+}
+
+// MoveResource handles POST /resources/:id/move
+//   - Copies a resource to another product like CopyResource, then deletes
+//     it from this one, releasing its tags and files.
+func MoveResource(c *gin.Context) {
+	transferResource(c, true)
+}
+
+// transferResource copies a resource to another product, and deletes the
+// original when moving it
+func transferResource(c *gin.Context, move bool) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	target, ok := copyTarget(c, product)
+	if !ok {
+		return
+	}
+	if !mayWriteTo(c, target) {
+		return
+	}
+
+	report, err := catalog.Copy(ctx, product, id, target)
+	if stdErrors.Is(err, catalog.ErrResourceNotFound) {
+		errors.RespondWithError(c, errors.ErrResourceNotFound, "Resource not found")
+		return
+	}
+	if stdErrors.Is(err, catalog.ErrRejected) {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidParam, fmt.Sprintf("Resource cannot be copied to %s", target), err.Error())
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to copy resource", err.Error())
+		return
+	}
+
+	if move {
+		if err := removeOriginal(ctx, product, id); err != nil {
+			logger.Errorf("Moved resource %s of %s to %s as %s but failed to delete the original: %v", id, product, target, report.ID, err)
+			errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, fmt.Sprintf("Resource was copied to %s as %s, but the original could not be deleted", target, report.ID), err.Error())
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// copyTarget reads the product a resource is copied or moved to from the
+// request body. It responds with an error and returns false if it is not
+// another valid product.
+func copyTarget(c *gin.Context, product string) (string, bool) {
+	if c.ContentType() != constants.ContentTypeJSON {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be application/json")
+		return "", false
+	}
+
+	body, ok := readJSONBody(c)
+	if !ok {
+		return "", false
+	}
+
+	var request copyRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid copy request", err.Error())
+		return "", false
+	}
+
+	switch {
+	case request.Product == "":
+		errors.RespondWithFieldError(c, errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldProduct, Code: validation.CodeRequired, Message: "product is required"})
+		return "", false
+	case !utils.IsValidProduct(request.Product):
+		errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{
+			Field:   constants.FormFieldProduct,
+			Code:    validation.CodeInvalidChoice,
+			Message: fmt.Sprintf("Unknown product %q", request.Product),
+			Params:  map[string]any{"values": products.Names(false)},
+		})
+		return "", false
+	case request.Product == product:
+		errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldProduct, Code: validation.CodeInvalid, Message: "product must be another product than the resource's"})
+		return "", false
+	}
+
+	return request.Product, true
+}
+
+// mayWriteTo reports whether the caller may copy or move resources to the
+// target product: admins may, and so may its owners, matched by the verified
+// email of their ID token. It responds with an error and returns false
+// otherwise.
+func mayWriteTo(c *gin.Context, target string) bool {
+	if middleware.IsAdmin(c) {
+		return true
+	}
+
+	if email, signedIn := middleware.GetUserEmailFromContext(c); signedIn {
+		product, _ := products.Lookup(target)
+		if slices.ContainsFunc(product.Owners, func(owner string) bool { return strings.EqualFold(owner, email) }) {
+			return true
+		}
+	}
+
+	if _, signedIn := middleware.GetUserFromContext(c); !signedIn {
+		errors.RespondWithError(c, errors.ErrUnauthorized, fmt.Sprintf("Copying to %s requires the admin key or the ID token of one of its owners", target))
+		return false
+	}
+	errors.RespondWithError(c, errors.ErrForbidden, fmt.Sprintf("Only admins and the owners of %s may copy resources to it", target))
+	return false
+}
+
+// removeOriginal deletes a moved resource from its product, once copied. A
+// resource deleted meanwhile is gone already.
+func removeOriginal(ctx context.Context, product, id string) error {
+	resourceService := db.NewResourceService(db.New())
+
+	doc, err := resourceService.GetByID(ctx, product, id)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var resource models.Resource
+	if err := doc.DataTo(&resource); err != nil {
+		return err
+	}
+	return removeResource(ctx, resourceService, product, id, resource)
+}
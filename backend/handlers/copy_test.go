@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+	"learninghub/products"
+)
+
+// productList is a products.Store listing fixed products
+type productList []models.Product
+
+func (l productList) List(context.Context) ([]models.Product, error) { return l, nil }
+
+func (l productList) Create(context.Context, models.Product) error { return nil }
+
+func TestTransferResourceRejectsInvalidTargets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	originalConfig := config.AppConfig
+	config.AppConfig = &config.EnvConfig{VALID_PRODUCTS: []string{"ecomm", "staging"}}
+	defer func() {
+		config.AppConfig = originalConfig
+	}()
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "not JSON", contentType: constants.ContentTypeMultipart, body: "", expectedError: errors.ErrInvalidContentType},
+		{name: "invalid JSON", contentType: constants.ContentTypeJSON, body: "{", expectedError: errors.ErrInvalidPayload},
+		{name: "unknown member", contentType: constants.ContentTypeJSON, body: `{"product":"staging","id":"other"}`, expectedError: errors.ErrInvalidPayload},
+		{name: "no product", contentType: constants.ContentTypeJSON, body: `{}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"product"}},
+		{name: "unknown product", contentType: constants.ContentTypeJSON, body: `{"product":"retail"}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"product"}},
+		{name: "same product", contentType: constants.ContentTypeJSON, body: `{"product":"ecomm"}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"product"}},
+	}
+
+	for _, handler := range []struct {
+		name   string
+		handle gin.HandlerFunc
+	}{{"copy", CopyResource}, {"move", MoveResource}} {
+		for _, tt := range tests {
+			t.Run(handler.name+"/"+tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/resources/abc/"+handler.name, strings.NewReader(tt.body))
+				c.Request.Header.Set("Content-Type", tt.contentType)
+				c.Params = gin.Params{{Key: "id", Value: "abc"}}
+				c.Set(constants.ProductContextKey, "ecomm")
+
+				handler.handle(c)
+
+				var response errors.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedFields, fieldsOf(response))
+			})
+		}
+	}
+}
+
+func TestMayWriteTo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	originalConfig, originalRegistry := config.AppConfig, products.Default
+	config.AppConfig = &config.EnvConfig{ADMIN_API_KEY: "secret"}
+	products.Default = products.New(productList{
+		{Name: "ecomm"},
+		{Name: "staging", Owners: []string{"Owner@example.com"}},
+	}, time.Hour)
+	require.NoError(t, products.Default.Refresh(context.Background()))
+	defer func() {
+		config.AppConfig, products.Default = originalConfig, originalRegistry
+	}()
+
+	tests := []struct {
+		name          string
+		adminKey      string
+		user          string
+		email         string
+		expectedError errors.ErrorCode
+	}{
+		{name: "admin", adminKey: "secret"},
+		{name: "owner", user: "user-1", email: "owner@example.com"},
+		{name: "owner with an invalid admin key", adminKey: "wrong", user: "user-1", email: "owner@example.com"},
+		{name: "anonymous", expectedError: errors.ErrUnauthorized},
+		{name: "invalid admin key", adminKey: "wrong", expectedError: errors.ErrUnauthorized},
+		{name: "other user", user: "user-2", email: "other@example.com", expectedError: errors.ErrForbidden},
+		{name: "unverified email", user: "user-3", expectedError: errors.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/resources/abc/copy", nil)
+			if tt.adminKey != "" {
+				c.Request.Header.Set(constants.HeaderAdminKey, tt.adminKey)
+			}
+			if tt.user != "" {
+				c.Set(constants.UserContextKey, tt.user)
+			}
+			if tt.email != "" {
+				c.Set(constants.UserEmailContextKey, tt.email)
+			}
+
+			allowed := mayWriteTo(c, "staging")
+
+			if tt.expectedError == "" {
+				assert.True(t, allowed)
+				assert.Empty(t, w.Body.String())
+				return
+			}
+			assert.False(t, allowed)
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+		})
+	}
+
+	// Owners of another product may not copy to this one
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/staging/resources/abc/copy", nil)
+	c.Set(constants.UserContextKey, "user-1")
+	c.Set(constants.UserEmailContextKey, "owner@example.com")
+	assert.False(t, mayWriteTo(c, "ecomm"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
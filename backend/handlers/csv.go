@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// csvColumnsField prefixes the field of rejected columns of a CSV import,
+// e.g. "columns.url"
+const csvColumnsField = "columns"
+
+// csvFields are the resource fields CSV imports read, the required ones first
+var csvFields = []string{
+	constants.FormFieldTitle,
+	constants.FormFieldDescription,
+	constants.FormFieldURL,
+	constants.FormFieldType,
+	constants.FormFieldTags,
+}
+
+// csvRequiredFields are the columns a CSV import must have. The type
+// defaults to article and tags are optional.
+var csvRequiredFields = csvFields[:3]
+
+// csvColumn is a column of a CSV import: a resource field, or a custom field
+type csvColumn struct {
+	field      string                  // Resource field, empty for a custom field
+	definition *models.FieldDefinition // Of a custom field
+}
+
+// csvRow is a resource read from a row of a CSV import
+type csvRow struct {
+	line         int // Of the row in the CSV, the header being line 1
+	resource     models.Resource
+	customFields map[string]any
+	err          *errors.ErrorResponse // Of a cell that could not be read
+}
+
+// csvResult is the outcome of a row of a CSV import
+type csvResult struct {
+	Row    int                   `json:"row"` // Line of the row in the CSV, the header being line 1
+	ID     string                `json:"id,omitempty"`
+	Title  string                `json:"title"`
+	Status string                `json:"status"`          // succeeded or failed
+	Error  *errors.ErrorResponse `json:"error,omitempty"` // Fields are those of the resource
+}
+
+// csvImportResponse is the response of POST /resources/import
+type csvImportResponse struct {
+	Results []csvResult `json:"results"`
+	Created int         `json:"created"` // Or would be, in a dry run
+	Failed  int         `json:"failed"`
+	DryRun  bool        `json:"dryRun"`
+}
+
+// ImportResourcesCSV handles POST /resources/import
+//   - Creates article and embed resources from the rows of a CSV, sent as
+//     text/csv or as the multipart field "file". Its header names the
+//     columns: title, description and url, optionally type (article by
+//     default), tags (separated by commas or semicolons) and custom fields.
+//   - Every row is checked with the rules of CreateResource, and fails or
+//     succeeds on its own, see csvResult. A malformed CSV or header fails
+//     the request before anything is created.
+//   - Resources are created in batches of constants.BulkBatchSize, and tag
+//     usage counts adjusted once.
+//   - With "dryRun=true" nothing is created, the report tells what would be.
+func ImportResourcesCSV(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	dryRun, ok := dryRunOption(c)
+	if !ok {
+		return
+	}
+
+	data, ok := readCSVBody(c)
+	if !ok {
+		return
+	}
+
+	definitions, err := db.NewFieldService(db.New()).List(ctx, product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+		return
+	}
+
+	rows, errResponse := parseCSVResources(bytes.NewReader(data), definitions)
+	if errResponse != nil {
+		respondWith(c, *errResponse)
+		return
+	}
+
+	resourceService := db.NewResourceService(db.New())
+	tagDeltas := make(map[string]int)
+
+	response := csvImportResponse{Results: make([]csvResult, 0, len(rows)), DryRun: dryRun}
+	for start := 0; start < len(rows); start += constants.BulkBatchSize {
+		batch := rows[start:min(start+constants.BulkBatchSize, len(rows))]
+		response.Results = append(response.Results, importCSVBatch(ctx, resourceService, product, batch, definitions, dryRun, tagDeltas)...)
+	}
+
+	// Once per import rather than per resource
+	utils.AdjustTagUsage(ctx, product, tagDeltas)
+
+	for _, result := range response.Results {
+		if result.Status == constants.BulkStatusSucceeded {
+			response.Created++
+		} else {
+			response.Failed++
+		}
+	}
+
+	logger.Infof("Imported %d resources of %s from CSV, %d rows failed (dry run: %v)", response.Created, product, response.Failed, dryRun)
+	c.JSON(http.StatusOK, response)
+}
+
+// readCSVBody reads the CSV of an import, the request body or its "file"
+// field. It responds with an error and returns false if it is too large or
+// cannot be read.
+func readCSVBody(c *gin.Context) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.MaxCSVSize)
+	tooLarge := func(err error) bool {
+		if maxBytesErr := (*http.MaxBytesError)(nil); stdErrors.As(err, &maxBytesErr) {
+			errors.RespondWithError(c, errors.ErrFileTooLarge, fmt.Sprintf("CSV too large. Maximum size is %d MB", constants.MaxCSVSize/(1<<20)))
+			return true
+		}
+		return false
+	}
+
+	var body io.Reader
+	switch c.ContentType() {
+	case constants.ContentTypeCSV:
+		body = c.Request.Body
+
+	case constants.ContentTypeMultipart:
+		if err := c.Request.ParseMultipartForm(constants.MaxCSVSize); err != nil {
+			if !tooLarge(err) {
+				handleMultipartFormError(c, err)
+			}
+			return nil, false
+		}
+
+		file, _, err := c.Request.FormFile(constants.FormFieldFile)
+		if err != nil {
+			errors.RespondWithFieldError(c, errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldFile, Code: validation.CodeRequired, Message: "CSV file is required"})
+			return nil, false
+		}
+		defer file.Close()
+		body = file
+
+	default:
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be text/csv or multipart/form-data")
+		return nil, false
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		if !tooLarge(err) {
+			errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Failed to read CSV", err.Error())
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// parseCSVResources reads the resources of the rows of a CSV, with the
+// product's custom field definitions. It returns the error of a malformed
+// CSV or header, or of too many rows. Cells that cannot be read only fail
+// their row.
+func parseCSVResources(r io.Reader, definitions []models.FieldDefinition) ([]csvRow, *errors.ErrorResponse) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, &errors.ErrorResponse{Error: errors.ErrInvalidPayload, Message: "CSV is empty"}
+	}
+	if err != nil {
+		return nil, &errors.ErrorResponse{Error: errors.ErrInvalidPayload, Message: "Invalid CSV", Details: err.Error()}
+	}
+
+	columns, fieldErrors := csvColumns(header, definitions)
+	if len(fieldErrors) > 0 {
+		response := validationError(fieldErrors)
+		return nil, &response
+	}
+
+	var rows []csvRow
+	now := time.Now()
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &errors.ErrorResponse{Error: errors.ErrInvalidPayload, Message: "Invalid CSV", Details: err.Error()}
+		}
+		if len(rows) == constants.MaxCSVRows {
+			return nil, &errors.ErrorResponse{Error: errors.ErrInvalidPayload, Message: fmt.Sprintf("CSV has too many rows. Maximum is %d resources", constants.MaxCSVRows)}
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := csvRow{line: line, resource: models.Resource{Type: constants.ResourceTypeArticle, CreatedAt: now, UpdatedAt: now}}
+		for i, column := range columns {
+			readCSVCell(&row, column, strings.TrimSpace(record[i]))
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, &errors.ErrorResponse{Error: errors.ErrInvalidPayload, Message: "CSV has no rows after its header"}
+	}
+	return rows, nil
+}
+
+// csvColumns maps the header of a CSV to resource and custom fields, by name
+// regardless of case, and returns the errors of unknown, repeated and missing
+// columns
+func csvColumns(header []string, definitions []models.FieldDefinition) ([]csvColumn, []errors.FieldError) {
+	columns := make([]csvColumn, len(header))
+	var fieldErrors []errors.FieldError
+	seen := make(map[string]bool, len(header))
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			// Spreadsheets export UTF-8 with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		field := csvColumnsField + "." + name
+
+		key := strings.ToLower(name)
+		if seen[key] {
+			fieldErrors = append(fieldErrors, errors.FieldError{Field: field, Code: validation.CodeConflict, Message: fmt.Sprintf("Column '%s' is repeated", name)})
+			continue
+		}
+		seen[key] = true
+
+		if index := slices.Index(csvFields, key); index >= 0 {
+			columns[i] = csvColumn{field: csvFields[index]}
+			continue
+		}
+		if index := slices.IndexFunc(definitions, func(d models.FieldDefinition) bool { return strings.EqualFold(d.Name, name) }); index >= 0 {
+			columns[i] = csvColumn{definition: &definitions[index]}
+			continue
+		}
+
+		fieldErrors = append(fieldErrors, errors.FieldError{
+			Field:   field,
+			Code:    validation.CodeUnknownField,
+			Message: fmt.Sprintf("Column '%s' is neither a resource field (%s) nor a custom field", name, strings.Join(csvFields, ", ")),
+		})
+	}
+
+	for _, name := range csvRequiredFields {
+		if !seen[name] {
+			fieldErrors = append(fieldErrors, errors.FieldError{Field: csvColumnsField + "." + name, Code: validation.CodeRequired, Message: fmt.Sprintf("Column '%s' is required", name)})
+		}
+	}
+	return columns, fieldErrors
+}
+
+// readCSVCell sets the field of a column to the value of its cell. An empty
+// cell leaves the field as it is, e.g. the default type.
+func readCSVCell(row *csvRow, column csvColumn, value string) {
+	if value == "" {
+		return
+	}
+
+	switch column.field {
+	case constants.FormFieldTitle:
+		row.resource.Title = value
+	case constants.FormFieldDescription:
+		row.resource.Description = value
+	case constants.FormFieldURL:
+		row.resource.URL = value
+	case constants.FormFieldType:
+		row.resource.Type = value
+	case constants.FormFieldTags:
+		row.resource.Tags = utils.NormalizeTags(strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }))
+	default:
+		customValue, err := utils.ParseCustomFieldFilter(*column.definition, value)
+		if err != nil {
+			if row.err != nil {
+				return
+			}
+			if fieldErr := (*utils.CustomFieldError)(nil); stdErrors.As(err, &fieldErr) {
+				response := customFieldError(fieldErr)
+				row.err = &response
+				return
+			}
+			row.err = &errors.ErrorResponse{Error: errors.ErrInvalidParam, Message: "Invalid custom fields", Details: err.Error()}
+			return
+		}
+		if row.customFields == nil {
+			row.customFields = make(map[string]any)
+		}
+		row.customFields[column.definition.Name] = customValue
+	}
+}
+
+// importCSVBatch checks the rows of a batch and creates their resources at
+// once, unless in a dry run. It returns their results, and counts the tags
+// of the created resources in tagDeltas.
+func importCSVBatch(ctx context.Context, resourceService *db.ResourceService, product string, batch []csvRow, definitions []models.FieldDefinition, dryRun bool, tagDeltas map[string]int) []csvResult {
+	results := make([]csvResult, len(batch))
+	fail := func(i int, response errors.ErrorResponse) {
+		results[i].Status = constants.BulkStatusFailed
+		results[i].Error = &response
+	}
+	fields := func() ([]models.FieldDefinition, error) {
+		return definitions, nil
+	}
+
+	var resources []models.Resource
+	var indexes []int // In the batch of the resources to create
+	for i, row := range batch {
+		results[i] = csvResult{Row: row.line, Title: row.resource.Title, Status: constants.BulkStatusSucceeded}
+		if row.err != nil {
+			fail(i, *row.err)
+			continue
+		}
+
+		// Files cannot be uploaded from a CSV
+		resource := row.resource
+		resourceType, known := utils.LookupResourceType(resource.Type)
+		if known && resourceType.Source != utils.SourceURL {
+			fail(i, *fieldErrorResponse(errors.ErrUnsupportedType, linkTypeError()))
+			continue
+		}
+
+		if response := checkNewResource(ctx, product, fields, &resource, row.customFields, false); response != nil {
+			fail(i, *response)
+			continue
+		}
+
+		// Fall back to the type's default, e.g. an embed provider's thumbnail
+		if resourceType.DefaultThumbnail != nil {
+			resource.ThumbnailURL = resourceType.DefaultThumbnail(resource)
+		}
+
+		resources = append(resources, resource)
+		indexes = append(indexes, i)
+	}
+
+	if dryRun || len(resources) == 0 {
+		return results
+	}
+
+	ids, createErrs := resourceService.CreateAll(ctx, product, resources)
+	for j, i := range indexes {
+		if err := createErrs[j]; err != nil {
+			logger.Infof("Failed to save resource of CSV row %d: %v", batch[i].line, err)
+			fail(i, errors.ErrorResponse{Error: errors.ErrMutationFailed, Message: "Failed to save resource"})
+			continue
+		}
+
+		results[i].ID = ids[j]
+		for _, tag := range resources[j].Tags {
+			tagDeltas[tag]++
+		}
+		referenceLinkedFiles(ctx, resources[j].URL)
+	}
+	return results
+}
+
+// linkTypeError rejects the type of a resource imported from a CSV, listing
+// the resource types whose content is linked
+func linkTypeError() errors.FieldError {
+	var names []string
+	for _, name := range utils.ResourceTypeNames() {
+		if resourceType, _ := utils.LookupResourceType(name); resourceType.Source == utils.SourceURL {
+			names = append(names, name)
+		}
+	}
+
+	return errors.FieldError{
+		Field:   constants.FormFieldType,
+		Code:    validation.CodeNotSupported,
+		Message: "Type of imported resources must be one of: " + strings.Join(names, ", "),
+		Params:  map[string]any{"supported": names},
+	}
+}
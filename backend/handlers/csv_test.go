@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestImportResourcesCSVRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	noFile := &bytes.Buffer{}
+	writer := multipart.NewWriter(noFile)
+	require.NoError(t, writer.WriteField(constants.FormFieldTitle, "links"))
+	require.NoError(t, writer.Close())
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "invalid dry run", query: "?dryRun=maybe", contentType: constants.ContentTypeCSV, body: "title", expectedError: errors.ErrInvalidParam, expectedFields: []string{"dryRun"}},
+		{name: "JSON", contentType: constants.ContentTypeJSON, body: "{}", expectedError: errors.ErrInvalidContentType},
+		{name: "no file", contentType: writer.FormDataContentType(), body: noFile.String(), expectedError: errors.ErrMissingRequired, expectedFields: []string{"file"}},
+		{name: "too large", contentType: constants.ContentTypeCSV, body: strings.Repeat("a", constants.MaxCSVSize+1), expectedError: errors.ErrFileTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/resources/import"+tt.query, strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			ImportResourcesCSV(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
+
+func TestParseCSVResources(t *testing.T) {
+	definitions := []models.FieldDefinition{
+		{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"beginner", "advanced"}},
+		{Name: "minutes", Type: constants.FieldTypeNumber},
+	}
+
+	data := "\ufeffTitle,Description,URL,Tags,Minutes,difficulty\n" +
+		"Intro,\"Getting\nstarted\",https://example.com/intro,\"Basics; onboarding,basics\",15,beginner\n" +
+		"Deep dive,More,https://example.com/deep,,,\n" +
+		"Broken,Bad cell,https://example.com/broken,,soon,\n"
+
+	rows, response := parseCSVResources(strings.NewReader(data), definitions)
+	require.Nil(t, response)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, 2, rows[0].line)
+	assert.Equal(t, "Intro", rows[0].resource.Title)
+	assert.Equal(t, "Getting\nstarted", rows[0].resource.Description)
+	assert.Equal(t, constants.ResourceTypeArticle, rows[0].resource.Type, "type defaults to article")
+	assert.Equal(t, []string{"basics", "onboarding"}, rows[0].resource.Tags)
+	assert.Equal(t, map[string]any{"minutes": 15.0, "difficulty": "beginner"}, rows[0].customFields)
+	assert.Nil(t, rows[0].err)
+
+	assert.Equal(t, 4, rows[1].line, "lines count those of multi-line cells")
+	assert.Empty(t, rows[1].resource.Tags)
+	assert.Nil(t, rows[1].customFields, "empty cells are left out")
+
+	require.NotNil(t, rows[2].err, "a cell that cannot be read fails its row")
+	assert.Equal(t, []string{"customFields.minutes"}, fieldsOf(*rows[2].err))
+}
+
+func TestParseCSVResourcesRejectsInvalidCSVs(t *testing.T) {
+	definitions := []models.FieldDefinition{{Name: "difficulty", Type: constants.FieldTypeString}}
+
+	tests := []struct {
+		name            string
+		data            string
+		expectedError   errors.ErrorCode
+		expectedFields  []string
+		expectedDetails string
+	}{
+		{name: "empty", data: "", expectedError: errors.ErrInvalidPayload},
+		{name: "no rows", data: "title,description,url\n", expectedError: errors.ErrInvalidPayload},
+		{name: "missing columns", data: "title,type\nIntro,article\n", expectedError: errors.ErrMissingRequired, expectedFields: []string{"columns.description", "columns.url"}},
+		{name: "unknown column", data: "title,description,url,author\n", expectedError: errors.ErrInvalidParam, expectedFields: []string{"columns.author"}},
+		{name: "repeated column", data: "title,description,url,URL\n", expectedError: errors.ErrInvalidParam, expectedFields: []string{"columns.URL"}},
+		{name: "wrong number of cells", data: "title,description,url\nIntro,Getting started\n", expectedError: errors.ErrInvalidPayload, expectedDetails: "line 2"},
+		{name: "unterminated quote", data: "title,description,url\nIntro,\"Getting started,https://example.com\n", expectedError: errors.ErrInvalidPayload, expectedDetails: "line 2"},
+		{name: "too many rows", data: "title,description,url\n" + strings.Repeat("Intro,Getting started,https://example.com\n", constants.MaxCSVRows+1), expectedError: errors.ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, response := parseCSVResources(strings.NewReader(tt.data), definitions)
+			assert.Nil(t, rows)
+			require.NotNil(t, response)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(*response))
+			assert.Contains(t, response.Details, tt.expectedDetails)
+		})
+	}
+}
+
+func TestImportCSVBatchDryRun(t *testing.T) {
+	rows, response := parseCSVResources(strings.NewReader("title,description,url,type,tags\n"+
+		"Intro,Getting started,https://example.com/intro,,basics\n"+
+		"Slides,Deck,https://example.com/deck.pdf,pdf,\n"+
+		"Podcast,Episode,https://example.com/episode,podcast,\n"+
+		",No title,not a url,,\n"), nil)
+	require.Nil(t, response)
+
+	tagDeltas := make(map[string]int)
+	results := importCSVBatch(context.Background(), nil, "ecomm", rows, nil, true, tagDeltas)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, csvResult{Row: 2, Title: "Intro", Status: constants.BulkStatusSucceeded}, results[0])
+
+	for i, expected := range []struct {
+		code   errors.ErrorCode
+		fields []string
+	}{
+		{errors.ErrUnsupportedType, []string{"type"}},
+		{errors.ErrUnsupportedType, []string{"type"}},
+		{errors.ErrInvalidParam, []string{"title", "url"}},
+	} {
+		result := results[i+1]
+		assert.Equal(t, constants.BulkStatusFailed, result.Status, "row %d", result.Row)
+		require.NotNil(t, result.Error, "row %d", result.Row)
+		assert.Equal(t, expected.code, result.Error.Error, "row %d", result.Row)
+		assert.Equal(t, expected.fields, fieldsOf(*result.Error), "row %d", result.Row)
+	}
+
+	assert.Empty(t, tagDeltas, "nothing is created in a dry run")
+}
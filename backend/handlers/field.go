@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+// GetFields handles GET /fields
+// Lists the custom field definitions of the product's resources.
+func GetFields(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	database := db.New()
+	fieldService := db.NewFieldService(database)
+
+	definitions, err := fieldService.List(ctx, product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, definitions)
+}
+
+// SetField handles PUT /fields/:name
+//   - Creates or replaces a custom field definition from a JSON body.
+//   - Values already stored on resources are not checked again against a
+//     replaced definition.
+func SetField(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	var definition models.FieldDefinition
+	if err := c.ShouldBindJSON(&definition); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid field definition", err.Error())
+		return
+	}
+	// The name is the one of the URL
+	definition.Name = name
+
+	if err := utils.ValidateFieldDefinition(&definition); err != nil {
+		errors.RespondWithError(c, errors.ErrInvalidParam, err.Error())
+		return
+	}
+
+	database := db.New()
+	fieldService := db.NewFieldService(database)
+
+	definitions, err := fieldService.List(ctx, product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+		return
+	}
+
+	// Replacing a field does not count against the limit
+	status := http.StatusCreated
+	if slices.ContainsFunc(definitions, func(d models.FieldDefinition) bool { return d.Name == name }) {
+		status = http.StatusOK
+	} else if len(definitions) >= constants.MaxCustomFields {
+		errors.RespondWithError(c, errors.ErrInvalidParam, fmt.Sprintf("A product can define at most %d custom fields", constants.MaxCustomFields))
+		return
+	}
+
+	if err := fieldService.Set(ctx, product, definition); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to save field", err.Error())
+		return
+	}
+
+	c.JSON(status, definition)
+}
+
+// DeleteField handles DELETE /fields/:name
+//   - Values stored on resources are kept, but no longer validated nor filterable.
+func DeleteField(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	database := db.New()
+	fieldService := db.NewFieldService(database)
+
+	definitions, err := fieldService.List(ctx, product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+		return
+	}
+	if !slices.ContainsFunc(definitions, func(d models.FieldDefinition) bool { return d.Name == name }) {
+		errors.RespondWithError(c, errors.ErrFieldNotFound, "Field not found")
+		return
+	}
+
+	if err := fieldService.Delete(ctx, product, name); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to delete field", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Field deleted successfully"})
+}
+
+// customFieldFilters parses the query parameters of GET /resources filtering
+// on the product's custom fields. Parameters that are not a custom field are
+// ignored, like an unknown type filter. Responds with an error and returns
+// false if a value does not fit its field.
+func customFieldFilters(c *gin.Context, product string) (map[string]any, bool) {
+	// The definitions are only read when there is something to filter
+	query := c.Request.URL.Query()
+	filtered := false
+	for name := range query {
+		filtered = filtered || !utils.IsReservedFieldName(name)
+	}
+	if !filtered {
+		return nil, true
+	}
+
+	definitions, err := db.NewFieldService(db.New()).List(c.Request.Context(), product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+		return nil, false
+	}
+
+	var filters map[string]any
+	for _, definition := range definitions {
+		if !query.Has(definition.Name) {
+			continue
+		}
+		value, err := utils.ParseCustomFieldFilter(definition, query.Get(definition.Name))
+		if fieldErr := (*utils.CustomFieldError)(nil); stdErrors.As(err, &fieldErr) {
+			errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: definition.Name, Code: fieldErr.Code, Message: err.Error(), Params: fieldErr.Params})
+			return nil, false
+		}
+		if err != nil {
+			errors.RespondWithErrorDetails(c, errors.ErrInvalidParam, "Invalid custom field filter", err.Error())
+			return nil, false
+		}
+		if filters == nil {
+			filters = make(map[string]any)
+		}
+		filters[definition.Name] = value
+	}
+	return filters, true
+}
+
+// mergeCustomFields applies custom field values to those of a resource, see
+// utils.MergeCustomFields. Responds with an error and returns false if the
+// values do not fit the product's fields.
+func mergeCustomFields(c *gin.Context, product string, resource *models.Resource, values map[string]any) bool {
+	definitions, err := db.NewFieldService(db.New()).List(c.Request.Context(), product)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch fields", err.Error())
+		return false
+	}
+
+	if response := customFieldsError(definitions, resource, values); response != nil {
+		respondWith(c, *response)
+		return false
+	}
+	return true
+}
+
+// customFieldsError merges custom field values into those of a resource like
+// mergeCustomFields, with the product's definitions fetched beforehand. It
+// returns the error of values that do not fit them.
+func customFieldsError(definitions []models.FieldDefinition, resource *models.Resource, values map[string]any) *errors.ErrorResponse {
+	merged, err := utils.MergeCustomFields(definitions, resource.CustomFields, values)
+	if fieldErr := (*utils.CustomFieldError)(nil); stdErrors.As(err, &fieldErr) {
+		response := customFieldError(fieldErr)
+		return &response
+	}
+	if err != nil {
+		return &errors.ErrorResponse{Error: errors.ErrInvalidParam, Message: "Invalid custom fields", Details: err.Error()}
+	}
+
+	resource.CustomFields = merged
+	return nil
+}
+
+// customFieldError returns the error of a rejected custom field value, see
+// validationError
+func customFieldError(fieldErr *utils.CustomFieldError) errors.ErrorResponse {
+	return validationError([]errors.FieldError{{
+		Field:   constants.FormFieldCustomFields + "." + fieldErr.Field,
+		Code:    fieldErr.Code,
+		Message: fieldErr.Error(),
+		Params:  fieldErr.Params,
+	}})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/utils"
+)
+
+// GetResourceImage handles GET /resources/:id/image
+//   - Redirects to a resized variant of the resource's thumbnail.
+//   - The width is snapped to one of constants.ImageVariantWidths and variants
+//     are created on first request, then served from storage.
+//
+// Query Params:
+//   - w: Desired width in pixels (default: largest variant width)
+//   - format: "jpeg", "webp" or "avif" (default: best format listed in the Accept header)
+func GetResourceImage(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	width := 0
+	if widthStr := c.Query(constants.QueryParamWidth); widthStr != "" {
+		parsed, err := strconv.Atoi(widthStr)
+		if err != nil || parsed <= 0 {
+			errors.RespondWithError(c, errors.ErrInvalidParam, "Width must be a positive integer")
+			return
+		}
+		width = parsed
+	}
+	width = utils.SnapVariantWidth(width)
+
+	format := c.Query(constants.QueryParamFormat)
+	if format == "" {
+		format = negotiateImageFormat(c.GetHeader("Accept"))
+		// The redirect target depends on the Accept header
+		c.Header("Vary", "Accept")
+	} else if !utils.IsValidImageFormat(format) {
+		errors.RespondWithError(c, errors.ErrInvalidParam, fmt.Sprintf("Format must be one of: %s", strings.Join(constants.ImageVariantFormats, ", ")))
+		return
+	}
+
+	// Create database services
+	database := db.New()
+	resourceService := db.NewResourceService(database)
+
+	doc, err := resourceService.GetByID(ctx, product, id)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrResourceNotFound, "Resource not found", err.Error())
+		return
+	}
+
+	var resource models.Resource
+	if err := doc.DataTo(&resource); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrDataConversionFailed, "Failed to process resource data", err.Error())
+		return
+	}
+
+	if resource.ThumbnailURL == "" {
+		errors.RespondWithError(c, errors.ErrResourceNotFound, "Resource has no image")
+		return
+	}
+
+	// External thumbnails cannot be resized, send the client to the original
+	if !utils.IsValidStorageURL(resource.ThumbnailURL) {
+		c.Redirect(http.StatusFound, resource.ThumbnailURL)
+		return
+	}
+
+	key := utils.VariantKey(width, format)
+	variantURL, cached := resource.Thumbnails[key]
+	if !cached {
+		variantURL, err = utils.CreateImageVariant(ctx, resource.ThumbnailURL, width, format)
+		if err != nil {
+			errors.RespondWithErrorDetails(c, errors.ErrInternalServer, "Failed to create image variant", err.Error())
+			return
+		}
+
+		if err := resourceService.SetThumbnailVariant(ctx, product, id, key, variantURL); err != nil {
+			// The variant is in storage under a deterministic name, the next request finds it
+			logger.Infof("Failed to record thumbnail variant %s of resource %s: %v", key, id, err)
+		}
+	}
+
+	signedURL, err := utils.GenerateSignedURL(ctx, variantURL, constants.DefaultSignedURLExpiration)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInternalServer, "Failed to generate image URL", err.Error())
+		return
+	}
+
+	// Let browsers reuse the redirect for most of the signed URL's lifetime
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", (constants.DefaultSignedURLExpiration-5)*60))
+	c.Redirect(http.StatusFound, signedURL)
+}
+
+// negotiateImageFormat picks the most efficient image format the client
+// accepts. Browsers advertise AVIF and WebP support in the Accept header of
+// image requests; JPEG is understood by all of them.
+func negotiateImageFormat(accept string) string {
+	switch {
+	case strings.Contains(accept, "image/avif"):
+		return constants.ImageFormatAVIF
+	case strings.Contains(accept, "image/webp"):
+		return constants.ImageFormatWebP
+	default:
+		return constants.ImageFormatJPEG
+	}
+}
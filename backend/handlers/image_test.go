@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"learninghub/constants"
+	"learninghub/errors"
+)
+
+func TestNegotiateImageFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{name: "Chrome", accept: "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8", want: constants.ImageFormatAVIF},
+		{name: "WebP only", accept: "image/webp,*/*", want: constants.ImageFormatWebP},
+		{name: "No modern format", accept: "image/png,image/*;q=0.8,*/*;q=0.5", want: constants.ImageFormatJPEG},
+		{name: "No Accept header", accept: "", want: constants.ImageFormatJPEG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiateImageFormat(tt.accept))
+		})
+	}
+}
+
+func TestGetResourceImageInvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "Non-numeric width", query: "w=large"},
+		{name: "Negative width", query: "w=-100"},
+		{name: "Unsupported format", query: "w=320&format=bmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/ecomm/resources/abc/image?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "abc"}}
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			GetResourceImage(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response errors.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.ErrInvalidParam, response.Error)
+		})
+	}
+}
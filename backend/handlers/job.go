@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/gc"
+	"learninghub/jobs"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/products"
+	"learninghub/utils"
+)
+
+// transcodePayload is the payload of constants.JobTypeTranscode jobs
+type transcodePayload struct {
+	ResourceID string `json:"resourceId"`
+	Source     string `json:"source"` // URL of the uploaded video
+}
+
+// convertPayload is the payload of constants.JobTypeConvert jobs
+type convertPayload struct {
+	ResourceID string `json:"resourceId"`
+	Source     string `json:"source"` // URL of the uploaded slide deck
+}
+
+// thumbnailPayload is the payload of constants.JobTypeThumbnail jobs
+type thumbnailPayload struct {
+	ResourceID string `json:"resourceId"`
+	Source     string `json:"source"` // See models.ThumbnailJob.Source
+}
+
+// purgePayload is the payload of constants.JobTypePurge jobs
+type purgePayload struct {
+	URLs     []string `json:"urls,omitempty"`     // Stored files
+	Prefixes []string `json:"prefixes,omitempty"` // Storage prefixes deleted with everything under them
+}
+
+// gcPayload is the payload of constants.JobTypeGC jobs
+type gcPayload struct {
+	DryRun bool `json:"dryRun,omitempty"` // Report orphans without deleting them
+}
+
+// GetJob handles GET /jobs/:id
+//   - Returns the status of a background job started by a request of the product.
+func GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	// Create database services
+	database := db.New()
+	jobService := db.NewJobService(database)
+
+	job, err := jobService.Get(ctx, id)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrJobNotFound, "Job not found", err.Error())
+		return
+	}
+
+	// Jobs are stored together, do not leak those of other products
+	if job.Product != product {
+		errors.RespondWithError(c, errors.ErrJobNotFound, "Job not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RegisterJobHandlers registers the handlers of the background jobs started
+// by the resource handlers
+func RegisterJobHandlers(queue *jobs.Queue) {
+	queue.Register(constants.JobTypeTranscode, runTranscodeJob, jobs.Options{
+		MaxAttempts: 3,
+		Timeout:     constants.TranscodeTimeout * time.Minute,
+	})
+	queue.Register(constants.JobTypeConvert, runConvertJob, jobs.Options{
+		MaxAttempts: 3,
+		Timeout:     constants.ConvertTimeout * time.Minute,
+	})
+	queue.Register(constants.JobTypeThumbnail, runThumbnailJob, jobs.Options{})
+	queue.Register(constants.JobTypePurge, runPurgeJob, jobs.Options{})
+	queue.Register(constants.JobTypeGC, runGCJob, jobs.Options{
+		MaxAttempts: 2,
+		Timeout:     constants.StorageGCTimeout * time.Minute,
+	})
+}
+
+// ScheduleJobs schedules the periodic jobs of every product of the registry,
+// including those created after startup. Archived products still have
+// storage to collect.
+func ScheduleJobs(queue *jobs.Queue) error {
+	if config.AppConfig.STORAGE_GC_INTERVAL == 0 {
+		return nil
+	}
+
+	interval := time.Duration(config.AppConfig.STORAGE_GC_INTERVAL) * time.Hour
+	return queue.ScheduleEach(func() []string { return products.Names(true) }, constants.JobTypeGC, gcPayload{}, interval)
+}
+
+// runTranscodeJob transcodes an uploaded video to HLS
+func runTranscodeJob(ctx context.Context, job models.Job) error {
+	var payload transcodePayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	prefix := utils.HLSPrefix(job.Product, payload.ResourceID, job.ID)
+	return utils.TranscodeToHLS(ctx, job.Product, payload.ResourceID, payload.Source, prefix, jobs.FinalAttempt(job))
+}
+
+// runConvertJob converts an uploaded slide deck to PDF, then starts generating
+// the deck's thumbnail from the PDF unless it has one
+func runConvertJob(ctx context.Context, job models.Job) error {
+	var payload convertPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	prefix := utils.ConversionPrefix(job.Product, payload.ResourceID, job.ID)
+	pdfURL, err := utils.ConvertToPDF(ctx, job.Product, payload.ResourceID, payload.Source, prefix, jobs.FinalAttempt(job))
+	if err != nil || pdfURL == "" {
+		return err
+	}
+
+	// The conversion is recorded: a failure from here on must not retry it
+	started, err := db.NewResourceService(db.New()).StartThumbnailJob(ctx, job.Product, payload.ResourceID, pdfURL)
+	if err != nil {
+		logger.Errorf("Failed to start thumbnail job of resource %s: %v", payload.ResourceID, err)
+		return nil
+	}
+	if started {
+		if _, err := jobs.Enqueue(ctx, job.Product, constants.JobTypeThumbnail, thumbnailPayload{ResourceID: payload.ResourceID, Source: pdfURL}); err != nil {
+			logger.Errorf("Failed to enqueue thumbnail job for resource %s: %v", payload.ResourceID, err)
+		}
+	}
+	return nil
+}
+
+// runThumbnailJob generates the thumbnail of an uploaded file, or the
+// responsive variants of an uploaded thumbnail. Output of a job superseded
+// while it ran is deleted.
+func runThumbnailJob(ctx context.Context, job models.Job) error {
+	var payload thumbnailPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	resourceService := db.NewResourceService(db.New())
+
+	doc, err := resourceService.GetByID(ctx, job.Product, payload.ResourceID)
+	if status.Code(err) == codes.NotFound {
+		return nil // Deleted since
+	}
+	if err != nil {
+		return err
+	}
+
+	var resource models.Resource
+	if err := doc.DataTo(&resource); err != nil {
+		return jobs.Permanent(err)
+	}
+	if resource.ThumbnailJob == nil || resource.ThumbnailJob.Source != payload.Source || resource.ThumbnailJob.Done {
+		return nil // Superseded or already done
+	}
+
+	var (
+		thumbnailURL string
+		metadata     *models.Metadata
+		variants     map[string]string
+		generated    []string // Objects to delete if the result cannot be recorded
+	)
+
+	if payload.Source == resource.ThumbnailURL {
+		thumbnailURL = payload.Source
+		variants, err = utils.GenerateImageVariantsFromObject(ctx, payload.Source)
+	} else {
+		var result *utils.FileUploadResult
+		if result, err = utils.GenerateThumbnailFromObject(ctx, payload.Source, job.Product, resource.Type); err == nil {
+			thumbnailURL, metadata, variants = result.PublicURL, result.Metadata, result.Variants
+			generated = append(generated, thumbnailURL)
+		}
+	}
+
+	if err != nil {
+		if jobs.FinalAttempt(job) {
+			// A resource without a thumbnail is still valid, stop tracking the job
+			if _, recordErr := resourceService.CompleteThumbnail(context.WithoutCancel(ctx), job.Product, payload.ResourceID, payload.Source, "", nil, nil); recordErr != nil {
+				logger.Infof("Failed to record thumbnail failure of resource %s: %v", payload.ResourceID, recordErr)
+			}
+		}
+		return err
+	}
+
+	recorded, err := resourceService.CompleteThumbnail(ctx, job.Product, payload.ResourceID, payload.Source, thumbnailURL, metadata, variants)
+	if err != nil || !recorded {
+		for _, variantURL := range variants {
+			generated = append(generated, variantURL)
+		}
+		if deleteErr := utils.DeleteObjects(context.WithoutCancel(ctx), "", generated, nil); deleteErr != nil {
+			logger.Infof("Failed to delete unused thumbnails of resource %s: %v", payload.ResourceID, deleteErr)
+		}
+	}
+	return err
+}
+
+// runPurgeJob deletes storage objects that are no longer referenced
+func runPurgeJob(ctx context.Context, job models.Job) error {
+	var payload purgePayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	return utils.DeleteObjects(ctx, job.ID, payload.URLs, payload.Prefixes)
+}
+
+// runGCJob deletes the orphaned storage objects of a product
+func runGCJob(ctx context.Context, job models.Job) error {
+	var payload gcPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+
+	report, err := gc.Collect(ctx, job.Product, gc.Options{
+		GracePeriod: constants.StorageGCGracePeriod * time.Hour,
+		DryRun:      payload.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Storage GC of %s: %d objects scanned, %d orphaned, %d deleted (%d bytes), %d skipped, %d failed (dry run: %t)",
+		report.Product, report.Scanned, len(report.Orphans), report.Deleted, report.Freed, report.Skipped, report.Failures, report.DryRun)
+	if report.Failures > 0 {
+		return fmt.Errorf("failed to delete %d orphaned objects", report.Failures)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"learninghub/constants"
+	"learninghub/jobs"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+// recordingStore is a jobs.Store that only records created jobs
+type recordingStore struct {
+	created []models.Job
+}
+
+func (s *recordingStore) Create(_ context.Context, job models.Job) (string, error) {
+	s.created = append(s.created, job)
+	return fmt.Sprintf("job-%d", len(s.created)), nil
+}
+
+func (s *recordingStore) CreateWithID(_ context.Context, _ string, job models.Job) (bool, error) {
+	s.created = append(s.created, job)
+	return true, nil
+}
+
+func (s *recordingStore) Get(context.Context, string) (*models.Job, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s *recordingStore) Due(context.Context, time.Time, int) ([]models.Job, error) {
+	return nil, nil
+}
+
+func (s *recordingStore) Update(context.Context, string, func(*models.Job) error) error {
+	return fmt.Errorf("not implemented")
+}
+
+// useRecordingQueue replaces jobs.Default for the duration of a test
+func useRecordingQueue(t *testing.T) *recordingStore {
+	original := jobs.Default
+	t.Cleanup(func() { jobs.Default = original })
+
+	store := &recordingStore{}
+	RegisterJobHandlers(jobs.Initialize(store))
+	return store
+}
+
+func TestStartResourceJobs(t *testing.T) {
+	t.Run("new resource", func(t *testing.T) {
+		store := useRecordingQueue(t)
+
+		resource := models.Resource{
+			ID:           "abc",
+			URL:          "https://storage/video.mp4",
+			Transcode:    utils.PendingTranscode("https://storage/video.mp4"),
+			ThumbnailJob: &models.ThumbnailJob{Source: "https://storage/video.mp4"},
+		}
+		startResourceJobs(context.Background(), "ecomm", &resource, nil)
+
+		assert.Equal(t, map[string]string{
+			constants.JobTypeTranscode: "job-1",
+			constants.JobTypeThumbnail: "job-2",
+		}, resource.Jobs)
+
+		if assert.Len(t, store.created, 2) {
+			assert.Equal(t, "ecomm", store.created[0].Product)
+			assert.Equal(t, map[string]any{"resourceId": "abc", "source": "https://storage/video.mp4"}, store.created[0].Payload)
+		}
+	})
+
+	t.Run("unchanged jobs are not restarted", func(t *testing.T) {
+		store := useRecordingQueue(t)
+
+		existing := models.Resource{
+			ID:           "abc",
+			Transcode:    utils.PendingTranscode("https://storage/video.mp4"),
+			ThumbnailJob: &models.ThumbnailJob{Source: "https://storage/video.mp4", Done: true},
+		}
+		updated := existing
+		updated.ThumbnailJob = &models.ThumbnailJob{Source: "https://storage/thumbnail.png"}
+
+		startResourceJobs(context.Background(), "ecomm", &updated, &existing)
+
+		assert.Equal(t, map[string]string{constants.JobTypeThumbnail: "job-1"}, updated.Jobs)
+		assert.Len(t, store.created, 1)
+	})
+
+	t.Run("queue unavailable", func(t *testing.T) {
+		original := jobs.Default
+		defer func() { jobs.Default = original }()
+		jobs.Default = nil
+
+		resource := models.Resource{ID: "abc", Transcode: utils.PendingTranscode("https://storage/video.mp4")}
+		startResourceJobs(context.Background(), "ecomm", &resource, nil)
+
+		assert.Empty(t, resource.Jobs, "the resource is saved without its jobs")
+	})
+}
+
+func TestResourceObjects(t *testing.T) {
+	resource := models.Resource{
+		ThumbnailURL: "https://storage/thumbnail.png",
+		Thumbnails:   map[string]string{"320.webp": "https://storage/thumbnail_320w.webp"},
+	}
+	assert.ElementsMatch(t, []string{"https://storage/thumbnail.png", "https://storage/thumbnail_320w.webp"}, thumbnailObjects(resource))
+
+	assert.Nil(t, models.Resource{}.OutputPrefixes())
+	assert.Nil(t, models.Resource{Transcode: &models.Transcode{Status: constants.TranscodeStatusProcessing}}.OutputPrefixes(), "nothing is stored before the stream is ready")
+	assert.Equal(t, []string{"ecomm/hls/abc/job-1", "ecomm/converted/abc/job-2"}, models.Resource{
+		Transcode:  &models.Transcode{Prefix: "ecomm/hls/abc/job-1"},
+		Conversion: &models.Conversion{Prefix: "ecomm/converted/abc/job-2"},
+	}.OutputPrefixes())
+}
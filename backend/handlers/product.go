@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/products"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// productRequest is the body of POST /products
+type productRequest struct {
+	Name         string               `json:"name"`
+	DisplayName  string               `json:"displayName"` // The name by default
+	Description  string               `json:"description"`
+	Owners       []string             `json:"owners"`
+	DefaultTags  []string             `json:"defaultTags"`
+	UploadPolicy *config.UploadPolicy `json:"uploadPolicy"`
+}
+
+// GetProducts handles GET /products
+//   - Lists the products that are not archived, ordered by name, from the
+//     registry of this instance.
+func GetProducts(c *gin.Context) {
+	c.JSON(http.StatusOK, products.List(false))
+}
+
+// CreateProduct handles POST /products, for admins
+//   - Creates a product from a JSON body. Its routes are served right away by
+//     this instance, and by the others once their registry is refreshed.
+//   - Names cannot be reused, by archived products neither: their
+//     collections and files are kept.
+func CreateProduct(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if c.ContentType() != constants.ContentTypeJSON {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be application/json")
+		return
+	}
+
+	body, ok := readJSONBody(c)
+	if !ok {
+		return
+	}
+
+	var request productRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid product", err.Error())
+		return
+	}
+
+	product := newProduct(request, time.Now())
+	if fieldErrors := validation.Product(product); len(fieldErrors) > 0 {
+		respondWith(c, validationError(fieldErrors))
+		return
+	}
+
+	database := db.New()
+	productService := db.NewProductService(database)
+
+	err := productService.Create(ctx, product)
+	if status.Code(err) == codes.AlreadyExists {
+		errors.RespondWithError(c, errors.ErrProductExists, "Product already exists")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to create product", err.Error())
+		return
+	}
+
+	products.Put(product)
+	logger.Infof("Created product %s", product.Name)
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// ArchiveProduct handles POST /products/:product/archive, for admins
+//   - Stops serving the routes of a product, on other instances once their
+//     registry is refreshed. Its resources, tags and files are kept.
+//   - Archiving an archived product changes nothing.
+func ArchiveProduct(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param(constants.ProductParamKey)
+
+	database := db.New()
+	productService := db.NewProductService(database)
+
+	product, err := productService.Archive(ctx, name, time.Now())
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrProductNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to archive product", err.Error())
+		return
+	}
+
+	products.Put(*product)
+	logger.Infof("Archived product %s", product.Name)
+
+	c.JSON(http.StatusOK, product)
+}
+
+// newProduct returns the product a create request describes, trimmed and
+// with its default tags normalized like a resource's tags
+func newProduct(request productRequest, now time.Time) models.Product {
+	product := models.Product{
+		Name:         strings.TrimSpace(request.Name),
+		DisplayName:  strings.TrimSpace(request.DisplayName),
+		Description:  strings.TrimSpace(request.Description),
+		UploadPolicy: request.UploadPolicy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if product.DisplayName == "" {
+		product.DisplayName = product.Name
+	}
+	for _, owner := range request.Owners {
+		product.Owners = append(product.Owners, strings.TrimSpace(owner))
+	}
+	if len(request.DefaultTags) > 0 {
+		product.DefaultTags = utils.NormalizeTags(request.DefaultTags)
+	}
+	return product
+}
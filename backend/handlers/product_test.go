@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestGetProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	originalConfig := config.AppConfig
+	config.AppConfig = &config.EnvConfig{VALID_PRODUCTS: []string{"ecomm", "crm"}}
+	defer func() {
+		config.AppConfig = originalConfig
+	}()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+
+	GetProducts(c)
+
+	var response []models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, response, 2)
+	assert.Equal(t, "crm", response[0].Name)
+	assert.Equal(t, "ecomm", response[1].Name)
+}
+
+func TestCreateProductRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "not JSON", contentType: constants.ContentTypeMultipart, body: "", expectedError: errors.ErrInvalidContentType},
+		{name: "invalid JSON", contentType: constants.ContentTypeJSON, body: "{", expectedError: errors.ErrInvalidPayload},
+		{name: "unknown member", contentType: constants.ContentTypeJSON, body: `{"name":"docs","archived":true}`, expectedError: errors.ErrInvalidPayload},
+		{name: "unknown upload policy setting", contentType: constants.ContentTypeJSON, body: `{"name":"docs","uploadPolicy":{"allowExternalUrl":false}}`, expectedError: errors.ErrInvalidPayload},
+		{name: "no name", contentType: constants.ContentTypeJSON, body: `{"displayName":"Docs"}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"name"}},
+		{name: "invalid fields", contentType: constants.ContentTypeJSON, body: `{"name":"Docs","owners":["docs"],"uploadPolicy":{"pdfActiveContent":"strip"}}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"name", "owners.0", "uploadPolicy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			CreateProduct(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
+
+func TestNewProduct(t *testing.T) {
+	now := time.Now()
+	product := newProduct(productRequest{
+		Name:        " docs ",
+		Owners:      []string{" docs@example.com "},
+		DefaultTags: []string{"Guides", " guides", ""},
+	}, now)
+
+	assert.Equal(t, "docs", product.Name)
+	assert.Equal(t, "docs", product.DisplayName, "the display name defaults to the name")
+	assert.Equal(t, []string{"docs@example.com"}, product.Owners)
+	assert.Equal(t, []string{"guides"}, product.DefaultTags)
+	assert.Equal(t, now, product.CreatedAt)
+	assert.False(t, product.Archived)
+}
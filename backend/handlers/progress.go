@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+	"learninghub/validation"
+)
+
+// progressRequest is the body of PUT /progress/:resourceId. Members left out
+// are kept.
+type progressRequest struct {
+	Status   string   `json:"status"`
+	Position *float64 `json:"position"`
+	Page     *int     `json:"page"`
+}
+
+// progressStatuses are the statuses of progress through a resource
+var progressStatuses = []string{constants.ProgressStatusStarted, constants.ProgressStatusCompleted}
+
+// UpdateProgress handles PUT /progress/:resourceId
+//   - Records the progress of the signed in user through a resource: its
+//     status, the playback position of videos and audio and the last page
+//     read of PDFs and slide decks.
+//   - The first update starts the resource. Completed resources stay
+//     completed until their status is set back to started.
+func UpdateProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+	resourceID := c.Param("resourceId")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	userID, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrUnauthorized, "Missing ID token")
+		return
+	}
+
+	if c.ContentType() != constants.ContentTypeJSON {
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be application/json")
+		return
+	}
+
+	body, ok := readJSONBody(c)
+	if !ok {
+		return
+	}
+
+	var request progressRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Invalid progress", err.Error())
+		return
+	}
+	request.Status = strings.TrimSpace(request.Status)
+
+	database := db.New()
+	doc, err := db.NewResourceService(database).GetByID(ctx, product, resourceID)
+	if status.Code(err) == codes.NotFound {
+		errors.RespondWithError(c, errors.ErrResourceNotFound, "Resource not found")
+		return
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch resource", err.Error())
+		return
+	}
+
+	var resource models.Resource
+	if err := doc.DataTo(&resource); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to read resource", err.Error())
+		return
+	}
+	if fieldErrors := checkProgress(request, resource); len(fieldErrors) > 0 {
+		respondWith(c, validationError(fieldErrors))
+		return
+	}
+
+	progressService := db.NewProgressService(database)
+	progress, err := progressService.Update(ctx, product, userID, resourceID, func(progress *models.Progress, exists bool) error {
+		applyProgress(progress, exists, request, time.Now())
+		return nil
+	})
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to save progress", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetMyProgress handles GET /me/progress
+//   - Returns the progress of the signed in user through the resources of
+//     the product, most recently updated first, and through the collections
+//     listing a resource they started.
+func GetMyProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	userID, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrUnauthorized, "Missing ID token")
+		return
+	}
+
+	database := db.New()
+	progress, err := db.NewProgressService(database).ListByUser(ctx, product, userID)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch progress", err.Error())
+		return
+	}
+	// Sorted here rather than in the query, which would need a composite index
+	slices.SortFunc(progress, func(a, b models.Progress) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+
+	collections := []models.CollectionProgress{}
+	if len(progress) > 0 {
+		list, err := db.NewCollectionService(database).List(ctx, product)
+		if err != nil {
+			errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch collections", err.Error())
+			return
+		}
+		collections = collectionProgress(list, progress)
+	}
+
+	c.JSON(http.StatusOK, models.UserProgress{Resources: progress, Collections: collections})
+}
+
+// checkProgress checks a progress request against the resource it records
+// progress through, and returns the errors of every rejected field
+func checkProgress(request progressRequest, resource models.Resource) []errors.FieldError {
+	var fieldErrors []errors.FieldError
+	reject := func(field, code, message string, params map[string]any) {
+		fieldErrors = append(fieldErrors, errors.FieldError{Field: field, Code: code, Message: field + " " + message, Params: params})
+	}
+
+	if request.Status != "" && !slices.Contains(progressStatuses, request.Status) {
+		reject(constants.FormFieldStatus, validation.CodeInvalidChoice, "must be one of: "+strings.Join(progressStatuses, ", "),
+			map[string]any{"values": progressStatuses})
+	}
+
+	if request.Position != nil {
+		duration := resourceDuration(resource)
+		switch position := *request.Position; {
+		case !slices.Contains([]string{constants.ResourceTypeVideo, constants.ResourceTypeAudio, constants.ResourceTypeEmbed}, resource.Type):
+			reject(constants.FormFieldPosition, validation.CodeNotAllowed, "only applies to videos, audio and embeds", nil)
+		case position < 0:
+			reject(constants.FormFieldPosition, validation.CodeInvalid, "must be at least 0", map[string]any{"min": 0})
+		case duration > 0 && position > duration:
+			reject(constants.FormFieldPosition, validation.CodeInvalid, fmt.Sprintf("must be at most the duration, %g seconds", duration),
+				map[string]any{"max": duration})
+		}
+	}
+
+	if request.Page != nil {
+		pages := 0
+		if resource.Metadata != nil {
+			pages = resource.Metadata.Pages
+		}
+		switch page := *request.Page; {
+		case !slices.Contains([]string{constants.ResourceTypePDF, constants.ResourceTypeSlides}, resource.Type):
+			reject(constants.FormFieldPage, validation.CodeNotAllowed, "only applies to PDFs and slide decks", nil)
+		case page < 1:
+			reject(constants.FormFieldPage, validation.CodeInvalid, "must be at least 1", map[string]any{"min": 1})
+		case pages > 0 && page > pages:
+			reject(constants.FormFieldPage, validation.CodeInvalid, fmt.Sprintf("must be at most the page count, %d", pages),
+				map[string]any{"max": pages})
+		}
+	}
+	return fieldErrors
+}
+
+// resourceDuration returns the duration of a video or audio resource in
+// seconds, or 0 if it is not known
+func resourceDuration(resource models.Resource) float64 {
+	switch {
+	case resource.Metadata != nil && resource.Metadata.Duration > 0:
+		return resource.Metadata.Duration
+	case resource.Embed != nil:
+		return resource.Embed.Duration
+	}
+	return 0
+}
+
+// applyProgress applies a checked progress request to the stored progress,
+// see UpdateProgress
+func applyProgress(progress *models.Progress, exists bool, request progressRequest, now time.Time) {
+	if !exists {
+		progress.Status = constants.ProgressStatusStarted
+		progress.StartedAt = now
+	}
+
+	switch request.Status {
+	case constants.ProgressStatusCompleted:
+		if progress.Status != constants.ProgressStatusCompleted {
+			progress.CompletedAt = &now
+		}
+		progress.Status = request.Status
+	case constants.ProgressStatusStarted:
+		progress.CompletedAt = nil
+		progress.Status = request.Status
+	}
+
+	if request.Position != nil {
+		progress.Position = *request.Position
+	}
+	if request.Page != nil {
+		progress.Page = *request.Page
+	}
+	progress.UpdatedAt = now
+}
+
+// collectionProgress returns the progress through the collections listing a
+// resource started or completed, in the order of collections
+func collectionProgress(collections []models.Collection, progress []models.Progress) []models.CollectionProgress {
+	statuses := make(map[string]string, len(progress))
+	for _, p := range progress {
+		statuses[p.ResourceID] = p.Status
+	}
+
+	list := []models.CollectionProgress{}
+	for _, collection := range collections {
+		started, completed := false, 0
+		for _, id := range collection.ResourceIDs {
+			switch statuses[id] {
+			case constants.ProgressStatusCompleted:
+				completed++
+				started = true
+			case constants.ProgressStatusStarted:
+				started = true
+			}
+		}
+		if !started {
+			continue
+		}
+
+		list = append(list, models.CollectionProgress{
+			CollectionID: collection.ID,
+			Title:        collection.Title,
+			Completed:    completed,
+			Total:        len(collection.ResourceIDs),
+			Percentage:   completed * 100 / len(collection.ResourceIDs),
+		})
+	}
+	return list
+}
+
+// deleteProgress deletes the progress of every user through deleted
+// resources. The resources are gone already, so failures are only logged.
+func deleteProgress(ctx context.Context, product string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	deleted, err := db.NewProgressService(db.New()).DeleteResources(ctx, product, ids)
+	if err != nil {
+		logger.Errorf("Failed to delete progress through deleted resources of %s: %v", product, err)
+		return
+	}
+	if deleted > 0 {
+		logger.Infof("Deleted the progress of %d users through deleted resources of %s", deleted, product)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestUpdateProgressRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		user          string
+		contentType   string
+		body          string
+		expectedError errors.ErrorCode
+	}{
+		{name: "not signed in", contentType: constants.ContentTypeJSON, body: `{}`, expectedError: errors.ErrUnauthorized},
+		{name: "not JSON", user: "ada", contentType: constants.ContentTypeMultipart, body: "", expectedError: errors.ErrInvalidContentType},
+		{name: "invalid JSON", user: "ada", contentType: constants.ContentTypeJSON, body: "{", expectedError: errors.ErrInvalidPayload},
+		{name: "unknown member", user: "ada", contentType: constants.ContentTypeJSON, body: `{"percentage":50}`, expectedError: errors.ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/ecomm/progress/intro", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			c.Params = gin.Params{{Key: "resourceId", Value: "intro"}}
+			c.Set(constants.ProductContextKey, "ecomm")
+			if tt.user != "" {
+				c.Set(constants.UserContextKey, tt.user)
+			}
+
+			UpdateProgress(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+		})
+	}
+}
+
+func TestCheckProgress(t *testing.T) {
+	position := func(seconds float64) *float64 { return &seconds }
+	page := func(number int) *int { return &number }
+	video := models.Resource{Type: constants.ResourceTypeVideo, Metadata: &models.Metadata{Duration: 90}}
+	embed := models.Resource{Type: constants.ResourceTypeEmbed, Embed: &models.Embed{Duration: 60}}
+	pdf := models.Resource{Type: constants.ResourceTypePDF, Metadata: &models.Metadata{Pages: 12}}
+	slides := models.Resource{Type: constants.ResourceTypeSlides, Metadata: &models.Metadata{}}
+
+	tests := []struct {
+		name           string
+		request        progressRequest
+		resource       models.Resource
+		expectedFields []string
+	}{
+		{name: "status only", request: progressRequest{Status: constants.ProgressStatusCompleted}, resource: pdf},
+		{name: "nothing", request: progressRequest{}, resource: video},
+		{name: "unknown status", request: progressRequest{Status: "paused"}, resource: video, expectedFields: []string{"status"}},
+		{name: "video position", request: progressRequest{Position: position(90)}, resource: video},
+		{name: "position past the end", request: progressRequest{Position: position(90.5)}, resource: video, expectedFields: []string{"position"}},
+		{name: "embed position", request: progressRequest{Position: position(30)}, resource: embed},
+		{name: "negative position", request: progressRequest{Position: position(-1)}, resource: embed, expectedFields: []string{"position"}},
+		{name: "position of a PDF", request: progressRequest{Position: position(5)}, resource: pdf, expectedFields: []string{"position"}},
+		{name: "PDF page", request: progressRequest{Page: page(12)}, resource: pdf},
+		{name: "page past the end", request: progressRequest{Page: page(13)}, resource: pdf, expectedFields: []string{"page"}},
+		{name: "page of slides not converted yet", request: progressRequest{Page: page(40)}, resource: slides},
+		{name: "page 0", request: progressRequest{Page: page(0)}, resource: slides, expectedFields: []string{"page"}},
+		{
+			name:           "every field rejected",
+			request:        progressRequest{Status: "done", Position: position(1), Page: page(1)},
+			resource:       models.Resource{Type: constants.ResourceTypeArticle},
+			expectedFields: []string{"status", "position", "page"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldErrors := checkProgress(tt.request, tt.resource)
+			assert.Equal(t, tt.expectedFields, fieldsOf(errors.ErrorResponse{FieldErrors: fieldErrors}))
+		})
+	}
+}
+
+func TestApplyProgress(t *testing.T) {
+	started := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	position := 42.5
+
+	var progress models.Progress
+	applyProgress(&progress, false, progressRequest{Position: &position}, started)
+	assert.Equal(t, models.Progress{Status: constants.ProgressStatusStarted, Position: 42.5, StartedAt: started, UpdatedAt: started}, progress)
+
+	completed := started.Add(time.Hour)
+	applyProgress(&progress, true, progressRequest{Status: constants.ProgressStatusCompleted}, completed)
+	assert.Equal(t, constants.ProgressStatusCompleted, progress.Status)
+	require.NotNil(t, progress.CompletedAt)
+	assert.Equal(t, completed, *progress.CompletedAt)
+
+	// Watching a completed video again keeps it completed
+	later := completed.Add(time.Hour)
+	position = 10
+	applyProgress(&progress, true, progressRequest{Position: &position, Status: constants.ProgressStatusCompleted}, later)
+	assert.Equal(t, constants.ProgressStatusCompleted, progress.Status)
+	assert.Equal(t, completed, *progress.CompletedAt, "completion date is kept")
+	assert.Equal(t, 10.0, progress.Position)
+	assert.Equal(t, started, progress.StartedAt)
+	assert.Equal(t, later, progress.UpdatedAt)
+
+	applyProgress(&progress, true, progressRequest{Status: constants.ProgressStatusStarted}, later)
+	assert.Equal(t, constants.ProgressStatusStarted, progress.Status)
+	assert.Nil(t, progress.CompletedAt)
+}
+
+func TestCollectionProgress(t *testing.T) {
+	collections := []models.Collection{
+		{ID: "onboarding", Title: "Onboarding", ResourceIDs: []string{"a", "b", "c"}},
+		{ID: "advanced", Title: "Advanced", ResourceIDs: []string{"d", "e"}},
+		{ID: "empty", Title: "Empty", ResourceIDs: []string{}},
+		{ID: "started", Title: "Started", ResourceIDs: []string{"c"}},
+	}
+	progress := []models.Progress{
+		{ResourceID: "a", Status: constants.ProgressStatusCompleted},
+		{ResourceID: "b", Status: constants.ProgressStatusCompleted},
+		{ResourceID: "c", Status: constants.ProgressStatusStarted},
+		{ResourceID: "x", Status: constants.ProgressStatusCompleted},
+	}
+
+	assert.Equal(t, []models.CollectionProgress{
+		{CollectionID: "onboarding", Title: "Onboarding", Completed: 2, Total: 3, Percentage: 66},
+		{CollectionID: "started", Title: "Started", Completed: 0, Total: 1, Percentage: 0},
+	}, collectionProgress(collections, progress))
+
+	assert.Empty(t, collectionProgress(collections, nil))
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+	"learninghub/pkg/jsonpatch"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// resourceDocument is the JSON representation of the editable fields of a
+// resource: the body of JSON creates, and the document JSON updates patch
+type resourceDocument struct {
+	Title        string         `json:"title"`
+	Description  string         `json:"description"`
+	Type         string         `json:"type"`
+	URL          string         `json:"url,omitempty"`
+	ThumbnailURL string         `json:"thumbnailUrl,omitempty"`
+	Tags         []string       `json:"tags"`
+	CustomFields map[string]any `json:"customFields,omitempty"`
+}
+
+// resourceDocumentFields describes the JSON type of each field of a
+// resourceDocument, for errors
+var resourceDocumentFields = map[string]string{
+	constants.FormFieldTitle:        "a string",
+	constants.FormFieldDescription:  "a string",
+	constants.FormFieldType:         "a string",
+	constants.FormFieldURL:          "a string",
+	constants.FormFieldThumbnailURL: "a string",
+	constants.FormFieldTags:         "an array of strings",
+	constants.FormFieldCustomFields: "an object",
+}
+
+// documentOf returns the editable fields of a resource
+func documentOf(resource models.Resource) resourceDocument {
+	return resourceDocument{
+		Title:        resource.Title,
+		Description:  resource.Description,
+		Type:         resource.Type,
+		URL:          resource.URL,
+		ThumbnailURL: resource.ThumbnailURL,
+		Tags:         resource.Tags,
+		CustomFields: resource.CustomFields,
+	}
+}
+
+// resourceChanges are the fields an update request sets, whatever its
+// format. Nil fields are left as they are.
+type resourceChanges struct {
+	Title        *string
+	Description  *string
+	Type         *string
+	URL          *string
+	ThumbnailURL *string
+	Tags         *[]string      // Normalized
+	CustomFields map[string]any // Values to merge, nil values unset fields
+}
+
+// readJSONBody reads a JSON request body. It responds with an error and
+// returns false if the body is too large or cannot be read.
+func readJSONBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, constants.MaxJSONBodySize))
+	if maxBytesErr := (*http.MaxBytesError)(nil); stdErrors.As(err, &maxBytesErr) {
+		errors.RespondWithError(c, errors.ErrInvalidPayload, fmt.Sprintf("Request body too large. Maximum size is %d KB", constants.MaxJSONBodySize/(1<<10)))
+		return nil, false
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidPayload, "Failed to read request body", err.Error())
+		return nil, false
+	}
+	return body, true
+}
+
+// decodeResourceDocument decodes the editable fields of a resource from a
+// JSON object. Null leaves a field empty. It responds with an error naming
+// the first unknown field, or field of the wrong type, and returns false.
+func decodeResourceDocument(c *gin.Context, body []byte) (resourceDocument, bool) {
+	var document resourceDocument
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		errors.RespondWithError(c, errors.ErrInvalidPayload, "Request body must be a JSON object")
+		return document, false
+	}
+
+	targets := map[string]any{
+		constants.FormFieldTitle:        &document.Title,
+		constants.FormFieldDescription:  &document.Description,
+		constants.FormFieldType:         &document.Type,
+		constants.FormFieldURL:          &document.URL,
+		constants.FormFieldThumbnailURL: &document.ThumbnailURL,
+		constants.FormFieldTags:         &document.Tags,
+		constants.FormFieldCustomFields: &document.CustomFields,
+	}
+
+	// Sorted, so the same body always reports the same field
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		target, known := targets[name]
+		if !known {
+			errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: name, Code: validation.CodeUnknownField, Message: fmt.Sprintf("%s is not an editable field", name)})
+			return document, false
+		}
+		if err := json.Unmarshal(members[name], target); err != nil {
+			errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: name, Code: validation.CodeInvalidType, Message: fmt.Sprintf("%s must be %s", name, resourceDocumentFields[name])})
+			return document, false
+		}
+	}
+
+	return document, true
+}
+
+// multipartChanges returns the fields a multipart update sets. Links sent
+// back unchanged are left out, like the unchanged fields of patches, so the
+// file keeps its stream, conversion and thumbnail. It responds with an error
+// and returns false if custom field values cannot be parsed.
+func multipartChanges(c *gin.Context, resource models.Resource) (resourceChanges, bool) {
+	formValue := func(field string) *string {
+		if value, exists := c.GetPostForm(field); exists {
+			return &value
+		}
+		return nil
+	}
+
+	changes := resourceChanges{
+		Title:        formValue(constants.FormFieldTitle),
+		Description:  formValue(constants.FormFieldDescription),
+		Type:         formValue(constants.FormFieldType),
+		URL:          formValue(constants.FormFieldURL),
+		ThumbnailURL: formValue(constants.FormFieldThumbnailURL),
+	}
+	if changes.URL != nil && *changes.URL == resource.URL {
+		changes.URL = nil
+	}
+	if changes.ThumbnailURL != nil && *changes.ThumbnailURL == resource.ThumbnailURL {
+		changes.ThumbnailURL = nil
+	}
+
+	if tagsStr, exists := c.GetPostForm(constants.FormFieldTags); exists {
+		tags := utils.NormalizeTags(strings.Split(tagsStr, ","))
+		changes.Tags = &tags
+	}
+
+	if raw, exists := c.GetPostForm(constants.FormFieldCustomFields); exists {
+		// An empty value only checks the required fields
+		changes.CustomFields = map[string]any{}
+		if raw != "" {
+			values, err := utils.ParseCustomFields(raw)
+			if err != nil {
+				errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldCustomFields, Code: validation.CodeInvalidType, Message: err.Error()})
+				return changes, false
+			}
+			changes.CustomFields = values
+		}
+	}
+
+	return changes, true
+}
+
+// patchChanges applies the JSON Merge Patch or JSON Patch of a request to the
+// editable fields of a resource, and returns the fields that changed. It
+// responds with an error and returns false if the patch cannot be applied.
+func patchChanges(c *gin.Context, resource models.Resource) (resourceChanges, bool) {
+	body, ok := readJSONBody(c)
+	if !ok {
+		return resourceChanges{}, false
+	}
+
+	currentJSON, err := json.Marshal(documentOf(resource))
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrDataConversionFailed, "Failed to process existing resource data", err.Error())
+		return resourceChanges{}, false
+	}
+
+	var patched []byte
+	if c.ContentType() == constants.ContentTypeJSONPatch {
+		patched, err = jsonpatch.Apply(currentJSON, body)
+	} else {
+		patched, err = jsonpatch.MergePatch(currentJSON, body)
+	}
+	if patchErr := (*jsonpatch.Error)(nil); stdErrors.As(err, &patchErr) {
+		field := strings.ReplaceAll(strings.TrimPrefix(patchErr.Path, "/"), "/", ".")
+		if patchErr.Test {
+			errors.RespondWithFieldError(c, errors.ErrPatchTestFailed, errors.FieldError{Field: field, Code: validation.CodeConflict, Message: "Patch test failed: " + patchErr.Error()})
+		} else {
+			errors.RespondWithFieldError(c, errors.ErrInvalidPayload, errors.FieldError{Field: field, Code: validation.CodeInvalid, Message: "Invalid patch: " + patchErr.Error()})
+		}
+		return resourceChanges{}, false
+	}
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrDataConversionFailed, "Failed to apply patch", err.Error())
+		return resourceChanges{}, false
+	}
+
+	document, ok := decodeResourceDocument(c, patched)
+	if !ok {
+		return resourceChanges{}, false
+	}
+
+	// Compared through JSON, like the patched document
+	var current resourceDocument
+	if err := json.Unmarshal(currentJSON, &current); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrDataConversionFailed, "Failed to process existing resource data", err.Error())
+		return resourceChanges{}, false
+	}
+
+	return changesBetween(current, document), true
+}
+
+// changesBetween returns the fields of document that differ from current
+func changesBetween(current, document resourceDocument) resourceChanges {
+	var changes resourceChanges
+
+	changed := func(currentValue, value string) *string {
+		if value == currentValue {
+			return nil
+		}
+		return &value
+	}
+	changes.Title = changed(current.Title, document.Title)
+	changes.Description = changed(current.Description, document.Description)
+	changes.Type = changed(current.Type, document.Type)
+	changes.URL = changed(current.URL, document.URL)
+	changes.ThumbnailURL = changed(current.ThumbnailURL, document.ThumbnailURL)
+
+	if tags := utils.NormalizeTags(document.Tags); !slices.Equal(tags, utils.NormalizeTags(current.Tags)) {
+		changes.Tags = &tags
+	}
+
+	for name, value := range document.CustomFields {
+		if currentValue, exists := current.CustomFields[name]; !exists || !reflect.DeepEqual(currentValue, value) {
+			if changes.CustomFields == nil {
+				changes.CustomFields = make(map[string]any)
+			}
+			changes.CustomFields[name] = value
+		}
+	}
+	for name := range current.CustomFields {
+		if _, kept := document.CustomFields[name]; !kept {
+			if changes.CustomFields == nil {
+				changes.CustomFields = make(map[string]any)
+			}
+			changes.CustomFields[name] = nil
+		}
+	}
+
+	return changes
+}
+
+// fields returns the names of the built-in fields the changes set
+func (changes resourceChanges) fields() []string {
+	var fields []string
+	for _, field := range []struct {
+		name string
+		set  bool
+	}{
+		{constants.FormFieldTitle, changes.Title != nil},
+		{constants.FormFieldDescription, changes.Description != nil},
+		{constants.FormFieldType, changes.Type != nil},
+		{constants.FormFieldURL, changes.URL != nil},
+		{constants.FormFieldThumbnailURL, changes.ThumbnailURL != nil},
+		{constants.FormFieldTags, changes.Tags != nil},
+	} {
+		if field.set {
+			fields = append(fields, field.name)
+		}
+	}
+	return fields
+}
+
+// validateResource checks the given fields of a resource, or all of them,
+// against the validation rules. It responds with the errors of every
+// rejected field and returns false if any is rejected.
+func validateResource(c *gin.Context, resource models.Resource, fields ...string) bool {
+	fieldErrors := validation.Resource(resource, fields...)
+	if len(fieldErrors) == 0 {
+		return true
+	}
+
+	response := validationError(fieldErrors)
+	errors.RespondWithFieldErrors(c, response.Error, response.Message, fieldErrors...)
+	return false
+}
+
+// respondWith sends an error response built beforehand, e.g. by a check
+// shared by several handlers
+func respondWith(c *gin.Context, response errors.ErrorResponse) {
+	c.JSON(errors.GetHTTPStatus(response.Error), response)
+}
+
+// fieldErrorResponse returns the error of a single rejected request field,
+// see errors.RespondWithFieldError
+func fieldErrorResponse(code errors.ErrorCode, fieldError errors.FieldError) *errors.ErrorResponse {
+	return &errors.ErrorResponse{Error: code, Message: fieldError.Message, FieldErrors: []errors.FieldError{fieldError}}
+}
+
+// validationError returns the error of rejected request fields:
+// MISSING_REQUIRED if they are all missing, INVALID_PARAM otherwise, with
+// their messages
+func validationError(fieldErrors []errors.FieldError) errors.ErrorResponse {
+	code := errors.ErrInvalidParam
+	if validation.OnlyRequired(fieldErrors) {
+		code = errors.ErrMissingRequired
+	}
+
+	messages := make([]string, len(fieldErrors))
+	for i, fieldErr := range fieldErrors {
+		messages[i] = fieldErr.Message
+	}
+	return errors.ErrorResponse{Error: code, Message: strings.Join(messages, "; "), FieldErrors: fieldErrors}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestCreateResourceRejectsInvalidJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedError  errors.ErrorCode
+		expectedFields []string
+	}{
+		{name: "unsupported content type", contentType: "text/plain", body: "title", expectedError: errors.ErrInvalidContentType},
+		{name: "not an object", contentType: constants.ContentTypeJSON, body: `["title"]`, expectedError: errors.ErrInvalidPayload},
+		{name: "missing title", contentType: constants.ContentTypeJSON, body: `{"description":"d","type":"article","url":"https://example.com"}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"title"}},
+		{name: "every missing field", contentType: constants.ContentTypeJSON, body: `{"url":"https://example.com"}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"title", "description", "type"}},
+		{name: "limits", contentType: constants.ContentTypeJSON, body: `{"title":"` + strings.Repeat("t", constants.MaxTitleLength+1) + `","description":"d","type":"article","url":"ftp://example.com","tags":["ok","` + strings.Repeat("x", constants.MaxTagLength+1) + `"]}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"title", "url", "tags.1"}},
+		{name: "unknown field", contentType: constants.ContentTypeJSON, body: `{"title":"t","createdAt":"2024-01-01T00:00:00Z"}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"createdAt"}},
+		{name: "tags of the wrong type", contentType: "application/json; charset=utf-8", body: `{"title":"t","tags":"a,b"}`, expectedError: errors.ErrInvalidParam, expectedFields: []string{"tags"}},
+		{name: "unsupported type", contentType: constants.ContentTypeJSON, body: `{"title":"t","description":"d","type":"podcast"}`, expectedError: errors.ErrUnsupportedType, expectedFields: []string{"type"}},
+		{name: "article without url", contentType: constants.ContentTypeJSON, body: `{"title":"t","description":"d","type":"article"}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"url"}},
+		{name: "file type without url", contentType: constants.ContentTypeJSON, body: `{"title":"t","description":"d","type":"pdf"}`, expectedError: errors.ErrMissingRequired, expectedFields: []string{"url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ecomm/resources", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			c.Set(constants.ProductContextKey, "ecomm")
+
+			CreateResource(c)
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+			assert.Equal(t, tt.expectedFields, fieldsOf(response))
+		})
+	}
+}
+
+func TestPatchChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resource := models.Resource{
+		Title:        "Intro",
+		Description:  "Getting started",
+		Type:         constants.ResourceTypeArticle,
+		URL:          "https://example.com/intro",
+		ThumbnailURL: "https://example.com/intro.png",
+		Tags:         []string{"onboarding"},
+		CustomFields: map[string]any{"difficulty": "beginner", "durationMinutes": int64(15)},
+	}
+
+	stringPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name          string
+		contentType   string
+		patch         string
+		expected      resourceChanges
+		expectedError errors.ErrorCode
+		expectedField string
+	}{
+		{
+			name:        "merge patch clears the thumbnail",
+			contentType: constants.ContentTypeMergePatch,
+			patch:       `{"title":"Introduction","thumbnailUrl":null}`,
+			expected:    resourceChanges{Title: stringPtr("Introduction"), ThumbnailURL: stringPtr("")},
+		},
+		{
+			name:        "plain JSON is a merge patch",
+			contentType: constants.ContentTypeJSON,
+			patch:       `{"customFields":{"difficulty":null,"durationMinutes":15}}`,
+			expected:    resourceChanges{CustomFields: map[string]any{"difficulty": nil}},
+		},
+		{
+			name:        "JSON patch",
+			contentType: constants.ContentTypeJSONPatch,
+			patch:       `[{"op":"test","path":"/title","value":"Intro"},{"op":"add","path":"/tags/-","value":" Basics "},{"op":"remove","path":"/thumbnailUrl"}]`,
+			expected:    resourceChanges{Tags: &[]string{"onboarding", "basics"}, ThumbnailURL: stringPtr("")},
+		},
+		{
+			name:        "unchanged values",
+			contentType: constants.ContentTypeMergePatch,
+			patch:       `{"title":"Intro","tags":["Onboarding"]}`,
+			expected:    resourceChanges{},
+		},
+		{
+			name:          "failed test",
+			contentType:   constants.ContentTypeJSONPatch,
+			patch:         `[{"op":"test","path":"/title","value":"Outro"}]`,
+			expectedError: errors.ErrPatchTestFailed,
+			expectedField: "title",
+		},
+		{
+			name:          "invalid operation",
+			contentType:   constants.ContentTypeJSONPatch,
+			patch:         `[{"op":"replace","path":"/tags/3","value":"x"}]`,
+			expectedError: errors.ErrInvalidPayload,
+			expectedField: "tags.3",
+		},
+		{
+			name:          "read-only field",
+			contentType:   constants.ContentTypeMergePatch,
+			patch:         `{"metadata":{"size":1}}`,
+			expectedError: errors.ErrInvalidParam,
+			expectedField: "metadata",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/api/v1/ecomm/resources/abc", strings.NewReader(tt.patch))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			changes, ok := patchChanges(c, resource)
+			if tt.expectedError != "" {
+				assert.False(t, ok)
+
+				var response errors.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, []string{tt.expectedField}, fieldsOf(response))
+				return
+			}
+
+			assert.True(t, ok, w.Body.String())
+			assert.Equal(t, tt.expected, changes)
+		})
+	}
+}
+
+func TestMultipartChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resource := models.Resource{
+		Title:        "Intro",
+		Type:         constants.ResourceTypeVideo,
+		URL:          "https://cdn.example.com/intro.mp4",
+		ThumbnailURL: "https://cdn.example.com/intro.png",
+	}
+
+	stringPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		expected resourceChanges
+	}{
+		{
+			name:     "same url",
+			fields:   map[string]string{"title": "Introduction", "url": resource.URL, "thumbnailUrl": resource.ThumbnailURL},
+			expected: resourceChanges{Title: stringPtr("Introduction")},
+		},
+		{
+			name:     "new url",
+			fields:   map[string]string{"url": "https://cdn.example.com/intro-v2.mp4"},
+			expected: resourceChanges{URL: stringPtr("https://cdn.example.com/intro-v2.mp4")},
+		},
+		{
+			name:     "cleared thumbnail",
+			fields:   map[string]string{"url": resource.URL, "thumbnailUrl": ""},
+			expected: resourceChanges{ThumbnailURL: stringPtr("")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			for name, value := range tt.fields {
+				require.NoError(t, writer.WriteField(name, value))
+			}
+			require.NoError(t, writer.Close())
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/api/v1/ecomm/resources/abc", body)
+			c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+			require.NoError(t, c.Request.ParseMultipartForm(constants.MaxFileSize))
+
+			changes, ok := multipartChanges(c, resource)
+			assert.True(t, ok, w.Body.String())
+			assert.Equal(t, tt.expected, changes)
+		})
+	}
+}
+
+// fieldsOf returns the fields an error response rejects
+func fieldsOf(response errors.ErrorResponse) []string {
+	var fields []string
+	for _, fieldErr := range response.FieldErrors {
+		fields = append(fields, fieldErr.Field)
+	}
+	return fields
+}
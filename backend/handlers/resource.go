@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	stdErrors "errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,13 +13,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"learninghub/config"
 	"learninghub/constants"
 	"learninghub/db"
 	"learninghub/errors"
+	"learninghub/jobs"
 	"learninghub/middleware"
 	"learninghub/models"
 	"learninghub/pkg/logger"
 	"learninghub/utils"
+	"learninghub/validation"
 )
 
 // GetResources handles GET /resources
@@ -27,6 +33,7 @@ import (
 //   - search: Search string for title/description
 //   - cursor: Offset for pagination (as stringified int)
 //   - limit: Number of items per page (default 20, max 100)
+//   - <field>: Value of a custom field of the product, e.g. difficulty=beginner
 func GetResources(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -66,6 +73,11 @@ func GetResources(c *gin.Context) {
 		validTypeFilter = typeFilter
 	}
 
+	fieldFilters, ok := customFieldFilters(c, product)
+	if !ok {
+		return
+	}
+
 	// Execute query with limit + 1 to check for more results
 	docs, err := resourceService.List(ctx, db.ResourceQuery{
 		Product: product,
@@ -73,6 +85,7 @@ func GetResources(c *gin.Context) {
 		Tags:    tags,
 		Cursor:  cursor,
 		Limit:   limit + 1,
+		Fields:  fieldFilters,
 	})
 	if err != nil {
 		errors.RespondWithErrorDetails(c, errors.ErrQueryFailed, "Failed to fetch resources", err.Error())
@@ -100,19 +113,7 @@ func GetResources(c *gin.Context) {
 		}
 
 		// Convert URLs to signed URLs before adding to response
-		signedURL, signedThumbnailURL, err := utils.ConvertResourceURLsToSigned(
-			ctx,
-			resource.URL,
-			resource.ThumbnailURL,
-			constants.DefaultSignedURLExpiration,
-		)
-		if err != nil {
-			logger.Infof("Error generating signed URLs for resource %s: %v", resource.ID, err)
-			// Continue with original URLs if signing fails
-		} else {
-			resource.URL = signedURL
-			resource.ThumbnailURL = signedThumbnailURL
-		}
+		signResource(ctx, &resource)
 
 		// Only add if we haven't reached the limit
 		if len(resources) < limit {
@@ -120,6 +121,8 @@ func GetResources(c *gin.Context) {
 		}
 	}
 
+	markBookmarked(c, product, resources)
+
 	// We have more if we fetched more documents than our limit
 	// AND we have exactly 'limit' resources after filtering
 	hasMore := len(docs) > limit && len(resources) == limit
@@ -174,29 +177,25 @@ func GetResource(c *gin.Context) {
 	resource.ID = doc.Ref.ID
 
 	// Convert URLs to signed URLs before returning
-	signedURL, signedThumbnailURL, err := utils.ConvertResourceURLsToSigned(
-		ctx,
-		resource.URL,
-		resource.ThumbnailURL,
-		constants.DefaultSignedURLExpiration,
-	)
-	if err != nil {
-		logger.Infof("Error generating signed URLs for resource %s: %v", resource.ID, err)
-		// Continue with original URLs if signing fails
-	} else {
-		resource.URL = signedURL
-		resource.ThumbnailURL = signedThumbnailURL
-	}
+	signResource(ctx, &resource)
+	resource.StreamURL = streamURL(product, resource)
+	resource.PreviewURL = previewURL(ctx, resource)
+
+	resources := []models.Resource{resource}
+	markBookmarked(c, product, resources)
 
-	c.JSON(http.StatusOK, resource)
+	c.JSON(http.StatusOK, resources[0])
 }
 
 // CreateResource handles POST /resources
-//   - Accepts multipart/form-data for resource creation.
+//   - Accepts multipart/form-data for resource creation, or application/json
+//     for resources linking their file by url (see resourceDocument).
 //   - Required fields: title, description, type.
-//   - For "video" and "pdf" types, if url provided in the request, it will be prioritized and used as the resource's URL.
-//     even if a file is uploaded.
-//   - For "article" type, url is required.
+//   - For file types ("video", "pdf", "audio", "slides", "code"), if url provided in the request, it will be prioritized
+//     and used as the resource's URL. even if a file is uploaded.
+//   - For link types ("article", "embed"), url is required. Embed URLs are resolved to their player.
+//   - How each type is validated and processed is declared by its utils.ResourceType.
+//   - customFields is a JSON object of custom field values, required fields must be set.
 func CreateResource(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -207,116 +206,155 @@ func CreateResource(c *gin.Context) {
 		return
 	}
 
-	contentType := c.GetHeader("Content-Type")
+	// Multipart requests may upload files, JSON ones link them by URL
+	var document resourceDocument
+	multipartRequest := false
 
-	// Check if it's actually a multipart form
-	if contentType == "" || !strings.Contains(contentType, "multipart/form-data") {
-		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be multipart/form-data")
-		return
-	}
+	switch c.ContentType() {
+	case constants.ContentTypeMultipart:
+		// Check Content-Length before parsing multipart form
+		contentLength := c.Request.ContentLength
+		if contentLength > constants.MaxFileSize {
+			errors.RespondWithError(c, errors.ErrFileTooLarge, fmt.Sprintf("Request size too large. Maximum size is %d MB", constants.MaxFileSize/(1<<20)))
+			return
+		}
 
-	// Check Content-Length before parsing multipart form
-	contentLength := c.Request.ContentLength
-	if contentLength > constants.MaxFileSize {
-		errors.RespondWithError(c, errors.ErrFileTooLarge, fmt.Sprintf("Request size too large. Maximum size is %d MB", constants.MaxFileSize/(1<<20)))
-		return
-	}
+		// Parse multipart form with MaxFileSize limit
+		if err := c.Request.ParseMultipartForm(constants.MaxFileSize); err != nil {
+			handleMultipartFormError(c, err)
+			return
+		}
+		multipartRequest = true
+
+		// Extract form fields
+		document = resourceDocument{
+			Title:        c.PostForm(constants.FormFieldTitle),
+			Description:  c.PostForm(constants.FormFieldDescription),
+			Type:         c.PostForm(constants.FormFieldType),
+			URL:          c.PostForm(constants.FormFieldURL),
+			ThumbnailURL: c.PostForm(constants.FormFieldThumbnailURL),
+			Tags:         strings.Split(c.PostForm(constants.FormFieldTags), ","),
+		}
+		if raw := c.PostForm(constants.FormFieldCustomFields); raw != "" {
+			values, err := utils.ParseCustomFields(raw)
+			if err != nil {
+				errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldCustomFields, Code: validation.CodeInvalidType, Message: err.Error()})
+				return
+			}
+			document.CustomFields = values
+		}
 
-	// Parse multipart form with MaxFileSize limit
-	if err := c.Request.ParseMultipartForm(constants.MaxFileSize); err != nil {
-		handleMultipartFormError(c, err)
+	case constants.ContentTypeJSON:
+		body, ok := readJSONBody(c)
+		if !ok {
+			return
+		}
+		if document, ok = decodeResourceDocument(c, body); !ok {
+			return
+		}
+
+	default:
+		errors.RespondWithError(c, errors.ErrInvalidContentType, "Request must be multipart/form-data or application/json")
 		return
 	}
 
-	// log the files for debugging
-	// if c.Request.MultipartForm.File != nil {
-	// 	for key, files := range c.Request.MultipartForm.File {
-	// 		log.Printf("File field '%s': %d files", key, len(files))
-	// 		for i, file := range files {
-	// 			log.Printf("  File %d: %s (size: %d)", i, file.Filename, file.Size)
-	// 		}
-	// 	}
-	// }
-
-	// Extract form fields
 	resource := models.Resource{
-		Title:       c.PostForm(constants.FormFieldTitle),
-		Description: c.PostForm(constants.FormFieldDescription),
-		Type:        c.PostForm(constants.FormFieldType),
+		Title:       document.Title,
+		Description: document.Description,
+		Type:        document.Type,
 
-		URL:          c.PostForm(constants.FormFieldURL),
-		ThumbnailURL: c.PostForm(constants.FormFieldThumbnailURL),
-		Tags:         utils.NormalizeTags(strings.Split(c.PostForm(constants.FormFieldTags), ",")),
+		URL:          document.URL,
+		ThumbnailURL: document.ThumbnailURL,
+		Tags:         utils.NormalizeTags(document.Tags),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	// Validate required fields
-	if resource.Title == "" || resource.Description == "" || resource.Type == "" {
-		errors.RespondWithError(c, errors.ErrMissingRequired, "Title, description, and type are required")
-		return
+	// Validate fields, type, links and custom fields
+	fields := func() ([]models.FieldDefinition, error) {
+		return db.NewFieldService(db.New()).List(ctx, product)
 	}
-
-	// Validate resource type
-	if !utils.IsValidResourceType(resource.Type) {
-		errors.RespondWithError(c, errors.ErrUnsupportedType, "Type must be 'video', 'pdf', or 'article'")
+	if response := checkNewResource(ctx, product, fields, &resource, document.CustomFields, multipartRequest); response != nil {
+		respondWith(c, *response)
 		return
 	}
+	resourceType, _ := utils.LookupResourceType(resource.Type)
+	policy := config.UploadPolicyFor(product)
 
-	// Check if resource type is article AND url is not provided
-	if resource.Type == constants.ResourceTypeArticle && resource.URL == "" {
-		errors.RespondWithError(c, errors.ErrMissingRequired, "URL must be provided for 'article' type")
-		return
-	}
+	// Whether the resource file was uploaded, rather than linked, and whether
+	// identical content was already stored
+	uploaded, duplicate := false, false
 
-	// Handle file uploads for video and pdf types if url is not provided
-	if (resource.Type == constants.ResourceTypeVideo || resource.Type == constants.ResourceTypePDF) && resource.URL == "" {
+	// Handle file uploads for file types if url is not provided
+	if resourceType.Source == utils.SourceFile && resource.URL == "" {
 		file, header, err := c.Request.FormFile(constants.FormFieldFile)
 		if err != nil {
-			errors.RespondWithErrorDetails(c, errors.ErrMissingRequired, fmt.Sprintf("File is required for %s resources", resource.Type), err.Error())
+			errors.RespondWithFieldError(c, errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldFile, Code: validation.CodeRequired, Message: fmt.Sprintf("File is required for %s resources", resource.Type)})
 			return
 		}
 		// successfully opened the file
 		defer file.Close()
 
+		if !checkUploadSize(c, policy, header, resource.Type, false) {
+			return
+		}
+
 		// Upload file to Cloud Storage
 		url, err := utils.UploadFile(ctx, file, header, product, resource.Type)
 		if err != nil {
-			// Check if this is a file validation error
-			if strings.Contains(err.Error(), constants.ErrFileValidationFailed) {
-				logger.Warnf("File validation failed: %v", err)
-				errors.RespondWithErrorDetails(c, errors.ErrInvalidFileType, "Invalid file type", fileTypeErrorDetail(resource.Type))
+			if respondToRejectedUpload(c, err, resource.Type, false) {
 				return
 			}
 			errors.RespondWithErrorDetails(c, errors.ErrUploadFailed, "Failed to upload file", err.Error())
 			return
 		}
 		resource.URL = url.PublicURL
+		resource.Metadata = url.Metadata
+		uploaded, duplicate = true, url.Duplicate
+
+		// e.g. uploaded videos are transcoded to HLS once the resource is saved
+		if resourceType.Uploaded != nil {
+			resourceType.Uploaded(&resource)
+		}
 	}
 
 	// Handle thumbnail upload if thumbnail url not provided
-	if resource.ThumbnailURL == "" {
+	if resource.ThumbnailURL == "" && multipartRequest {
 		// Handle thumbnail upload (optional)
 		thumbnailFile, thumbnailHeader, err := c.Request.FormFile(constants.FormFieldThumbnail)
 		if err == nil {
 			defer thumbnailFile.Close()
 
+			if !checkUploadSize(c, policy, thumbnailHeader, constants.ResourceTypeImage, true) {
+				return
+			}
+
 			thumbnailURL, err := utils.UploadFile(ctx, thumbnailFile, thumbnailHeader, product, constants.ResourceTypeImage)
 
 			if err != nil {
-				// Check if this is a file validation error for thumbnail
-				if strings.Contains(err.Error(), constants.ErrFileValidationFailed) {
-					logger.Warnf("Thumbnail validation failed: %v", err)
-					errors.RespondWithErrorDetails(c, errors.ErrInvalidFileType, "Invalid thumbnail file type", fileTypeErrorDetail(constants.ResourceTypeImage))
+				if respondToRejectedUpload(c, err, constants.ResourceTypeImage, true) {
 					return
 				}
 				logger.Infof("Failed to upload thumbnail: %v", err)
 			} else {
 				resource.ThumbnailURL = thumbnailURL.PublicURL
+				resource.ThumbnailMetadata = thumbnailURL.Metadata
+				// Its responsive variants are made in the background
+				resource.ThumbnailJob = &models.ThumbnailJob{Source: resource.ThumbnailURL}
 			}
 		}
 	}
 
+	// Generate a thumbnail from the uploaded file in the background if none was provided
+	if resource.ThumbnailURL == "" && uploaded && resourceType.ThumbnailFromUpload {
+		resource.ThumbnailJob = &models.ThumbnailJob{Source: resource.URL}
+	}
+
+	// Otherwise fall back to the type's default, e.g. an embed provider's thumbnail
+	if resource.ThumbnailURL == "" && resourceType.DefaultThumbnail != nil {
+		resource.ThumbnailURL = resourceType.DefaultThumbnail(resource)
+	}
+
 	// Create database services
 	database := db.New()
 	resourceService := db.NewResourceService(database)
@@ -333,27 +371,30 @@ func CreateResource(c *gin.Context) {
 
 	resource.ID = docRef.ID
 
-	// Convert URLs to signed URLs before returning
-	signedURL, signedThumbnailURL, err := utils.ConvertResourceURLsToSigned(
-		ctx,
-		resource.URL,
-		resource.ThumbnailURL,
-		constants.DefaultSignedURLExpiration,
-	)
-	if err != nil {
-		logger.Infof("Error generating signed URLs for new resource: %v", err)
-	} else {
-		resource.URL = signedURL
-		resource.ThumbnailURL = signedThumbnailURL
+	if duplicate {
+		resource.Warnings = duplicateFileWarnings(ctx, resourceService, product, resource)
 	}
 
+	if !uploaded {
+		referenceLinkedFiles(ctx, resource.URL)
+	}
+	referenceLinkedFiles(ctx, document.ThumbnailURL)
+
+	startResourceJobs(ctx, product, &resource, nil)
+
+	// Convert URLs to signed URLs before returning
+	signResource(ctx, &resource)
+
 	c.JSON(http.StatusCreated, resource)
 }
 
 // UpdateResource handles PATCH /resources/:id
-//   - Accepts multipart/form-data for resource update.
+//   - Accepts multipart/form-data, a JSON Merge Patch (application/json or
+//     application/merge-patch+json) or a JSON Patch (application/json-patch+json)
+//     of the resource's editable fields, see resourceDocument.
 //   - Only allows updating fields except for resource type (cannot be changed).
-//   - Handles file and thumbnail replacement if provided.
+//   - Handles file and thumbnail replacement if provided, uploads in multipart requests only.
+//   - customFields values are merged into the existing ones, null unsets a field.
 func UpdateResource(c *gin.Context) {
 	ctx := c.Request.Context()
 	id := c.Param("id")
@@ -382,9 +423,32 @@ func UpdateResource(c *gin.Context) {
 		return
 	}
 
-	// Parse multipart form
-	if err := c.Request.ParseMultipartForm(constants.MaxFileSize); err != nil {
-		handleMultipartFormError(c, err)
+	// The fields the request sets, whatever its format
+	var changes resourceChanges
+	multipartRequest := false
+
+	switch c.ContentType() {
+	case constants.ContentTypeMultipart:
+		if err := c.Request.ParseMultipartForm(constants.MaxFileSize); err != nil {
+			handleMultipartFormError(c, err)
+			return
+		}
+		multipartRequest = true
+
+		var ok bool
+		if changes, ok = multipartChanges(c, existingResource); !ok {
+			return
+		}
+
+	case constants.ContentTypeJSON, constants.ContentTypeMergePatch, constants.ContentTypeJSONPatch:
+		var ok bool
+		if changes, ok = patchChanges(c, existingResource); !ok {
+			return
+		}
+
+	default:
+		errors.RespondWithError(c, errors.ErrInvalidContentType,
+			"Request must be multipart/form-data, application/merge-patch+json or application/json-patch+json")
 		return
 	}
 
@@ -395,101 +459,184 @@ func UpdateResource(c *gin.Context) {
 	bytes, _ := json.Marshal(existingResource)
 	json.Unmarshal(bytes, &updatedResource)
 
-	if title, titleExists := c.GetPostForm(constants.FormFieldTitle); titleExists {
-		updatedResource.Title = title
+	// The JSON copy drops the internal state of background jobs
+	updatedResource.Transcode = existingResource.Transcode
+	updatedResource.Conversion = existingResource.Conversion
+	updatedResource.ThumbnailJob = existingResource.ThumbnailJob
+
+	// Files the resource no longer references, deleted once the update is saved
+	var (
+		obsoleteURLs     []string
+		obsoletePrefixes []string
+	)
+
+	if changes.Title != nil {
+		updatedResource.Title = *changes.Title
 	}
-	if description, descriptionExists := c.GetPostForm(constants.FormFieldDescription); descriptionExists {
-		updatedResource.Description = description
+	if changes.Description != nil {
+		updatedResource.Description = *changes.Description
 	}
-	if resourceType, typeExists := c.GetPostForm(constants.FormFieldType); typeExists {
-		updatedResource.Type = resourceType
+	if changes.Type != nil {
+		updatedResource.Type = *changes.Type
 		// Validate resource type
 		if !utils.IsValidResourceType(updatedResource.Type) {
-			errors.RespondWithError(c, errors.ErrUnsupportedType, "Type must be 'video', 'pdf', or 'article'")
+			errors.RespondWithFieldError(c, errors.ErrUnsupportedType, unsupportedTypeError())
 			return
 		}
 
 		// Check if trying to change resource type
 		if existingResource.Type != updatedResource.Type {
-			errors.RespondWithError(c, errors.ErrInvalidParam, "Resource type cannot be changed")
+			errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldType, Code: validation.CodeImmutable, Message: "Resource type cannot be changed"})
 			return
 		}
 	}
-	if tagsStr, tagsStrExists := c.GetPostForm(constants.FormFieldTags); tagsStrExists {
+	if changes.Tags != nil {
 		oldTags = existingResource.Tags
-		newTags = utils.NormalizeTags(strings.Split(tagsStr, ","))
+		newTags = *changes.Tags
 
 		updatedResource.Tags = newTags
 	}
+	if changes.URL != nil {
+		updatedResource.URL = *changes.URL
+	}
+	if changes.ThumbnailURL != nil {
+		updatedResource.ThumbnailURL = *changes.ThumbnailURL
+	}
+
+	// Validate the changed fields, resources saved before the limits keep
+	// the values they have
+	if fields := changes.fields(); len(fields) > 0 && !validateResource(c, updatedResource, fields...) {
+		return
+	}
+	if changes.CustomFields != nil {
+		if !mergeCustomFields(c, product, &updatedResource, changes.CustomFields) {
+			return
+		}
+	}
 	updatedResource.UpdatedAt = time.Now()
 
 	// Handle URL and file updates
-	urlFromForm, urlFromFormExists := c.GetPostForm(constants.FormFieldURL)
-	_, fileExists := c.Request.MultipartForm.File[constants.FormFieldFile]
+	newURL, urlSet := "", changes.URL != nil
+	if urlSet {
+		newURL = *changes.URL
+	}
+
+	// Files are uploaded in multipart requests only
+	var fileExists, thumbnailFileExists bool
+	if multipartRequest {
+		_, fileExists = c.Request.MultipartForm.File[constants.FormFieldFile]
+		_, thumbnailFileExists = c.Request.MultipartForm.File[constants.FormFieldThumbnail]
+	}
 
 	// If both URL and file are provided, return error
-	if urlFromFormExists && fileExists {
-		errors.RespondWithError(c, errors.ErrInvalidParam, "Either provide url or file")
+	if urlSet && fileExists {
+		errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldURL, Code: validation.CodeConflict, Message: "Either provide url or file", Params: map[string]any{"with": constants.FormFieldFile}})
 		return
 	}
 
-	if urlFromFormExists {
-		if existingResource.URL != "" {
-			if err = utils.DeleteFileFromURL(ctx, existingResource.URL); err != nil {
-				logger.Infof("Failed to delete old file: %v", err)
-			}
+	// The resource file can be replaced, not removed
+	if urlSet && newURL == "" {
+		errors.RespondWithFieldError(c, errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldURL, Code: validation.CodeRequired, Message: "URL cannot be cleared, provide another url or file"})
+		return
+	}
+
+	// replaceFile swaps the resource file. The old file, its HLS stream and
+	// its PDF conversion become obsolete, and jobs working on them are
+	// superseded.
+	replaceFile := func(newURL string) {
+		obsoleteURLs = append(obsoleteURLs, existingResource.URL)
+		obsoletePrefixes = append(obsoletePrefixes, existingResource.OutputPrefixes()...)
+		updatedResource.URL = newURL
+		updatedResource.Metadata = nil
+		updatedResource.Transcode = nil
+		updatedResource.Conversion = nil
+		if existingResource.ThumbnailJob != nil && (existingResource.ThumbnailJob.Source == existingResource.URL ||
+			(existingResource.Conversion != nil && existingResource.ThumbnailJob.Source == existingResource.Conversion.URL)) {
+			updatedResource.ThumbnailJob = nil
 		}
+	}
 
-		updatedResource.URL = urlFromForm
+	// The type cannot change, unknown types are left as they are
+	resourceType, _ := utils.LookupResourceType(existingResource.Type)
+
+	// Enforce the product's upload policy on linked files
+	policy := config.UploadPolicyFor(product)
+	if urlSet && resourceType.Source == utils.SourceFile && !checkLinkedURL(c, policy, newURL, constants.FormFieldURL) {
+		return
 	}
 
-	if fileExists && (existingResource.Type == constants.ResourceTypeVideo || existingResource.Type == constants.ResourceTypePDF) {
+	if urlSet {
+		// Linked videos are not transcoded, nor linked slide decks converted
+		replaceFile(newURL)
+
+		// A new link is resolved again, e.g. an embed to its player
+		if resourceType.Source == utils.SourceURL && !resolveURL(c, resourceType, &updatedResource) {
+			return
+		}
+	}
+
+	// Whether a new resource file was uploaded, and whether identical content
+	// was already stored
+	uploaded, duplicate := false, false
+
+	if fileExists && resourceType.Source == utils.SourceFile {
 		// User provided a new file to upload
 		if file, header, err := c.Request.FormFile(constants.FormFieldFile); err == nil {
 			defer file.Close()
 
-			// Delete old file if it was stored in our storage
-			if existingResource.URL != "" {
-				if err = utils.DeleteFileFromURL(ctx, existingResource.URL); err != nil {
-					logger.Infof("Failed to delete old file: %v", err)
-				}
+			if !checkUploadSize(c, policy, header, existingResource.Type, false) {
+				return
 			}
 
 			// Upload new file
 			uploadResult, err := utils.UploadFile(ctx, file, header, product, existingResource.Type)
 			if err != nil {
-				// Check if this is a file validation error
-				if strings.Contains(err.Error(), constants.ErrFileValidationFailed) {
-					logger.Warnf("File validation failed: %v", err)
-					errors.RespondWithErrorDetails(c, errors.ErrInvalidFileType, "Invalid file type", fileTypeErrorDetail(existingResource.Type))
+				if respondToRejectedUpload(c, err, existingResource.Type, false) {
 					return
 				}
 				errors.RespondWithErrorDetails(c, errors.ErrUploadFailed, "Failed to upload new file", err.Error())
 				return
 			}
-			updatedResource.URL = uploadResult.PublicURL
+			if uploadResult.PublicURL == existingResource.URL {
+				// The same file again: keep its stream and thumbnail, only
+				// drop the reference the upload took
+				obsoleteURLs = append(obsoleteURLs, uploadResult.PublicURL)
+			} else {
+				replaceFile(uploadResult.PublicURL)
+				updatedResource.Metadata = uploadResult.Metadata
+				uploaded, duplicate = true, uploadResult.Duplicate
+
+				if resourceType.Uploaded != nil {
+					resourceType.Uploaded(&updatedResource)
+				}
+			}
 		}
 	}
 
-	// Handle thumbnail URL and file updates
-	thumbnailURLFromForm, thumbnailURLFromFormExists := c.GetPostForm(constants.FormFieldThumbnailURL)
-	_, thumbnailFileExists := c.Request.MultipartForm.File[constants.FormFieldThumbnail]
+	// Handle thumbnail URL and file updates. An empty thumbnail URL clears
+	// the thumbnail.
+	newThumbnailURL, thumbnailURLSet := "", changes.ThumbnailURL != nil
+	if thumbnailURLSet {
+		newThumbnailURL = *changes.ThumbnailURL
+	}
 
 	// If both thumbnail URL and thumbnail file are provided, return error
-	if thumbnailURLFromFormExists && thumbnailFileExists {
-		errors.RespondWithError(c, errors.ErrInvalidParam, "Either provide url or thumbnail")
+	if thumbnailURLSet && thumbnailFileExists {
+		errors.RespondWithFieldError(c, errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldThumbnailURL, Code: validation.CodeConflict, Message: "Either provide url or thumbnail", Params: map[string]any{"with": constants.FormFieldThumbnail}})
+		return
+	}
+
+	if thumbnailURLSet && !checkLinkedURL(c, policy, newThumbnailURL, constants.FormFieldThumbnailURL) {
 		return
 	}
 
-	if thumbnailURLFromFormExists {
+	if thumbnailURLSet {
 		// User provided a new thumbnail URL
-		// Delete old thumbnail if it was stored in our storage
-		if existingResource.ThumbnailURL != "" {
-			if err = utils.DeleteFileFromURL(ctx, existingResource.ThumbnailURL); err != nil {
-				logger.Infof("Failed to delete old thumbnail: %v", err)
-			}
-		}
-		updatedResource.ThumbnailURL = thumbnailURLFromForm
+		obsoleteURLs = append(obsoleteURLs, thumbnailObjects(existingResource)...)
+		updatedResource.ThumbnailURL = newThumbnailURL
+		updatedResource.ThumbnailMetadata = nil
+		updatedResource.Thumbnails = nil
+		updatedResource.ThumbnailJob = nil
 	}
 
 	if thumbnailFileExists {
@@ -497,29 +644,43 @@ func UpdateResource(c *gin.Context) {
 		if thumbnailFile, thumbnailHeader, err := c.Request.FormFile(constants.FormFieldThumbnail); err == nil {
 			defer thumbnailFile.Close()
 
-			// Delete old thumbnail if it was stored in our storage
-			if existingResource.ThumbnailURL != "" {
-				if err = utils.DeleteFileFromURL(ctx, existingResource.ThumbnailURL); err != nil {
-					logger.Infof("Failed to delete old thumbnail: %v", err)
-				}
+			if !checkUploadSize(c, policy, thumbnailHeader, constants.ResourceTypeImage, true) {
+				return
 			}
 
 			// Upload new thumbnail
 			thumbnailResult, err := utils.UploadFile(ctx, thumbnailFile, thumbnailHeader, product, constants.ResourceTypeImage)
 			if err != nil {
-				// Check if this is a file validation error for thumbnail
-				if strings.Contains(err.Error(), constants.ErrFileValidationFailed) {
-					logger.Warnf("Thumbnail validation failed: %v", err)
-					errors.RespondWithErrorDetails(c, errors.ErrInvalidFileType, "Invalid thumbnail file type", fileTypeErrorDetail(constants.ResourceTypeImage))
+				if respondToRejectedUpload(c, err, constants.ResourceTypeImage, true) {
 					return
 				}
 				logger.Infof("Failed to upload thumbnail: %v", err)
+			} else if thumbnailResult.PublicURL == existingResource.ThumbnailURL {
+				// The same thumbnail again: keep its variants
+				obsoleteURLs = append(obsoleteURLs, thumbnailResult.PublicURL)
 			} else {
+				obsoleteURLs = append(obsoleteURLs, thumbnailObjects(existingResource)...)
 				updatedResource.ThumbnailURL = thumbnailResult.PublicURL
+				updatedResource.ThumbnailMetadata = thumbnailResult.Metadata
+				updatedResource.Thumbnails = nil
+				// Its responsive variants are made in the background
+				updatedResource.ThumbnailJob = &models.ThumbnailJob{Source: updatedResource.ThumbnailURL}
 			}
 		}
 	}
 
+	// Generate a thumbnail for a new file in the background unless the resource has one
+	if updatedResource.ThumbnailURL == "" && uploaded && resourceType.ThumbnailFromUpload {
+		updatedResource.ThumbnailJob = &models.ThumbnailJob{Source: updatedResource.URL}
+	}
+
+	// A new link brings its default thumbnail, e.g. a new embed its
+	// provider's, unless the thumbnail was set explicitly
+	if urlSet && resourceType.DefaultThumbnail != nil && !thumbnailURLSet && !thumbnailFileExists &&
+		(existingResource.ThumbnailURL == "" || existingResource.ThumbnailURL == resourceType.DefaultThumbnail(existingResource)) {
+		updatedResource.ThumbnailURL = resourceType.DefaultThumbnail(updatedResource)
+	}
+
 	// Save updated resource to product-specific collection
 	err = resourceService.Update(ctx, product, id, updatedResource)
 	if err != nil {
@@ -535,20 +696,24 @@ func UpdateResource(c *gin.Context) {
 
 	updatedResource.ID = id
 
-	// Convert URLs to signed URLs before returning
-	signedURL, signedThumbnailURL, err := utils.ConvertResourceURLsToSigned(
-		ctx,
-		updatedResource.URL,
-		updatedResource.ThumbnailURL,
-		constants.DefaultSignedURLExpiration,
-	)
-	if err != nil {
-		logger.Infof("Error generating signed URLs for updated resource: %v", err)
-	} else {
-		updatedResource.URL = signedURL
-		updatedResource.ThumbnailURL = signedThumbnailURL
+	if duplicate {
+		updatedResource.Warnings = duplicateFileWarnings(ctx, resourceService, product, updatedResource)
 	}
 
+	// Before the purge, which may release the same files
+	if urlSet {
+		referenceLinkedFiles(ctx, newURL)
+	}
+	if thumbnailURLSet {
+		referenceLinkedFiles(ctx, newThumbnailURL)
+	}
+
+	purgeObjects(ctx, product, obsoleteURLs, obsoletePrefixes)
+	startResourceJobs(ctx, product, &updatedResource, &existingResource)
+
+	// Convert URLs to signed URLs before returning
+	signResource(ctx, &updatedResource)
+
 	c.JSON(http.StatusOK, updatedResource)
 }
 
@@ -582,29 +747,104 @@ func DeleteResource(c *gin.Context) {
 		return
 	}
 
-	// Delete files from Cloud Storage
-	if resource.URL != "" {
-		if err := utils.DeleteFileFromURL(ctx, resource.URL); err != nil {
-			logger.Infof("Failed to delete file: %v", err)
-		}
-	}
-	if resource.ThumbnailURL != "" {
-		if err := utils.DeleteFileFromURL(ctx, resource.ThumbnailURL); err != nil {
-			logger.Infof("Failed to delete thumbnail: %v", err)
-		}
+	if err := removeResource(ctx, resourceService, product, id, resource); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to delete resource", err.Error())
+		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"message": "Resource deleted successfully"})
+}
+
+// removeResource deletes a resource read beforehand, releases its tags,
+// forgets it (see forgetResources) and deletes its files in the background
+func removeResource(ctx context.Context, resourceService *db.ResourceService, product, id string, resource models.Resource) error {
 	// Update tag usage counts
 	utils.UpdateTagUsage(ctx, product, resource.Tags, -1)
 
 	// Delete from Firestore product-specific collection
-	err = resourceService.Delete(ctx, product, id)
+	if err := resourceService.Delete(ctx, product, id); err != nil {
+		return err
+	}
+	forgetResources(ctx, product, []string{id})
+
+	// Delete files from Cloud Storage in the background
+	purgeObjects(ctx, product, append([]string{resource.URL}, thumbnailObjects(resource)...), resource.OutputPrefixes())
+	return nil
+}
+
+// forgetResources removes deleted resources from collections, and deletes
+// the progress and bookmarks of users on them
+func forgetResources(ctx context.Context, product string, ids []string) {
+	removeFromCollections(ctx, product, ids)
+	deleteProgress(ctx, product, ids)
+	deleteBookmarks(ctx, product, ids)
+}
+
+// unsupportedTypeError rejects the type of a resource, listing the registered
+// resource types
+func unsupportedTypeError() errors.FieldError {
+	names := utils.ResourceTypeNames()
+	return errors.FieldError{
+		Field:   constants.FormFieldType,
+		Code:    validation.CodeNotSupported,
+		Message: "Type must be one of: " + strings.Join(names, ", "),
+		Params:  map[string]any{"supported": names},
+	}
+}
+
+// checkNewResource checks a new resource with the rules of CreateResource:
+// the validation rules, its type, its URL unless its file is uploaded, its
+// custom fields, the product's upload policy on linked files and the type's
+// Resolve hook. Custom field values are merged into the resource, with the
+// product's definitions from fields, only fetched once the other fields are
+// valid. It returns the error of the first failed check, or nil.
+func checkNewResource(ctx context.Context, product string, fields func() ([]models.FieldDefinition, error), resource *models.Resource, customFields map[string]any, uploading bool) *errors.ErrorResponse {
+	// Validate required fields and limits
+	if fieldErrors := validation.Resource(*resource); len(fieldErrors) > 0 {
+		response := validationError(fieldErrors)
+		return &response
+	}
+
+	// Validate resource type
+	resourceType, known := utils.LookupResourceType(resource.Type)
+	if !known {
+		return fieldErrorResponse(errors.ErrUnsupportedType, unsupportedTypeError())
+	}
+
+	// Check if resource type is a link (article, embed) AND url is not provided
+	if resourceType.Source == utils.SourceURL && resource.URL == "" {
+		return fieldErrorResponse(errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldURL, Code: validation.CodeRequired, Message: fmt.Sprintf("URL must be provided for '%s' type", resource.Type)})
+	}
+
+	// Files not uploaded with the request must be linked
+	if resourceType.Source == utils.SourceFile && resource.URL == "" && !uploading {
+		return fieldErrorResponse(errors.ErrMissingRequired, errors.FieldError{Field: constants.FormFieldURL, Code: validation.CodeRequired, Message: fmt.Sprintf("URL of the %s file must be provided, or the file uploaded with multipart/form-data", resource.Type)})
+	}
+
+	// Validate custom fields against the product's definitions
+	definitions, err := fields()
 	if err != nil {
-		errors.RespondWithErrorDetails(c, errors.ErrMutationFailed, "Failed to delete resource", err.Error())
-		return
+		return &errors.ErrorResponse{Error: errors.ErrQueryFailed, Message: "Failed to fetch fields", Details: err.Error()}
+	}
+	if response := customFieldsError(definitions, resource, customFields); response != nil {
+		return response
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Resource deleted successfully"})
+	// Enforce the product's upload policy on linked files
+	policy := config.UploadPolicyFor(product)
+	if resourceType.Source == utils.SourceFile {
+		if response := linkedURLError(policy, resource.URL, constants.FormFieldURL); response != nil {
+			return response
+		}
+	}
+	if response := linkedURLError(policy, resource.ThumbnailURL, constants.FormFieldThumbnailURL); response != nil {
+		return response
+	}
+
+	if resourceType.Source == utils.SourceURL {
+		return resolveURLError(ctx, resourceType, resource)
+	}
+	return nil
 }
 
 // handleMultipartFormError handles errors from ParseMultipartForm
@@ -621,17 +861,254 @@ func handleMultipartFormError(c *gin.Context, err error) {
 	}
 }
 
+// thumbnailObjects returns the URLs of a resource's thumbnail and its variants
+func thumbnailObjects(resource models.Resource) []string {
+	urls := []string{resource.ThumbnailURL}
+	for _, variantURL := range resource.Thumbnails {
+		urls = append(urls, variantURL)
+	}
+	return urls
+}
+
+// signResource replaces the stored file, thumbnail and thumbnail variant URLs
+// of a resource with signed URLs. URLs that cannot be signed are kept.
+func signResource(ctx context.Context, resource *models.Resource) {
+	signedURL, signedThumbnailURL, err := utils.ConvertResourceURLsToSigned(
+		ctx,
+		resource.URL,
+		resource.ThumbnailURL,
+		constants.DefaultSignedURLExpiration,
+	)
+	if err != nil {
+		logger.Infof("Error generating signed URLs for resource %s: %v", resource.ID, err)
+		// Continue with original URLs if signing fails
+	} else {
+		resource.URL = signedURL
+		resource.ThumbnailURL = signedThumbnailURL
+	}
+	resource.Thumbnails = utils.SignThumbnailVariants(ctx, resource.Thumbnails, constants.DefaultSignedURLExpiration)
+}
+
+// previewURL returns a signed URL of a slide deck's PDF conversion once it is ready
+func previewURL(ctx context.Context, resource models.Resource) string {
+	if resource.Conversion == nil || resource.Conversion.Status != constants.ConversionStatusReady || resource.Conversion.URL == "" {
+		return ""
+	}
+
+	signedURL, err := utils.GenerateSignedURL(ctx, resource.Conversion.URL, constants.DefaultSignedURLExpiration)
+	if err != nil {
+		logger.Infof("Error generating signed preview URL for resource %s: %v", resource.ID, err)
+		return ""
+	}
+	return signedURL
+}
+
+// resolveURL runs the Resolve hook of a resource type on the URL of a
+// resource, e.g. to resolve an embed to its player. It responds with an error
+// and returns false if the URL cannot be resolved.
+func resolveURL(c *gin.Context, resourceType utils.ResourceType, resource *models.Resource) bool {
+	if response := resolveURLError(c.Request.Context(), resourceType, resource); response != nil {
+		respondWith(c, *response)
+		return false
+	}
+	return true
+}
+
+// resolveURLError runs the Resolve hook of a resource type like resolveURL,
+// and returns the error of a URL that cannot be resolved
+func resolveURLError(ctx context.Context, resourceType utils.ResourceType, resource *models.Resource) *errors.ErrorResponse {
+	if resourceType.Resolve == nil {
+		return nil
+	}
+
+	err := resourceType.Resolve(ctx, resource)
+	if unsupported := (*utils.UnsupportedURLError)(nil); stdErrors.As(err, &unsupported) {
+		return fieldErrorResponse(errors.ErrInvalidParam, errors.FieldError{Field: constants.FormFieldURL, Code: validation.CodeNotSupported, Message: unsupported.Error()})
+	}
+	if err != nil {
+		logger.Infof("Failed to resolve %s URL %s: %v", resourceType.Name, resource.URL, err)
+		return &errors.ErrorResponse{Error: errors.ErrInvalidParam, Message: fmt.Sprintf("Could not resolve the %s from its URL", resourceType.Name), Details: err.Error()}
+	}
+	return nil
+}
+
+// referenceLinkedFiles takes references to the uploads a saved resource links
+// by URL, see utils.ReferenceFileFromURL. Failures are logged: the resource is
+// saved, at worst the file goes with the resource that uploaded it.
+func referenceLinkedFiles(ctx context.Context, fileURLs ...string) {
+	for _, fileURL := range fileURLs {
+		if fileURL == "" {
+			continue
+		}
+		if err := utils.ReferenceFileFromURL(ctx, fileURL); err != nil {
+			logger.Errorf("Failed to reference linked file: %v", err)
+		}
+	}
+}
+
+// duplicateFileWarnings warns that the file uploaded for a resource already
+// backs another resource of the product. The upload itself is fine: the
+// content is stored once and shared.
+func duplicateFileWarnings(ctx context.Context, resourceService *db.ResourceService, product string, resource models.Resource) []models.Warning {
+	if resource.Metadata == nil || resource.Metadata.SHA256 == "" {
+		return nil
+	}
+
+	docs, err := resourceService.FindBySHA256(ctx, product, resource.Metadata.SHA256, 2)
+	if err != nil {
+		logger.Infof("Failed to look up resources sharing the file of resource %s: %v", resource.ID, err)
+		return nil
+	}
+
+	for _, doc := range docs {
+		if doc.Ref.ID == resource.ID {
+			continue
+		}
+
+		title, _ := doc.Data()["title"].(string)
+		return []models.Warning{{
+			Code:       constants.WarningDuplicateFile,
+			Message:    fmt.Sprintf("An identical file already backs the resource %q", title),
+			ResourceID: doc.Ref.ID,
+		}}
+	}
+
+	return nil
+}
+
+// startResourceJobs enqueues the background jobs a saved resource needs: those
+// whose state is new compared to the previous version of the resource, if any.
+// The job IDs are added to the resource for the response. A resource is usable
+// without its jobs, so failures to enqueue are only logged.
+func startResourceJobs(ctx context.Context, product string, resource, previous *models.Resource) {
+	var previousTranscode *models.Transcode
+	var previousConversion *models.Conversion
+	var previousThumbnailJob *models.ThumbnailJob
+	if previous != nil {
+		previousTranscode, previousConversion, previousThumbnailJob = previous.Transcode, previous.Conversion, previous.ThumbnailJob
+	}
+
+	enqueue := func(jobType string, payload any) {
+		jobID, err := jobs.Enqueue(ctx, product, jobType, payload)
+		if err != nil {
+			logger.Errorf("Failed to enqueue %s job for resource %s: %v", jobType, resource.ID, err)
+			return
+		}
+
+		if resource.Jobs == nil {
+			resource.Jobs = make(map[string]string)
+		}
+		resource.Jobs[jobType] = jobID
+	}
+
+	if resource.Transcode != nil && resource.Transcode != previousTranscode {
+		enqueue(constants.JobTypeTranscode, transcodePayload{ResourceID: resource.ID, Source: resource.Transcode.Source})
+	}
+	if resource.Conversion != nil && resource.Conversion != previousConversion {
+		enqueue(constants.JobTypeConvert, convertPayload{ResourceID: resource.ID, Source: resource.Conversion.Source})
+	}
+	if resource.ThumbnailJob != nil && resource.ThumbnailJob != previousThumbnailJob {
+		enqueue(constants.JobTypeThumbnail, thumbnailPayload{ResourceID: resource.ID, Source: resource.ThumbnailJob.Source})
+	}
+}
+
+// purgeObjects deletes stored files and HLS outputs a resource no longer
+// references in the background. Links to external files are ignored. If the
+// job cannot be enqueued the files are deleted right away.
+func purgeObjects(ctx context.Context, product string, fileURLs, prefixes []string) {
+	var stored []string
+	for _, fileURL := range fileURLs {
+		if fileURL != "" && utils.IsValidStorageURL(fileURL) {
+			stored = append(stored, fileURL)
+		}
+	}
+	if len(stored) == 0 && len(prefixes) == 0 {
+		return
+	}
+
+	if _, err := jobs.Enqueue(ctx, product, constants.JobTypePurge, purgePayload{URLs: stored, Prefixes: prefixes}); err != nil {
+		logger.Warnf("Failed to enqueue purge job, deleting files now: %v", err)
+		if err := utils.DeleteObjects(ctx, "", stored, prefixes); err != nil {
+			logger.Infof("Failed to delete files: %v", err)
+		}
+	}
+}
+
+// checkUploadSize responds with an error and returns false if an upload
+// exceeds the size the product's policy allows for its resource type
+func checkUploadSize(c *gin.Context, policy config.UploadPolicy, header *multipart.FileHeader, resourceType string, thumbnail bool) bool {
+	maxSize := policy.MaxFileSize(resourceType)
+	if header.Size <= maxSize {
+		return true
+	}
+
+	subject := "File"
+	if thumbnail {
+		subject = "Thumbnail"
+	}
+	errors.RespondWithError(c, errors.ErrFileTooLarge, fmt.Sprintf("%s too large. Maximum size is %d MB", subject, maxSize/(1<<20)))
+	return false
+}
+
+// checkLinkedURL responds with an error and returns false if a request field
+// links a file stored outside our bucket while the product's policy only
+// allows uploads
+func checkLinkedURL(c *gin.Context, policy config.UploadPolicy, fileURL, field string) bool {
+	if response := linkedURLError(policy, fileURL, field); response != nil {
+		respondWith(c, *response)
+		return false
+	}
+	return true
+}
+
+// linkedURLError returns the error of a link the product's policy does not
+// allow, see checkLinkedURL
+func linkedURLError(policy config.UploadPolicy, fileURL, field string) *errors.ErrorResponse {
+	if fileURL == "" || policy.ExternalURLsAllowed() || utils.IsValidStorageURL(fileURL) {
+		return nil
+	}
+
+	return fieldErrorResponse(errors.ErrInvalidParam, errors.FieldError{Field: field, Code: validation.CodeNotAllowed, Message: fmt.Sprintf("External URLs are not allowed for %s, upload the file instead", field)})
+}
+
+// respondToRejectedUpload responds to an UploadFile error caused by the file
+// itself (failed validation or malware) and reports whether it did. Other
+// errors are left to the caller.
+func respondToRejectedUpload(c *gin.Context, err error, resourceType string, thumbnail bool) bool {
+	subject := "File"
+	if thumbnail {
+		subject = "Thumbnail"
+	}
+
+	switch {
+	case strings.Contains(err.Error(), constants.ErrMalwareDetected):
+		logger.Warnf("%s rejected by malware scan: %v", subject, err)
+		errors.RespondWithErrorDetails(c, errors.ErrMalwareDetected, subject+" was flagged as malicious", "The file has been quarantined and was not saved")
+		return true
+
+	case strings.Contains(err.Error(), constants.ErrScanSizeLimit):
+		logger.Warnf("%s too large to scan: %v", subject, err)
+		errors.RespondWithErrorDetails(c, errors.ErrFileTooLarge, subject+" is larger than the malware scanner accepts", "The scanner's size limit is below the maximum file size, see StreamMaxLength of clamd")
+		return true
+
+	case strings.Contains(err.Error(), constants.ErrFileValidationFailed):
+		logger.Warnf("%s validation failed: %v", subject, err)
+		message := "Invalid file type"
+		if thumbnail {
+			message = "Invalid thumbnail file type"
+		}
+		errors.RespondWithErrorDetails(c, errors.ErrInvalidFileType, message, fileTypeErrorDetail(resourceType))
+		return true
+	}
+
+	return false
+}
+
 // fileTypeErrorDetail returns a user-friendly error message for file validation failures
 // based on the resource type.
 func fileTypeErrorDetail(resourceType string) string {
-	switch resourceType {
-	case constants.ResourceTypeVideo:
-		return "Only MP4 and WebM video formats are supported"
-	case constants.ResourceTypePDF:
-		return "Only PDF files are supported"
-	case constants.ResourceTypeImage:
-		return "The uploaded file is not a supported image format"
-	default:
-		return "The uploaded file type is not supported"
+	if hint := utils.FileTypeHint(resourceType); hint != "" {
+		return hint
 	}
+	return "The uploaded file type is not supported"
 }
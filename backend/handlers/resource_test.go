@@ -105,3 +105,36 @@ func TestHandleMultipartFormErrorWithMaxFileSize(t *testing.T) {
 	expectedSize := constants.MaxFileSize / (1 << 20) // Convert to MB
 	assert.Contains(t, response.Message, fmt.Sprintf("%d MB", expectedSize))
 }
+
+func TestRespondToRejectedUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		err           error
+		expectedError errors.ErrorCode
+	}{
+		{name: "malware", err: fmt.Errorf("%s: Eicar-Test-Signature", constants.ErrMalwareDetected), expectedError: errors.ErrMalwareDetected},
+		{name: "too large to scan", err: fmt.Errorf("%s: clamd error: INSTREAM size limit exceeded.", constants.ErrScanSizeLimit), expectedError: errors.ErrFileTooLarge},
+		{name: "invalid file", err: fmt.Errorf("%s: text/plain", constants.ErrFileValidationFailed), expectedError: errors.ErrInvalidFileType},
+		{name: "other error", err: stdErrors.New("malware scan failed: failed to connect to clamd")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			responded := respondToRejectedUpload(c, tt.err, constants.ResourceTypeVideo, false)
+
+			assert.Equal(t, tt.expectedError != "", responded)
+			if !responded {
+				return
+			}
+			var response errors.ErrorResponse
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			assert.Equal(t, tt.expectedError, response.Error)
+		})
+	}
+}
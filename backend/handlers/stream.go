@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/errors"
+	"learninghub/firebase"
+	"learninghub/middleware"
+	"learninghub/models"
+	"learninghub/utils"
+)
+
+// maxPlaylistSize bounds the playlists read from storage
+const maxPlaylistSize = 1 << 20 // 1MB
+
+// GetResourceStream handles GET /resources/:id/hls/*file
+//   - Serves the HLS playlists of a transcoded video, authorized by the token
+//     issued in the resource's streamUrl.
+//   - Playlists are rewritten: nested playlists point back here with the same
+//     token, segments point to signed storage URLs.
+//
+// Query Params:
+//   - token: Media token from streamUrl
+func GetResourceStream(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	// Get product from context (validated by middleware)
+	product, exists := middleware.GetProductFromContext(c)
+	if !exists {
+		errors.RespondWithError(c, errors.ErrInvalidProduct, "Invalid product parameter")
+		return
+	}
+
+	token := c.Query(constants.MediaTokenParam)
+	if err := utils.VerifyMediaToken(token, product, id); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrUnauthorized, "Invalid or expired stream token", err.Error())
+		return
+	}
+
+	// Only playlists are served here; segments are fetched from storage directly
+	file := path.Clean("/" + c.Param("file"))
+	if path.Ext(file) != ".m3u8" {
+		errors.RespondWithError(c, errors.ErrInvalidParam, "Only playlists can be requested")
+		return
+	}
+
+	// Create database services
+	database := db.New()
+	resourceService := db.NewResourceService(database)
+
+	doc, err := resourceService.GetByID(ctx, product, id)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrResourceNotFound, "Resource not found", err.Error())
+		return
+	}
+
+	var resource models.Resource
+	if err := doc.DataTo(&resource); err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrDataConversionFailed, "Failed to process resource data", err.Error())
+		return
+	}
+
+	if resource.Transcode == nil || resource.Transcode.Status != constants.TranscodeStatusReady {
+		errors.RespondWithError(c, errors.ErrResourceNotFound, "Stream is not available")
+		return
+	}
+
+	// path.Clean on a rooted path removed any "..", so the object stays under the prefix
+	objectName := resource.Transcode.Prefix + file
+	playlist, err := readPlaylist(ctx, objectName)
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrResourceNotFound, "Playlist not found", err.Error())
+		return
+	}
+
+	rewritten, err := utils.RewritePlaylist(playlist, func(uri string) (string, error) {
+		return resolvePlaylistURI(ctx, resource.Transcode.Prefix, objectName, uri, token)
+	})
+	if err != nil {
+		errors.RespondWithErrorDetails(c, errors.ErrInternalServer, "Failed to prepare playlist", err.Error())
+		return
+	}
+
+	// The segment URLs inside expire with their signature
+	c.Header("Cache-Control", "private, max-age=60")
+	c.Data(http.StatusOK, constants.HLSContentType, rewritten)
+}
+
+// readPlaylist reads a playlist object from storage
+func readPlaylist(ctx context.Context, objectName string) ([]byte, error) {
+	reader, err := firebase.StorageClient.Bucket(firebase.StorageBucket).Object(objectName).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(io.LimitReader(reader, maxPlaylistSize))
+}
+
+// resolvePlaylistURI rewrites a URI found in the playlist stored at objectName.
+// Nested playlists stay relative and carry the token; segments and other media
+// get a signed URL of their storage object, which must be under prefix.
+func resolvePlaylistURI(ctx context.Context, prefix, objectName, uri, token string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.IsAbs() || strings.HasPrefix(parsed.Path, "/") {
+		return "", fmt.Errorf("unexpected playlist URI: %q", uri)
+	}
+
+	if path.Ext(parsed.Path) == ".m3u8" {
+		return parsed.Path + "?" + constants.MediaTokenParam + "=" + url.QueryEscape(token), nil
+	}
+
+	mediaObject := path.Join(path.Dir(objectName), parsed.Path)
+	if !strings.HasPrefix(mediaObject, prefix+"/") {
+		return "", fmt.Errorf("playlist URI outside of the stream: %q", uri)
+	}
+
+	// Players load a VOD playlist once, so its segment URLs must outlive playback
+	return utils.GenerateSignedObjectURL(ctx, mediaObject, constants.HLSSegmentExpiration)
+}
+
+// streamURL returns the tokenized master playlist URL of a transcoded video,
+// relative to the API host, or "" if the video is not ready to stream
+func streamURL(product string, resource models.Resource) string {
+	if resource.Transcode == nil || resource.Transcode.Status != constants.TranscodeStatusReady {
+		return ""
+	}
+
+	token := utils.SignMediaToken(product, resource.ID, time.Now().Add(constants.MediaTokenExpiration*time.Minute))
+	return fmt.Sprintf("/api/v1/%s/resources/%s/%s/%s?%s=%s",
+		product, resource.ID, constants.HLSPathSegment, constants.HLSMasterPlaylist, constants.MediaTokenParam, url.QueryEscape(token))
+}
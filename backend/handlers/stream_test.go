@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+func TestStreamURL(t *testing.T) {
+	resource := models.Resource{ID: "abc"}
+	assert.Empty(t, streamURL("ecomm", resource), "no stream without transcoding")
+
+	resource.Transcode = &models.Transcode{Status: constants.TranscodeStatusProcessing}
+	assert.Empty(t, streamURL("ecomm", resource), "no stream before transcoding is done")
+
+	resource.Transcode.Status = constants.TranscodeStatusReady
+	url := streamURL("ecomm", resource)
+	assert.True(t, strings.HasPrefix(url, "/api/v1/ecomm/resources/abc/hls/master.m3u8?token="), url)
+}
+
+func TestResolvePlaylistURI(t *testing.T) {
+	prefix := "ecomm/hls/abc/1"
+	master := prefix + "/master.m3u8"
+
+	resolved, err := resolvePlaylistURI(context.Background(), prefix, master, "720p/index.m3u8", "1.sig")
+	assert.NoError(t, err)
+	assert.Equal(t, "720p/index.m3u8?token=1.sig", resolved, "nested playlists come back with the token")
+
+	for _, uri := range []string{
+		"../../other/1/720p/segment_0000.ts",
+		"https://evil.example/segment.ts",
+		"/etc/passwd",
+	} {
+		_, err := resolvePlaylistURI(context.Background(), prefix, master, uri, "1.sig")
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestGetResourceStreamRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, query := range []string{"", "?token=", "?token=1.forged"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/ecomm/resources/abc/hls/master.m3u8"+query, nil)
+		c.Params = gin.Params{{Key: "id", Value: "abc"}, {Key: "file", Value: "/master.m3u8"}}
+		c.Set(constants.ProductContextKey, "ecomm")
+
+		GetResourceStream(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, query)
+	}
+}
@@ -0,0 +1,631 @@
+package jobs
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"learninghub/constants"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+)
+
+// claimBatchSize is how many due jobs a worker looks at when claiming one,
+// so workers racing for the oldest job can fall back to the next ones
+const claimBatchSize = 10
+
+var (
+	// ErrNotInitialized is returned by Enqueue before Initialize was called
+	ErrNotInitialized = errors.New("job queue is not initialized")
+
+	errNotClaimable = errors.New("job is not claimable")
+	errLeaseLost    = errors.New("job lease lost")
+)
+
+// Store persists jobs. Implemented by db.JobService.
+type Store interface {
+	// Create stores a new job and returns its ID
+	Create(ctx context.Context, job models.Job) (string, error)
+	// CreateWithID stores a new job under the given ID. It returns false,
+	// storing nothing, if a job with that ID exists.
+	CreateWithID(ctx context.Context, id string, job models.Job) (bool, error)
+	// Get retrieves a job by ID
+	Get(ctx context.Context, id string) (*models.Job, error)
+	// Due lists queued and running jobs whose AvailableAt is not after now,
+	// oldest first
+	Due(ctx context.Context, now time.Time, limit int) ([]models.Job, error)
+	// Update applies fn to the stored job atomically. When fn returns an
+	// error nothing is written and the error is returned. fn may be called
+	// more than once if the job is modified concurrently.
+	Update(ctx context.Context, id string, fn func(job *models.Job) error) error
+}
+
+// Handler processes a job. Failed attempts are retried with exponential
+// backoff, unless the error is wrapped with Permanent.
+type Handler func(ctx context.Context, job models.Job) error
+
+// Options tunes how the jobs of a type are run
+type Options struct {
+	MaxAttempts int           // Default: constants.JobDefaultMaxAttempts
+	Timeout     time.Duration // Per attempt. Default: constants.JobDefaultTimeout seconds
+}
+
+type registration struct {
+	handler Handler
+	options Options
+}
+
+// schedule enqueues a job periodically for each of its products, see
+// Queue.ScheduleEach
+type schedule struct {
+	products func() []string
+	jobType  string
+	payload  map[string]any
+	interval time.Duration
+	last     map[string]time.Time // Start of the latest period this instance enqueued, by product
+}
+
+// permanentError marks a job failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps an error so the job is dead-lettered instead of retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// FinalAttempt reports whether a failure of the running attempt of job
+// dead-letters it. Handlers use it to clean up state tracking the job.
+func FinalAttempt(job models.Job) bool {
+	return job.Attempts >= job.MaxAttempts
+}
+
+// DecodePayload decodes the payload of a job into v, the inverse of the
+// encoding done by Enqueue
+func DecodePayload(job models.Job, v any) error {
+	encoded, err := json.Marshal(job.Payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return Permanent(fmt.Errorf("invalid %s job payload: %w", job.Type, err))
+	}
+	return nil
+}
+
+// encodePayload converts a payload struct into the map stored with the job
+func encodePayload(payload any) (map[string]any, error) {
+	if payload == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, fmt.Errorf("job payload must be a JSON object: %w", err)
+	}
+	return decoded, nil
+}
+
+// Queue runs persisted jobs on a pool of workers.
+//
+// Workers claim due jobs by leasing them: a claimed job is reserved for
+// JobLeaseDuration and the lease is renewed while the handler runs. If an
+// instance dies, its leases expire and other workers pick the jobs up again,
+// so every job runs at least once. Handlers must therefore be idempotent.
+type Queue struct {
+	store     Store
+	handlers  map[string]registration
+	schedules []*schedule
+	wake      chan struct{}
+
+	pollInterval   time.Duration
+	leaseDuration  time.Duration
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+
+	stopClaiming  context.CancelFunc
+	cancelRunning context.CancelFunc
+	workers       sync.WaitGroup
+}
+
+// Default is the queue handlers enqueue jobs on, set by Initialize
+var Default *Queue
+
+// New creates a queue backed by store. Handlers must be registered before the
+// queue is started.
+func New(store Store) *Queue {
+	return &Queue{
+		store:          store,
+		handlers:       make(map[string]registration),
+		wake:           make(chan struct{}, 1),
+		pollInterval:   constants.JobPollInterval * time.Second,
+		leaseDuration:  constants.JobLeaseDuration * time.Second,
+		retryBaseDelay: constants.JobRetryBaseDelay * time.Second,
+		retryMaxDelay:  constants.JobRetryMaxDelay * time.Second,
+	}
+}
+
+// Initialize creates Default on top of store
+func Initialize(store Store) *Queue {
+	Default = New(store)
+	return Default
+}
+
+// Enqueue adds a job to Default, see Queue.Enqueue
+func Enqueue(ctx context.Context, product, jobType string, payload any) (string, error) {
+	if Default == nil {
+		return "", ErrNotInitialized
+	}
+	return Default.Enqueue(ctx, product, jobType, payload)
+}
+
+// Register sets the handler of a job type
+func (q *Queue) Register(jobType string, handler Handler, options Options) {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = constants.JobDefaultMaxAttempts
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = constants.JobDefaultTimeout * time.Second
+	}
+
+	q.handlers[jobType] = registration{handler: handler, options: options}
+}
+
+// Enqueue stores a job to be run as soon as a worker is free and returns its
+// ID. The payload must marshal to a JSON object; handlers read it back with
+// DecodePayload.
+func (q *Queue) Enqueue(ctx context.Context, product, jobType string, payload any) (string, error) {
+	registered, exists := q.handlers[jobType]
+	if !exists {
+		return "", fmt.Errorf("unknown job type: %s", jobType)
+	}
+
+	encoded, err := encodePayload(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s job payload: %w", jobType, err)
+	}
+
+	id, err := q.store.Create(ctx, newJob(product, jobType, encoded, registered.options, time.Now()))
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s job: %w", jobType, err)
+	}
+
+	q.wakeWorker()
+	return id, nil
+}
+
+// Schedule enqueues a job every interval while the queue is started, on
+// every instance. Periods are aligned on the Unix epoch and each period's job
+// gets an ID derived from it, so only the first instance to get there creates
+// it. Schedules must be added before the queue is started.
+func (q *Queue) Schedule(product, jobType string, payload any, interval time.Duration) error {
+	return q.ScheduleEach(func() []string { return []string{product} }, jobType, payload, interval)
+}
+
+// ScheduleEach schedules a job like Schedule for each product products
+// returns. It is called every time jobs are enqueued, so products added while
+// the queue runs, on any instance, get their jobs from the next poll on.
+func (q *Queue) ScheduleEach(products func() []string, jobType string, payload any, interval time.Duration) error {
+	if _, exists := q.handlers[jobType]; !exists {
+		return fmt.Errorf("unknown job type: %s", jobType)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("invalid %s job interval: %s", jobType, interval)
+	}
+
+	encoded, err := encodePayload(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s job payload: %w", jobType, err)
+	}
+
+	q.schedules = append(q.schedules, &schedule{
+		products: products,
+		jobType:  jobType,
+		payload:  encoded,
+		interval: interval,
+		last:     map[string]time.Time{},
+	})
+	return nil
+}
+
+// newJob returns a queued job, due at now
+func newJob(product, jobType string, payload map[string]any, options Options, now time.Time) models.Job {
+	return models.Job{
+		Product:     product,
+		Type:        jobType,
+		Payload:     payload,
+		Status:      constants.JobStatusQueued,
+		MaxAttempts: options.MaxAttempts,
+		AvailableAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// wakeWorker spares an idle local worker the wait for its next poll
+func (q *Queue) wakeWorker() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the workers. With zero workers this instance only enqueues
+// jobs and leaves them to other instances.
+func (q *Queue) Start(workers int) {
+	claimCtx, stopClaiming := context.WithCancel(context.Background())
+	runCtx, cancelRunning := context.WithCancel(context.Background())
+	q.stopClaiming, q.cancelRunning = stopClaiming, cancelRunning
+
+	for range workers {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			q.work(claimCtx, runCtx)
+		}()
+	}
+
+	if len(q.schedules) > 0 {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			q.runSchedules(claimCtx)
+		}()
+	}
+
+	logger.Infof("Job queue started with %d workers", workers)
+}
+
+// Stop stops claiming jobs and waits for the running ones to finish. Jobs
+// still running when ctx is done are cancelled and queued again without
+// counting the interrupted attempt.
+func (q *Queue) Stop(ctx context.Context) {
+	if q.stopClaiming == nil {
+		return
+	}
+	q.stopClaiming()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		q.cancelRunning()
+		<-done
+	}
+	q.cancelRunning()
+}
+
+// work claims and runs jobs until claimCtx is cancelled
+func (q *Queue) work(claimCtx, runCtx context.Context) {
+	for claimCtx.Err() == nil {
+		job, err := q.claim(claimCtx)
+		if err != nil && claimCtx.Err() == nil {
+			logger.Errorf("Failed to claim job: %v", err)
+		}
+
+		if job == nil {
+			// Spread the polls of the workers of all instances
+			wait := q.pollInterval/2 + rand.N(q.pollInterval)
+			select {
+			case <-claimCtx.Done():
+			case <-q.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		q.run(runCtx, job)
+	}
+}
+
+// runSchedules enqueues scheduled jobs until ctx is cancelled
+func (q *Queue) runSchedules(ctx context.Context) {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.enqueueScheduled(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueueScheduled creates the job of the current period of each schedule
+// and product, unless this or another instance already did
+func (q *Queue) enqueueScheduled(ctx context.Context, now time.Time) {
+	for _, s := range q.schedules {
+		period := now.Truncate(s.interval)
+		for _, product := range s.products() {
+			if !period.After(s.last[product]) {
+				continue
+			}
+
+			id := fmt.Sprintf("%s-%s-%d", s.jobType, product, period.Unix())
+			created, err := q.store.CreateWithID(ctx, id, newJob(product, s.jobType, s.payload, q.handlers[s.jobType].options, now))
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Errorf("Failed to store scheduled %s job %s: %v", s.jobType, id, err)
+				}
+				continue
+			}
+
+			s.last[product] = period
+			if created {
+				logger.Infof("Scheduled %s job %s", s.jobType, id)
+				q.wakeWorker()
+			}
+		}
+	}
+}
+
+// claim leases the oldest due job, if any. A running job shows up as due once
+// its lease expired, meaning its worker died: the lost attempt counts.
+func (q *Queue) claim(ctx context.Context) (*models.Job, error) {
+	now := time.Now()
+	due, err := q.store.Due(ctx, now, claimBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range due {
+		var (
+			claimed *models.Job
+			buried  string
+		)
+
+		err := q.store.Update(ctx, candidate.ID, func(job *models.Job) error {
+			claimed, buried = nil, ""
+
+			if (job.Status != constants.JobStatusQueued && job.Status != constants.JobStatusRunning) || job.AvailableAt.After(now) {
+				// Claimed or finished by another worker since it was listed
+				return errNotClaimable
+			}
+
+			if job.Status == constants.JobStatusRunning {
+				job.LastError = "attempt abandoned: its worker stopped renewing the lease"
+				if FinalAttempt(*job) {
+					bury(job, now)
+					buried = job.LastError
+					return nil
+				}
+			}
+
+			if _, exists := q.handlers[job.Type]; !exists {
+				job.LastError = fmt.Sprintf("unknown job type: %s", job.Type)
+				bury(job, now)
+				buried = job.LastError
+				return nil
+			}
+
+			job.Status = constants.JobStatusRunning
+			job.Attempts++
+			job.LeaseID = newLeaseID()
+			job.AvailableAt = now.Add(q.leaseDuration)
+			job.UpdatedAt = now
+
+			leased := *job
+			claimed = &leased
+			return nil
+		})
+		if errors.Is(err, errNotClaimable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if claimed != nil {
+			claimed.ID = candidate.ID
+			return claimed, nil
+		}
+		if buried != "" {
+			logger.Errorf("Job %s (%s) is dead: %s", candidate.ID, candidate.Type, buried)
+		}
+	}
+
+	return nil, nil
+}
+
+// run runs a claimed job and records its outcome
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	options := q.handlers[job.Type].options
+
+	attemptCtx, cancelAttempt := context.WithTimeout(ctx, options.Timeout)
+	defer cancelAttempt()
+
+	stopRenewal := q.renewLease(attemptCtx, cancelAttempt, job)
+	err := q.call(attemptCtx, job)
+	leaseLost := stopRenewal()
+
+	if leaseLost {
+		// Another worker owns the job now, the outcome is theirs to record
+		logger.Warnf("Job %s (%s) lost its lease, outcome discarded: %v", job.ID, job.Type, err)
+		return
+	}
+
+	// Recording the outcome must survive the shutdown that may have cancelled ctx
+	if releaseErr := q.release(context.WithoutCancel(ctx), job, err, ctx.Err() != nil); releaseErr != nil {
+		logger.Errorf("Failed to record outcome of job %s (%s): %v", job.ID, job.Type, releaseErr)
+	}
+}
+
+// call runs the job's handler, turning panics into errors
+func (q *Queue) call(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.Errorf("Job %s (%s) panicked: %v\n%s", job.ID, job.Type, recovered, debug.Stack())
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return q.handlers[job.Type].handler(ctx, *job)
+}
+
+// renewLease keeps a running job leased until the returned stop function is
+// called. If the lease is lost the attempt is cancelled; stop reports it.
+func (q *Queue) renewLease(ctx context.Context, cancelAttempt context.CancelFunc, job *models.Job) (stop func() bool) {
+	done := make(chan struct{})
+	var lost bool
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(q.leaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			err := q.store.Update(context.WithoutCancel(ctx), job.ID, func(stored *models.Job) error {
+				if stored.Status != constants.JobStatusRunning || stored.LeaseID != job.LeaseID {
+					return errLeaseLost
+				}
+				stored.AvailableAt = time.Now().Add(q.leaseDuration)
+				return nil
+			})
+			if errors.Is(err, errLeaseLost) {
+				lost = true
+				cancelAttempt()
+				return
+			}
+			if err != nil {
+				// Transient; the lease is still valid until its expiry
+				logger.Warnf("Failed to renew lease of job %s (%s): %v", job.ID, job.Type, err)
+			}
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		wg.Wait()
+		return lost
+	}
+}
+
+// release records the outcome of an attempt: success, a retry after a
+// backoff, or the dead-letter state once attempts are exhausted
+func (q *Queue) release(ctx context.Context, job *models.Job, handlerErr error, interrupted bool) error {
+	var outcome string
+
+	err := q.store.Update(ctx, job.ID, func(stored *models.Job) error {
+		if stored.Status != constants.JobStatusRunning || stored.LeaseID != job.LeaseID {
+			return errLeaseLost
+		}
+
+		now := time.Now()
+		stored.LeaseID = ""
+		stored.UpdatedAt = now
+
+		var permanent permanentError
+		switch {
+		case handlerErr == nil:
+			stored.Status = constants.JobStatusSucceeded
+			stored.LastError = ""
+			finish(stored, now)
+			outcome = "succeeded"
+
+		case interrupted:
+			// Shutting down: hand the job over without charging the attempt
+			stored.Status = constants.JobStatusQueued
+			stored.Attempts--
+			stored.AvailableAt = now
+			stored.LastError = "interrupted by shutdown"
+			outcome = "interrupted"
+
+		case errors.As(handlerErr, &permanent) || FinalAttempt(*stored):
+			stored.LastError = handlerErr.Error()
+			bury(stored, now)
+			outcome = "dead"
+
+		default:
+			stored.Status = constants.JobStatusQueued
+			stored.AvailableAt = now.Add(q.backoff(stored.Attempts))
+			stored.LastError = handlerErr.Error()
+			outcome = "retry"
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch outcome {
+	case "dead":
+		logger.Errorf("Job %s (%s) is dead after %d attempts: %v", job.ID, job.Type, job.Attempts, handlerErr)
+	case "retry":
+		logger.Warnf("Job %s (%s) attempt %d failed, will retry: %v", job.ID, job.Type, job.Attempts, handlerErr)
+	}
+	return nil
+}
+
+// backoff returns the delay before retrying a job that failed attempt
+// number attempts, doubling from retryBaseDelay up to retryMaxDelay, with
+// jitter so jobs failing together do not retry together
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.retryMaxDelay
+	if shift := attempts - 1; shift < 32 {
+		delay = min(q.retryBaseDelay<<shift, q.retryMaxDelay)
+	}
+	return delay + rand.N(delay/10+1)
+}
+
+// bury dead-letters a job: it stays in the store for inspection
+func bury(job *models.Job, now time.Time) {
+	job.Status = constants.JobStatusDead
+	job.LeaseID = ""
+	job.UpdatedAt = now
+	finish(job, now)
+}
+
+// finish stamps a job that reached a final status
+func finish(job *models.Job, now time.Time) {
+	expireAt := now.Add(constants.JobRetention * 24 * time.Hour)
+	job.FinishedAt = &now
+	job.ExpireAt = &expireAt
+}
+
+// newLeaseID returns a random identifier for one attempt of a job
+func newLeaseID() string {
+	b := make([]byte, 12)
+	cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
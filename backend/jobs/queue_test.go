@@ -0,0 +1,500 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// memStore is an in-memory Store
+type memStore struct {
+	mu     sync.Mutex
+	jobs   map[string]models.Job
+	nextID int
+}
+
+func newMemStore() *memStore {
+	return &memStore{jobs: make(map[string]models.Job)}
+}
+
+func (s *memStore) Create(_ context.Context, job models.Job) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	id := fmt.Sprintf("job-%d", s.nextID)
+	s.jobs[id] = job
+	return id, nil
+}
+
+func (s *memStore) CreateWithID(_ context.Context, id string, job models.Job) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[id]; exists {
+		return false, nil
+	}
+	s.jobs[id] = job
+	return true, nil
+}
+
+func (s *memStore) Get(_ context.Context, id string) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	job.ID = id
+	return &job, nil
+}
+
+func (s *memStore) Due(_ context.Context, now time.Time, limit int) ([]models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.Job
+	for id, job := range s.jobs {
+		if (job.Status == constants.JobStatusQueued || job.Status == constants.JobStatusRunning) && !job.AvailableAt.After(now) {
+			job.ID = id
+			due = append(due, job)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].AvailableAt.Before(due[j].AvailableAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *memStore) Update(_ context.Context, id string, fn func(job *models.Job) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return fmt.Errorf("job %s not found", id)
+	}
+	if err := fn(&job); err != nil {
+		return err
+	}
+	s.jobs[id] = job
+	return nil
+}
+
+// get returns a stored job, failing the test if it does not exist
+func (s *memStore) get(t *testing.T, id string) models.Job {
+	t.Helper()
+	job, err := s.Get(context.Background(), id)
+	require.NoError(t, err)
+	return *job
+}
+
+// newTestQueue returns a queue on a fresh memStore with short delays
+func newTestQueue() (*Queue, *memStore) {
+	store := newMemStore()
+	q := New(store)
+	q.pollInterval = 10 * time.Millisecond
+	q.leaseDuration = time.Minute
+	q.retryBaseDelay = time.Minute
+	return q, store
+}
+
+// runNext claims and runs the next due job, returning its ID
+func runNext(t *testing.T, q *Queue) string {
+	t.Helper()
+	job, err := q.claim(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, job, "expected a due job")
+	q.run(context.Background(), job)
+	return job.ID
+}
+
+type testPayload struct {
+	ResourceID string   `json:"resourceId"`
+	URLs       []string `json:"urls"`
+}
+
+func TestEnqueueAndRun(t *testing.T) {
+	q, store := newTestQueue()
+
+	var received testPayload
+	q.Register("test", func(_ context.Context, job models.Job) error {
+		assert.Equal(t, "ecomm", job.Product)
+		return DecodePayload(job, &received)
+	}, Options{})
+
+	id, err := q.Enqueue(context.Background(), "ecomm", "test", testPayload{ResourceID: "abc", URLs: []string{"a", "b"}})
+	require.NoError(t, err)
+
+	job := store.get(t, id)
+	assert.Equal(t, constants.JobStatusQueued, job.Status)
+	assert.Equal(t, constants.JobDefaultMaxAttempts, job.MaxAttempts)
+
+	assert.Equal(t, id, runNext(t, q))
+	assert.Equal(t, testPayload{ResourceID: "abc", URLs: []string{"a", "b"}}, received)
+
+	job = store.get(t, id)
+	assert.Equal(t, constants.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Empty(t, job.LeaseID)
+	assert.NotNil(t, job.FinishedAt)
+	assert.NotNil(t, job.ExpireAt)
+
+	_, err = q.Enqueue(context.Background(), "ecomm", "unregistered", nil)
+	assert.Error(t, err)
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	q, store := newTestQueue()
+
+	attempts := 0
+	q.Register("flaky", func(context.Context, models.Job) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}, Options{})
+
+	id, err := q.Enqueue(context.Background(), "ecomm", "flaky", nil)
+	require.NoError(t, err)
+
+	before := time.Now()
+	runNext(t, q)
+
+	job := store.get(t, id)
+	assert.Equal(t, constants.JobStatusQueued, job.Status)
+	assert.Equal(t, "temporary failure", job.LastError)
+	assert.True(t, job.AvailableAt.After(before.Add(time.Minute-time.Second)), "retry is delayed by the backoff")
+
+	next, err := q.claim(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, next, "the job is not due before its backoff elapsed")
+
+	// Fast-forward the backoff
+	store.Update(context.Background(), id, func(job *models.Job) error {
+		job.AvailableAt = time.Now()
+		return nil
+	})
+	runNext(t, q)
+
+	job = store.get(t, id)
+	assert.Equal(t, constants.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Empty(t, job.LastError)
+}
+
+func TestDeadLetter(t *testing.T) {
+	t.Run("attempts exhausted", func(t *testing.T) {
+		q, store := newTestQueue()
+		q.retryBaseDelay = 0
+
+		q.Register("failing", func(_ context.Context, job models.Job) error {
+			return fmt.Errorf("failure %d", job.Attempts)
+		}, Options{MaxAttempts: 3})
+
+		id, err := q.Enqueue(context.Background(), "ecomm", "failing", nil)
+		require.NoError(t, err)
+
+		for range 3 {
+			runNext(t, q)
+		}
+
+		job := store.get(t, id)
+		assert.Equal(t, constants.JobStatusDead, job.Status)
+		assert.Equal(t, 3, job.Attempts)
+		assert.Equal(t, "failure 3", job.LastError)
+
+		next, err := q.claim(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, next, "dead jobs are not retried")
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		q, store := newTestQueue()
+		q.Register("invalid", func(context.Context, models.Job) error {
+			return Permanent(errors.New("resource is gone"))
+		}, Options{})
+
+		id, err := q.Enqueue(context.Background(), "ecomm", "invalid", nil)
+		require.NoError(t, err)
+		runNext(t, q)
+
+		job := store.get(t, id)
+		assert.Equal(t, constants.JobStatusDead, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Equal(t, "resource is gone", job.LastError)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		q, store := newTestQueue()
+		q.Register("panicking", func(context.Context, models.Job) error {
+			panic("boom")
+		}, Options{MaxAttempts: 1})
+
+		id, err := q.Enqueue(context.Background(), "ecomm", "panicking", nil)
+		require.NoError(t, err)
+		runNext(t, q)
+
+		job := store.get(t, id)
+		assert.Equal(t, constants.JobStatusDead, job.Status)
+		assert.Contains(t, job.LastError, "boom")
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		q, store := newTestQueue()
+		id, _ := store.Create(context.Background(), models.Job{Type: "retired", Status: constants.JobStatusQueued, MaxAttempts: 5})
+
+		job, err := q.claim(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, job)
+		assert.Equal(t, constants.JobStatusDead, store.get(t, id).Status)
+	})
+}
+
+func TestExpiredLeaseIsReclaimed(t *testing.T) {
+	q, store := newTestQueue()
+
+	runs := 0
+	q.Register("test", func(context.Context, models.Job) error {
+		runs++
+		return nil
+	}, Options{MaxAttempts: 2})
+
+	// Left running by a worker that died
+	abandoned := models.Job{
+		Type:        "test",
+		Status:      constants.JobStatusRunning,
+		Attempts:    1,
+		MaxAttempts: 2,
+		LeaseID:     "dead-worker",
+		AvailableAt: time.Now().Add(-time.Second),
+	}
+	id, _ := store.Create(context.Background(), abandoned)
+
+	runNext(t, q)
+	assert.Equal(t, 1, runs)
+	job := store.get(t, id)
+	assert.Equal(t, constants.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts, "the abandoned attempt counts")
+
+	// An abandoned final attempt dead-letters the job instead
+	abandoned.Attempts = 2
+	id, _ = store.Create(context.Background(), abandoned)
+
+	next, err := q.claim(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, constants.JobStatusDead, store.get(t, id).Status)
+	assert.Equal(t, 1, runs)
+
+	// A running job with a valid lease is left alone
+	abandoned.Attempts = 1
+	abandoned.AvailableAt = time.Now().Add(time.Minute)
+	store.Create(context.Background(), abandoned)
+
+	next, err = q.claim(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestLostLeaseCancelsAttempt(t *testing.T) {
+	q, store := newTestQueue()
+	q.leaseDuration = 30 * time.Millisecond
+
+	started := make(chan string)
+	q.Register("slow", func(ctx context.Context, job models.Job) error {
+		started <- job.ID
+		<-ctx.Done()
+		return ctx.Err()
+	}, Options{})
+
+	id, err := q.Enqueue(context.Background(), "ecomm", "slow", nil)
+	require.NoError(t, err)
+
+	job, err := q.claim(context.Background())
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		q.run(context.Background(), job)
+		close(done)
+	}()
+	<-started
+
+	// Another worker takes the job over
+	store.Update(context.Background(), id, func(job *models.Job) error {
+		job.LeaseID = "other-worker"
+		return nil
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("attempt was not cancelled after losing its lease")
+	}
+
+	stored := store.get(t, id)
+	assert.Equal(t, constants.JobStatusRunning, stored.Status, "the new owner records the outcome")
+	assert.Equal(t, "other-worker", stored.LeaseID)
+}
+
+func TestStartAndStop(t *testing.T) {
+	q, store := newTestQueue()
+
+	processed := make(chan string, 1)
+	started := make(chan struct{})
+	q.Register("quick", func(_ context.Context, job models.Job) error {
+		processed <- job.ID
+		return nil
+	}, Options{})
+	q.Register("blocking", func(ctx context.Context, _ models.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, Options{})
+
+	q.Start(1)
+
+	id, err := q.Enqueue(context.Background(), "ecomm", "quick", nil)
+	require.NoError(t, err)
+
+	select {
+	case processedID := <-processed:
+		assert.Equal(t, id, processedID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not processed")
+	}
+
+	id, err = q.Enqueue(context.Background(), "ecomm", "blocking", nil)
+	require.NoError(t, err)
+	<-started
+
+	// Stop without waiting for the running job
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Stop(ctx)
+
+	job := store.get(t, id)
+	assert.Equal(t, constants.JobStatusQueued, job.Status, "interrupted jobs are handed over")
+	assert.Equal(t, 0, job.Attempts, "the interrupted attempt is not charged")
+	assert.Empty(t, job.LeaseID)
+}
+
+func TestSchedule(t *testing.T) {
+	q, store := newTestQueue()
+	q.Register("gc", func(context.Context, models.Job) error { return nil }, Options{MaxAttempts: 2})
+
+	require.NoError(t, q.Schedule("ecomm", "gc", testPayload{ResourceID: "abc"}, time.Hour))
+	assert.Error(t, q.Schedule("ecomm", "unregistered", nil, time.Hour))
+	assert.Error(t, q.Schedule("ecomm", "gc", nil, 0))
+
+	// Another instance sharing the store
+	other := New(store)
+	other.Register("gc", func(context.Context, models.Job) error { return nil }, Options{})
+	require.NoError(t, other.Schedule("ecomm", "gc", testPayload{ResourceID: "abc"}, time.Hour))
+
+	now := time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)
+	q.enqueueScheduled(context.Background(), now)
+	other.enqueueScheduled(context.Background(), now)
+	q.enqueueScheduled(context.Background(), now.Add(20*time.Minute))
+	require.Len(t, store.jobs, 1, "one job per period across instances")
+
+	id := fmt.Sprintf("gc-ecomm-%d", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC).Unix())
+	job := store.get(t, id)
+	assert.Equal(t, constants.JobStatusQueued, job.Status)
+	assert.Equal(t, 2, job.MaxAttempts)
+	assert.Equal(t, map[string]any{"resourceId": "abc", "urls": nil}, job.Payload)
+
+	q.enqueueScheduled(context.Background(), now.Add(time.Hour))
+	assert.Len(t, store.jobs, 2, "the next period gets its own job")
+}
+
+func TestScheduleEach(t *testing.T) {
+	q, store := newTestQueue()
+	q.Register("gc", func(context.Context, models.Job) error { return nil }, Options{})
+
+	products := []string{"ecomm"}
+	require.NoError(t, q.ScheduleEach(func() []string { return products }, "gc", nil, time.Hour))
+
+	now := time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)
+	period := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC).Unix()
+	q.enqueueScheduled(context.Background(), now)
+	require.Len(t, store.jobs, 1)
+
+	// A product created while the queue runs gets the job of the current period
+	products = append(products, "staging")
+	q.enqueueScheduled(context.Background(), now.Add(time.Minute))
+	require.Len(t, store.jobs, 2)
+	assert.Equal(t, "staging", store.get(t, fmt.Sprintf("gc-staging-%d", period)).Product)
+	assert.Equal(t, "ecomm", store.get(t, fmt.Sprintf("gc-ecomm-%d", period)).Product)
+
+	q.enqueueScheduled(context.Background(), now.Add(time.Hour))
+	assert.Len(t, store.jobs, 4, "the next period gets a job per product")
+}
+
+func TestBackoff(t *testing.T) {
+	q, _ := newTestQueue()
+	q.retryBaseDelay = 30 * time.Second
+	q.retryMaxDelay = time.Hour
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempts), func(t *testing.T) {
+			delay := q.backoff(tt.attempts)
+			assert.GreaterOrEqual(t, delay, tt.want)
+			assert.LessOrEqual(t, delay, tt.want+tt.want/10, "jitter is at most 10%")
+		})
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	encoded, err := encodePayload(testPayload{ResourceID: "abc"})
+	require.NoError(t, err)
+
+	var decoded testPayload
+	assert.NoError(t, DecodePayload(models.Job{Payload: encoded}, &decoded))
+	assert.Equal(t, "abc", decoded.ResourceID)
+
+	var mismatched struct {
+		ResourceID int `json:"resourceId"`
+	}
+	err = DecodePayload(models.Job{Type: "test", Payload: encoded}, &mismatched)
+	var permanent permanentError
+	assert.ErrorAs(t, err, &permanent, "a malformed payload cannot be fixed by retrying")
+
+	_, err = encodePayload([]string{"not", "an", "object"})
+	assert.Error(t, err)
+}
+
+func TestEnqueueWithoutDefault(t *testing.T) {
+	original := Default
+	defer func() { Default = original }()
+	Default = nil
+
+	_, err := Enqueue(context.Background(), "ecomm", "test", nil)
+	assert.ErrorIs(t, err, ErrNotInitialized)
+}
@@ -11,10 +11,14 @@ import (
 
 	"learninghub/config"
 	"learninghub/constants"
+	"learninghub/db"
 	"learninghub/firebase"
 	"learninghub/handlers"
+	"learninghub/jobs"
 	"learninghub/middleware"
 	logger "learninghub/pkg/logger"
+	"learninghub/products"
+	"learninghub/scanner"
 	"learninghub/utils"
 )
 
@@ -40,7 +44,7 @@ func main() {
 	// Populate AppConfig with env variables
 	err := config.LoadConfig()
 	if err != nil {
-		logger.Infof("Error loading configuration: %v", err)
+		logger.Fatalf("Error loading configuration: %v", err)
 	}
 
 	// Initialize Firebase
@@ -49,6 +53,25 @@ func main() {
 		logger.Infof("Failed to initialize Firebase: %v", err)
 	}
 
+	// Load the product registry, registering the products of VALID_PRODUCTS.
+	// Until it loads, only those are served.
+	if _, err := products.Initialize(signalCtx, db.NewProductService(db.New()), config.AppConfig.VALID_PRODUCTS); err != nil {
+		logger.Errorf("Failed to load products, serving VALID_PRODUCTS: %v", err)
+	}
+
+	// Initialize the malware scanner used for uploads
+	if err := scanner.Initialize(); err != nil {
+		logger.Fatalf("Failed to initialize malware scanner: %v", err)
+	}
+
+	// Start the background job workers
+	jobQueue := jobs.Initialize(db.NewJobService(db.New()))
+	handlers.RegisterJobHandlers(jobQueue)
+	if err := handlers.ScheduleJobs(jobQueue); err != nil {
+		logger.Errorf("Failed to schedule jobs: %v", err)
+	}
+	jobQueue.Start(config.AppConfig.JOB_WORKERS)
+
 	// Setup Gin router
 	r := setupRouter()
 	port := config.AppConfig.PORT
@@ -85,6 +108,12 @@ func main() {
 		logger.Fatalf("Server forced to shutdown: %v\n", err)
 	}
 
+	// Give running jobs a moment to finish, the others are handed over to other instances
+	logger.Infof("Stopping job workers...")
+	jobsCtx, jobsCtxStop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer jobsCtxStop()
+	jobQueue.Stop(jobsCtx)
+
 	logger.Infof("Closing Firebase connections...")
 	firebase.CloseFirebase()
 
@@ -112,16 +141,52 @@ func setupRouter() *gin.Engine {
 	// /api/v1/:product/resources
 	api := r.Group("/api/v1")
 	{
+		// Product registry, see the products package
+		api.GET("/products", handlers.GetProducts)
+		api.POST("/products", middleware.AdminAuthMiddleware(), handlers.CreateProduct)
+		api.POST("/products/:product/archive", middleware.AdminAuthMiddleware(), handlers.ArchiveProduct)
+
 		// Product-specific routes
 		productGroup := api.Group("/:product", middleware.ProductValidationMiddleware())
 		{
-			productGroup.GET("/resources", handlers.GetResources)
-			productGroup.GET("/resources/:id", handlers.GetResource)
+			// Signed in users see which resources they bookmarked
+			productGroup.GET("/resources", middleware.OptionalUserAuthMiddleware(), handlers.GetResources)
+			productGroup.GET("/resources/:id", middleware.OptionalUserAuthMiddleware(), handlers.GetResource)
 			productGroup.POST("/resources", handlers.CreateResource)
+			productGroup.POST("/resources/bulk", handlers.BulkResources)
+			productGroup.POST("/resources/import", handlers.ImportResourcesCSV)
 			productGroup.PATCH("/resources/:id", handlers.UpdateResource)
 			productGroup.DELETE("/resources/:id", handlers.DeleteResource)
+			// Copies and moves need the admin key or the ID token of an owner of the target product
+			productGroup.POST("/resources/:id/copy", middleware.OptionalUserAuthMiddleware(), handlers.CopyResource)
+			productGroup.POST("/resources/:id/move", middleware.OptionalUserAuthMiddleware(), handlers.MoveResource)
+			productGroup.GET("/resources/:id/image", handlers.GetResourceImage)
+			productGroup.GET("/resources/:id/hls/*file", handlers.GetResourceStream)
 
 			productGroup.GET("/tags", handlers.GetTags)
+
+			productGroup.GET("/fields", handlers.GetFields)
+			productGroup.PUT("/fields/:name", handlers.SetField)
+			productGroup.DELETE("/fields/:name", handlers.DeleteField)
+
+			productGroup.GET("/collections", handlers.GetCollections)
+			productGroup.GET("/collections/:id", handlers.GetCollection)
+			productGroup.POST("/collections", handlers.CreateCollection)
+			productGroup.PUT("/collections/:id", handlers.UpdateCollection)
+			productGroup.POST("/collections/:id/reorder", handlers.ReorderCollection)
+			productGroup.DELETE("/collections/:id", handlers.DeleteCollection)
+
+			// Progress and bookmarks of the signed in user, see middleware.UserAuthMiddleware
+			productGroup.PUT("/progress/:resourceId", middleware.UserAuthMiddleware(), handlers.UpdateProgress)
+			productGroup.GET("/me/progress", middleware.UserAuthMiddleware(), handlers.GetMyProgress)
+			productGroup.GET("/me/bookmarks", middleware.UserAuthMiddleware(), handlers.GetMyBookmarks)
+			productGroup.POST("/me/bookmarks/:resourceId", middleware.UserAuthMiddleware(), handlers.AddBookmark)
+			productGroup.DELETE("/me/bookmarks/:resourceId", middleware.UserAuthMiddleware(), handlers.RemoveBookmark)
+
+			productGroup.GET("/jobs/:id", handlers.GetJob)
+
+			productGroup.GET("/export", middleware.AdminAuthMiddleware(), handlers.ExportCatalog)
+			productGroup.POST("/import", middleware.AdminAuthMiddleware(), handlers.ImportCatalog)
 		}
 	}
 
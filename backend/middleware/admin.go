@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/errors"
+)
+
+// AdminAuthMiddleware restricts routes to requests sending ADMIN_API_KEY in
+// the X-Admin-Key header. Without a configured key, the routes are disabled.
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := config.AppConfig.ADMIN_API_KEY
+		if key == "" {
+			errors.AbortWithError(c, errors.ErrForbidden, "Admin endpoints are disabled")
+			return
+		}
+
+		provided := c.GetHeader(constants.HeaderAdminKey)
+		if provided == "" {
+			errors.AbortWithError(c, errors.ErrUnauthorized, "Missing admin key")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			errors.AbortWithError(c, errors.ErrUnauthorized, "Invalid admin key")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether a request sends the configured ADMIN_API_KEY, for
+// routes open to others that admins may use without restrictions
+func IsAdmin(c *gin.Context) bool {
+	key := config.AppConfig.ADMIN_API_KEY
+	provided := c.GetHeader(constants.HeaderAdminKey)
+	return key != "" && provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/errors"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		configuredKey string
+		providedKey   string
+		expectedError errors.ErrorCode
+	}{
+		{name: "valid key", configuredKey: "secret", providedKey: "secret"},
+		{name: "disabled", configuredKey: "", providedKey: "secret", expectedError: errors.ErrForbidden},
+		{name: "missing key", configuredKey: "secret", providedKey: "", expectedError: errors.ErrUnauthorized},
+		{name: "invalid key", configuredKey: "secret", providedKey: "secreT", expectedError: errors.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.EnvConfig{ADMIN_API_KEY: tt.configuredKey}
+
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			nextCalled := false
+			r.POST("/products", AdminAuthMiddleware(), func(c *gin.Context) {
+				nextCalled = true
+				c.Status(http.StatusCreated)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/products", nil)
+			if tt.providedKey != "" {
+				req.Header.Set(constants.HeaderAdminKey, tt.providedKey)
+			}
+			r.ServeHTTP(w, req)
+
+			if tt.expectedError == "" {
+				assert.True(t, nextCalled)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				return
+			}
+
+			assert.False(t, nextCalled)
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedError, response.Error)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	stdErrors "errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/firebase"
+	"learninghub/pkg/logger"
+)
+
+// VerifyIDToken verifies a Firebase ID token and returns the ID of its user
+// and their email, left empty unless verified. Replaced in tests.
+var VerifyIDToken = func(ctx context.Context, token string) (string, string, error) {
+	if firebase.AuthClient == nil {
+		return "", "", stdErrors.New("authentication is not initialized")
+	}
+	verified, err := firebase.AuthClient.VerifyIDToken(ctx, token)
+	if err != nil {
+		return "", "", err
+	}
+
+	email, _ := verified.Claims["email"].(string)
+	if emailVerified, _ := verified.Claims["email_verified"].(bool); !emailVerified {
+		email = ""
+	}
+	return verified.UID, email, nil
+}
+
+// UserAuthMiddleware restricts routes to signed in users, sending their
+// Firebase ID token as a bearer token, and adds their ID to the context
+func UserAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticateUser(c, true) {
+			c.Next()
+		}
+	}
+}
+
+// OptionalUserAuthMiddleware adds the ID of signed in users to the context
+// like UserAuthMiddleware, and lets anonymous requests through. Invalid ID
+// tokens are still rejected, as their users expect to be signed in.
+func OptionalUserAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticateUser(c, false) {
+			c.Next()
+		}
+	}
+}
+
+// authenticateUser verifies the ID token of a request and adds the ID and
+// verified email of its user to the context. It aborts with an error and
+// returns false if the token is invalid, or missing and required.
+func authenticateUser(c *gin.Context, required bool) bool {
+	header := c.GetHeader(constants.HeaderAuthorization)
+	if header == "" && !required {
+		return true
+	}
+
+	token, found := strings.CutPrefix(header, constants.BearerPrefix)
+	if !found || strings.TrimSpace(token) == "" {
+		errors.AbortWithError(c, errors.ErrUnauthorized, "Missing ID token")
+		return false
+	}
+
+	userID, email, err := VerifyIDToken(c.Request.Context(), strings.TrimSpace(token))
+	if err != nil {
+		logger.Infof("Rejected ID token: %v", err)
+		errors.AbortWithError(c, errors.ErrUnauthorized, "Invalid ID token")
+		return false
+	}
+
+	c.Set(constants.UserContextKey, userID)
+	if email != "" {
+		c.Set(constants.UserEmailContextKey, email)
+	}
+	return true
+}
+
+// GetUserFromContext extracts the ID of the signed in user from gin context
+func GetUserFromContext(c *gin.Context) (string, bool) {
+	userID, ok := c.Get(constants.UserContextKey)
+	if !ok {
+		return "", false
+	}
+
+	id, ok := userID.(string)
+	return id, ok && id != ""
+}
+
+// GetUserEmailFromContext extracts the verified email of the signed in user
+// from gin context
+func GetUserEmailFromContext(c *gin.Context) (string, bool) {
+	email, ok := c.Get(constants.UserEmailContextKey)
+	if !ok {
+		return "", false
+	}
+
+	address, ok := email.(string)
+	return address, ok && address != ""
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/errors"
+)
+
+// verifyTestTokens accepts "valid-token", of user-1 with a verified email,
+// and "unverified-token", of user-2 without one, until restored
+func verifyTestTokens() (restore func()) {
+	originalVerify := VerifyIDToken
+	VerifyIDToken = func(_ context.Context, token string) (string, string, error) {
+		switch token {
+		case "valid-token":
+			return "user-1", "user-1@example.com", nil
+		case "unverified-token":
+			return "user-2", "", nil
+		}
+		return "", "", stdErrors.New("token has expired")
+	}
+	return func() {
+		VerifyIDToken = originalVerify
+	}
+}
+
+func TestUserAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer verifyTestTokens()()
+
+	tests := []struct {
+		name          string
+		authorization string
+		expectedError errors.ErrorCode
+	}{
+		{name: "valid token", authorization: "Bearer valid-token"},
+		{name: "missing header", authorization: "", expectedError: errors.ErrUnauthorized},
+		{name: "not a bearer token", authorization: "Basic dXNlcjpwYXNz", expectedError: errors.ErrUnauthorized},
+		{name: "empty token", authorization: "Bearer  ", expectedError: errors.ErrUnauthorized},
+		{name: "invalid token", authorization: "Bearer expired-token", expectedError: errors.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			var userID, email string
+			r.GET("/me/progress", UserAuthMiddleware(), func(c *gin.Context) {
+				userID, _ = GetUserFromContext(c)
+				email, _ = GetUserEmailFromContext(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me/progress", nil)
+			if tt.authorization != "" {
+				req.Header.Set(constants.HeaderAuthorization, tt.authorization)
+			}
+			r.ServeHTTP(w, req)
+
+			if tt.expectedError == "" {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "user-1", userID)
+				assert.Equal(t, "user-1@example.com", email)
+				return
+			}
+
+			assert.Empty(t, userID)
+			assert.Equal(t, errors.GetHTTPStatus(tt.expectedError), w.Code)
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedError, response.Error)
+		})
+	}
+}
+
+func TestOptionalUserAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer verifyTestTokens()()
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedUser   string
+		expectedEmail  string
+		expectedStatus int
+	}{
+		{name: "valid token", authorization: "Bearer valid-token", expectedUser: "user-1", expectedEmail: "user-1@example.com", expectedStatus: http.StatusOK},
+		{name: "unverified email", authorization: "Bearer unverified-token", expectedUser: "user-2", expectedStatus: http.StatusOK},
+		{name: "anonymous", authorization: "", expectedStatus: http.StatusOK},
+		{name: "not a bearer token", authorization: "Basic dXNlcjpwYXNz", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer expired-token", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			var userID, email string
+			r.GET("/resources", OptionalUserAuthMiddleware(), func(c *gin.Context) {
+				userID, _ = GetUserFromContext(c)
+				email, _ = GetUserEmailFromContext(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/resources", nil)
+			if tt.authorization != "" {
+				req.Header.Set(constants.HeaderAuthorization, tt.authorization)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedUser, userID)
+			assert.Equal(t, tt.expectedEmail, email)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Bookmark is a resource a user saved for later
+type Bookmark struct {
+	UserID     string    `json:"-" firestore:"userId"`
+	ResourceID string    `json:"resourceId" firestore:"resourceId"`
+	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
+}
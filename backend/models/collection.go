@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Collection is a learning path: resources of a product in the order they
+// are meant to be followed, grouped under optional section headings
+type Collection struct {
+	ID            string           `json:"id" firestore:"-"`
+	Title         string           `json:"title" firestore:"title"`
+	Description   string           `json:"description" firestore:"description"`
+	CoverImageURL string           `json:"coverImageUrl,omitempty" firestore:"coverImageUrl,omitempty"`
+	Items         []CollectionItem `json:"items" firestore:"items"`
+	ResourceIDs   []string         `json:"-" firestore:"resourceIds"` // IDs of the resource items, to find the collections referencing a resource
+	CreatedAt     time.Time        `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// CollectionItem is either a resource or a section heading, which groups the
+// resources following it up to the next heading
+type CollectionItem struct {
+	ResourceID string    `json:"resourceId,omitempty" firestore:"resourceId,omitempty"`
+	Heading    string    `json:"heading,omitempty" firestore:"heading,omitempty"`
+	Resource   *Resource `json:"resource,omitempty" firestore:"-"` // Set in responses of a single collection
+}
+
+// IndexResources sets the resource IDs of a collection from its items
+func (c *Collection) IndexResources() {
+	c.ResourceIDs = []string{}
+	for _, item := range c.Items {
+		if item.ResourceID != "" {
+			c.ResourceIDs = append(c.ResourceIDs, item.ResourceID)
+		}
+	}
+}
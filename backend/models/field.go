@@ -0,0 +1,12 @@
+package models
+
+// FieldDefinition describes a custom field of a product's resources. Values
+// are stored in Resource.CustomFields under the field's name.
+type FieldDefinition struct {
+	Name        string   `json:"name" firestore:"name"`
+	Label       string   `json:"label,omitempty" firestore:"label,omitempty"`             // Shown in forms instead of the name
+	Description string   `json:"description,omitempty" firestore:"description,omitempty"` // Help text
+	Type        string   `json:"type" firestore:"type"`                                   // "string" | "number" | "boolean" | "enum"
+	Required    bool     `json:"required" firestore:"required"`                           // Whether every created resource must set it
+	Values      []string `json:"values,omitempty" firestore:"values,omitempty"`           // Allowed values of enum fields
+}
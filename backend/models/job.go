@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Job represents a unit of background work, persisted so it survives restarts
+type Job struct {
+	ID          string         `json:"id" firestore:"-"`
+	Product     string         `json:"product" firestore:"product"`
+	Type        string         `json:"type" firestore:"type"`                               // "transcode" | "thumbnail" | "purge"
+	Payload     map[string]any `json:"payload,omitempty" firestore:"payload,omitempty"`     // Job type specific arguments
+	Status      string         `json:"status" firestore:"status"`                           // "queued" | "running" | "succeeded" | "dead"
+	Attempts    int            `json:"attempts" firestore:"attempts"`                       // Attempts started so far, including the running one
+	MaxAttempts int            `json:"maxAttempts" firestore:"maxAttempts"`                 // Attempts before the job is dead-lettered
+	LastError   string         `json:"lastError,omitempty" firestore:"lastError,omitempty"` // Error of the latest failed attempt
+	AvailableAt time.Time      `json:"-" firestore:"availableAt"`                           // When a queued job is due, or a running job's lease expires
+	LeaseID     string         `json:"-" firestore:"leaseId,omitempty"`                     // Identifies the worker attempt holding a running job
+	ExpireAt    *time.Time     `json:"-" firestore:"expireAt,omitempty"`                    // Finished jobs are deleted by a Firestore TTL policy after this time
+	CreatedAt   time.Time      `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt" firestore:"updatedAt"`
+	FinishedAt  *time.Time     `json:"finishedAt,omitempty" firestore:"finishedAt,omitempty"` // When the job succeeded or was dead-lettered
+}
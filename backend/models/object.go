@@ -0,0 +1,48 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"learninghub/constants"
+)
+
+// StoredObject tracks a content-addressed upload, stored once per product
+// however many resources reference it. The document ID is the SHA-256 of the
+// content.
+type StoredObject struct {
+	Object      string    `json:"object" firestore:"object"`         // Storage object name, "<product>/<type>/<sha256><ext>"
+	Generation  int64     `json:"generation" firestore:"generation"` // Storage generation written by the upload that registered the object
+	Refs        int       `json:"refs" firestore:"refs"`             // Resource references, the object is deleted when none are left
+	Size        int64     `json:"size" firestore:"size"`             // bytes
+	ContentType string    `json:"contentType" firestore:"contentType"`
+	Releases    []string  `json:"-" firestore:"releases,omitempty"` // Latest releases by ID, see Release
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Release drops a reference on behalf of releaseID and reports whether it
+// did. A release recorded already drops nothing, so a retried purge job
+// releases its references once. Releases without an ID are not recorded.
+func (o *StoredObject) Release(releaseID string, now time.Time) bool {
+	if releaseID != "" {
+		if slices.Contains(o.Releases, releaseID) {
+			return false
+		}
+		o.Releases = append(o.Releases, releaseID)
+		if len(o.Releases) > constants.MaxObjectReleases {
+			o.Releases = o.Releases[len(o.Releases)-constants.MaxObjectReleases:]
+		}
+	}
+
+	o.Refs--
+	o.UpdatedAt = now
+	return true
+}
+
+// Warning reports something worth knowing about a request that succeeded
+type Warning struct {
+	Code       string `json:"code"` // e.g. "DUPLICATE_FILE"
+	Message    string `json:"message"`
+	ResourceID string `json:"resourceId,omitempty"` // Resource the warning is about
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"learninghub/config"
+)
+
+// Product is a product of the registry. Its name is the :product segment of
+// its routes and prefixes its collections and storage paths.
+type Product struct {
+	Name         string               `json:"name" firestore:"name"`
+	DisplayName  string               `json:"displayName" firestore:"displayName"`
+	Description  string               `json:"description,omitempty" firestore:"description,omitempty"`
+	Owners       []string             `json:"owners,omitempty" firestore:"owners,omitempty"`             // Emails of the people responsible for the product
+	DefaultTags  []string             `json:"defaultTags,omitempty" firestore:"defaultTags,omitempty"`   // Suggested for new resources
+	UploadPolicy *config.UploadPolicy `json:"uploadPolicy,omitempty" firestore:"uploadPolicy,omitempty"` // Merged over the one of UPLOAD_POLICY_FILE
+	Archived     bool                 `json:"archived" firestore:"archived"`                             // Archived products are kept but no longer served
+	ArchivedAt   *time.Time           `json:"archivedAt,omitempty" firestore:"archivedAt,omitempty"`
+	CreatedAt    time.Time            `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt    time.Time            `json:"updatedAt" firestore:"updatedAt"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Progress is the progress of a user through a resource
+type Progress struct {
+	UserID      string     `json:"-" firestore:"userId"`
+	ResourceID  string     `json:"resourceId" firestore:"resourceId"`
+	Status      string     `json:"status" firestore:"status"`                         // "started" | "completed"
+	Position    float64    `json:"position,omitempty" firestore:"position,omitempty"` // Playback position in seconds, videos and audio
+	Page        int        `json:"page,omitempty" firestore:"page,omitempty"`         // Last page read, PDFs and slide decks
+	StartedAt   time.Time  `json:"startedAt" firestore:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" firestore:"completedAt,omitempty"` // Until the resource is started again
+	UpdatedAt   time.Time  `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// CollectionProgress is the progress of a user through the resources of a
+// collection
+type CollectionProgress struct {
+	CollectionID string `json:"collectionId"`
+	Title        string `json:"title"`
+	Completed    int    `json:"completed"`  // Resources completed
+	Total        int    `json:"total"`      // Resources of the collection
+	Percentage   int    `json:"percentage"` // Of the resources completed, rounded down
+}
+
+// UserProgress is the progress of a user through the resources and
+// collections of a product
+type UserProgress struct {
+	Resources   []Progress           `json:"resources"`   // Most recently updated first
+	Collections []CollectionProgress `json:"collections"` // Collections with a resource started or completed, newest first
+}
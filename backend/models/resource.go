@@ -4,13 +4,109 @@ import "time"
 
 // Resource represents a learning resource
 type Resource struct {
-	ID           string    `json:"id" firestore:"-"`
-	Title        string    `json:"title" firestore:"title" binding:"required"`
-	Description  string    `json:"description" firestore:"description" binding:"required"`
-	Type         string    `json:"type" firestore:"type" binding:"required,oneof=video pdf article"`
-	URL          string    `json:"url" firestore:"url"`
-	ThumbnailURL string    `json:"thumbnailUrl,omitempty" firestore:"thumbnailUrl,omitempty"`
-	Tags         []string  `json:"tags" firestore:"tags"`
-	CreatedAt    time.Time `json:"createdAt" firestore:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt" firestore:"updatedAt"`
+	ID                string            `json:"id" firestore:"-"`
+	Title             string            `json:"title" firestore:"title" binding:"required"`
+	Description       string            `json:"description" firestore:"description" binding:"required"`
+	Type              string            `json:"type" firestore:"type" binding:"required"` // A registered type, see utils.RegisterResourceType
+	URL               string            `json:"url" firestore:"url"`
+	ThumbnailURL      string            `json:"thumbnailUrl,omitempty" firestore:"thumbnailUrl,omitempty"`
+	Thumbnails        map[string]string `json:"thumbnails,omitempty" firestore:"thumbnails,omitempty"`               // Thumbnail variants keyed by "<width>.<format>", e.g. "320.webp"
+	ThumbnailJob      *ThumbnailJob     `json:"-" firestore:"thumbnailJob,omitempty"`                                // Background generation of the thumbnail or its variants
+	Transcode         *Transcode        `json:"transcode,omitempty" firestore:"transcode,omitempty"`                 // HLS transcoding of an uploaded video
+	StreamURL         string            `json:"streamUrl,omitempty" firestore:"-"`                                   // Tokenized HLS master playlist, set in responses once transcoding is ready
+	Conversion        *Conversion       `json:"conversion,omitempty" firestore:"conversion,omitempty"`               // PDF conversion of an uploaded slide deck
+	PreviewURL        string            `json:"previewUrl,omitempty" firestore:"-"`                                  // Signed URL of the converted PDF, set in responses once conversion is ready
+	Embed             *Embed            `json:"embed,omitempty" firestore:"embed,omitempty"`                         // Player of an embed, resolved from its URL
+	Jobs              map[string]string `json:"jobs,omitempty" firestore:"-"`                                        // IDs of the background jobs started by a request, keyed by job type
+	Metadata          *Metadata         `json:"metadata,omitempty" firestore:"metadata,omitempty"`                   // Uploaded file, absent for linked URLs
+	ThumbnailMetadata *Metadata         `json:"thumbnailMetadata,omitempty" firestore:"thumbnailMetadata,omitempty"` // Uploaded or generated thumbnail
+	Warnings          []Warning         `json:"warnings,omitempty" firestore:"-"`                                    // Set in create and update responses, e.g. when the uploaded file duplicates another resource's
+	Bookmarked        *bool             `json:"bookmarked,omitempty" firestore:"-"`                                  // Whether the signed in user bookmarked the resource, set in responses to them
+	Tags              []string          `json:"tags" firestore:"tags"`
+	CustomFields      map[string]any    `json:"customFields,omitempty" firestore:"customFields,omitempty"` // Values of the product's custom fields, see FieldDefinition
+	CreatedAt         time.Time         `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt         time.Time         `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// OutputPrefixes returns the storage prefixes of the files background jobs
+// derived from a resource's file: a video's HLS stream, a deck's PDF
+func (r Resource) OutputPrefixes() []string {
+	var prefixes []string
+	if r.Transcode != nil && r.Transcode.Prefix != "" {
+		prefixes = append(prefixes, r.Transcode.Prefix)
+	}
+	if r.Conversion != nil && r.Conversion.Prefix != "" {
+		prefixes = append(prefixes, r.Conversion.Prefix)
+	}
+	return prefixes
+}
+
+// Metadata describes an uploaded file. Fields that do not apply to the file
+// type, or could not be read, are left empty.
+type Metadata struct {
+	Size       int64   `json:"size" firestore:"size"` // bytes
+	MIMEType   string  `json:"mimeType" firestore:"mimeType"`
+	SHA256     string  `json:"sha256,omitempty" firestore:"sha256,omitempty"`
+	Duration   float64 `json:"duration,omitempty" firestore:"duration,omitempty"`     // seconds, videos and audio
+	Width      int     `json:"width,omitempty" firestore:"width,omitempty"`           // pixels as displayed, videos and images
+	Height     int     `json:"height,omitempty" firestore:"height,omitempty"`         // pixels as displayed, videos and images
+	VideoCodec string  `json:"videoCodec,omitempty" firestore:"videoCodec,omitempty"` // e.g. "h264"
+	AudioCodec string  `json:"audioCodec,omitempty" firestore:"audioCodec,omitempty"` // e.g. "aac"
+	Pages      int     `json:"pages,omitempty" firestore:"pages,omitempty"`           // PDFs
+	Title      string  `json:"title,omitempty" firestore:"title,omitempty"`           // Embedded in the document, PDFs
+	Author     string  `json:"author,omitempty" firestore:"author,omitempty"`         // Embedded in the document, PDFs
+
+	Archive *ArchiveListing `json:"archive,omitempty" firestore:"archive,omitempty"` // Code archives
+}
+
+// ArchiveListing describes the content of an archive
+type ArchiveListing struct {
+	Files            int            `json:"files" firestore:"files"`
+	UncompressedSize int64          `json:"uncompressedSize" firestore:"uncompressedSize"` // bytes
+	Entries          []ArchiveEntry `json:"entries" firestore:"entries"`                   // The first files, see constants.ArchiveListingLimit
+	Truncated        bool           `json:"truncated,omitempty" firestore:"truncated,omitempty"`
+}
+
+// ArchiveEntry is a file in an archive
+type ArchiveEntry struct {
+	Path string `json:"path" firestore:"path"`
+	Size int64  `json:"size" firestore:"size"` // bytes, uncompressed
+}
+
+// Embed describes the player of an external video, as its provider's oEmbed
+// endpoint reports it
+type Embed struct {
+	Provider     string  `json:"provider" firestore:"provider"` // "youtube" | "vimeo" | "loom"
+	EmbedURL     string  `json:"embedUrl" firestore:"embedUrl"` // iframe source, always on the provider's player host
+	Title        string  `json:"title,omitempty" firestore:"title,omitempty"`
+	AuthorName   string  `json:"authorName,omitempty" firestore:"authorName,omitempty"`
+	ThumbnailURL string  `json:"thumbnailUrl,omitempty" firestore:"thumbnailUrl,omitempty"`
+	Width        int     `json:"width,omitempty" firestore:"width,omitempty"`       // pixels
+	Height       int     `json:"height,omitempty" firestore:"height,omitempty"`     // pixels
+	Duration     float64 `json:"duration,omitempty" firestore:"duration,omitempty"` // seconds, when the provider reports it
+}
+
+// Transcode represents the state of a video's HLS transcoding job
+type Transcode struct {
+	Status    string    `json:"status" firestore:"status"`                   // "pending" | "processing" | "ready" | "failed"
+	Source    string    `json:"-" firestore:"source"`                        // URL of the video being transcoded
+	Prefix    string    `json:"-" firestore:"prefix,omitempty"`              // Storage prefix of the master playlist and renditions
+	Error     string    `json:"error,omitempty" firestore:"error,omitempty"` // Why transcoding failed
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Conversion represents the state of a slide deck's PDF conversion job
+type Conversion struct {
+	Status    string    `json:"status" firestore:"status"`                   // "pending" | "processing" | "ready" | "failed"
+	Source    string    `json:"-" firestore:"source"`                        // URL of the deck being converted
+	Prefix    string    `json:"-" firestore:"prefix,omitempty"`              // Storage prefix of the converted PDF
+	URL       string    `json:"-" firestore:"url,omitempty"`                 // URL of the converted PDF
+	Error     string    `json:"error,omitempty" firestore:"error,omitempty"` // Why conversion failed
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// ThumbnailJob represents the state of a resource's thumbnail job
+type ThumbnailJob struct {
+	Source string `firestore:"source"` // URL of the file a thumbnail is generated from, or of the uploaded thumbnail to make variants of
+	Done   bool   `firestore:"done"`   // Whether the job finished, successfully or not
 }
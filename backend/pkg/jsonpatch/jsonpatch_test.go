@@ -0,0 +1,107 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+	}{
+		{name: "replace member", document: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{name: "add member", document: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{name: "null removes", document: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{name: "arrays are replaced", document: `{"a":["b"]}`, patch: `{"a":["c","d"]}`, expected: `{"a":["c","d"]}`},
+		{name: "nested merge", document: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"d":null,"f":1}}`, expected: `{"a":{"b":"c","f":1}}`},
+		{name: "object replaces scalar", document: `{"a":"b"}`, patch: `{"a":{"c":null,"d":1}}`, expected: `{"a":{"d":1}}`},
+		{name: "non-object patch replaces", document: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{name: "empty patch", document: `{"a":"b"}`, patch: `{}`, expected: `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.document), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{"a":`))
+	var patchErr *Error
+	assert.True(t, errors.As(err, &patchErr))
+}
+
+func TestApply(t *testing.T) {
+	document := `{"title":"Intro","tags":["a","b"],"fields":{"level":1,"a/b":"slash","m~n":"tilde"}}`
+
+	tests := []struct {
+		name      string
+		patch     string
+		expected  string
+		wantError string
+		wantTest  bool
+	}{
+		{
+			name:     "replace",
+			patch:    `[{"op":"replace","path":"/title","value":"Getting started"}]`,
+			expected: `{"title":"Getting started","tags":["a","b"],"fields":{"level":1,"a/b":"slash","m~n":"tilde"}}`,
+		},
+		{
+			name:     "add to array end and index",
+			patch:    `[{"op":"add","path":"/tags/-","value":"c"},{"op":"add","path":"/tags/0","value":"z"}]`,
+			expected: `{"title":"Intro","tags":["z","a","b","c"],"fields":{"level":1,"a/b":"slash","m~n":"tilde"}}`,
+		},
+		{
+			name:     "remove escaped members",
+			patch:    `[{"op":"remove","path":"/fields/a~1b"},{"op":"remove","path":"/fields/m~0n"}]`,
+			expected: `{"title":"Intro","tags":["a","b"],"fields":{"level":1}}`,
+		},
+		{
+			name:     "add null",
+			patch:    `[{"op":"add","path":"/fields/level","value":null}]`,
+			expected: `{"title":"Intro","tags":["a","b"],"fields":{"level":null,"a/b":"slash","m~n":"tilde"}}`,
+		},
+		{
+			name:     "move and copy",
+			patch:    `[{"op":"move","from":"/tags/0","path":"/first"},{"op":"copy","from":"/fields","path":"/copy"},{"op":"remove","path":"/copy/level"}]`,
+			expected: `{"title":"Intro","tags":["b"],"first":"a","fields":{"level":1,"a/b":"slash","m~n":"tilde"},"copy":{"a/b":"slash","m~n":"tilde"}}`,
+		},
+		{
+			name:     "passing test",
+			patch:    `[{"op":"test","path":"/tags","value":["a","b"]},{"op":"test","path":"/fields/level","value":1.0}]`,
+			expected: document,
+		},
+		{name: "failing test", patch: `[{"op":"remove","path":"/title"},{"op":"test","path":"/tags/0","value":"b"}]`, wantError: "operation 1 on '/tags/0': value does not match", wantTest: true},
+		{name: "replace missing member", patch: `[{"op":"replace","path":"/description","value":"x"}]`, wantError: "path not found"},
+		{name: "remove out of bounds", patch: `[{"op":"remove","path":"/tags/2"}]`, wantError: "out of bounds"},
+		{name: "leading zero index", patch: `[{"op":"remove","path":"/tags/01"}]`, wantError: "invalid array index"},
+		{name: "add without value", patch: `[{"op":"add","path":"/description"}]`, wantError: "missing value"},
+		{name: "move into itself", patch: `[{"op":"move","from":"/fields","path":"/fields/nested"}]`, wantError: "into itself"},
+		{name: "unsupported op", patch: `[{"op":"merge","path":"/title"}]`, wantError: "unsupported op 'merge'"},
+		{name: "invalid pointer", patch: `[{"op":"remove","path":"title"}]`, wantError: "must be empty or start with '/'"},
+		{name: "invalid escape", patch: `[{"op":"remove","path":"/fields/m~2n"}]`, wantError: "invalid escape"},
+		{name: "not an array", patch: `{"op":"remove","path":"/title"}`, wantError: "patch must be an array of operations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Apply([]byte(document), []byte(tt.patch))
+			if tt.wantError != "" {
+				var patchErr *Error
+				require.True(t, errors.As(err, &patchErr), "got %v", err)
+				assert.Contains(t, patchErr.Error(), tt.wantError)
+				assert.Equal(t, tt.wantTest, patchErr.Test)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
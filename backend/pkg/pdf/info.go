@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Info describes a document for display
+type Info struct {
+	Pages  int    `json:"pages"`
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+}
+
+// Info returns the page count from the page tree and the title and author
+// from the document information dictionary. Missing entries are left empty.
+func (d *Document) Info() Info {
+	var info Info
+
+	if pages, ok := d.Resolve(d.Catalog()["Pages"]).(Dict); ok {
+		if count, ok := d.Resolve(pages["Count"]).(int64); ok && count > 0 {
+			info.Pages = int(count)
+		}
+	}
+
+	if dict, ok := d.Resolve(d.trailer["Info"]).(Dict); ok {
+		info.Title = d.textString(dict["Title"])
+		info.Author = d.textString(dict["Author"])
+	}
+
+	return info
+}
+
+// textString decodes a text string object (PDF 1.7 section 7.9.2.2): UTF-16BE
+// with a byte order mark, UTF-8 with a byte order mark (PDF 2.0), or
+// PDFDocEncoding. Control characters are dropped and whitespace is trimmed.
+func (d *Document) textString(obj Object) string {
+	value, ok := d.Resolve(obj).(String)
+	if !ok {
+		return ""
+	}
+
+	var text string
+	switch {
+	case strings.HasPrefix(string(value), "\xfe\xff"):
+		text = decodeUTF16BE([]byte(value[2:]))
+	case strings.HasPrefix(string(value), "\xef\xbb\xbf"):
+		text = strings.ToValidUTF8(string(value[3:]), "�")
+	default:
+		text = decodePDFDoc([]byte(value))
+	}
+
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text))
+}
+
+// decodeUTF16BE decodes big-endian UTF-16, ignoring a trailing odd byte
+func decodeUTF16BE(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// pdfDocHigh maps the bytes 0x80-0x9f of PDFDocEncoding, which differ from
+// Latin-1. Zero entries are undefined.
+var pdfDocHigh = [32]rune{
+	'•', '†', '‡', '…', '—', '–', 'ƒ', '⁄',
+	'‹', '›', '−', '‰', '„', '“', '”', '‘',
+	'’', '‚', '™', 'ﬁ', 'ﬂ', 'Ł', 'Œ', 'Š',
+	'Ÿ', 'Ž', 'ı', 'ł', 'œ', 'š', 'ž', 0,
+}
+
+// decodePDFDoc decodes PDFDocEncoding, which matches Latin-1 outside 0x80-0x9f
+// and the accent characters at 0x18-0x1f (dropped as control characters here)
+func decodePDFDoc(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch {
+		case c >= 0x80 && c <= 0x9f:
+			if r := pdfDocHigh[c-0x80]; r != 0 {
+				sb.WriteRune(r)
+			} else {
+				sb.WriteRune(utf8.RuneError)
+			}
+		case c == 0xa0:
+			sb.WriteRune('€')
+		default:
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
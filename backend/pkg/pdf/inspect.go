@@ -0,0 +1,237 @@
+package pdf
+
+import (
+	"sort"
+)
+
+// Feature is a kind of interactive or active content found in a document
+type Feature string
+
+const (
+	// Active content: runs code, acts without user intent, or carries payloads
+	FeatureJavaScript        Feature = "javascript"
+	FeatureOpenAction        Feature = "open-action"
+	FeatureAdditionalActions Feature = "additional-actions"
+	FeatureLaunch            Feature = "launch"
+	FeatureSubmitForm        Feature = "submit-form"
+	FeatureImportData        Feature = "import-data"
+	FeatureEmbeddedFile      Feature = "embedded-file"
+	FeatureXFA               Feature = "xfa"
+	FeatureRichMedia         Feature = "rich-media"
+	FeatureMultimedia        Feature = "multimedia"
+
+	// Passive content: navigation the reader has to click on
+	FeatureURI        Feature = "uri"
+	FeatureRemoteGoTo Feature = "remote-goto"
+)
+
+// activeFeatures are the features Sanitize removes and policies reject
+var activeFeatures = map[Feature]bool{
+	FeatureJavaScript:        true,
+	FeatureOpenAction:        true,
+	FeatureAdditionalActions: true,
+	FeatureLaunch:            true,
+	FeatureSubmitForm:        true,
+	FeatureImportData:        true,
+	FeatureEmbeddedFile:      true,
+	FeatureXFA:               true,
+	FeatureRichMedia:         true,
+	FeatureMultimedia:        true,
+}
+
+// IsActive reports whether a feature counts as active content
+func (f Feature) IsActive() bool {
+	return activeFeatures[f]
+}
+
+// actionFeatures maps action types (the /S entry of an action dictionary)
+var actionFeatures = map[Name]Feature{
+	"JavaScript":       FeatureJavaScript,
+	"Launch":           FeatureLaunch,
+	"SubmitForm":       FeatureSubmitForm,
+	"ImportData":       FeatureImportData,
+	"GoToE":            FeatureEmbeddedFile,
+	"RichMediaExecute": FeatureRichMedia,
+	"Sound":            FeatureMultimedia,
+	"Movie":            FeatureMultimedia,
+	"Rendition":        FeatureMultimedia,
+	"URI":              FeatureURI,
+	"GoToR":            FeatureRemoteGoTo,
+}
+
+// annotationFeatures maps annotation subtypes (the /Subtype entry)
+var annotationFeatures = map[Name]Feature{
+	"FileAttachment": FeatureEmbeddedFile,
+	"RichMedia":      FeatureRichMedia,
+	"Screen":         FeatureMultimedia,
+	"Sound":          FeatureMultimedia,
+	"Movie":          FeatureMultimedia,
+	"3D":             FeatureMultimedia,
+}
+
+// keyFeatures maps dictionary keys whose mere presence enables a feature
+var keyFeatures = map[Name]Feature{
+	"JS":               FeatureJavaScript,
+	"JavaScript":       FeatureJavaScript,
+	"OpenAction":       FeatureOpenAction,
+	"AA":               FeatureAdditionalActions,
+	"EmbeddedFiles":    FeatureEmbeddedFile,
+	"EF":               FeatureEmbeddedFile,
+	"XFA":              FeatureXFA,
+	"RichMediaContent": FeatureRichMedia,
+	"URI":              FeatureURI,
+}
+
+// Report describes what a document contains
+type Report struct {
+	Version   string          `json:"version"`
+	Encrypted bool            `json:"encrypted"`
+	Objects   int             `json:"objects"`
+	Features  map[Feature]int `json:"features"`
+
+	// Undecodable counts object streams that could not be decompressed.
+	// Their contents are unknown, so the document cannot be vouched for.
+	Undecodable int `json:"undecodable,omitempty"`
+}
+
+// Has reports whether the document contains a feature
+func (r *Report) Has(feature Feature) bool {
+	return r.Features[feature] > 0
+}
+
+// ActiveContent returns the active features found, sorted
+func (r *Report) ActiveContent() []Feature {
+	var found []Feature
+	for feature, count := range r.Features {
+		if count > 0 && feature.IsActive() {
+			found = append(found, feature)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+	return found
+}
+
+// Inspectable reports whether every part of the document could be examined.
+// Encrypted strings and undecodable object streams may hide content.
+func (r *Report) Inspectable() bool {
+	return !r.Encrypted && r.Undecodable == 0
+}
+
+// Inspect walks the object graph reachable from the trailer and reports the
+// interactive features it uses. Unreachable objects are ignored: a viewer
+// never loads them.
+func (d *Document) Inspect() *Report {
+	report := &Report{
+		Version:     d.version,
+		Encrypted:   d.Encrypted(),
+		Features:    map[Feature]int{},
+		Undecodable: d.undecodable,
+	}
+
+	reachable := d.reachable()
+	report.Objects = len(reachable)
+
+	for num := range reachable {
+		walkDicts(d.objects[num].value, 0, func(dict Dict) {
+			for _, feature := range dictFeatures(dict) {
+				report.Features[feature]++
+			}
+		})
+	}
+
+	return report
+}
+
+// dictFeatures returns the features a single dictionary enables
+func dictFeatures(dict Dict) []Feature {
+	found := map[Feature]bool{}
+
+	for key := range dict {
+		if feature, ok := keyFeatures[key]; ok {
+			found[feature] = true
+		}
+	}
+	if feature, ok := actionFeatures[dict.name("S")]; ok {
+		found[feature] = true
+	}
+	if feature, ok := annotationFeatures[dict.name("Subtype")]; ok && dict.name("Type") != "XObject" {
+		found[feature] = true
+	}
+	if dict.name("Type") == "EmbeddedFile" {
+		found[FeatureEmbeddedFile] = true
+	}
+
+	features := make([]Feature, 0, len(found))
+	for feature := range found {
+		features = append(features, feature)
+	}
+	return features
+}
+
+// walkDicts calls fn for every dictionary nested in obj, without following references
+func walkDicts(obj Object, depth int, fn func(Dict)) {
+	if depth > maxDepth {
+		return
+	}
+
+	switch v := obj.(type) {
+	case Dict:
+		fn(v)
+		for _, value := range v {
+			walkDicts(value, depth+1, fn)
+		}
+	case *Stream:
+		walkDicts(v.Dict, depth+1, fn)
+	case Array:
+		for _, item := range v {
+			walkDicts(item, depth+1, fn)
+		}
+	}
+}
+
+// walkRefs calls fn for every reference nested in obj
+func walkRefs(obj Object, depth int, fn func(Ref)) {
+	if depth > maxDepth {
+		return
+	}
+
+	switch v := obj.(type) {
+	case Ref:
+		fn(v)
+	case Dict:
+		for _, value := range v {
+			walkRefs(value, depth+1, fn)
+		}
+	case *Stream:
+		walkRefs(v.Dict, depth+1, fn)
+	case Array:
+		for _, item := range v {
+			walkRefs(item, depth+1, fn)
+		}
+	}
+}
+
+// reachable returns the numbers of all objects reachable from the trailer
+func (d *Document) reachable() map[int]bool {
+	seen := map[int]bool{}
+	var queue []int
+
+	visit := func(ref Ref) {
+		if _, ok := d.objects[ref.Num]; ok && !seen[ref.Num] {
+			seen[ref.Num] = true
+			queue = append(queue, ref.Num)
+		}
+	}
+
+	for _, key := range []Name{"Root", "Info"} {
+		walkRefs(d.trailer[key], 0, visit)
+	}
+
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		walkRefs(d.objects[num].value, 0, visit)
+	}
+
+	return seen
+}
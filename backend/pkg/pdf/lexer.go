@@ -0,0 +1,416 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxTokenLength bounds the size of a single name, string or keyword so a
+// crafted file cannot make the lexer buffer unbounded input.
+const maxTokenLength = 8 << 20 // 8MB
+
+var (
+	errTokenTooLong = errors.New("pdf: token exceeds maximum length")
+
+	// errSyntax marks malformed input the parser can skip over, as opposed to
+	// read errors from the source
+	errSyntax = errors.New("pdf: syntax error")
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenKeyword
+	tokenInteger
+	tokenReal
+	tokenName
+	tokenString
+	tokenDictStart
+	tokenDictEnd
+	tokenArrayStart
+	tokenArrayEnd
+)
+
+// token is a lexical PDF token. For names and strings, text holds the decoded
+// value; for everything else it holds the raw characters.
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int64
+}
+
+func (t token) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+// lexer tokenizes PDF syntax from an io.ReaderAt. It reads through a buffered
+// section reader and tracks the absolute offset of every byte consumed, so the
+// parser can record where stream data starts and jump over it.
+type lexer struct {
+	src     io.ReaderAt
+	size    int64
+	r       *bufio.Reader
+	offset  int64
+	pending []token
+}
+
+func newLexer(src io.ReaderAt, size int64) *lexer {
+	lx := &lexer{src: src, size: size}
+	lx.seek(0)
+	return lx
+}
+
+// seek repositions the lexer at an absolute offset, discarding pushed back tokens
+func (lx *lexer) seek(offset int64) {
+	offset = min(max(offset, 0), lx.size)
+	lx.r = bufio.NewReaderSize(io.NewSectionReader(lx.src, offset, lx.size-offset), 64<<10)
+	lx.offset = offset
+	lx.pending = lx.pending[:0]
+}
+
+// unread pushes a token back; tokens are returned again in LIFO order
+func (lx *lexer) unread(t token) {
+	lx.pending = append(lx.pending, t)
+}
+
+func (lx *lexer) readByte() (byte, error) {
+	b, err := lx.r.ReadByte()
+	if err == nil {
+		lx.offset++
+	}
+	return b, err
+}
+
+func (lx *lexer) unreadByte() {
+	if lx.r.UnreadByte() == nil {
+		lx.offset--
+	}
+}
+
+func (lx *lexer) peekByte() (byte, bool) {
+	b, err := lx.r.Peek(1)
+	if err != nil {
+		return 0, false
+	}
+	return b[0], true
+}
+
+// skipEOL consumes the end-of-line marker that follows the "stream" keyword
+func (lx *lexer) skipEOL() {
+	if b, ok := lx.peekByte(); ok && b == '\r' {
+		lx.readByte()
+	}
+	if b, ok := lx.peekByte(); ok && b == '\n' {
+		lx.readByte()
+	}
+}
+
+func isWhitespace(b byte) bool {
+	switch b {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(b byte) bool {
+	switch b {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(b byte) bool {
+	return !isWhitespace(b) && !isDelimiter(b)
+}
+
+// skipSpace skips whitespace and comments
+func (lx *lexer) skipSpace() error {
+	for {
+		b, err := lx.readByte()
+		if err != nil {
+			return err
+		}
+
+		if b == '%' {
+			for {
+				b, err = lx.readByte()
+				if err != nil {
+					return err
+				}
+				if b == '\n' || b == '\r' {
+					break
+				}
+			}
+			continue
+		}
+
+		if !isWhitespace(b) {
+			lx.unreadByte()
+			return nil
+		}
+	}
+}
+
+// next returns the next token. Read errors other than EOF are reported as
+// errors; EOF is reported as a tokenEOF token.
+func (lx *lexer) next() (token, error) {
+	if n := len(lx.pending); n > 0 {
+		t := lx.pending[n-1]
+		lx.pending = lx.pending[:n-1]
+		return t, nil
+	}
+
+	if err := lx.skipSpace(); err != nil {
+		if err == io.EOF {
+			return token{kind: tokenEOF, offset: lx.offset}, nil
+		}
+		return token{}, err
+	}
+
+	start := lx.offset
+	b, err := lx.readByte()
+	if err != nil {
+		return token{}, err
+	}
+
+	switch b {
+	case '/':
+		name, err := lx.readName()
+		return token{kind: tokenName, text: name, offset: start}, err
+
+	case '(':
+		str, err := lx.readLiteralString()
+		return token{kind: tokenString, text: str, offset: start}, err
+
+	case '<':
+		if next, ok := lx.peekByte(); ok && next == '<' {
+			lx.readByte()
+			return token{kind: tokenDictStart, text: "<<", offset: start}, nil
+		}
+		str, err := lx.readHexString()
+		return token{kind: tokenString, text: str, offset: start}, err
+
+	case '>':
+		if next, ok := lx.peekByte(); ok && next == '>' {
+			lx.readByte()
+			return token{kind: tokenDictEnd, text: ">>", offset: start}, nil
+		}
+		return token{kind: tokenKeyword, text: ">", offset: start}, nil
+
+	case '[':
+		return token{kind: tokenArrayStart, text: "[", offset: start}, nil
+
+	case ']':
+		return token{kind: tokenArrayEnd, text: "]", offset: start}, nil
+
+	case '{', '}', ')':
+		// PostScript calculator braces and stray parens carry no meaning for us
+		return token{kind: tokenKeyword, text: string(b), offset: start}, nil
+	}
+
+	lx.unreadByte()
+	word, err := lx.readRegular()
+	if err != nil {
+		return token{}, err
+	}
+
+	return token{kind: classifyWord(word), text: word, offset: start}, nil
+}
+
+// classifyWord decides whether a run of regular characters is a number or a keyword
+func classifyWord(word string) tokenKind {
+	if _, err := strconv.ParseInt(word, 10, 64); err == nil {
+		return tokenInteger
+	}
+
+	digits, dots := 0, 0
+	for i := 0; i < len(word); i++ {
+		switch c := word[i]; {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			dots++
+		case (c == '+' || c == '-') && i == 0:
+		default:
+			return tokenKeyword
+		}
+	}
+	if digits > 0 && dots <= 1 {
+		return tokenReal
+	}
+
+	return tokenKeyword
+}
+
+func (lx *lexer) readRegular() (string, error) {
+	var buf bytes.Buffer
+	for {
+		b, err := lx.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !isRegular(b) {
+			lx.unreadByte()
+			break
+		}
+		if buf.Len() >= maxTokenLength {
+			return "", errTokenTooLong
+		}
+		buf.WriteByte(b)
+	}
+	return buf.String(), nil
+}
+
+// readName reads a name after its leading slash, decoding #xx escapes.
+// Decoding matters: "/Java#53cript" is the same key as "/JavaScript" to a
+// viewer, and is a classic way of hiding from naive byte scanners.
+func (lx *lexer) readName() (string, error) {
+	raw, err := lx.readRegular()
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.ContainsRune([]byte(raw), '#') {
+		return raw, nil
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) && isHex(raw[i+1]) && isHex(raw[i+2]) {
+			buf.WriteByte(unhex(raw[i+1])<<4 | unhex(raw[i+2]))
+			i += 2
+			continue
+		}
+		buf.WriteByte(raw[i])
+	}
+	return buf.String(), nil
+}
+
+func (lx *lexer) readLiteralString() (string, error) {
+	var buf bytes.Buffer
+	depth := 1
+
+	for {
+		b, err := lx.readByte()
+		if err != nil {
+			return "", fmt.Errorf("%w: unterminated string: %v", errSyntax, err)
+		}
+		if buf.Len() >= maxTokenLength {
+			return "", errTokenTooLong
+		}
+
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.String(), nil
+			}
+		case '\\':
+			b, err = lx.readByte()
+			if err != nil {
+				return "", fmt.Errorf("%w: unterminated string: %v", errSyntax, err)
+			}
+			switch b {
+			case 'n':
+				b = '\n'
+			case 'r':
+				b = '\r'
+			case 't':
+				b = '\t'
+			case 'b':
+				b = '\b'
+			case 'f':
+				b = '\f'
+			case '\r':
+				// Line continuation, optionally followed by \n
+				if next, ok := lx.peekByte(); ok && next == '\n' {
+					lx.readByte()
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if b >= '0' && b <= '7' {
+					value := b - '0'
+					for i := 0; i < 2; i++ {
+						next, ok := lx.peekByte()
+						if !ok || next < '0' || next > '7' {
+							break
+						}
+						lx.readByte()
+						value = value<<3 | (next - '0')
+					}
+					b = value
+				}
+			}
+		}
+
+		buf.WriteByte(b)
+	}
+}
+
+func (lx *lexer) readHexString() (string, error) {
+	var buf bytes.Buffer
+	var high byte
+	odd := false
+
+	for {
+		b, err := lx.readByte()
+		if err != nil {
+			return "", fmt.Errorf("%w: unterminated hex string: %v", errSyntax, err)
+		}
+		if b == '>' {
+			break
+		}
+		if isWhitespace(b) {
+			continue
+		}
+		if !isHex(b) {
+			return "", fmt.Errorf("%w: invalid character %q in hex string", errSyntax, b)
+		}
+		if buf.Len() >= maxTokenLength {
+			return "", errTokenTooLong
+		}
+
+		if odd {
+			buf.WriteByte(high<<4 | unhex(b))
+		} else {
+			high = unhex(b)
+		}
+		odd = !odd
+	}
+
+	// An odd number of digits behaves as if followed by 0
+	if odd {
+		buf.WriteByte(high << 4)
+	}
+
+	return buf.String(), nil
+}
+
+func isHex(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func unhex(b byte) byte {
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0'
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10
+	default:
+		return b - 'A' + 10
+	}
+}
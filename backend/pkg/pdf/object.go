@@ -0,0 +1,123 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Object is any PDF object: nil (null), bool, int64, Real, Name, String,
+// Array, Dict, Ref or *Stream.
+type Object interface{}
+
+// Name is a PDF name object, without its leading slash
+type Name string
+
+// Real is a PDF real number, kept in its original textual form so it is
+// written back exactly as it was read
+type Real string
+
+// String is a PDF string object (literal or hex), holding the decoded bytes
+type String string
+
+// Array is a PDF array object
+type Array []Object
+
+// Dict is a PDF dictionary object
+type Dict map[Name]Object
+
+// Ref is an indirect reference ("12 0 R")
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// Stream is a stream object. The payload is not held in memory: only its
+// position in the source file is recorded, so large images and fonts cost
+// nothing until they are copied out.
+type Stream struct {
+	Dict   Dict
+	offset int64
+	length int64
+}
+
+// name returns the value of a name entry, or "" if absent or of another type
+func (d Dict) name(key Name) Name {
+	value, _ := d[key].(Name)
+	return value
+}
+
+// writeObject serializes an object in PDF syntax. Dictionary keys are sorted
+// so output is deterministic. Strings are always written in hex form, which
+// needs no escaping.
+func writeObject(w io.Writer, obj Object) error {
+	var buf bytes.Buffer
+	appendObject(&buf, obj)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func appendObject(buf *bytes.Buffer, obj Object) {
+	switch v := obj.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case Real:
+		buf.WriteString(string(v))
+	case Name:
+		appendName(buf, v)
+	case String:
+		buf.WriteByte('<')
+		buf.WriteString(hex.EncodeToString([]byte(v)))
+		buf.WriteByte('>')
+	case Ref:
+		fmt.Fprintf(buf, "%d %d R", v.Num, v.Gen)
+	case Array:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			appendObject(buf, item)
+		}
+		buf.WriteByte(']')
+	case Dict:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, string(key))
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("<<")
+		for _, key := range keys {
+			appendName(buf, Name(key))
+			buf.WriteByte(' ')
+			appendObject(buf, v[Name(key)])
+		}
+		buf.WriteString(">>")
+	case *Stream:
+		appendObject(buf, v.Dict)
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// appendName writes a name, escaping delimiters, whitespace and non-printable
+// bytes as #xx
+func appendName(buf *bytes.Buffer, name Name) {
+	buf.WriteByte('/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 0x21 || c > 0x7e || c == '#' || isDelimiter(c) {
+			fmt.Fprintf(buf, "#%02X", c)
+			continue
+		}
+		buf.WriteByte(c)
+	}
+}
@@ -0,0 +1,608 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// maxDepth bounds nesting of arrays and dictionaries
+	maxDepth = 100
+
+	// maxObjects bounds the number of indirect objects in a document
+	maxObjects = 1_000_000
+
+	// maxDecodedStream bounds the decompressed size of an object stream, which
+	// protects against compression bombs
+	maxDecodedStream = 64 << 20 // 64MB
+)
+
+var (
+	// ErrNotPDF is returned when the input has no PDF header
+	ErrNotPDF = errors.New("pdf: missing %PDF header")
+
+	// ErrNoCatalog is returned when no document catalog (/Root) can be found
+	ErrNoCatalog = errors.New("pdf: document catalog not found")
+
+	// ErrEncrypted is returned when an operation needs to rewrite an encrypted document
+	ErrEncrypted = errors.New("pdf: document is encrypted")
+
+	errUnsupportedFilter = errors.New("pdf: unsupported stream filter")
+)
+
+// indirect is the latest definition of an indirect object
+type indirect struct {
+	gen   int
+	value Object
+	// position orders definitions so incremental updates win: the file offset
+	// of the definition, or of the containing object stream
+	position int64
+}
+
+// Document is a parsed PDF. Object definitions are held in memory, stream
+// payloads stay in the source and are read on demand.
+type Document struct {
+	src     io.ReaderAt
+	size    int64
+	version string
+	objects map[int]*indirect
+	trailer Dict
+
+	// objectStreams that could not be decoded; their contents are unknown
+	undecodable int
+}
+
+// Parse reads a PDF from src.
+//
+// The file is scanned sequentially for "N G obj ... endobj" definitions rather
+// than trusting the cross-reference table, which is exactly what attackers
+// tamper with. Compressed object streams are decompressed and their objects
+// added to the document, so nothing hides from Inspect inside a FlateDecode
+// stream. Malformed objects are skipped rather than failing the whole parse.
+func Parse(src io.ReaderAt, size int64) (*Document, error) {
+	version, err := readVersion(src, size)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		src:     src,
+		size:    size,
+		version: version,
+		objects: make(map[int]*indirect),
+		trailer: Dict{},
+	}
+
+	p := &parser{doc: doc, lx: newLexer(src, size)}
+	if err := p.scan(); err != nil {
+		return nil, err
+	}
+
+	doc.fixIndirectLengths()
+	doc.expandObjectStreams()
+
+	if _, ok := doc.trailer["Root"].(Ref); !ok {
+		return nil, ErrNoCatalog
+	}
+
+	return doc, nil
+}
+
+// readVersion finds the %PDF-x.y header within the first kilobyte
+func readVersion(src io.ReaderAt, size int64) (string, error) {
+	head := make([]byte, min(size, 1024))
+	n, err := src.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	idx := bytes.Index(head, []byte("%PDF-"))
+	if idx < 0 {
+		return "", ErrNotPDF
+	}
+
+	version := head[idx+5:]
+	end := 0
+	for end < len(version) && end < 8 && (version[end] == '.' || (version[end] >= '0' && version[end] <= '9')) {
+		end++
+	}
+	if end == 0 {
+		return "1.7", nil
+	}
+
+	return string(version[:end]), nil
+}
+
+// Version returns the version from the file header, e.g. "1.7"
+func (d *Document) Version() string {
+	return d.version
+}
+
+// Encrypted reports whether the document uses the standard security handler
+func (d *Document) Encrypted() bool {
+	_, ok := d.trailer["Encrypt"]
+	return ok
+}
+
+// Resolve follows indirect references until it reaches a direct object.
+// Missing objects resolve to nil, as the specification requires.
+func (d *Document) Resolve(obj Object) Object {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj
+		}
+		def, ok := d.objects[ref.Num]
+		if !ok {
+			return nil
+		}
+		obj = def.value
+	}
+	return nil
+}
+
+// Catalog returns the document catalog (the /Root dictionary)
+func (d *Document) Catalog() Dict {
+	catalog, _ := d.Resolve(d.trailer["Root"]).(Dict)
+	return catalog
+}
+
+// fixIndirectLengths corrects streams whose /Length is an indirect reference.
+// During the scan those lengths are unknown and the payload is delimited by
+// searching for "endstream", which a payload may legitimately contain.
+func (d *Document) fixIndirectLengths() {
+	for _, def := range d.objects {
+		stream, ok := def.value.(*Stream)
+		if !ok {
+			continue
+		}
+		if _, ok := stream.Dict["Length"].(Ref); !ok {
+			continue
+		}
+
+		length, ok := d.Resolve(stream.Dict["Length"]).(int64)
+		if !ok || length < 0 || stream.offset+length > d.size {
+			continue
+		}
+
+		lx := newLexer(d.src, d.size)
+		lx.seek(stream.offset + length)
+		if tok, err := lx.next(); err == nil && tok.is(tokenKeyword, "endstream") {
+			stream.length = length
+		}
+	}
+}
+
+// expandObjectStreams decodes every object stream (/Type /ObjStm) and adds the
+// objects it contains, unless a later definition of the same object exists.
+func (d *Document) expandObjectStreams() {
+	// Collect first: expanding adds entries to d.objects
+	var streams []*indirect
+	for _, def := range d.objects {
+		if stream, ok := def.value.(*Stream); ok && stream.Dict.name("Type") == "ObjStm" {
+			streams = append(streams, def)
+		}
+	}
+
+	for _, def := range streams {
+		if err := d.expandObjectStream(def); err != nil {
+			d.undecodable++
+		}
+	}
+}
+
+func (d *Document) expandObjectStream(def *indirect) error {
+	stream := def.value.(*Stream)
+
+	count, okCount := d.Resolve(stream.Dict["N"]).(int64)
+	first, okFirst := d.Resolve(stream.Dict["First"]).(int64)
+	if !okCount || !okFirst || count < 0 || first < 0 {
+		return fmt.Errorf("pdf: object stream without /N or /First")
+	}
+
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return err
+	}
+	if first > int64(len(data)) {
+		return fmt.Errorf("pdf: object stream /First out of range")
+	}
+
+	src := bytes.NewReader(data)
+	header := newLexer(src, first)
+
+	type entry struct {
+		num    int
+		offset int64
+	}
+	entries := make([]entry, 0, min(count, 4096))
+	for i := int64(0); i < count; i++ {
+		numTok, err := header.next()
+		if err != nil || numTok.kind != tokenInteger {
+			return fmt.Errorf("pdf: malformed object stream header")
+		}
+		offsetTok, err := header.next()
+		if err != nil || offsetTok.kind != tokenInteger {
+			return fmt.Errorf("pdf: malformed object stream header")
+		}
+		num, _ := strconv.Atoi(numTok.text)
+		offset, _ := strconv.ParseInt(offsetTok.text, 10, 64)
+		entries = append(entries, entry{num: num, offset: offset})
+	}
+
+	p := &parser{doc: d, lx: newLexer(src, int64(len(data)))}
+	for _, e := range entries {
+		if existing, ok := d.objects[e.num]; ok && existing.position > def.position {
+			continue
+		}
+
+		p.lx.seek(first + e.offset)
+		value, err := p.parseObject(0)
+		if err != nil {
+			continue
+		}
+		if err := d.define(e.num, 0, value, def.position); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeStream returns the decoded payload of a stream. Only FlateDecode
+// without predictors is supported, which is what object streams use in practice.
+func (d *Document) decodeStream(stream *Stream) ([]byte, error) {
+	raw := io.NewSectionReader(d.src, stream.offset, stream.length)
+
+	var filters []Name
+	switch filter := d.Resolve(stream.Dict["Filter"]).(type) {
+	case nil:
+	case Name:
+		filters = []Name{filter}
+	case Array:
+		for _, item := range filter {
+			name, ok := d.Resolve(item).(Name)
+			if !ok {
+				return nil, errUnsupportedFilter
+			}
+			filters = append(filters, name)
+		}
+	default:
+		return nil, errUnsupportedFilter
+	}
+
+	var reader io.Reader = raw
+	switch {
+	case len(filters) == 0:
+	case len(filters) == 1 && (filters[0] == "FlateDecode" || filters[0] == "Fl"):
+		if params, ok := d.Resolve(stream.Dict["DecodeParms"]).(Dict); ok {
+			if predictor, ok := params["Predictor"].(int64); ok && predictor > 1 {
+				return nil, errUnsupportedFilter
+			}
+		}
+		zr, err := zlib.NewReader(raw)
+		if err != nil {
+			return nil, fmt.Errorf("pdf: invalid flate stream: %w", err)
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return nil, errUnsupportedFilter
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxDecodedStream+1))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: failed to decode stream: %w", err)
+	}
+	if len(data) > maxDecodedStream {
+		return nil, fmt.Errorf("pdf: decoded stream exceeds %d bytes", maxDecodedStream)
+	}
+
+	return data, nil
+}
+
+// define records an object definition unless a later one already exists
+func (d *Document) define(num, gen int, value Object, position int64) error {
+	if num <= 0 {
+		return nil
+	}
+	if existing, ok := d.objects[num]; ok {
+		if existing.position > position {
+			return nil
+		}
+	} else if len(d.objects) >= maxObjects {
+		return fmt.Errorf("pdf: more than %d objects", maxObjects)
+	}
+
+	d.objects[num] = &indirect{gen: gen, value: value, position: position}
+	return nil
+}
+
+// parser turns lexer tokens into objects
+type parser struct {
+	doc *Document
+	lx  *lexer
+}
+
+// scan walks the whole file, collecting object definitions and trailers
+func (p *parser) scan() error {
+	for {
+		tok, err := p.lx.next()
+		if errors.Is(err, errSyntax) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case tok.kind == tokenEOF:
+			return nil
+
+		case tok.kind == tokenInteger:
+			// "num gen obj" starts an object; anything else (xref entries,
+			// startxref offsets) is skipped one token at a time
+			genTok, err := p.lx.next()
+			if errors.Is(err, errSyntax) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if genTok.kind != tokenInteger {
+				p.lx.unread(genTok)
+				continue
+			}
+			objTok, err := p.lx.next()
+			if errors.Is(err, errSyntax) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !objTok.is(tokenKeyword, "obj") {
+				p.lx.unread(objTok)
+				p.lx.unread(genTok)
+				continue
+			}
+
+			num, errNum := strconv.Atoi(tok.text)
+			gen, errGen := strconv.Atoi(genTok.text)
+			if errNum != nil || errGen != nil {
+				continue
+			}
+			if err := p.parseIndirect(num, gen, tok.offset); err != nil {
+				return err
+			}
+
+		case tok.is(tokenKeyword, "trailer"):
+			if trailer, err := p.parseObject(0); err == nil {
+				if dict, ok := trailer.(Dict); ok {
+					p.mergeTrailer(dict)
+				}
+			}
+		}
+	}
+}
+
+// parseIndirect parses the body of "num gen obj ... endobj". Only fatal
+// conditions are returned as errors; a malformed object is skipped.
+func (p *parser) parseIndirect(num, gen int, position int64) error {
+	value, err := p.parseObject(0)
+	if err != nil {
+		return fatal(err)
+	}
+
+	tok, err := p.lx.next()
+	if err != nil {
+		return fatal(err)
+	}
+
+	if tok.is(tokenKeyword, "stream") {
+		dict, ok := value.(Dict)
+		if !ok || len(p.lx.pending) > 0 {
+			return nil
+		}
+
+		p.lx.skipEOL()
+		start := p.lx.offset
+		value = &Stream{Dict: dict, offset: start, length: p.skipStreamData(dict, start)}
+
+		// Cross-reference streams carry the trailer in PDF 1.5+
+		if dict.name("Type") == "XRef" {
+			p.mergeTrailer(dict)
+		}
+
+		if tok, err = p.lx.next(); err != nil {
+			return fatal(err)
+		}
+	}
+
+	if !tok.is(tokenKeyword, "endobj") {
+		p.lx.unread(tok)
+	}
+
+	return p.doc.define(num, gen, value, position)
+}
+
+// fatal filters the errors that must stop a scan: anything but malformed
+// syntax, which is skipped
+func fatal(err error) error {
+	if errors.Is(err, errSyntax) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+	return err
+}
+
+// skipStreamData moves the lexer past a stream payload and returns its length.
+// A direct /Length is trusted only if "endstream" follows it; otherwise the
+// payload runs up to the next "endstream" keyword.
+func (p *parser) skipStreamData(dict Dict, start int64) int64 {
+	if length, ok := dict["Length"].(int64); ok && length >= 0 && start+length <= p.lx.size {
+		p.lx.seek(start + length)
+		if tok, err := p.lx.next(); err == nil && tok.is(tokenKeyword, "endstream") {
+			return length
+		}
+	}
+
+	idx := indexFrom(p.lx.src, p.lx.size, start, []byte("endstream"))
+	if idx < 0 {
+		p.lx.seek(p.lx.size)
+		return p.lx.size - start
+	}
+
+	end := idx
+	if tail := readAt(p.lx.src, idx-2, 2); len(tail) == 2 && tail[0] == '\r' && tail[1] == '\n' {
+		end -= 2
+	} else if len(tail) == 2 && (tail[1] == '\n' || tail[1] == '\r') {
+		end--
+	}
+
+	p.lx.seek(idx + int64(len("endstream")))
+	return max(end-start, 0)
+}
+
+func (p *parser) mergeTrailer(dict Dict) {
+	for _, key := range []Name{"Root", "Info", "ID", "Encrypt"} {
+		if value, ok := dict[key]; ok {
+			p.doc.trailer[key] = value
+		}
+	}
+}
+
+// parseObject parses one direct object, or a reference
+func (p *parser) parseObject(depth int) (Object, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: objects nested deeper than %d", errSyntax, maxDepth)
+	}
+
+	tok, err := p.lx.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok.kind {
+	case tokenEOF:
+		return nil, io.ErrUnexpectedEOF
+
+	case tokenInteger:
+		value, _ := strconv.ParseInt(tok.text, 10, 64)
+
+		// An integer followed by another integer and "R" is a reference
+		genTok, err := p.lx.next()
+		if err != nil {
+			return nil, err
+		}
+		if genTok.kind == tokenInteger {
+			refTok, err := p.lx.next()
+			if err != nil {
+				return nil, err
+			}
+			if refTok.is(tokenKeyword, "R") {
+				gen, _ := strconv.Atoi(genTok.text)
+				return Ref{Num: int(value), Gen: gen}, nil
+			}
+			p.lx.unread(refTok)
+		}
+		p.lx.unread(genTok)
+		return value, nil
+
+	case tokenReal:
+		return Real(tok.text), nil
+
+	case tokenName:
+		return Name(tok.text), nil
+
+	case tokenString:
+		return String(tok.text), nil
+
+	case tokenArrayStart:
+		array := Array{}
+		for {
+			next, err := p.lx.next()
+			if err != nil {
+				return nil, err
+			}
+			if next.kind == tokenArrayEnd {
+				return array, nil
+			}
+			p.lx.unread(next)
+
+			item, err := p.parseObject(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+
+	case tokenDictStart:
+		dict := Dict{}
+		for {
+			key, err := p.lx.next()
+			if err != nil {
+				return nil, err
+			}
+			if key.kind == tokenDictEnd {
+				return dict, nil
+			}
+			if key.kind != tokenName {
+				return nil, fmt.Errorf("%w: expected name as dictionary key at offset %d", errSyntax, key.offset)
+			}
+
+			value, err := p.parseObject(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			// A null value is equivalent to omitting the entry
+			if value != nil {
+				dict[Name(key.text)] = value
+			}
+		}
+
+	case tokenKeyword:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unexpected token %q at offset %d", errSyntax, tok.text, tok.offset)
+}
+
+// indexFrom returns the offset of the first occurrence of pattern at or after
+// from, reading src in chunks. It returns -1 if there is none.
+func indexFrom(src io.ReaderAt, size, from int64, pattern []byte) int64 {
+	const chunkSize = 64 << 10
+	overlap := int64(len(pattern) - 1)
+
+	for offset := from; offset < size; offset += chunkSize - overlap {
+		chunk := readAt(src, offset, min(chunkSize, size-offset))
+		if idx := bytes.Index(chunk, pattern); idx >= 0 {
+			return offset + int64(idx)
+		}
+		if int64(len(chunk)) < chunkSize {
+			break
+		}
+	}
+
+	return -1
+}
+
+// readAt reads up to n bytes at offset, returning what could be read
+func readAt(src io.ReaderAt, offset, n int64) []byte {
+	if offset < 0 || n <= 0 {
+		return nil
+	}
+	buf := make([]byte, n)
+	read, _ := src.ReadAt(buf, offset)
+	return buf[:read]
+}
@@ -0,0 +1,281 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF assembles a PDF from object bodies, numbered from 1. Bodies that
+// start with "stream:" become streams with the remainder as payload.
+// The cross-reference table is deliberately bogus: Parse must not need it.
+func buildPDF(trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+
+	for i, body := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		if payload, ok := strings.CutPrefix(body, "stream:"); ok {
+			dict, data, _ := strings.Cut(payload, "|")
+			fmt.Fprintf(&buf, "<<%s /Length %d>>\nstream\n%s\nendstream", dict, len(data), data)
+		} else {
+			buf.WriteString(body)
+		}
+		buf.WriteString("\nendobj\n")
+	}
+
+	fmt.Fprintf(&buf, "xref\n0 1\n0000000000 65535 f \ntrailer\n%s\nstartxref\n0\n%%%%EOF\n", trailer)
+	return buf.Bytes()
+}
+
+// objectStream builds the payload of a compressed object stream
+func objectStream(first int, objects map[int]string) (string, string) {
+	var header, body bytes.Buffer
+	for num := first; num < first+len(objects); num++ {
+		fmt.Fprintf(&header, "%d %d ", num, body.Len())
+		body.WriteString(objects[num])
+		body.WriteByte(' ')
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(header.Bytes())
+	zw.Write(body.Bytes())
+	zw.Close()
+
+	dict := fmt.Sprintf(" /Type /ObjStm /N %d /First %d /Filter /FlateDecode", len(objects), header.Len())
+	return dict, compressed.String()
+}
+
+func parse(t *testing.T, data []byte) *Document {
+	t.Helper()
+	doc, err := Parse(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return doc
+}
+
+func TestParseRejectsNonPDF(t *testing.T) {
+	data := []byte("GIF89a not a pdf")
+	_, err := Parse(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorIs(t, err, ErrNotPDF)
+}
+
+func TestParseRequiresCatalog(t *testing.T) {
+	data := buildPDF("<< /Size 2 >>", "<< /Type /Pages /Kids [] /Count 0 >>")
+	_, err := Parse(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorIs(t, err, ErrNoCatalog)
+}
+
+func TestInspectCleanDocumentWithLinks(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Annots [4 0 R] /Contents 5 0 R >>",
+		"<< /Type /Annot /Subtype /Link /A << /S /URI /URI (https://example.com/manual) >> >>",
+		"stream:|BT /F1 12 Tf (Hello) Tj ET",
+	)
+
+	report := parse(t, data).Inspect()
+
+	assert.Equal(t, "1.7", report.Version)
+	assert.Equal(t, 5, report.Objects)
+	assert.True(t, report.Has(FeatureURI), "links are reported")
+	assert.Empty(t, report.ActiveContent(), "links are not active content")
+	assert.True(t, report.Inspectable())
+}
+
+func TestInspectActiveContent(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R /OpenAction 4 0 R /Names << /EmbeddedFiles 5 0 R >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /AA << /O << /S /Launch /F (calc.exe) >> >> >>",
+		"<< /S /JavaScript /JS (app.alert(1)) >>",
+		"<< /Names [(a.exe) << /Type /Filespec /EF << /F 6 0 R >> >>] >>",
+		"stream: /Type /EmbeddedFile|MZ payload",
+	)
+
+	report := parse(t, data).Inspect()
+
+	assert.Equal(t, []Feature{
+		FeatureAdditionalActions,
+		FeatureEmbeddedFile,
+		FeatureJavaScript,
+		FeatureLaunch,
+		FeatureOpenAction,
+	}, report.ActiveContent())
+}
+
+func TestInspectIgnoresUnreachableObjects(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /S /JavaScript /JS (app.alert(1)) >>", // Referenced by nothing
+	)
+
+	assert.Empty(t, parse(t, data).Inspect().ActiveContent())
+}
+
+func TestInspectDecodesEscapedNames(t *testing.T) {
+	// "/Java#53cript" is "/JavaScript" and "/J#53" is "/JS"
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R /OpenAction << /S /Java#53cript /J#53 (app.alert(1)) >> >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+	)
+
+	assert.True(t, parse(t, data).Inspect().Has(FeatureJavaScript))
+}
+
+func TestInspectLooksInsideObjectStreams(t *testing.T) {
+	dict, payload := objectStream(3, map[int]string{
+		3: "<< /Type /Pages /Kids [] /Count 0 >>",
+		4: "<< /S /JavaScript /JS (this.exportDataObject()) >>",
+	})
+
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 3 0 R /OpenAction 4 0 R >>",
+		"stream:"+dict+"|"+payload,
+	)
+
+	report := parse(t, data).Inspect()
+	assert.True(t, report.Has(FeatureJavaScript), "JavaScript hidden in a compressed object stream")
+	assert.True(t, report.Inspectable())
+}
+
+func TestInspectReportsUndecodableObjectStreams(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		"stream: /Type /ObjStm /N 1 /First 4 /Filter /LZWDecode|garbage",
+	)
+
+	report := parse(t, data).Inspect()
+	assert.Equal(t, 1, report.Undecodable)
+	assert.False(t, report.Inspectable())
+}
+
+func TestParseStreamWithIndirectLength(t *testing.T) {
+	// The payload contains "endstream"; only the indirect /Length tells where it really ends
+	payload := "q endstream Q"
+	data := []byte(fmt.Sprintf("%%PDF-1.4\n"+
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n"+
+		"2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n"+
+		"3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n"+
+		"4 0 obj << /Length 5 0 R >>\nstream\n%s\nendstream\nendobj\n"+
+		"5 0 obj %d endobj\n"+
+		"trailer << /Root 1 0 R >>\n%%%%EOF\n", payload, len(payload)))
+
+	doc := parse(t, data)
+	stream, ok := doc.objects[4].value.(*Stream)
+	require.True(t, ok)
+	assert.Equal(t, int64(len(payload)), stream.length)
+}
+
+func TestWriteSanitized(t *testing.T) {
+	dict, payload := objectStream(7, map[int]string{
+		7: "<< /S /JavaScript /JS (app.launchURL('https://evil.example')) >>",
+	})
+
+	data := buildPDF("<< /Root 1 0 R /Info 8 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R /OpenAction 7 0 R /Names << /EmbeddedFiles 5 0 R /Dests 9 0 R >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Annots [4 0 R 10 0 R] /Contents 6 0 R /AA << /O 7 0 R >> >>",
+		"<< /Type /Annot /Subtype /Link /A << /S /URI /URI (https://example.com) /Next 7 0 R >> >>",
+		"<< /Names [(a.exe) << /Type /Filespec /EF << /F 11 0 R >> >>] >>",
+		"stream:|BT /F1 12 Tf (Hello) Tj ET",
+		"stream:"+dict+"|"+payload,
+		"<< /Title (Manual) >>",
+		"<< /Names [] >>",
+		"<< /Type /Annot /Subtype /FileAttachment /FS << /EF << /F 11 0 R >> >> >>",
+		"stream: /Type /EmbeddedFile|MZ payload",
+	)
+
+	original := parse(t, data).Inspect()
+	require.NotEmpty(t, original.ActiveContent())
+
+	var out bytes.Buffer
+	n, err := parse(t, data).WriteSanitized(&out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+
+	sanitized := parse(t, out.Bytes())
+	report := sanitized.Inspect()
+
+	assert.Empty(t, report.ActiveContent(), "all active content is removed")
+	assert.True(t, report.Has(FeatureURI), "links survive sanitizing")
+	assert.NotContains(t, out.String(), "MZ payload", "embedded files are not written")
+	assert.Contains(t, out.String(), "BT /F1 12 Tf (Hello) Tj ET", "page content is copied as-is")
+
+	// The page keeps its link and loses the attachment annotation
+	page, ok := sanitized.Resolve(Ref{Num: 3}).(Dict)
+	require.True(t, ok)
+	assert.Equal(t, Array{Ref{Num: 4}}, page["Annots"])
+}
+
+func TestWriteSanitizedRefusesEncrypted(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R /Encrypt 3 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /Filter /Standard /V 2 >>",
+	)
+
+	doc := parse(t, data)
+	assert.False(t, doc.Inspect().Inspectable())
+
+	_, err := doc.WriteSanitized(&bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrEncrypted)
+}
+
+func TestInspectSampleFiles(t *testing.T) {
+	for _, name := range []string{"ecommerce_catalog.pdf", "ecommerce_simple.pdf"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile("../../httpClientTest/pdfs/" + name)
+			if err != nil {
+				t.Skipf("Test file not found: %v", err)
+			}
+
+			doc := parse(t, data)
+			assert.Empty(t, doc.Inspect().ActiveContent())
+
+			var out bytes.Buffer
+			_, err = doc.WriteSanitized(&out)
+			require.NoError(t, err)
+			assert.Equal(t, doc.Inspect().Objects, parse(t, out.Bytes()).Inspect().Objects)
+		})
+	}
+
+	t.Run("malicious_test.pdf", func(t *testing.T) {
+		data, err := os.ReadFile("../../httpClientTest/pdfs/malicious_test.pdf")
+		if err != nil {
+			t.Skipf("Test file not found: %v", err)
+		}
+
+		assert.NotEmpty(t, parse(t, data).Inspect().ActiveContent())
+	})
+}
+
+func TestInfo(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R /Info 3 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 34 >>",
+		"<< /Title <FEFF0043006100660065003A002000E9> /Author (Jane \\215Doe\\216 ) >>",
+	)
+
+	info := parse(t, data).Info()
+
+	assert.Equal(t, Info{Pages: 34, Title: "Cafe: é", Author: "Jane “Doe”"}, info)
+}
+
+func TestInfoMissingEntries(t *testing.T) {
+	data := buildPDF("<< /Root 1 0 R >>",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] >>",
+	)
+
+	assert.Equal(t, Info{}, parse(t, data).Info())
+}
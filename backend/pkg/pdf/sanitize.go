@@ -0,0 +1,252 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// strippedKeys are dictionary entries removed wherever they appear. Keys are
+// named rather than values, so the entry disappears even if it points to an
+// object that is otherwise harmless.
+var strippedKeys = map[Name]bool{
+	"JS":                true,
+	"JavaScript":        true,
+	"OpenAction":        true,
+	"AA":                true,
+	"EmbeddedFiles":     true,
+	"EF":                true,
+	"XFA":               true,
+	"NeedsRendering":    true, // Only meaningful for XFA forms
+	"RichMediaContent":  true,
+	"RichMediaSettings": true,
+}
+
+// dropped reports whether a dictionary is itself active content: an action of
+// an active type, an active annotation, or an embedded file stream.
+func dropped(dict Dict) bool {
+	if feature, ok := actionFeatures[dict.name("S")]; ok && feature.IsActive() {
+		return true
+	}
+	if feature, ok := annotationFeatures[dict.name("Subtype")]; ok && feature.IsActive() && dict.name("Type") != "XObject" {
+		return true
+	}
+	return dict.name("Type") == "EmbeddedFile"
+}
+
+// sanitizer rewrites objects with active content removed
+type sanitizer struct {
+	// removed holds indirect objects that are active content in their entirety.
+	// References to them are removed like the objects themselves.
+	removed map[int]bool
+}
+
+// clean returns obj without active content. keep is false when obj as a whole
+// is active content and must be removed from its container.
+func (s *sanitizer) clean(obj Object, depth int) (Object, bool) {
+	if depth > maxDepth {
+		return nil, false
+	}
+
+	switch v := obj.(type) {
+	case Ref:
+		return v, !s.removed[v.Num]
+
+	case Dict:
+		if dropped(v) {
+			return nil, false
+		}
+		cleaned := make(Dict, len(v))
+		for key, value := range v {
+			if strippedKeys[key] {
+				continue
+			}
+			if value, keep := s.clean(value, depth+1); keep {
+				cleaned[key] = value
+			}
+		}
+		return cleaned, true
+
+	case Array:
+		cleaned := make(Array, 0, len(v))
+		for _, item := range v {
+			if item, keep := s.clean(item, depth+1); keep {
+				cleaned = append(cleaned, item)
+			}
+		}
+		return cleaned, true
+
+	case *Stream:
+		dict, keep := s.clean(v.Dict, depth+1)
+		if !keep {
+			return nil, false
+		}
+		return &Stream{Dict: dict.(Dict), offset: v.offset, length: v.length}, true
+	}
+
+	return obj, true
+}
+
+// countingWriter tracks the number of bytes written, for xref offsets
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriteSanitized writes a copy of the document with all active content removed
+// (JavaScript, open and additional actions, launch/submit/import actions,
+// embedded files, XFA, rich media and multimedia). Links stay intact.
+//
+// Only objects still reachable after sanitizing are written, compressed object
+// streams are flattened into plain objects, and a fresh cross-reference table
+// is generated. Stream payloads are copied from the source without being
+// decoded. Encrypted documents are refused with ErrEncrypted.
+func (d *Document) WriteSanitized(w io.Writer) (int64, error) {
+	if d.Encrypted() {
+		return 0, ErrEncrypted
+	}
+
+	s := &sanitizer{removed: map[int]bool{}}
+	for num, def := range d.objects {
+		switch v := def.value.(type) {
+		case Dict:
+			s.removed[num] = dropped(v)
+		case *Stream:
+			s.removed[num] = dropped(v.Dict)
+		}
+	}
+
+	cleaned := make(map[int]Object, len(d.objects))
+	for num, def := range d.objects {
+		if s.removed[num] {
+			continue
+		}
+		if value, keep := s.clean(def.value, 0); keep {
+			cleaned[num] = value
+		}
+	}
+
+	trailer := Dict{}
+	for _, key := range []Name{"Root", "Info", "ID"} {
+		if value, keep := s.clean(d.trailer[key], 0); keep && value != nil {
+			trailer[key] = value
+		}
+	}
+
+	// Walk the cleaned graph: whatever sanitizing cut off is not written
+	nums := reachableFrom(cleaned, trailer)
+
+	cw := &countingWriter{w: w}
+	if _, err := fmt.Fprintf(cw, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", d.version); err != nil {
+		return cw.n, err
+	}
+
+	size := 1
+	if len(nums) > 0 {
+		size = nums[len(nums)-1] + 1
+	}
+	offsets := make([]int64, size)
+
+	for _, num := range nums {
+		offsets[num] = cw.n
+		if err := d.writeIndirect(cw, num, cleaned[num]); err != nil {
+			return cw.n, err
+		}
+	}
+
+	xrefOffset := cw.n
+	if _, err := fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f\r\n", size); err != nil {
+		return cw.n, err
+	}
+	for num := 1; num < size; num++ {
+		entry := "0000000000 65535 f\r\n"
+		if offsets[num] > 0 {
+			entry = fmt.Sprintf("%010d %05d n\r\n", offsets[num], d.objects[num].gen)
+		}
+		if _, err := io.WriteString(cw, entry); err != nil {
+			return cw.n, err
+		}
+	}
+
+	trailer["Size"] = int64(size)
+	if _, err := io.WriteString(cw, "trailer\n"); err != nil {
+		return cw.n, err
+	}
+	if err := writeObject(cw, trailer); err != nil {
+		return cw.n, err
+	}
+	_, err := fmt.Fprintf(cw, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return cw.n, err
+}
+
+// writeIndirect writes "num gen obj ... endobj", copying stream payloads from the source
+func (d *Document) writeIndirect(w io.Writer, num int, value Object) error {
+	if _, err := fmt.Fprintf(w, "%d %d obj\n", num, d.objects[num].gen); err != nil {
+		return err
+	}
+
+	stream, isStream := value.(*Stream)
+	if isStream {
+		// Lengths may have been indirect; the copy always gets a direct one
+		dict := make(Dict, len(stream.Dict))
+		for key, v := range stream.Dict {
+			dict[key] = v
+		}
+		dict["Length"] = stream.length
+		value = dict
+	}
+
+	if err := writeObject(w, value); err != nil {
+		return err
+	}
+
+	if isStream {
+		if _, err := io.WriteString(w, "\nstream\r\n"); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(d.src, stream.offset, stream.length)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\r\nendstream"); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\nendobj\n")
+	return err
+}
+
+// reachableFrom returns the sorted numbers of objects reachable from a trailer
+func reachableFrom(objects map[int]Object, trailer Dict) []int {
+	seen := map[int]bool{}
+	var queue []int
+
+	visit := func(ref Ref) {
+		if _, ok := objects[ref.Num]; ok && !seen[ref.Num] {
+			seen[ref.Num] = true
+			queue = append(queue, ref.Num)
+		}
+	}
+
+	walkRefs(trailer, 0, visit)
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		walkRefs(objects[num], 0, visit)
+	}
+
+	nums := make([]int, 0, len(seen))
+	for num := range seen {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	return nums
+}
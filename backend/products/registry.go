@@ -0,0 +1,239 @@
+// Package products keeps the registry of products, stored in the database
+// with their metadata so they can be created and archived without a
+// redeploy.
+//
+// Every instance caches the registry and reads it again in the background
+// once it is older than PRODUCT_REGISTRY_TTL: a product created or archived
+// on another instance is picked up within that time, while lookups never wait
+// for the database. Products listed in VALID_PRODUCTS are created on startup
+// if they do not exist, so deployments configured before the registry keep
+// their products.
+package products
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/config"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+)
+
+// refreshTimeout bounds a background read of the registry
+const refreshTimeout = 10 * time.Second
+
+// Store persists products. Implemented by db.ProductService.
+type Store interface {
+	List(ctx context.Context) ([]models.Product, error)
+	Create(ctx context.Context, product models.Product) error
+}
+
+// Registry caches the products of a store
+type Registry struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu         sync.RWMutex
+	products   map[string]models.Product
+	checkedAt  time.Time // Of the latest read, successful or not
+	refreshing bool
+}
+
+// Default is the registry lookups use, set by Initialize
+var Default *Registry
+
+// New creates a registry on top of store, which is read on the first lookup
+func New(store Store, ttl time.Duration) *Registry {
+	return &Registry{store: store, ttl: ttl, now: time.Now, products: map[string]models.Product{}}
+}
+
+// Initialize creates Default on top of store, creating the products of seed
+// that do not exist, and makes config.UploadPolicyFor use the upload
+// policies stored with products
+func Initialize(ctx context.Context, store Store, seed []string) (*Registry, error) {
+	registry := New(store, time.Duration(config.AppConfig.PRODUCT_REGISTRY_TTL)*time.Second)
+	if err := registry.Seed(ctx, seed); err != nil {
+		return nil, err
+	}
+
+	Default = registry
+	config.ProductUploadPolicy = func(product string) *config.UploadPolicy {
+		if p, ok := Lookup(product); ok {
+			return p.UploadPolicy
+		}
+		return nil
+	}
+	return registry, nil
+}
+
+// Refresh reads the products of the store
+func (r *Registry) Refresh(ctx context.Context) error {
+	list, err := r.store.List(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkedAt = r.now()
+	if err != nil {
+		return err
+	}
+
+	r.products = make(map[string]models.Product, len(list))
+	for _, product := range list {
+		r.products[product.Name] = product
+	}
+	return nil
+}
+
+// Seed reads the products of the store and creates those of names that do
+// not exist. Products are only created with their name: their metadata is
+// set by admins afterwards.
+func (r *Registry) Seed(ctx context.Context, names []string) error {
+	if err := r.Refresh(ctx); err != nil {
+		return err
+	}
+
+	seededElsewhere := false
+	for _, name := range names {
+		r.mu.RLock()
+		_, exists := r.products[name]
+		r.mu.RUnlock()
+		if exists {
+			continue
+		}
+
+		now := r.now()
+		product := models.Product{Name: name, DisplayName: name, CreatedAt: now, UpdatedAt: now}
+		err := r.store.Create(ctx, product)
+		if status.Code(err) == codes.AlreadyExists {
+			// Another instance seeded it, and admins may have set its
+			// metadata since: it is read rather than cached as created
+			seededElsewhere = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		logger.Infof("Registered product %s from VALID_PRODUCTS", name)
+		r.Put(product)
+	}
+
+	if seededElsewhere {
+		return r.Refresh(ctx)
+	}
+	return nil
+}
+
+// Put caches a product this instance wrote, so it does not wait for the next
+// refresh to see it
+func (r *Registry) Put(product models.Product) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[product.Name] = product
+}
+
+// Get returns a product, archived or not
+func (r *Registry) Get(name string) (models.Product, bool) {
+	r.refreshIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	product, exists := r.products[name]
+	return product, exists
+}
+
+// List returns the products ordered by name, without the archived ones
+// unless archived is set
+func (r *Registry) List(archived bool) []models.Product {
+	r.refreshIfStale()
+
+	r.mu.RLock()
+	list := make([]models.Product, 0, len(r.products))
+	for _, product := range r.products {
+		if archived || !product.Archived {
+			list = append(list, product)
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(list, func(a, b models.Product) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// refreshIfStale starts reading the products again once the cache expired,
+// unless a read is running. Lookups keep using the cache meanwhile, and after
+// a failed read until it expires again.
+func (r *Registry) refreshIfStale() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refreshing || r.now().Sub(r.checkedAt) < r.ttl {
+		return
+	}
+	r.refreshing = true
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		if err := r.Refresh(ctx); err != nil {
+			logger.Warnf("Failed to refresh products, using cached ones: %v", err)
+		}
+
+		r.mu.Lock()
+		r.refreshing = false
+		r.mu.Unlock()
+	}()
+}
+
+// Lookup returns a product of Default, archived or not. Until Default is
+// initialized, e.g. in tests, the products of VALID_PRODUCTS exist.
+func Lookup(name string) (models.Product, bool) {
+	if Default == nil {
+		if !slices.Contains(config.AppConfig.VALID_PRODUCTS, name) {
+			return models.Product{}, false
+		}
+		return models.Product{Name: name, DisplayName: name}, true
+	}
+	return Default.Get(name)
+}
+
+// IsActive reports whether a product exists and is not archived
+func IsActive(name string) bool {
+	product, exists := Lookup(name)
+	return exists && !product.Archived
+}
+
+// List returns the products of Default ordered by name, see Registry.List
+func List(archived bool) []models.Product {
+	if Default == nil {
+		list := make([]models.Product, 0, len(config.AppConfig.VALID_PRODUCTS))
+		for _, name := range slices.Sorted(slices.Values(config.AppConfig.VALID_PRODUCTS)) {
+			list = append(list, models.Product{Name: name, DisplayName: name})
+		}
+		return list
+	}
+	return Default.List(archived)
+}
+
+// Put caches a product this instance wrote in Default, see Registry.Put
+func Put(product models.Product) {
+	if Default != nil {
+		Default.Put(product)
+	}
+}
+
+// Names returns the names of the products of List
+func Names(archived bool) []string {
+	list := List(archived)
+	names := make([]string, len(list))
+	for i, product := range list {
+		names[i] = product.Name
+	}
+	return names
+}
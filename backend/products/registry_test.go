@@ -0,0 +1,200 @@
+package products
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/config"
+	"learninghub/models"
+)
+
+// memoryStore is a Store keeping products in memory
+type memoryStore struct {
+	mu       sync.Mutex
+	products map[string]models.Product
+	lists    int
+	listErr  error
+}
+
+func newMemoryStore(list ...models.Product) *memoryStore {
+	store := &memoryStore{products: map[string]models.Product{}}
+	for _, product := range list {
+		store.products[product.Name] = product
+	}
+	return store
+}
+
+func (s *memoryStore) List(_ context.Context) ([]models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists++
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+
+	list := make([]models.Product, 0, len(s.products))
+	for _, product := range s.products {
+		list = append(list, product)
+	}
+	return list, nil
+}
+
+func (s *memoryStore) Create(_ context.Context, product models.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.products[product.Name]; exists {
+		return status.Error(codes.AlreadyExists, "exists")
+	}
+	s.products[product.Name] = product
+	return nil
+}
+
+func (s *memoryStore) set(product models.Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products[product.Name] = product
+}
+
+func (s *memoryStore) listCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists
+}
+
+func TestRegistrySeed(t *testing.T) {
+	store := newMemoryStore(models.Product{Name: "ecomm", DisplayName: "E-commerce"})
+	registry := New(store, time.Minute)
+
+	require.NoError(t, registry.Seed(context.Background(), []string{"ecomm", "crm"}))
+
+	ecomm, exists := registry.Get("ecomm")
+	require.True(t, exists)
+	assert.Equal(t, "E-commerce", ecomm.DisplayName, "existing products are left as they are")
+
+	crm, exists := registry.Get("crm")
+	require.True(t, exists)
+	assert.Equal(t, "crm", crm.DisplayName)
+	assert.Contains(t, store.products, "crm")
+}
+
+// racingStore is a memoryStore where another instance stores each product
+// of seeded right before it is created
+type racingStore struct {
+	*memoryStore
+	seeded map[string]models.Product
+}
+
+func (s *racingStore) Create(ctx context.Context, product models.Product) error {
+	if stored, exists := s.seeded[product.Name]; exists {
+		s.set(stored)
+	}
+	return s.memoryStore.Create(ctx, product)
+}
+
+func TestRegistrySeedReadsProductsSeededElsewhere(t *testing.T) {
+	stored := models.Product{Name: "crm", DisplayName: "CRM", Owners: []string{"owner@example.com"}}
+	store := &racingStore{memoryStore: newMemoryStore(), seeded: map[string]models.Product{"crm": stored}}
+	registry := New(store, time.Minute)
+
+	require.NoError(t, registry.Seed(context.Background(), []string{"crm"}))
+
+	crm, exists := registry.Get("crm")
+	require.True(t, exists)
+	assert.Equal(t, stored, crm, "the stored product is cached, not the one seeding would create")
+}
+
+func TestRegistryList(t *testing.T) {
+	store := newMemoryStore(
+		models.Product{Name: "docs"},
+		models.Product{Name: "crm", Archived: true},
+		models.Product{Name: "ecomm"},
+	)
+	registry := New(store, time.Minute)
+	require.NoError(t, registry.Refresh(context.Background()))
+
+	names := func(list []models.Product) []string {
+		var names []string
+		for _, product := range list {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"docs", "ecomm"}, names(registry.List(false)))
+	assert.Equal(t, []string{"crm", "docs", "ecomm"}, names(registry.List(true)))
+}
+
+func TestRegistryRefreshesOnceStale(t *testing.T) {
+	store := newMemoryStore(models.Product{Name: "ecomm"})
+	registry := New(store, time.Minute)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+	require.NoError(t, registry.Refresh(context.Background()))
+
+	store.set(models.Product{Name: "docs"})
+	_, exists := registry.Get("docs")
+	assert.False(t, exists, "the cache is used until it expires")
+	assert.Equal(t, 1, store.listCount())
+
+	now = now.Add(time.Minute)
+	registry.Get("docs")
+	assert.Eventually(t, func() bool {
+		_, exists := registry.Get("docs")
+		return exists
+	}, time.Second, 5*time.Millisecond, "expired caches are read again in the background")
+	assert.Equal(t, 2, store.listCount(), "lookups during a read do not start another")
+}
+
+func TestRegistryKeepsCacheWhenRefreshFails(t *testing.T) {
+	store := newMemoryStore(models.Product{Name: "ecomm"})
+	registry := New(store, time.Minute)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+	require.NoError(t, registry.Refresh(context.Background()))
+
+	store.listErr = stdErrors.New("unavailable")
+	assert.Error(t, registry.Refresh(context.Background()))
+
+	_, exists := registry.Get("ecomm")
+	assert.True(t, exists)
+	assert.Equal(t, 2, store.listCount(), "a failed read is not retried before the cache expires again")
+}
+
+func TestLookup(t *testing.T) {
+	config.AppConfig = &config.EnvConfig{VALID_PRODUCTS: []string{"ecomm"}}
+	t.Cleanup(func() {
+		Default = nil
+		config.ProductUploadPolicy = nil
+	})
+
+	// Without a registry, VALID_PRODUCTS are the products
+	Default = nil
+	assert.True(t, IsActive("ecomm"))
+	assert.False(t, IsActive("crm"))
+	assert.Equal(t, []string{"ecomm"}, Names(false))
+
+	external := false
+	store := newMemoryStore(
+		models.Product{Name: "crm", Archived: true},
+		models.Product{Name: "docs", UploadPolicy: &config.UploadPolicy{AllowExternalURLs: &external}},
+	)
+	_, err := Initialize(context.Background(), store, []string{"ecomm"})
+	require.NoError(t, err)
+
+	assert.True(t, IsActive("ecomm"))
+	assert.True(t, IsActive("docs"))
+	assert.False(t, IsActive("crm"), "archived products are not active")
+	_, exists := Lookup("crm")
+	assert.True(t, exists)
+	assert.Equal(t, []string{"docs", "ecomm"}, Names(false))
+
+	assert.False(t, config.UploadPolicyFor("docs").ExternalURLsAllowed())
+	assert.True(t, config.UploadPolicyFor("ecomm").ExternalURLsAllowed())
+}
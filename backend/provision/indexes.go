@@ -0,0 +1,139 @@
+// Package provision prepares the database of a new product: the composite
+// indexes its queries need, and starter content.
+//
+// Firestore serves queries filtering on one field and ordering on another
+// only from a composite index, which must be declared in
+// firestore.indexes.json and deployed before the product is used. Indexes
+// generates them from the queries of the db package, MergeIndexFile adds them
+// to the file and ApplyIndexes creates them through the Firestore admin API.
+// Seed registers the product and creates sample resources, with the starter
+// tags they use.
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// Settings of composite index fields, as firestore.indexes.json spells them
+const (
+	QueryScopeCollection = "COLLECTION"
+	OrderAscending       = "ASCENDING"
+	OrderDescending      = "DESCENDING"
+	ArrayConfigContains  = "CONTAINS"
+)
+
+// Index is a composite index, in the layout of firestore.indexes.json
+type Index struct {
+	CollectionGroup string       `json:"collectionGroup"`
+	QueryScope      string       `json:"queryScope"`
+	Fields          []IndexField `json:"fields"`
+}
+
+// IndexField is a field of a composite index, either ordered or an array
+type IndexField struct {
+	FieldPath   string `json:"fieldPath"`
+	Order       string `json:"order,omitempty"`
+	ArrayConfig string `json:"arrayConfig,omitempty"`
+}
+
+// indexFile is the layout of firestore.indexes.json. Entries are kept as
+// they are written, only compared through Index.
+type indexFile struct {
+	Indexes        []json.RawMessage `json:"indexes"`
+	FieldOverrides json.RawMessage   `json:"fieldOverrides,omitempty"`
+}
+
+// Indexes returns the composite indexes the queries of a product need,
+// those of db.ResourceService.List:
+//   - resources newest first, filtered by type, by tags, or by both
+//   - resources newest first, filtered by one of the given custom fields.
+//     Firestore merges these with the type index when both filter.
+//
+// Tags are only ordered by usage count and resources looked up by content
+// hash, which the single-field indexes Firestore maintains by default serve:
+// <product>_tags needs no composite index.
+func Indexes(product string, fields []models.FieldDefinition) []Index {
+	resources := constants.GetResourcesCollectionName(product)
+	newestFirst := IndexField{FieldPath: "createdAt", Order: OrderDescending}
+	byType := IndexField{FieldPath: "type", Order: OrderAscending}
+	byTags := IndexField{FieldPath: "tags", ArrayConfig: ArrayConfigContains}
+
+	indexes := []Index{
+		{CollectionGroup: resources, QueryScope: QueryScopeCollection, Fields: []IndexField{byType, newestFirst}},
+		{CollectionGroup: resources, QueryScope: QueryScopeCollection, Fields: []IndexField{byTags, newestFirst}},
+		{CollectionGroup: resources, QueryScope: QueryScopeCollection, Fields: []IndexField{byType, byTags, newestFirst}},
+	}
+	for _, field := range fields {
+		indexes = append(indexes, Index{
+			CollectionGroup: resources,
+			QueryScope:      QueryScopeCollection,
+			Fields:          []IndexField{{FieldPath: "customFields." + field.Name, Order: OrderAscending}, newestFirst},
+		})
+	}
+	return indexes
+}
+
+// MergeIndexFile adds the indexes missing from the firestore.indexes.json at
+// path and returns those it added. The other content of the file is kept; a
+// missing file is created.
+func MergeIndexFile(path string, indexes []Index) ([]Index, error) {
+	var file indexFile
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read index file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse index file %s: %w", path, err)
+		}
+	}
+
+	existing := make([]Index, 0, len(file.Indexes))
+	for _, raw := range file.Indexes {
+		var index Index
+		if err := json.Unmarshal(raw, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse index file %s: %w", path, err)
+		}
+		existing = append(existing, index)
+	}
+
+	var added []Index
+	for _, index := range indexes {
+		if slices.ContainsFunc(existing, index.equal) || slices.ContainsFunc(added, index.equal) {
+			continue
+		}
+		raw, err := json.Marshal(index)
+		if err != nil {
+			return nil, err
+		}
+		file.Indexes = append(file.Indexes, raw)
+		added = append(added, index)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, buffer.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write index file: %w", err)
+	}
+	return added, nil
+}
+
+// equal reports whether two indexes index the same fields the same way
+func (i Index) equal(other Index) bool {
+	return i.CollectionGroup == other.CollectionGroup && i.QueryScope == other.QueryScope && slices.Equal(i.Fields, other.Fields)
+}
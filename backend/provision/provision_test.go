@@ -0,0 +1,119 @@
+package provision
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore/apiv1/admin/adminpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+func TestIndexesMatchIndexFile(t *testing.T) {
+	data, err := os.ReadFile("../firestore.indexes.json")
+	require.NoError(t, err)
+
+	var file struct {
+		Indexes []Index `json:"indexes"`
+	}
+	require.NoError(t, json.Unmarshal(data, &file))
+	declared := slices.DeleteFunc(file.Indexes, func(i Index) bool { return i.CollectionGroup != "ecomm_resources" })
+
+	assert.Equal(t, declared, Indexes("ecomm", nil))
+}
+
+func TestIndexesCustomFields(t *testing.T) {
+	indexes := Indexes("hr", []models.FieldDefinition{{Name: "difficulty", Type: "enum"}})
+
+	require.Len(t, indexes, 4)
+	for _, index := range indexes {
+		assert.Equal(t, "hr_resources", index.CollectionGroup)
+	}
+	assert.Equal(t, []IndexField{
+		{FieldPath: "customFields.difficulty", Order: OrderAscending},
+		{FieldPath: "createdAt", Order: OrderDescending},
+	}, indexes[3].Fields)
+}
+
+func TestMergeIndexFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "firestore.indexes.json")
+	original, err := os.ReadFile("../firestore.indexes.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, original, 0o644))
+
+	added, err := MergeIndexFile(path, Indexes("ecomm", nil))
+	require.NoError(t, err)
+	assert.Empty(t, added, "declared indexes are not added again")
+
+	added, err = MergeIndexFile(path, Indexes("hr", nil))
+	require.NoError(t, err)
+	assert.Len(t, added, 3)
+
+	added, err = MergeIndexFile(path, Indexes("hr", nil))
+	require.NoError(t, err)
+	assert.Empty(t, added, "merging is idempotent")
+
+	var file map[string][]map[string]any
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Len(t, file["indexes"], 4+3)
+	assert.Len(t, file["fieldOverrides"], 1, "field overrides are kept")
+}
+
+func TestMergeIndexFileCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "firestore.indexes.json")
+
+	added, err := MergeIndexFile(path, Indexes("hr", nil))
+	require.NoError(t, err)
+	assert.Len(t, added, 3)
+
+	var file struct {
+		Indexes []Index `json:"indexes"`
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Equal(t, Indexes("hr", nil), file.Indexes)
+}
+
+func TestIndexProto(t *testing.T) {
+	definition, err := Indexes("hr", nil)[2].proto()
+	require.NoError(t, err)
+
+	assert.Equal(t, adminpb.Index_COLLECTION, definition.QueryScope)
+	require.Len(t, definition.Fields, 3)
+	assert.Equal(t, adminpb.Index_IndexField_ASCENDING, definition.Fields[0].GetOrder())
+	assert.Equal(t, adminpb.Index_IndexField_CONTAINS, definition.Fields[1].GetArrayConfig())
+	assert.Equal(t, adminpb.Index_IndexField_DESCENDING, definition.Fields[2].GetOrder())
+
+	_, err = Index{QueryScope: QueryScopeCollection, Fields: []IndexField{{FieldPath: "type", Order: "SIDEWAYS"}}}.proto()
+	assert.Error(t, err)
+}
+
+func TestSamplesFor(t *testing.T) {
+	now := time.Now()
+	product := models.Product{Name: "hr", DisplayName: "Human Resources", DefaultTags: []string{"People"}}
+
+	resources, err := samplesFor(product, now)
+	require.NoError(t, err)
+	require.Len(t, resources, len(sampleResources))
+
+	assert.Equal(t, "Welcome to Human Resources", resources[0].Title)
+	for i, resource := range resources {
+		assert.Equal(t, constants.ResourceTypeArticle, resource.Type)
+		assert.Contains(t, resource.Tags, SampleTag)
+		assert.Contains(t, resource.Tags, "people", "default tags are normalized")
+		assert.NotContains(t, resource.Description, "{product}")
+		if i > 0 {
+			assert.True(t, resource.CreatedAt.Before(resources[i-1].CreatedAt), "samples list newest first")
+		}
+	}
+}
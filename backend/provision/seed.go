@@ -0,0 +1,168 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/models"
+	"learninghub/utils"
+	"learninghub/validation"
+)
+
+// SampleTag marks the sample resources of a seeded product, so they are easy
+// to find and delete once real content is added
+const SampleTag = "sample"
+
+// sampleResource is a resource every product starts with. Titles and
+// descriptions may refer to the product's display name as {product}.
+type sampleResource struct {
+	title       string
+	description string
+	url         string
+	tags        []string
+}
+
+// sampleResources are the resources of a seeded product, newest first. They
+// are articles, which link pages rather than stored files.
+var sampleResources = []sampleResource{
+	{
+		title:       "Welcome to {product}",
+		description: "Start here: what the {product} learning hub offers and how it is organized. Replace this sample with your own introduction.",
+		url:         "https://example.com/learninghub/welcome",
+		tags:        []string{"getting-started"},
+	},
+	{
+		title:       "Adding resources",
+		description: "Videos, PDFs, slide decks, audio, code samples, articles and embeds can be added to {product}, one by one or imported from a CSV file.",
+		url:         "https://example.com/learninghub/adding-resources",
+		tags:        []string{"getting-started", "how-to"},
+	},
+	{
+		title:       "Organizing with tags",
+		description: "Tags group related resources and filter the {product} catalog. The default tags of the product are suggested for new resources.",
+		url:         "https://example.com/learninghub/tags",
+		tags:        []string{"how-to"},
+	},
+}
+
+// SeedReport reports the seeding of a product
+type SeedReport struct {
+	Product    string   `json:"product"`
+	Registered bool     `json:"registered"`          // Whether the product was added to the registry
+	Resources  []string `json:"resources,omitempty"` // IDs of the sample resources created
+	Tags       []string `json:"tags,omitempty"`      // Starter tags, used by the sample resources
+	Skipped    string   `json:"skipped,omitempty"`   // Why no sample resources were created
+}
+
+// Seed adds a product to the registry unless it is registered, then creates
+// the sample resources, tagged with the product's default tags and starter
+// tags. A product that has resources already, or requires custom fields the
+// samples cannot fill, is left without samples.
+func Seed(ctx context.Context, product models.Product) (*SeedReport, error) {
+	database := db.New()
+	productService := db.NewProductService(database)
+	resourceService := db.NewResourceService(database)
+	report := &SeedReport{Product: product.Name}
+
+	now := time.Now()
+	product.CreatedAt, product.UpdatedAt = now, now
+	err := productService.Create(ctx, product)
+	switch {
+	case err == nil:
+		report.Registered = true
+	case status.Code(err) == codes.AlreadyExists:
+		registered, err := productService.Get(ctx, product.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read product: %w", err)
+		}
+		product = *registered
+	default:
+		return nil, fmt.Errorf("failed to register product: %w", err)
+	}
+	if product.Archived {
+		return nil, errors.New("product is archived")
+	}
+
+	existing, err := resourceService.List(ctx, db.ResourceQuery{Product: product.Name, Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+	if len(existing) > 0 {
+		report.Skipped = "the product has resources"
+		return report, nil
+	}
+
+	definitions, err := db.NewFieldService(database).List(ctx, product.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom fields: %w", err)
+	}
+	if slices.ContainsFunc(definitions, func(d models.FieldDefinition) bool { return d.Required }) {
+		report.Skipped = "the product requires custom fields"
+		return report, nil
+	}
+
+	resources, err := samplesFor(product, now)
+	if err != nil {
+		return nil, err
+	}
+
+	tagDeltas := make(map[string]int)
+	ids, errs := resourceService.CreateAll(ctx, product.Name, resources)
+	for i, err := range errs {
+		if err != nil {
+			return report, fmt.Errorf("failed to create sample resource %q: %w", resources[i].Title, err)
+		}
+		report.Resources = append(report.Resources, ids[i])
+		for _, tag := range resources[i].Tags {
+			tagDeltas[tag]++
+		}
+	}
+
+	if err := db.NewTagService(database).AdjustUsage(ctx, product.Name, tagDeltas); err != nil {
+		return report, fmt.Errorf("failed to update tag usage: %w", err)
+	}
+	report.Tags = slices.Sorted(maps.Keys(tagDeltas))
+	return report, nil
+}
+
+// samplesFor returns the sample resources of a product, created a second
+// apart so they list in order, and checked like created resources
+func samplesFor(product models.Product, now time.Time) ([]models.Resource, error) {
+	displayName := product.DisplayName
+	if displayName == "" {
+		displayName = product.Name
+	}
+
+	resources := make([]models.Resource, len(sampleResources))
+	for i, sample := range sampleResources {
+		createdAt := now.Add(-time.Duration(i) * time.Second)
+		resources[i] = models.Resource{
+			Title:       strings.ReplaceAll(sample.title, "{product}", displayName),
+			Description: strings.ReplaceAll(sample.description, "{product}", displayName),
+			Type:        constants.ResourceTypeArticle,
+			URL:         sample.url,
+			Tags:        utils.NormalizeTags(slices.Concat(sample.tags, product.DefaultTags, []string{SampleTag})),
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		}
+
+		if fieldErrors := validation.Resource(resources[i]); len(fieldErrors) > 0 {
+			messages := make([]string, len(fieldErrors))
+			for j, fieldErr := range fieldErrors {
+				messages[j] = fieldErr.Message
+			}
+			return nil, fmt.Errorf("invalid sample resource %q: %s", resources[i].Title, strings.Join(messages, "; "))
+		}
+	}
+	return resources, nil
+}
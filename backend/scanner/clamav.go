@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"learninghub/constants"
+)
+
+const (
+	// clamAVChunkSize is the size of each INSTREAM chunk. clamd rejects chunks
+	// larger than its StreamMaxLength, which defaults to 25MB.
+	clamAVChunkSize = 64 << 10
+
+	// clamAVDialTimeout bounds connecting to clamd
+	clamAVDialTimeout = 5 * time.Second
+)
+
+// ClamAV scans content with a clamd daemon using the INSTREAM command
+// (https://docs.clamav.net/manual/Usage/Scanning.html#clamd).
+type ClamAV struct {
+	network string
+	address string
+}
+
+// NewClamAV creates a scanner for a clamd address of the form
+// "tcp://host:3310" or "unix:///var/run/clamav/clamd.ctl"
+func NewClamAV(address string) (*ClamAV, error) {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clamd address %q: %w", address, err)
+	}
+
+	switch parsed.Scheme {
+	case "tcp":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("invalid clamd address %q: missing host", address)
+		}
+		return &ClamAV{network: "tcp", address: parsed.Host}, nil
+	case "unix":
+		if parsed.Path == "" {
+			return nil, fmt.Errorf("invalid clamd address %q: missing socket path", address)
+		}
+		return &ClamAV{network: "unix", address: parsed.Path}, nil
+	default:
+		return nil, fmt.Errorf("invalid clamd address %q: scheme must be tcp or unix", address)
+	}
+}
+
+// Name returns the scanner name
+func (c *ClamAV) Name() string {
+	return constants.ScannerClamAV
+}
+
+// Ping checks that clamd is up
+func (c *ClamAV) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply to PING: %q", reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd in chunks and returns its verdict
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	reply, err := c.command(ctx, "zINSTREAM\x00", func(w io.Writer) error {
+		buf := make([]byte, clamAVChunkSize)
+		header := make([]byte, 4)
+
+		for {
+			n, readErr := r.Read(buf)
+			if n > 0 {
+				binary.BigEndian.PutUint32(header, uint32(n))
+				if _, err := w.Write(header); err != nil {
+					return err
+				}
+				if _, err := w.Write(buf[:n]); err != nil {
+					return err
+				}
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				return readErr
+			}
+		}
+
+		// A zero-length chunk terminates the stream
+		_, err := w.Write([]byte{0, 0, 0, 0})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseClamAVReply(reply)
+}
+
+// command sends a null-terminated command, optionally followed by a payload,
+// and returns clamd's null-terminated reply
+func (c *ClamAV) command(ctx context.Context, command string, payload func(io.Writer) error) (string, error) {
+	dialer := net.Dialer{Timeout: clamAVDialTimeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes as soon as the context ends
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	writer := bufio.NewWriterSize(conn, clamAVChunkSize+4)
+	if _, err := writer.WriteString(command); err != nil {
+		return "", fmt.Errorf("failed to send clamd command: %w", err)
+	}
+
+	var payloadErr error
+	if payload != nil {
+		payloadErr = payload(writer)
+	}
+	if payloadErr == nil {
+		payloadErr = writer.Flush()
+	}
+
+	// clamd may close the stream early (e.g. "INSTREAM size limit exceeded")
+	// and still explain why, so try to read the reply even after a write error.
+	reply, readErr := bufio.NewReader(conn).ReadString(0)
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	if readErr != nil && reply == "" {
+		if payloadErr != nil {
+			return "", fmt.Errorf("failed to stream to clamd: %w", payloadErr)
+		}
+		return "", fmt.Errorf("failed to read clamd reply: %w", readErr)
+	}
+
+	return reply, nil
+}
+
+// parseClamAVReply interprets an INSTREAM reply:
+//
+//	stream: OK
+//	stream: Eicar-Test-Signature FOUND
+//	INSTREAM size limit exceeded. ERROR
+func parseClamAVReply(reply string) (*Result, error) {
+	status := strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case status == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	case strings.HasSuffix(status, " ERROR") && strings.Contains(status, "size limit exceeded"):
+		return nil, fmt.Errorf("%w: clamd error: %s", ErrTooLarge, strings.TrimSuffix(status, " ERROR"))
+	case strings.HasSuffix(status, " ERROR"):
+		return nil, fmt.Errorf("clamd error: %s", strings.TrimSuffix(status, " ERROR"))
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+}
@@ -0,0 +1,244 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eicar is the standard antivirus test string
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd is a minimal clamd speaking the z-prefixed PING and INSTREAM
+// commands. It reports streams containing the EICAR string as infected.
+type fakeClamd struct {
+	listener net.Listener
+	received chan []byte
+	reply    string // Overrides the INSTREAM verdict when set
+}
+
+func startFakeClamd(t *testing.T, network, address string) *fakeClamd {
+	t.Helper()
+
+	listener, err := net.Listen(network, address)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	clamd := &fakeClamd{listener: listener, received: make(chan []byte, 1)}
+	go clamd.serve()
+	return clamd
+}
+
+func (f *fakeClamd) address() string {
+	return f.listener.Addr().Network() + "://" + f.listener.Addr().String()
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	command, err := reader.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch command {
+	case "zPING\x00":
+		conn.Write([]byte("PONG\x00"))
+
+	case "zINSTREAM\x00":
+		var content bytes.Buffer
+		header := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return
+			}
+			size := binary.BigEndian.Uint32(header)
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&content, reader, int64(size)); err != nil {
+				return
+			}
+		}
+		f.received <- content.Bytes()
+
+		reply := "stream: OK"
+		switch {
+		case f.reply != "":
+			reply = f.reply
+		case strings.Contains(content.String(), eicar):
+			reply = "stream: Eicar-Test-Signature FOUND"
+		}
+		conn.Write([]byte(reply + "\x00"))
+
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func TestNewClamAV(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		target  string
+		wantErr bool
+	}{
+		{address: "tcp://127.0.0.1:3310", network: "tcp", target: "127.0.0.1:3310"},
+		{address: "unix:///var/run/clamav/clamd.ctl", network: "unix", target: "/var/run/clamav/clamd.ctl"},
+		{address: "127.0.0.1:3310", wantErr: true},
+		{address: "http://127.0.0.1:3310", wantErr: true},
+		{address: "tcp://", wantErr: true},
+		{address: "unix://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			clamAV, err := NewClamAV(tt.address)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.network, clamAV.network)
+			assert.Equal(t, tt.target, clamAV.address)
+		})
+	}
+}
+
+func TestClamAVScan(t *testing.T) {
+	listeners := map[string]func(t *testing.T) *fakeClamd{
+		"tcp": func(t *testing.T) *fakeClamd {
+			return startFakeClamd(t, "tcp", "127.0.0.1:0")
+		},
+		"unix": func(t *testing.T) *fakeClamd {
+			return startFakeClamd(t, "unix", filepath.Join(t.TempDir(), "clamd.sock"))
+		},
+	}
+
+	for network, start := range listeners {
+		t.Run(network, func(t *testing.T) {
+			clamd := start(t)
+			clamAV, err := NewClamAV(clamd.address())
+			require.NoError(t, err)
+
+			require.NoError(t, clamAV.Ping(context.Background()))
+
+			// Larger than one chunk, so the stream is split
+			clean := bytes.Repeat([]byte("clean content "), clamAVChunkSize/7)
+			result, err := clamAV.Scan(context.Background(), bytes.NewReader(clean))
+			require.NoError(t, err)
+			assert.False(t, result.Infected)
+			assert.Equal(t, clean, <-clamd.received, "clamd receives the complete content")
+
+			result, err = clamAV.Scan(context.Background(), strings.NewReader("prefix "+eicar))
+			require.NoError(t, err)
+			assert.True(t, result.Infected)
+			assert.Equal(t, "Eicar-Test-Signature", result.Signature)
+			<-clamd.received
+		})
+	}
+}
+
+func TestClamAVScanErrors(t *testing.T) {
+	t.Run("clamd error reply", func(t *testing.T) {
+		clamd := startFakeClamd(t, "tcp", "127.0.0.1:0")
+		clamd.reply = "INSTREAM size limit exceeded. ERROR"
+		clamAV, err := NewClamAV(clamd.address())
+		require.NoError(t, err)
+
+		_, err = clamAV.Scan(context.Background(), strings.NewReader("content"))
+		assert.ErrorContains(t, err, "INSTREAM size limit exceeded")
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("clamd unreachable", func(t *testing.T) {
+		clamAV, err := NewClamAV("unix://" + filepath.Join(t.TempDir(), "missing.sock"))
+		require.NoError(t, err)
+
+		_, err = clamAV.Scan(context.Background(), strings.NewReader("content"))
+		assert.ErrorContains(t, err, "failed to connect to clamd")
+	})
+
+	t.Run("context cancelled while waiting for a verdict", func(t *testing.T) {
+		// Accepts connections but never answers
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go io.Copy(io.Discard, conn)
+			}
+		}()
+
+		clamAV, err := NewClamAV("tcp://" + listener.Addr().String())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err = clamAV.Scan(ctx, strings.NewReader("content"))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		infected  bool
+		signature string
+		wantErr   bool
+		tooLarge  bool
+	}{
+		{reply: "stream: OK"},
+		{reply: "stream: Win.Test.EICAR_HDB-1 FOUND", infected: true, signature: "Win.Test.EICAR_HDB-1"},
+		{reply: "INSTREAM size limit exceeded. ERROR", wantErr: true, tooLarge: true},
+		{reply: "Can't allocate memory ERROR", wantErr: true},
+		{reply: "garbage", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			result, err := parseClamAVReply(tt.reply)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.tooLarge, errors.Is(err, ErrTooLarge))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.infected, result.Infected)
+			assert.Equal(t, tt.signature, result.Signature)
+		})
+	}
+}
+
+func TestNoop(t *testing.T) {
+	result, err := Noop{}.Scan(context.Background(), strings.NewReader(eicar))
+	require.NoError(t, err)
+	assert.False(t, result.Infected)
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/pkg/logger"
+)
+
+// Result is the verdict of a malware scan
+type Result struct {
+	Infected bool
+	// Signature names the detected malware, empty when clean
+	Signature string
+}
+
+// ErrTooLarge is returned by scanners refusing content larger than they are
+// configured to scan, e.g. clamd beyond its StreamMaxLength
+var ErrTooLarge = errors.New("content exceeds the scanner's size limit")
+
+// Scanner inspects file content for malware. Scan consumes r until EOF (or
+// until it has a verdict) and must honour ctx cancellation.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
+
+// Default is the scanner uploads go through, set by Initialize
+var Default Scanner = Noop{}
+
+// Noop is a Scanner that accepts everything without reading it
+type Noop struct{}
+
+// Name returns the scanner name
+func (Noop) Name() string {
+	return constants.ScannerNone
+}
+
+// Scan reports every file as clean
+func (Noop) Scan(context.Context, io.Reader) (*Result, error) {
+	return &Result{}, nil
+}
+
+// Initialize sets Default from the MALWARE_SCANNER configuration.
+// The no-op scanner stays in place if the configured one cannot be created.
+func Initialize() error {
+	switch config.AppConfig.MALWARE_SCANNER {
+	case "", constants.ScannerNone:
+		Default = Noop{}
+
+	case constants.ScannerClamAV:
+		clamAV, err := NewClamAV(config.AppConfig.CLAMAV_ADDRESS)
+		if err != nil {
+			return fmt.Errorf("error creating ClamAV scanner: %w", err)
+		}
+
+		if err := clamAV.Ping(context.Background()); err != nil {
+			// clamd may still be starting; scans fail closed until it answers
+			logger.Warnf("ClamAV is not reachable at %s yet: %v", config.AppConfig.CLAMAV_ADDRESS, err)
+		}
+
+		Default = clamAV
+
+	default:
+		return fmt.Errorf("unknown malware scanner: %s", config.AppConfig.MALWARE_SCANNER)
+	}
+
+	logger.Infof("Using malware scanner: %s", Default.Name())
+	return nil
+}
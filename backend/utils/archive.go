@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"learninghub/constants"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+)
+
+// archiveUninspectableMessage is the user-facing reason for rejecting an archive that could not be read
+const archiveUninspectableMessage = "archive is malformed or uses an unsupported format and cannot be uploaded"
+
+// inspectArchive reads the central directory of a zip archive, a code archive
+// or an OOXML/ODF slide deck, and checks that extracting it is safe: no entry
+// escapes the extraction directory or is a symbolic link, nothing is
+// encrypted, and the declared sizes stay within constants.ArchiveMax*, which
+// rules out zip bombs. Only the directory is read, never the entries.
+//
+// Returns the listing of the archive, or a user-facing rejection message.
+func inspectArchive(file io.ReaderAt, size int64) (*models.ArchiveListing, string) {
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		logger.Infof("Archive upload rejected: failed to read archive: %v", err)
+		return nil, archiveUninspectableMessage
+	}
+
+	if len(archive.File) > constants.ArchiveMaxEntries {
+		return nil, "archive contains too many files"
+	}
+
+	listing := &models.ArchiveListing{Entries: []models.ArchiveEntry{}}
+	for _, entry := range archive.File {
+		if rejection := checkArchiveEntry(entry); rejection != "" {
+			logger.Infof("Archive upload rejected: entry %q: %s", entry.Name, rejection)
+			return nil, rejection
+		}
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+
+		listing.Files++
+		listing.UncompressedSize += int64(entry.UncompressedSize64)
+		if listing.UncompressedSize > constants.ArchiveMaxUncompressedSize || entry.UncompressedSize64 > constants.ArchiveMaxUncompressedSize {
+			return nil, "archive is too large once extracted"
+		}
+
+		if len(listing.Entries) < constants.ArchiveListingLimit {
+			listing.Entries = append(listing.Entries, models.ArchiveEntry{Path: entry.Name, Size: int64(entry.UncompressedSize64)})
+		} else {
+			listing.Truncated = true
+		}
+	}
+
+	return listing, ""
+}
+
+// checkArchiveEntry returns why an archive entry is unsafe to extract, or an
+// empty string
+func checkArchiveEntry(entry *zip.File) string {
+	name := entry.Name
+
+	switch {
+	case name == "" || !utf8.ValidString(name) || strings.ContainsRune(name, 0):
+		return "archive contains a file with an invalid name"
+
+	// Backslashes are separators on Windows, and drive letters or leading
+	// slashes make a path absolute
+	case strings.ContainsRune(name, '\\') || strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':'):
+		return "archive contains a file with an absolute path"
+
+	case !fs.ValidPath(strings.TrimSuffix(name, "/")) || path.Clean(name) == "..":
+		return "archive contains a file outside of its folder"
+
+	case entry.Mode()&fs.ModeSymlink != 0:
+		return "archive contains a symbolic link"
+
+	// Bit 0 of the general purpose flags marks encrypted entries
+	case entry.Flags&0x1 != 0:
+		return "archive contains encrypted files"
+
+	// Small files of repetitive text legitimately compress very well
+	case entry.UncompressedSize64 > constants.ArchiveRatioMinSize && entry.UncompressedSize64 > entry.CompressedSize64*constants.ArchiveMaxCompressionRatio:
+		return "archive contains a file with a suspicious compression ratio"
+	}
+
+	return ""
+}
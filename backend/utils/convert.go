@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+)
+
+// ConversionPrefix returns the storage prefix for the PDF conversion of a
+// slide deck by a job. Each job writes to its own prefix, so a job never
+// overwrites the output of another one.
+func ConversionPrefix(product, id, jobID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", product, constants.ConvertedPathSegment, id, jobID)
+}
+
+// ConvertToPDF converts a stored slide deck to PDF with LibreOffice, stores it
+// under prefix and tracks the job's progress on the resource, like
+// TranscodeToHLS. The URL of the PDF is returned, or an empty string when the
+// deck was replaced in the meantime and the output thrown away.
+func ConvertToPDF(ctx context.Context, product, id, sourceURL, prefix string, finalAttempt bool) (string, error) {
+	resourceService := db.NewResourceService(db.New())
+
+	objectName := path.Join(prefix, constants.ConvertedPDFName)
+	pdfURL, err := generatePublicURL(objectName, firebase.StorageBucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate public URL: %w", err)
+	}
+
+	setStatus := func(status, message string, pages int) (bool, error) {
+		conversion := models.Conversion{Status: status, Source: sourceURL, Error: message, UpdatedAt: time.Now()}
+		if status == constants.ConversionStatusReady {
+			conversion.Prefix, conversion.URL = prefix, pdfURL
+		}
+		return resourceService.UpdateConversion(context.WithoutCancel(ctx), product, id, conversion, pages)
+	}
+
+	if current, err := setStatus(constants.ConversionStatusProcessing, "", 0); err != nil || !current {
+		return "", err
+	}
+
+	if err := DeleteFolder(ctx, prefix); err != nil {
+		return "", fmt.Errorf("failed to clear output of an earlier attempt: %w", err)
+	}
+
+	pages, err := convertAndUpload(ctx, sourceURL, objectName)
+	if err != nil {
+		if cleanupErr := DeleteFolder(context.WithoutCancel(ctx), prefix); cleanupErr != nil {
+			logger.Infof("Failed to delete partial conversion output %s: %v", prefix, cleanupErr)
+		}
+
+		status := constants.ConversionStatusPending
+		if finalAttempt {
+			status = constants.ConversionStatusFailed
+		}
+		if _, statusErr := setStatus(status, err.Error(), 0); statusErr != nil {
+			logger.Errorf("Failed to record conversion failure of resource %s/%s: %v", product, id, statusErr)
+		}
+		return "", err
+	}
+
+	current, err := setStatus(constants.ConversionStatusReady, "", pages)
+	if err != nil || !current {
+		if cleanupErr := DeleteFolder(context.WithoutCancel(ctx), prefix); cleanupErr != nil {
+			logger.Infof("Failed to delete stale conversion output %s: %v", prefix, cleanupErr)
+		}
+		return "", err
+	}
+	return pdfURL, nil
+}
+
+// convertAndUpload downloads a stored slide deck, converts it to PDF and
+// uploads the PDF as objectName. Returns the page count of the PDF, 0 if it
+// could not be read.
+func convertAndUpload(ctx context.Context, sourceURL, objectName string) (int, error) {
+	_, sourceObject, err := parseStorageURL(sourceURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse storage URL: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "convert-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// LibreOffice picks its import filter from the extension, and names the
+	// output after the input
+	source := filepath.Join(workDir, "slides"+path.Ext(sourceObject))
+	if err := downloadObject(ctx, sourceURL, source); err != nil {
+		return 0, err
+	}
+
+	outputDir := filepath.Join(workDir, "pdf")
+	if err := convertSlides(ctx, source, outputDir, filepath.Join(workDir, "profile")); err != nil {
+		return 0, err
+	}
+
+	converted, err := os.Open(filepath.Join(outputDir, "slides.pdf"))
+	if err != nil {
+		return 0, fmt.Errorf("soffice did not produce a PDF: %w", err)
+	}
+	defer converted.Close()
+
+	size, err := fileSize(converted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine PDF size: %w", err)
+	}
+
+	// Decks can carry macros and links of their own: the PDF served to
+	// viewers is held to the same standard as uploaded ones
+	inspection, rejection := inspectPDF(converted, size, constants.PDFActiveContentSanitize)
+	if rejection != "" {
+		return 0, fmt.Errorf("converted PDF rejected: %s", rejection)
+	}
+
+	var pdfReader io.Reader = converted
+	if inspection.Sanitize {
+		sanitized := inspection.sanitizedReader()
+		defer sanitized.Close()
+		pdfReader = sanitized
+	}
+
+	if err := writeObject(ctx, objectName, "application/pdf", pdfReader); err != nil {
+		return 0, err
+	}
+
+	return inspection.document.Info().Pages, nil
+}
+
+// convertSlides converts a local slide deck to PDF in outputDir with soffice.
+// Every conversion uses its own LibreOffice profile in profileDir, since
+// instances sharing one lock each other out.
+func convertSlides(ctx context.Context, source, outputDir, profileDir string) error {
+	_, err := runTool(ctx, config.AppConfig.SOFFICE_PATH,
+		"-env:UserInstallation=file://"+filepath.ToSlash(profileDir),
+		"--headless", "--norestore", "--nolockcheck",
+		"--convert-to", "pdf",
+		"--outdir", outputDir,
+		source,
+	)
+	return err
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// ErrUnsupportedEmbed is returned by ResolveEmbed for URLs of no known provider
+var ErrUnsupportedEmbed error = &UnsupportedURLError{Reason: "URL must be a YouTube, Vimeo or Loom video"}
+
+// embedProvider is a video host whose pages are resolved to players with oEmbed
+type embedProvider struct {
+	Name        string
+	Hosts       []string // Hosts of the pages users link
+	Endpoint    string   // oEmbed endpoint
+	PlayerHosts []string // Hosts the player iframe may load from
+}
+
+// embedProviders are the supported embed providers
+var embedProviders = []embedProvider{
+	{
+		Name:        "youtube",
+		Hosts:       []string{"youtube.com", "www.youtube.com", "m.youtube.com", "youtu.be"},
+		Endpoint:    "https://www.youtube.com/oembed",
+		PlayerHosts: []string{"www.youtube.com", "www.youtube-nocookie.com"},
+	},
+	{
+		Name:        "vimeo",
+		Hosts:       []string{"vimeo.com", "www.vimeo.com", "player.vimeo.com"},
+		Endpoint:    "https://vimeo.com/api/oembed.json",
+		PlayerHosts: []string{"player.vimeo.com"},
+	},
+	{
+		Name:        "loom",
+		Hosts:       []string{"loom.com", "www.loom.com"},
+		Endpoint:    "https://www.loom.com/v1/oembed",
+		PlayerHosts: []string{"www.loom.com"},
+	},
+}
+
+// embedClient fetches oEmbed responses
+var embedClient = &http.Client{Timeout: constants.EmbedTimeout * time.Second}
+
+// oembedResponse is the part of an oEmbed response we use
+type oembedResponse struct {
+	Type         string  `json:"type"` // "video" | "rich" | ...
+	Title        string  `json:"title"`
+	AuthorName   string  `json:"author_name"`
+	ThumbnailURL string  `json:"thumbnail_url"`
+	HTML         string  `json:"html"`
+	Width        int     `json:"width"`
+	Height       int     `json:"height"`
+	Duration     float64 `json:"duration"` // seconds, Vimeo and Loom only
+}
+
+// iframeSrcPattern finds the player URL in the HTML of an oEmbed response
+var iframeSrcPattern = regexp.MustCompile(`<iframe[^>]*\ssrc="([^"]+)"`)
+
+// ResolveEmbed asks the provider of a video page for its player.
+//
+// Only the player URL is kept from the HTML the provider returns, and only if
+// it points to one of the provider's player hosts: the HTML itself is never
+// stored nor served, so a compromised or spoofed response cannot inject markup.
+func ResolveEmbed(ctx context.Context, pageURL string) (*models.Embed, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil || (page.Scheme != "https" && page.Scheme != "http") {
+		return nil, ErrUnsupportedEmbed
+	}
+
+	host := strings.ToLower(page.Hostname())
+	index := slices.IndexFunc(embedProviders, func(provider embedProvider) bool {
+		return slices.Contains(provider.Hosts, host)
+	})
+	if index < 0 {
+		return nil, ErrUnsupportedEmbed
+	}
+	provider := embedProviders[index]
+
+	response, err := fetchOEmbed(ctx, provider, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := iframeSrcPattern.FindStringSubmatch(response.HTML)
+	if matches == nil {
+		return nil, fmt.Errorf("%s returned no player for this URL", provider.Name)
+	}
+	player, err := url.Parse(strings.ReplaceAll(matches[1], "&amp;", "&"))
+	if err != nil || player.Scheme != "https" || !slices.Contains(provider.PlayerHosts, player.Hostname()) {
+		return nil, fmt.Errorf("%s returned an unexpected player URL", provider.Name)
+	}
+
+	embed := &models.Embed{
+		Provider:   provider.Name,
+		EmbedURL:   player.String(),
+		Title:      response.Title,
+		AuthorName: response.AuthorName,
+		Width:      response.Width,
+		Height:     response.Height,
+		Duration:   response.Duration,
+	}
+	if thumbnail, err := url.Parse(response.ThumbnailURL); err == nil && thumbnail.Scheme == "https" {
+		embed.ThumbnailURL = thumbnail.String()
+	}
+
+	return embed, nil
+}
+
+// fetchOEmbed queries the oEmbed endpoint of a provider for a page
+func fetchOEmbed(ctx context.Context, provider embedProvider, pageURL string) (*oembedResponse, error) {
+	query := url.Values{"url": {pageURL}, "format": {"json"}}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.Endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build oEmbed request: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+
+	res, err := embedClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", provider.Name, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%s video not found or not embeddable", provider.Name)
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s answered with status %d", provider.Name, res.StatusCode)
+	}
+
+	var response oembedResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, constants.EmbedMaxResponseSize)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse %s oEmbed response: %w", provider.Name, err)
+	}
+	return &response, nil
+}
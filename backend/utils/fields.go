@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"learninghub/constants"
+	"learninghub/models"
+	"learninghub/validation"
+)
+
+// customFieldNamePattern keeps field names usable as Firestore field paths and
+// query parameters
+var customFieldNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,63}$`)
+
+// reservedFieldNames are the query parameters of GET /resources, which custom
+// field filters share
+var reservedFieldNames = []string{
+	constants.QueryParamType,
+	constants.QueryParamTags,
+	constants.QueryParamSearch,
+	constants.QueryParamCursor,
+	constants.QueryParamLimit,
+}
+
+// CustomFieldError is returned for a rejected custom field definition or value
+type CustomFieldError struct {
+	Field  string
+	Reason string
+	Code   string         // See the validation package
+	Params map[string]any // Params of the code
+}
+
+func (e *CustomFieldError) Error() string {
+	return fmt.Sprintf("custom field '%s' %s", e.Field, e.Reason)
+}
+
+// IsReservedFieldName reports whether name is a built-in query parameter of
+// GET /resources rather than a custom field filter
+func IsReservedFieldName(name string) bool {
+	return slices.Contains(reservedFieldNames, name)
+}
+
+// ValidateFieldDefinition checks a custom field definition. Labels,
+// descriptions and enum values are trimmed.
+func ValidateFieldDefinition(definition *models.FieldDefinition) error {
+	reject := func(format string, args ...any) error {
+		return &CustomFieldError{Field: definition.Name, Reason: fmt.Sprintf(format, args...), Code: validation.CodeInvalid}
+	}
+
+	if !customFieldNamePattern.MatchString(definition.Name) {
+		return reject("must start with a letter and contain only letters, digits and underscores, up to 64 characters")
+	}
+	if IsReservedFieldName(definition.Name) {
+		return reject("is a reserved query parameter")
+	}
+
+	definition.Label = strings.TrimSpace(definition.Label)
+	definition.Description = strings.TrimSpace(definition.Description)
+	if utf8.RuneCountInString(definition.Label) > constants.MaxFieldStringLength ||
+		utf8.RuneCountInString(definition.Description) > constants.MaxFieldStringLength {
+		return reject("label and description must be at most %d characters", constants.MaxFieldStringLength)
+	}
+
+	switch definition.Type {
+	case constants.FieldTypeString, constants.FieldTypeNumber, constants.FieldTypeBoolean:
+		if len(definition.Values) > 0 {
+			return reject("lists values but is not an enum")
+		}
+	case constants.FieldTypeEnum:
+		if len(definition.Values) == 0 || len(definition.Values) > constants.MaxFieldEnumValues {
+			return reject("must list 1 to %d values", constants.MaxFieldEnumValues)
+		}
+		for i, value := range definition.Values {
+			value = strings.TrimSpace(value)
+			if value == "" || utf8.RuneCountInString(value) > constants.MaxFieldStringLength {
+				return reject("has an empty or too long value")
+			}
+			if slices.Contains(definition.Values[:i], value) {
+				return reject("lists '%s' twice", value)
+			}
+			definition.Values[i] = value
+		}
+	default:
+		return reject("has unsupported type '%s'; supported types: string, number, boolean, enum", definition.Type)
+	}
+
+	return nil
+}
+
+// ParseCustomFields decodes the JSON object of custom field values sent in the
+// customFields form field
+func ParseCustomFields(raw string) (map[string]any, error) {
+	var values map[string]any
+	if err := json.Unmarshal([]byte(raw), &values); err != nil || values == nil {
+		return nil, &CustomFieldError{Field: constants.FormFieldCustomFields, Reason: "must be a JSON object of field values", Code: validation.CodeInvalidType}
+	}
+	return values, nil
+}
+
+// MergeCustomFields applies custom field values to the existing values of a
+// resource and returns the result.
+//
+// Values are checked against the product's definitions: unknown fields are
+// rejected, and null or empty strings unset a field. Every required field
+// must have a value in the result. Existing values of fields that are no
+// longer defined are kept as they are.
+func MergeCustomFields(definitions []models.FieldDefinition, existing, values map[string]any) (map[string]any, error) {
+	merged := make(map[string]any, len(existing)+len(values))
+	for name, value := range existing {
+		merged[name] = value
+	}
+
+	for name, value := range values {
+		index := slices.IndexFunc(definitions, func(d models.FieldDefinition) bool { return d.Name == name })
+		if index < 0 {
+			return nil, &CustomFieldError{Field: name, Reason: "is not defined for this product", Code: validation.CodeUnknownField}
+		}
+
+		if value == nil || value == "" {
+			delete(merged, name)
+			continue
+		}
+
+		checked, err := checkCustomFieldValue(definitions[index], value)
+		if err != nil {
+			return nil, err
+		}
+		merged[name] = checked
+	}
+
+	for _, definition := range definitions {
+		if _, set := merged[definition.Name]; definition.Required && !set {
+			return nil, &CustomFieldError{Field: definition.Name, Reason: "is required", Code: validation.CodeRequired}
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
+
+// checkCustomFieldValue checks a decoded JSON value against its definition
+func checkCustomFieldValue(definition models.FieldDefinition, value any) (any, error) {
+	reject := func(reason, code string, params map[string]any) error {
+		return &CustomFieldError{Field: definition.Name, Reason: reason, Code: code, Params: params}
+	}
+
+	switch definition.Type {
+	case constants.FieldTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, reject("must be a string", validation.CodeInvalidType, nil)
+		}
+		if utf8.RuneCountInString(s) > constants.MaxFieldStringLength {
+			return nil, reject(fmt.Sprintf("must be at most %d characters", constants.MaxFieldStringLength),
+				validation.CodeTooLong, map[string]any{"max": constants.MaxFieldStringLength})
+		}
+	case constants.FieldTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return nil, reject("must be a number", validation.CodeInvalidType, nil)
+		}
+	case constants.FieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return nil, reject("must be true or false", validation.CodeInvalidType, nil)
+		}
+	case constants.FieldTypeEnum:
+		s, ok := value.(string)
+		if !ok || !slices.Contains(definition.Values, s) {
+			return nil, reject("must be one of: "+strings.Join(definition.Values, ", "),
+				validation.CodeInvalidChoice, map[string]any{"values": definition.Values})
+		}
+	default:
+		return nil, reject("has unsupported type '"+definition.Type+"'", validation.CodeInvalid, nil)
+	}
+
+	return value, nil
+}
+
+// ParseCustomFieldFilter converts the query parameter filtering resources on a
+// custom field to the type of its values
+func ParseCustomFieldFilter(definition models.FieldDefinition, raw string) (any, error) {
+	switch definition.Type {
+	case constants.FieldTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, &CustomFieldError{Field: definition.Name, Reason: "must be a number", Code: validation.CodeInvalidType}
+		}
+		return number, nil
+	case constants.FieldTypeBoolean:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, &CustomFieldError{Field: definition.Name, Reason: "must be true or false", Code: validation.CodeInvalidType}
+		}
+		return boolean, nil
+	default:
+		return checkCustomFieldValue(definition, raw)
+	}
+}
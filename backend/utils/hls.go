@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"learninghub/config"
+	"learninghub/pkg/logger"
+)
+
+var (
+	fallbackTokenKeyOnce sync.Once
+	fallbackTokenKey     []byte
+)
+
+// mediaTokenKey returns the HMAC key for media tokens. Without a configured
+// MEDIA_TOKEN_SECRET a random key is used, so tokens only survive as long as
+// the process.
+func mediaTokenKey() []byte {
+	if config.AppConfig != nil && config.AppConfig.MEDIA_TOKEN_SECRET != "" {
+		return []byte(config.AppConfig.MEDIA_TOKEN_SECRET)
+	}
+
+	fallbackTokenKeyOnce.Do(func() {
+		logger.Warnf("MEDIA_TOKEN_SECRET is not set, stream tokens are only valid until the server restarts")
+		fallbackTokenKey = make([]byte, 32)
+		rand.Read(fallbackTokenKey)
+	})
+	return fallbackTokenKey
+}
+
+// mediaTokenSignature signs the resource a token grants access to
+func mediaTokenSignature(product, id string, expires int64) string {
+	mac := hmac.New(sha256.New, mediaTokenKey())
+	fmt.Fprintf(mac, "%s/%s/%d", product, id, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignMediaToken creates a token granting access to a resource's HLS playlists
+// until expires. It has the form "<expiry unix seconds>.<signature>".
+func SignMediaToken(product, id string, expires time.Time) string {
+	return fmt.Sprintf("%d.%s", expires.Unix(), mediaTokenSignature(product, id, expires.Unix()))
+}
+
+// VerifyMediaToken checks that a token was issued for the resource and has not expired
+func VerifyMediaToken(token, product, id string) error {
+	expiresStr, signature, found := strings.Cut(token, ".")
+	if !found {
+		return fmt.Errorf("malformed token")
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed token expiry")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(mediaTokenSignature(product, id, expires))) {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("token expired")
+	}
+
+	return nil
+}
+
+// playlistURIAttribute matches the URI attribute of tags such as EXT-X-MAP,
+// EXT-X-MEDIA and EXT-X-KEY
+var playlistURIAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// RewritePlaylist replaces every URI of an HLS playlist (RFC 8216) with the
+// result of resolve: the URI lines of segments and variant streams, and the
+// URI attributes of tags.
+func RewritePlaylist(playlist []byte, resolve func(uri string) (string, error)) ([]byte, error) {
+	var out bytes.Buffer
+	var resolveErr error
+
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			line = playlistURIAttribute.ReplaceAllStringFunc(line, func(attribute string) string {
+				uri := playlistURIAttribute.FindStringSubmatch(attribute)[1]
+				resolved, err := resolve(uri)
+				if err != nil {
+					resolveErr = err
+					return attribute
+				}
+				return `URI="` + resolved + `"`
+			})
+		default:
+			resolved, err := resolve(line)
+			if err != nil {
+				return nil, err
+			}
+			line = resolved
+		}
+
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"mime/multipart"
+	"strconv"
+	"time"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+)
+
+// probeOutput is the part of `ffprobe -print_format json -show_format
+// -show_streams` output we use
+type probeOutput struct {
+	Format struct {
+		Duration string `json:"duration"` // seconds, e.g. "734.120000"
+	} `json:"format"`
+	Streams []probeStream `json:"streams"`
+}
+
+// probeStream is a stream reported by ffprobe
+type probeStream struct {
+	CodecType   string `json:"codec_type"` // "video" | "audio" | ...
+	CodecName   string `json:"codec_name"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"` // Cover art, not the video itself
+	} `json:"disposition"`
+	Tags struct {
+		Rotate string `json:"rotate"` // Older ffprobe versions
+	} `json:"tags"`
+	SideDataList []struct {
+		Rotation int `json:"rotation"`
+	} `json:"side_data_list"`
+}
+
+// rotation returns the display rotation of a video stream in degrees
+func (s probeStream) rotation() int {
+	for _, sideData := range s.SideDataList {
+		if sideData.Rotation != 0 {
+			return sideData.Rotation
+		}
+	}
+	rotate, _ := strconv.Atoi(s.Tags.Rotate)
+	return rotate
+}
+
+// extractMetadata reads the type specific metadata of a stored upload into
+// metadata, see ResourceType.ExtractMetadata. Metadata is informative, so
+// failures are logged and leave the fields empty rather than failing the
+// upload.
+func extractMetadata(ctx context.Context, file multipart.File, size int64, fileType string, inspection *Inspection, metadata *models.Metadata) {
+	resourceType, _ := uploadType(fileType)
+	if resourceType.ExtractMetadata == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.MetadataTimeout*time.Second)
+	defer cancel()
+
+	resourceType.ExtractMetadata(ctx, file, size, inspection, metadata)
+}
+
+// extractPDFMetadata reads the page count, title and author of an inspected PDF
+func extractPDFMetadata(_ context.Context, _ multipart.File, _ int64, inspection *Inspection, metadata *models.Metadata) {
+	if inspection == nil || inspection.PDF == nil {
+		return
+	}
+	info := inspection.PDF.document.Info()
+	metadata.Pages, metadata.Title, metadata.Author = info.Pages, info.Title, info.Author
+}
+
+// extractImageMetadata reads the dimensions of an image
+func extractImageMetadata(_ context.Context, file multipart.File, size int64, _ *Inspection, metadata *models.Metadata) {
+	imageConfig, _, err := image.DecodeConfig(io.NewSectionReader(file, 0, size))
+	if err != nil {
+		logger.Infof("Failed to read image dimensions: %v", err)
+		return
+	}
+	metadata.Width, metadata.Height = imageConfig.Width, imageConfig.Height
+}
+
+// probeMediaMetadata returns a metadata extractor probing video or audio
+// files with ffprobe, see probeMedia
+func probeMediaMetadata(mediaType string) func(context.Context, multipart.File, int64, *Inspection, *models.Metadata) {
+	return func(ctx context.Context, file multipart.File, _ int64, _ *Inspection, metadata *models.Metadata) {
+		path, cleanup, err := localCopy(file)
+		if err != nil {
+			logger.Infof("Failed to probe %s: %v", mediaType, err)
+			return
+		}
+		defer cleanup()
+
+		if err := probeMedia(ctx, path, mediaType, metadata); err != nil {
+			logger.Infof("Failed to probe %s: %v", mediaType, err)
+		}
+	}
+}
+
+// probeMedia reads the duration, display resolution and codecs of a local
+// video or audio file with ffprobe. mediaType is the stream the file must
+// have, "video" or "audio".
+func probeMedia(ctx context.Context, path, mediaType string, metadata *models.Metadata) error {
+	output, err := runTool(ctx, config.AppConfig.FFPROBE_PATH,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format", "-show_streams",
+		path,
+	)
+	if err != nil {
+		return err
+	}
+
+	var probe probeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil && duration > 0 && !math.IsInf(duration, 0) {
+		metadata.Duration = math.Round(duration*1000) / 1000
+	}
+
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 0 && metadata.VideoCodec == "":
+			metadata.VideoCodec = stream.CodecName
+			metadata.Width, metadata.Height = stream.Width, stream.Height
+			// Phones record portrait videos as rotated landscape frames
+			if rotation := stream.rotation(); rotation%180 != 0 {
+				metadata.Width, metadata.Height = metadata.Height, metadata.Width
+			}
+		case stream.CodecType == "audio" && metadata.AudioCodec == "":
+			metadata.AudioCodec = stream.CodecName
+		}
+	}
+
+	switch {
+	case mediaType == constants.ResourceTypeVideo && metadata.VideoCodec == "":
+		return fmt.Errorf("no video stream found")
+	case mediaType == constants.ResourceTypeAudio && metadata.AudioCodec == "":
+		return fmt.Errorf("no audio stream found")
+	}
+	return nil
+}
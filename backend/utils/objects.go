@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+// contentObjectPattern matches content-addressed uploads,
+// "<product>/<type>/<sha256><ext>", and the image variants derived from them,
+// "<product>/<type>/<sha256>_<width>w.<format>"
+var contentObjectPattern = regexp.MustCompile(`^([^/]+)/[^/]+/([0-9a-f]{64})(_[0-9]+w)?\.[0-9a-z]+$`)
+
+// contentObjectName returns the object an upload is stored at: the same
+// content is stored once per product and type
+func contentObjectName(product, fileType, sha256, extension string) string {
+	return fmt.Sprintf("%s/%s/%s%s", product, fileType, sha256, extension)
+}
+
+// ParseContentObject reports whether an object is a content-addressed upload
+// or, when variant is true, an image variant of one
+func ParseContentObject(objectName string) (product, sha256 string, variant, ok bool) {
+	matches := contentObjectPattern.FindStringSubmatch(objectName)
+	if matches == nil {
+		return "", "", false, false
+	}
+	return matches[1], matches[2], matches[3] != "", true
+}
+
+// storeContentAddressed moves an upload staged at a unique name to its content
+// address and takes a reference to it, and returns the object it is stored
+// at. If identical content is already stored the staged copy is dropped,
+// duplicate is true and the object is the registered one, which may be stored
+// under another type.
+//
+// An object is written whenever no reference to it is registered, even if it
+// still exists: its last reference may be in the middle of being released, and
+// the release only deletes the generation it knows about.
+func storeContentAddressed(ctx context.Context, bucket *storage.BucketHandle, staged, product, fileType, sha256, extension string, size int64, contentType string) (objectName string, duplicate bool, err error) {
+	// The staged copy is never served, drop it however this ends
+	defer func() {
+		if deleteErr := bucket.Object(staged).Delete(context.WithoutCancel(ctx)); deleteErr != nil {
+			logger.Errorf("Failed to delete staged upload %s: %v", staged, deleteErr)
+		}
+	}()
+
+	objectName = contentObjectName(product, fileType, sha256, extension)
+	objectService := db.NewObjectService(db.New())
+
+	// The same content may be stored under another type, e.g. as a thumbnail
+	acquired, err := objectService.Acquire(ctx, product, sha256)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up stored object: %w", err)
+	}
+	if acquired != nil {
+		return acquired.Object, true, nil
+	}
+
+	// Metadata (content type and disposition) is copied from the staged object
+	attrs, err := bucket.Object(objectName).CopierFrom(bucket.Object(staged)).Run(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to store object: %w", err)
+	}
+
+	registered, duplicate, err := objectService.Register(ctx, product, sha256, models.StoredObject{
+		Object:      objectName,
+		Generation:  attrs.Generation,
+		Size:        size,
+		ContentType: contentType,
+	})
+	if err != nil {
+		// The object may already be registered by a concurrent upload, so it
+		// is left for garbage collection rather than deleted
+		return "", false, fmt.Errorf("failed to register stored object: %w", err)
+	}
+
+	return registered.Object, duplicate, nil
+}
+
+// StorageObjectName returns the object a URL points to, if it is stored in
+// our bucket
+func StorageObjectName(fileURL string) (string, bool) {
+	if !IsValidStorageURL(fileURL) {
+		return "", false
+	}
+
+	bucketName, objectName, err := parseStorageURL(fileURL)
+	if err != nil || bucketName != firebase.StorageBucket {
+		return "", false
+	}
+	return objectName, true
+}
+
+// ReferenceFileFromURL takes a reference to a content-addressed upload linked
+// by URL rather than uploaded, so it outlives the resource that uploaded it.
+// Other URLs, including variants and external links, need no reference.
+func ReferenceFileFromURL(ctx context.Context, fileURL string) error {
+	objectName, ok := StorageObjectName(fileURL)
+	if !ok {
+		return nil
+	}
+
+	product, sha256, variant, ok := ParseContentObject(objectName)
+	if !ok || variant {
+		return nil
+	}
+
+	if _, err := db.NewObjectService(db.New()).Acquire(ctx, product, sha256); err != nil {
+		return fmt.Errorf("failed to reference object %s: %w", sha256, err)
+	}
+	return nil
+}
+
+// releaseObject releases a reference to a content-addressed upload, see
+// db.ObjectService.Release. Replaced in tests.
+var releaseObject = func(ctx context.Context, product, sha256, releaseID string) (*models.StoredObject, error) {
+	return db.NewObjectService(db.New()).Release(ctx, product, sha256, releaseID)
+}
+
+// releaseContentObject drops a reference to a content-addressed upload. The
+// object and its image variants are deleted with the last reference, unless
+// the object was written again since it was registered. Releasing again with
+// the same non-empty releaseID drops nothing.
+//
+// The reference is released before the object is deleted, so a failed
+// deletion is not retried and leaves an unreferenced object for garbage
+// collection.
+func releaseContentObject(ctx context.Context, bucket *storage.BucketHandle, product, sha256, releaseID string) error {
+	released, err := releaseObject(ctx, product, sha256, releaseID)
+	if err != nil {
+		return fmt.Errorf("failed to release object %s: %w", sha256, err)
+	}
+	if released == nil {
+		return nil // Still referenced, or not registered
+	}
+
+	err = bucket.Object(released.Object).If(storage.Conditions{GenerationMatch: released.Generation}).Delete(ctx)
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return nil // Uploaded again meanwhile, the new upload owns it
+	}
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete object %s: %w", released.Object, err)
+	}
+
+	return deleteImageVariants(ctx, bucket, released.Object)
+}
+
+// deleteImageVariants deletes every variant an image may have, see
+// variantObjectName
+func deleteImageVariants(ctx context.Context, bucket *storage.BucketHandle, sourceObject string) error {
+	var errs []error
+	for _, width := range constants.ImageVariantWidths {
+		for _, format := range constants.ImageVariantFormats {
+			objectName := variantObjectName(sourceObject, width, format)
+			if err := bucket.Object(objectName).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				errs = append(errs, fmt.Errorf("failed to delete %s: %w", objectName, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/pkg/logger"
+	"learninghub/pkg/pdf"
+)
+
+// pdfRejectedMessage is the user-facing reason for rejecting a PDF with active content
+const pdfRejectedMessage = "PDF contains potentially malicious embedded content and cannot be uploaded"
+
+// pdfUninspectableMessage is the user-facing reason for rejecting a PDF that could not be examined
+const pdfUninspectableMessage = "PDF is encrypted, malformed or uses unsupported compression and cannot be uploaded"
+
+// PDFInspection is the outcome of the structural check of an uploaded PDF
+type PDFInspection struct {
+	Report *pdf.Report
+
+	// Sanitize is true when the PDF contains active content that must be
+	// stripped before the file is stored
+	Sanitize bool
+
+	document *pdf.Document
+}
+
+// pdfActiveContentMode returns how PDFs with active content are handled: the
+// mode of the product's upload policy if it sets one, PDF_ACTIVE_CONTENT_MODE
+// otherwise. Anything but an explicit "sanitize" rejects, so a typo fails safe.
+func pdfActiveContentMode(policyMode string) string {
+	mode := policyMode
+	if mode == "" && config.AppConfig != nil {
+		mode = config.AppConfig.PDF_ACTIVE_CONTENT_MODE
+	}
+	if mode == constants.PDFActiveContentSanitize {
+		return constants.PDFActiveContentSanitize
+	}
+	return constants.PDFActiveContentReject
+}
+
+// inspectPDF parses a PDF and decides whether it can be stored as-is, must be
+// sanitized first, or must be rejected, according to mode (see
+// pdfActiveContentMode).
+//
+// Unlike scanning for byte patterns, the structural inspection decompresses
+// object streams and only looks at objects a viewer would actually load, so
+// hyperlinks (/URI) in a manual are fine while JavaScript hidden in a
+// compressed stream is still found. The file is read through io.ReaderAt, which
+// keeps only object dictionaries in memory, never stream payloads.
+//
+// Returns the inspection, or a user-facing rejection message.
+func inspectPDF(file io.ReaderAt, size int64, mode string) (*PDFInspection, string) {
+	document, err := pdf.Parse(file, size)
+	if err != nil {
+		logger.Infof("PDF upload rejected: failed to parse PDF: %v", err)
+		return nil, pdfUninspectableMessage
+	}
+
+	report := document.Inspect()
+	if !report.Inspectable() {
+		logger.Infof("PDF upload rejected: content cannot be inspected (encrypted: %t, undecodable object streams: %d)", report.Encrypted, report.Undecodable)
+		return nil, pdfUninspectableMessage
+	}
+
+	inspection := &PDFInspection{Report: report, document: document}
+
+	activeContent := report.ActiveContent()
+	if len(activeContent) == 0 {
+		return inspection, ""
+	}
+
+	if mode == constants.PDFActiveContentSanitize {
+		logger.Infof("PDF active content will be stripped: %v", activeContent)
+		inspection.Sanitize = true
+		return inspection, ""
+	}
+
+	// Log the features found (helpful for incident response) but do not
+	// expose them to the end user.
+	logger.Infof("PDF upload rejected: active content detected: %v", activeContent)
+	return nil, pdfRejectedMessage
+}
+
+// sanitizedReader streams the sanitized copy of an inspected PDF. The copy is
+// produced on demand as the reader is consumed; closing the reader early stops
+// the writer.
+func (i *PDFInspection) sanitizedReader() io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		_, err := i.document.WriteSanitized(pw)
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// fileSize returns the size of a seekable file and restores its position
+func fileSize(file io.Seeker) (int64, error) {
+	current, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := file.Seek(current, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to restore file position: %w", err)
+	}
+
+	return size, nil
+}
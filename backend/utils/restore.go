@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/firebase"
+	"learninghub/pkg/logger"
+)
+
+// mpegTSPacketSize is the size of the packets of MPEG transport streams, the
+// segments of HLS streams. Every packet starts with mpegTSSyncByte.
+const (
+	mpegTSPacketSize = 188
+	mpegTSSyncByte   = 0x47
+)
+
+// outputType describes the outputs of transcoding restored from catalog
+// archives: HLS playlists, detected from magic bytes, and MPEG-TS segments,
+// which are not and are inspected instead
+var outputType = ResourceType{
+	Name:   constants.FileTypeJobOutput,
+	Source: SourceFile,
+	AcceptMIME: func(mtype *mimetype.MIME) bool {
+		return mtype.Is(constants.HLSContentType) || mtype.Is("application/octet-stream")
+	},
+	MIMEError: "file type '%s' is not an HLS playlist or segment",
+	Inspect:   inspectJobOutput,
+}
+
+// inspectJobOutput rejects a job output that is neither an HLS playlist nor
+// made of MPEG-TS packets
+func inspectJobOutput(file io.ReaderAt, size int64, _ config.UploadPolicy) (*Inspection, string) {
+	head := make([]byte, min(size, sniffLength))
+	if _, err := file.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, fmt.Sprintf("failed to read file: %v", err)
+	}
+	if mimetype.Detect(head).Is(constants.HLSContentType) {
+		return nil, ""
+	}
+
+	if size == 0 || size%mpegTSPacketSize != 0 {
+		return nil, "file is not an MPEG-TS segment"
+	}
+	for offset := 0; offset < len(head); offset += mpegTSPacketSize {
+		if head[offset] != mpegTSSyncByte {
+			return nil, "file is not an MPEG-TS segment"
+		}
+	}
+	return nil, ""
+}
+
+// RestoredFile is a file stored by RestoreFile
+type RestoredFile struct {
+	Generation  int64
+	Size        int64
+	ContentType string
+}
+
+// RestoreFile stores a file restored from a catalog archive at objectName,
+// with the checks of UploadFile (see checkUpload), the product's size limit
+// and the malware scan. fileType is a file resource type, image, or
+// constants.FileTypeJobOutput. The object gets the MIME type detected from
+// the content, whatever the archive lists; job outputs get the type of their
+// name, like TranscodeToHLS writes them, which must match their content.
+//
+// The content of a content-addressed upload must hash to its name, so an
+// archive cannot store other content in place of an upload; it is hashed
+// before anything is written. For the same reason content the inspection
+// would replace, e.g. a PDF to sanitize, is rejected. The file is staged
+// under a unique name and only copied to objectName once every check passed.
+// Infected files are quarantined like uploads, and errors are reported like
+// those of UploadFile.
+func RestoreFile(ctx context.Context, file multipart.File, product, fileType, objectName string) (*RestoredFile, error) {
+	policy := config.UploadPolicyFor(product)
+
+	size, err := fileSize(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine file size: %w", err)
+	}
+	if maxSize := policy.MaxFileSize(fileType); size > maxSize {
+		return nil, fmt.Errorf("%s: %s is larger than the %d MB allowed for %s files of this product", constants.ErrFileValidationFailed, objectName, maxSize>>20, fileType)
+	}
+
+	if _, sha256, variant, ok := ParseContentObject(objectName); ok && !variant {
+		pipeline := newUploadPipeline()
+		if _, err := io.Copy(pipeline, io.NewSectionReader(file, 0, size)); err != nil {
+			return nil, fmt.Errorf("failed to hash file: %w", err)
+		}
+		if pipeline.Checksum() != sha256 {
+			return nil, fmt.Errorf("%s: the content of %s does not match its SHA-256", constants.ErrFileValidationFailed, objectName)
+		}
+	}
+
+	head, validationResult, inspection, err := checkUpload(file, policy, fileType)
+	if err != nil {
+		return nil, err
+	}
+	if inspection.replaces() {
+		return nil, fmt.Errorf("%s: %s has active content, which would have to be removed", constants.ErrFileValidationFailed, objectName)
+	}
+
+	contentType := validationResult.DetectedMIME
+	if fileType == constants.FileTypeJobOutput {
+		named := hlsContentType(objectName)
+		if named == "application/octet-stream" || (named == constants.HLSContentType) != (contentType == constants.HLSContentType) {
+			return nil, fmt.Errorf("%s: the content of %s does not match its name", constants.ErrFileValidationFailed, objectName)
+		}
+		contentType = named
+	}
+
+	staged, err := generateUniqueFilename(path.Base(objectName), product, fileType, validationResult.Extension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate filename: %w", err)
+	}
+
+	bucket := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	written, checksum, verdict, err := writeScanned(ctx, bucket, staged, contentType, io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		return nil, err
+	}
+	if verdict.Infected {
+		return nil, handleInfectedUpload(ctx, staged, product, fileType, objectName, checksum, verdict)
+	}
+
+	// The staged copy is never served, drop it however this ends
+	defer func() {
+		if deleteErr := bucket.Object(staged).Delete(context.WithoutCancel(ctx)); deleteErr != nil {
+			logger.Errorf("Failed to delete staged file %s: %v", staged, deleteErr)
+		}
+	}()
+
+	// Metadata (content type and disposition) is copied from the staged object
+	attrs, err := bucket.Object(objectName).CopierFrom(bucket.Object(staged)).Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store object %s: %w", objectName, err)
+	}
+
+	return &RestoredFile{Generation: attrs.Generation, Size: written, ContentType: contentType}, nil
+}
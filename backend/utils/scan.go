@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"learninghub/constants"
+	"learninghub/firebase"
+	"learninghub/pkg/logger"
+	"learninghub/scanner"
+)
+
+// malwareScan feeds an upload to a scanner while it streams to storage.
+//
+// It is used as one of the writers of the upload's io.TeeReader. Writes never
+// fail: once the scanner stops reading (it reached a verdict or failed), the
+// remaining bytes are discarded and the upload carries on. The verdict is
+// collected with wait after the upload has been written.
+type malwareScan struct {
+	writer  *io.PipeWriter
+	stopped bool
+
+	done   chan struct{}
+	result *scanner.Result
+	err    error
+}
+
+// startMalwareScan starts scanning everything written to the returned malwareScan
+func startMalwareScan(ctx context.Context, s scanner.Scanner) *malwareScan {
+	pr, pw := io.Pipe()
+	scan := &malwareScan{writer: pw, done: make(chan struct{})}
+
+	go func() {
+		defer close(scan.done)
+		scan.result, scan.err = s.Scan(ctx, pr)
+		// Unblock pending and future writes
+		pr.Close()
+	}()
+
+	return scan
+}
+
+// Write passes b on to the scanner
+func (s *malwareScan) Write(b []byte) (int, error) {
+	if !s.stopped {
+		if _, err := s.writer.Write(b); err != nil {
+			s.stopped = true
+		}
+	}
+	return len(b), nil
+}
+
+// wait signals the end of the content and returns the scanner's verdict
+func (s *malwareScan) wait() (*scanner.Result, error) {
+	s.writer.Close()
+	<-s.done
+	return s.result, s.err
+}
+
+// abort stops the scan without waiting for a verdict
+func (s *malwareScan) abort(err error) {
+	s.writer.CloseWithError(err)
+}
+
+// quarantineObject moves an infected object under constants.QuarantinePrefix,
+// out of reach of resource URLs, and keeps it for later analysis.
+func quarantineObject(ctx context.Context, objectName string) (string, error) {
+	bucket := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	source := bucket.Object(objectName)
+	quarantineName := constants.QuarantinePrefix + objectName
+
+	if _, err := bucket.Object(quarantineName).CopierFrom(source).Run(ctx); err != nil {
+		return "", fmt.Errorf("failed to copy object to quarantine: %w", err)
+	}
+
+	if err := source.Delete(ctx); err != nil {
+		return "", fmt.Errorf("failed to delete quarantined object: %w", err)
+	}
+
+	return quarantineName, nil
+}
+
+// handleInfectedUpload quarantines an infected upload and logs the event.
+// The returned error is what UploadFile reports to the caller.
+func handleInfectedUpload(ctx context.Context, objectName, product, fileType, originalFilename, checksum string, result *scanner.Result) error {
+	// The upload is already stored; finish moving it even if the request is gone
+	ctx = context.WithoutCancel(ctx)
+
+	quarantineName, err := quarantineObject(ctx, objectName)
+	if err != nil {
+		logger.Errorf("Failed to quarantine infected upload %s: %v", objectName, err)
+
+		// Never leave an infected object where it can be served
+		if deleteErr := firebase.StorageClient.Bucket(firebase.StorageBucket).Object(objectName).Delete(ctx); deleteErr != nil {
+			logger.Errorf("Failed to delete infected upload %s: %v", objectName, deleteErr)
+		}
+	}
+
+	logger.Warn("Malware detected in upload", map[string]interface{}{
+		"scanner":    scanner.Default.Name(),
+		"signature":  result.Signature,
+		"product":    product,
+		"type":       fileType,
+		"filename":   originalFilename,
+		"object":     objectName,
+		"quarantine": quarantineName,
+		"sha256":     checksum,
+	})
+
+	return fmt.Errorf("%s: %s", constants.ErrMalwareDetected, result.Signature)
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// sniffLength is the number of leading bytes used for magic bytes detection.
+// It matches the default read limit of the mimetype package, so detecting from
+// the head gives the same answer as mimetype.DetectReader on the whole file.
+const sniffLength = 3072
+
+// sniffContent reads the head of r and detects its MIME type from magic bytes.
+//
+// r is not rewound: the caller must replay the returned head in front of the
+// remaining reader (see io.MultiReader) to consume the complete content.
+func sniffContent(r io.Reader) ([]byte, *mimetype.MIME, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+
+	return head, mimetype.Detect(head), nil
+}
+
+// uploadPipeline observes every byte of an upload on its way to storage and
+// computes the SHA-256 checksum of the stored content. It is used as the writer
+// of an io.TeeReader, so an error returned from Write aborts the copy to storage.
+type uploadPipeline struct {
+	hasher hash.Hash
+}
+
+// newUploadPipeline creates the pipeline for an upload
+func newUploadPipeline() *uploadPipeline {
+	return &uploadPipeline{hasher: sha256.New()}
+}
+
+// Write hashes b
+func (p *uploadPipeline) Write(b []byte) (int, error) {
+	return p.hasher.Write(b)
+}
+
+// Checksum returns the hex encoded SHA-256 of everything written so far
+func (p *uploadPipeline) Checksum() string {
+	return hex.EncodeToString(p.hasher.Sum(nil))
+}
@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	_ "image/gif" // Register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/firebase"
+	"learninghub/models"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxThumbnailSourcePixels bounds the images decoded for thumbnails, so a small
+// file declaring huge dimensions cannot exhaust memory
+const maxThumbnailSourcePixels = 50_000_000
+
+// GenerateThumbnail renders a JPEG thumbnail for an uploaded file and stores it
+// under the product's image prefix.
+//
+// The image is rendered by the RenderThumbnail hook of the resource type:
+// images are resized, PDFs and slide decks converted to PDF have their first
+// page rendered with pdftoppm and videos have a frame extracted with ffmpeg.
+// The file is read from the start, whatever its current position. The
+// responsive variants of the thumbnail are returned in
+// FileUploadResult.Variants.
+func GenerateThumbnail(ctx context.Context, file multipart.File, header *multipart.FileHeader, product, resourceType string) (*FileUploadResult, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ThumbnailTimeout*time.Second)
+	defer cancel()
+
+	fileType, _ := uploadType(resourceType)
+	if fileType.RenderThumbnail == nil {
+		return nil, fmt.Errorf("thumbnails are not supported for %s resources", resourceType)
+	}
+
+	source, err := fileType.RenderThumbnail(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail := resizeToWidth(source, constants.ThumbnailMaxWidth)
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, thumbnail, &jpeg.Options{Quality: constants.ThumbnailJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	baseName := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	filename, err := generateUniqueFilename(baseName+"_thumbnail", product, constants.ResourceTypeImage, ".jpg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate filename: %w", err)
+	}
+
+	metadata := &models.Metadata{
+		Size:     int64(encoded.Len()),
+		MIMEType: "image/jpeg",
+		SHA256:   fmt.Sprintf("%x", sha256.Sum256(encoded.Bytes())),
+		Width:    thumbnail.Bounds().Dx(),
+		Height:   thumbnail.Bounds().Dy(),
+	}
+	if err := writeObject(ctx, filename, "image/jpeg", &encoded); err != nil {
+		return nil, err
+	}
+
+	publicURL, err := generatePublicURL(filename, firebase.StorageBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate public URL: %w", err)
+	}
+
+	return &FileUploadResult{
+		PublicURL:   publicURL,
+		Filename:    filename,
+		Size:        metadata.Size,
+		ContentType: metadata.MIMEType,
+		SHA256:      metadata.SHA256,
+		Variants:    GenerateImageVariants(ctx, source, filename),
+		Metadata:    metadata,
+	}, nil
+}
+
+// GenerateThumbnailFromObject generates a thumbnail for a resource file stored
+// in our bucket, see GenerateThumbnail
+func GenerateThumbnailFromObject(ctx context.Context, sourceURL, product, resourceType string) (*FileUploadResult, error) {
+	_, objectName, err := parseStorageURL(sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse storage URL: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "thumbnail-source-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	localPath := filepath.Join(workDir, "source")
+	if err := downloadObject(ctx, sourceURL, localPath); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local copy: %w", err)
+	}
+	defer file.Close()
+
+	return GenerateThumbnail(ctx, file, &multipart.FileHeader{Filename: uploadedFilename(objectName)}, product, resourceType)
+}
+
+// uploadedFilename recovers the sanitized original filename from an object
+// name built by generateUniqueFilename, e.g. "intro.pdf" from
+// "ecomm/pdf/1700000000_intro.pdf"
+func uploadedFilename(objectName string) string {
+	name := path.Base(objectName)
+	if timestamp, rest, found := strings.Cut(name, "_"); found && rest != "" && strings.Trim(timestamp, "0123456789") == "" {
+		return rest
+	}
+	return name
+}
+
+// decodeImage decodes an image after checking its declared dimensions
+func decodeImage(r io.ReadSeeker) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxThumbnailSourcePixels {
+		return nil, fmt.Errorf("image is too large to thumbnail: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind image: %w", err)
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// resizeToWidth scales img down to width, keeping its aspect ratio.
+// Images narrower than width are returned as-is.
+func resizeToWidth(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+	return resized
+}
+
+// renderPDFPage renders the first page of a PDF with pdftoppm
+func renderPDFPage(ctx context.Context, file multipart.File) (image.Image, error) {
+	path, cleanup, err := localCopy(file)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	outputDir, err := os.MkdirTemp("", "thumbnail-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	// -singlefile writes <root>.png instead of numbering the pages
+	root := filepath.Join(outputDir, "page")
+	if _, err := runTool(ctx, config.AppConfig.PDFTOPPM_PATH,
+		"-f", "1", "-l", "1", "-singlefile", "-png",
+		"-scale-to", fmt.Sprint(constants.ThumbnailMaxWidth*2),
+		path, root,
+	); err != nil {
+		return nil, err
+	}
+
+	page, err := os.Open(root + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("pdftoppm did not produce an image: %w", err)
+	}
+	defer page.Close()
+
+	return decodeImage(page)
+}
+
+// extractVideoFrame grabs a frame of a video with ffmpeg. The frame one second
+// in avoids the black frame many videos start with; very short videos fall back
+// to the first frame.
+func extractVideoFrame(ctx context.Context, file multipart.File) (image.Image, error) {
+	path, cleanup, err := localCopy(file)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return extractVideoFrameFromPath(ctx, path)
+}
+
+// extractVideoFrameFromPath grabs a frame of a video file, see extractVideoFrame
+func extractVideoFrameFromPath(ctx context.Context, path string) (image.Image, error) {
+	var lastErr error
+	for _, offset := range []string{"1", "0"} {
+		frame, err := runTool(ctx, config.AppConfig.FFMPEG_PATH,
+			"-hide_banner", "-loglevel", "error",
+			"-ss", offset, "-i", path,
+			"-frames:v", "1", "-f", "image2pipe", "-c:v", "png", "pipe:1",
+		)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(frame) == 0 {
+			lastErr = fmt.Errorf("ffmpeg found no frame at %ss", offset)
+			continue
+		}
+
+		return decodeImage(bytes.NewReader(frame))
+	}
+
+	return nil, lastErr
+}
+
+// runTool runs an external program and returns its standard output
+func runTool(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return runToolWithInput(ctx, nil, name, args...)
+}
+
+// runToolWithInput runs an external program fed with stdin and returns its
+// standard output
+func runToolWithInput(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", filepath.Base(name), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// localCopy returns a path to the content of file for tools that need one.
+// Large multipart uploads are already spooled to disk and are used in place;
+// in-memory ones are written to a temporary file, removed by cleanup.
+func localCopy(file multipart.File) (path string, cleanup func(), err error) {
+	if osFile, ok := file.(*os.File); ok {
+		return osFile.Name(), func() {}, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	cleanup = func() { os.Remove(tmp.Name()) }
+
+	_, err = io.Copy(tmp, file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	return tmp.Name(), cleanup, nil
+}
+
+// writeObject stores generated content in the bucket
+func writeObject(ctx context.Context, objectName, contentType string, r io.Reader) error {
+	uploadCtx, cancelUpload := context.WithCancel(ctx)
+	defer cancelUpload()
+
+	writer := firebase.StorageClient.Bucket(firebase.StorageBucket).Object(objectName).NewWriter(uploadCtx)
+	writer.ContentType = contentType
+	writer.ContentDisposition = "inline"
+
+	if _, err := io.Copy(writer, r); err != nil {
+		cancelUpload()
+		writer.Close()
+		return fmt.Errorf("failed to upload %s: %w", objectName, err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to finalize upload of %s: %w", objectName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/db"
+	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/pkg/logger"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// hlsRendition is one quality level of an HLS stream
+type hlsRendition struct {
+	Name         string // Directory of the rendition's playlist and segments
+	Height       int
+	VideoBitrate int // kbit/s
+	AudioBitrate int // kbit/s
+}
+
+// hlsRenditions is the bitrate ladder, from lowest to highest quality
+var hlsRenditions = []hlsRendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 160},
+}
+
+// selectRenditions returns the renditions worth producing for a source video.
+// Videos are never upscaled: a source below the lowest rendition gets a single
+// rendition at its own height.
+func selectRenditions(sourceHeight int) []hlsRendition {
+	var selected []hlsRendition
+	for _, rendition := range hlsRenditions {
+		if rendition.Height <= sourceHeight {
+			selected = append(selected, rendition)
+		}
+	}
+
+	if len(selected) == 0 {
+		lowest := hlsRenditions[0]
+		lowest.Height = sourceHeight &^ 1 // H.264 needs even dimensions
+		selected = append(selected, lowest)
+	}
+
+	return selected
+}
+
+// masterPlaylist builds the HLS master playlist listing the renditions of a
+// video with the given source dimensions
+func masterPlaylist(renditions []hlsRendition, sourceWidth, sourceHeight int) []byte {
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+
+	for _, rendition := range renditions {
+		// Matches the width ffmpeg picks for scale=-2:<height>
+		width := (sourceWidth*rendition.Height/sourceHeight + 1) &^ 1
+		bandwidth := (rendition.VideoBitrate + rendition.AudioBitrate) * 1000 * 11 / 10 // Peak, with muxing overhead
+
+		fmt.Fprintf(&playlist, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", bandwidth, width, rendition.Height)
+		fmt.Fprintf(&playlist, "%s/index.m3u8\n", rendition.Name)
+	}
+
+	return []byte(playlist.String())
+}
+
+// transcodeRendition encodes one rendition of a video into dir as an HLS
+// playlist (index.m3u8) and MPEG-TS segments
+func transcodeRendition(ctx context.Context, source, dir string, rendition hlsRendition) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create rendition directory: %w", err)
+	}
+
+	_, err := runTool(ctx, config.AppConfig.FFMPEG_PATH,
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", source,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", fmt.Sprintf("scale=-2:%d", rendition.Height),
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main", "-pix_fmt", "yuv420p",
+		"-b:v", fmt.Sprintf("%dk", rendition.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", rendition.VideoBitrate*107/100),
+		"-bufsize", fmt.Sprintf("%dk", rendition.VideoBitrate*3/2),
+		// Segments must start on a keyframe to be switchable between renditions
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", constants.HLSSegmentSeconds), "-sc_threshold", "0",
+		"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", rendition.AudioBitrate), "-ac", "2",
+		"-f", "hls",
+		"-hls_time", fmt.Sprint(constants.HLSSegmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "segment_%04d.ts"),
+		filepath.Join(dir, "index.m3u8"),
+	)
+	return err
+}
+
+// transcodeToDir transcodes a local video into an HLS stream in dir:
+// a master playlist and one directory per rendition
+func transcodeToDir(ctx context.Context, source, dir string) error {
+	frame, err := extractVideoFrameFromPath(ctx, source)
+	if err != nil {
+		return fmt.Errorf("failed to read video dimensions: %w", err)
+	}
+	width, height := frame.Bounds().Dx(), frame.Bounds().Dy()
+
+	renditions := selectRenditions(height)
+	for _, rendition := range renditions {
+		if err := transcodeRendition(ctx, source, filepath.Join(dir, rendition.Name), rendition); err != nil {
+			return fmt.Errorf("failed to transcode %s rendition: %w", rendition.Name, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, constants.HLSMasterPlaylist), masterPlaylist(renditions, width, height), 0o644); err != nil {
+		return fmt.Errorf("failed to write master playlist: %w", err)
+	}
+
+	return nil
+}
+
+// HLSPrefix returns the storage prefix for the HLS output of a transcoding
+// job. Each job writes to its own prefix, so a job never overwrites the stream
+// of another one.
+func HLSPrefix(product, id, jobID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", product, constants.HLSPathSegment, id, jobID)
+}
+
+// TranscodeToHLS transcodes a stored video into HLS renditions and a master
+// playlist under prefix, and tracks the job's progress on the resource. A job
+// whose video was replaced in the meantime throws its output away.
+//
+// Failures leave the resource pending for a retry, or failed on the final
+// attempt. Output of an interrupted earlier attempt is cleared first.
+func TranscodeToHLS(ctx context.Context, product, id, sourceURL, prefix string, finalAttempt bool) error {
+	resourceService := db.NewResourceService(db.New())
+
+	setStatus := func(status, message string) (bool, error) {
+		transcode := models.Transcode{Status: status, Source: sourceURL, Error: message, UpdatedAt: time.Now()}
+		if status == constants.TranscodeStatusReady {
+			transcode.Prefix = prefix
+		}
+		return resourceService.UpdateTranscode(context.WithoutCancel(ctx), product, id, transcode)
+	}
+
+	if current, err := setStatus(constants.TranscodeStatusProcessing, ""); err != nil || !current {
+		return err
+	}
+
+	if err := DeleteFolder(ctx, prefix); err != nil {
+		return fmt.Errorf("failed to clear output of an earlier attempt: %w", err)
+	}
+
+	if err := transcodeAndUpload(ctx, sourceURL, prefix); err != nil {
+		if cleanupErr := DeleteFolder(context.WithoutCancel(ctx), prefix); cleanupErr != nil {
+			logger.Infof("Failed to delete partial HLS output %s: %v", prefix, cleanupErr)
+		}
+
+		status := constants.TranscodeStatusPending
+		if finalAttempt {
+			status = constants.TranscodeStatusFailed
+		}
+		if _, statusErr := setStatus(status, err.Error()); statusErr != nil {
+			logger.Errorf("Failed to record transcoding failure of resource %s/%s: %v", product, id, statusErr)
+		}
+		return err
+	}
+
+	current, err := setStatus(constants.TranscodeStatusReady, "")
+	if err != nil || !current {
+		if cleanupErr := DeleteFolder(context.WithoutCancel(ctx), prefix); cleanupErr != nil {
+			logger.Infof("Failed to delete stale HLS output %s: %v", prefix, cleanupErr)
+		}
+	}
+	return err
+}
+
+// transcodeAndUpload downloads a stored video, transcodes it and uploads the
+// HLS stream under prefix
+func transcodeAndUpload(ctx context.Context, sourceURL, prefix string) error {
+	workDir, err := os.MkdirTemp("", "transcode-*")
+	if err != nil {
+		return fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	source := filepath.Join(workDir, "source")
+	if err := downloadObject(ctx, sourceURL, source); err != nil {
+		return err
+	}
+
+	outputDir := filepath.Join(workDir, "hls")
+	if err := transcodeToDir(ctx, source, outputDir); err != nil {
+		return err
+	}
+
+	return uploadDir(ctx, outputDir, prefix)
+}
+
+// downloadObject copies a stored object to a local file
+func downloadObject(ctx context.Context, objectURL, dst string) error {
+	bucketName, objectName, err := parseStorageURL(objectURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse storage URL: %w", err)
+	}
+
+	reader, err := firebase.StorageClient.Bucket(bucketName).Object(objectName).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", objectName, err)
+	}
+	defer reader.Close()
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create local copy: %w", err)
+	}
+
+	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+// uploadDir uploads every file under dir to the bucket, keeping the relative paths under prefix
+func uploadDir(ctx context.Context, dir, prefix string) error {
+	return filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relative, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return writeObject(ctx, path.Join(prefix, filepath.ToSlash(relative)), hlsContentType(filePath), file)
+	})
+}
+
+// hlsContentType returns the MIME type of an HLS output file
+func hlsContentType(name string) string {
+	switch filepath.Ext(name) {
+	case ".m3u8":
+		return constants.HLSContentType
+	case ".ts":
+		return "video/mp2t"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// DeleteFolder deletes every object under a storage prefix
+func DeleteFolder(ctx context.Context, prefix string) error {
+	bucket := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	objects := bucket.Objects(ctx, &storage.Query{Prefix: strings.TrimSuffix(prefix, "/") + "/"})
+
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+
+		if err := bucket.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", attrs.Name, err)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/models"
+)
+
+// Where the content of resources of a type comes from
+const (
+	SourceFile = "file" // A file, uploaded or linked by URL
+	SourceURL  = "url"  // A link to a page, never uploaded
+)
+
+// ResourceType describes how resources of a type are validated, stored and
+// processed. Handlers and content validation look types up in the registry,
+// see RegisterResourceType, rather than branching on their names.
+//
+// Only Name and Source are required. The upload hooks apply to SourceFile
+// types, Resolve to SourceURL types.
+type ResourceType struct {
+	Name   string
+	Source string // SourceFile | SourceURL
+
+	// MIMETypes is the allowlist of MIME types detected from the magic bytes
+	// of uploads. AcceptMIME replaces it when set.
+	MIMETypes  []string
+	AcceptMIME func(mtype *mimetype.MIME) bool
+	// MIMEError is the rejection reason of another MIME type, formatted with it
+	MIMEError string
+	// FileHint tells users which files are accepted once an upload is rejected
+	FileHint string
+
+	// Inspect checks the content of an upload beyond its type, see
+	// inspectPDF. Returns the inspection, or a user-facing rejection message.
+	Inspect func(file io.ReaderAt, size int64, policy config.UploadPolicy) (*Inspection, string)
+	// ExtractMetadata reads the type specific metadata of a stored upload.
+	// Metadata is informative: failures are logged, not returned.
+	ExtractMetadata func(ctx context.Context, file multipart.File, size int64, inspection *Inspection, metadata *models.Metadata)
+	// RenderThumbnail renders the image thumbnails are made of
+	RenderThumbnail func(ctx context.Context, file multipart.File) (image.Image, error)
+	// ThumbnailFromUpload generates a thumbnail from uploads of the type
+	// when the resource has none
+	ThumbnailFromUpload bool
+
+	// Uploaded runs when a file is uploaded for a resource of the type,
+	// before it is saved, e.g. to queue the processing of the file
+	Uploaded func(resource *models.Resource)
+	// Resolve runs when the URL of a resource of the type is set, before it
+	// is saved. Errors wrapping an *UnsupportedURLError are shown to users.
+	Resolve func(ctx context.Context, resource *models.Resource) error
+	// DefaultThumbnail returns the thumbnail of a resource that has none, e.g.
+	// the one Resolve found
+	DefaultThumbnail func(resource models.Resource) string
+}
+
+// Inspection is the outcome of ResourceType.Inspect
+type Inspection struct {
+	PDF     *PDFInspection         // PDFs
+	Archive *models.ArchiveListing // Zip based files
+}
+
+// replaces reports whether the uploaded file is stored as a replacement
+func (i *Inspection) replaces() bool {
+	return i != nil && i.PDF != nil && i.PDF.Sanitize
+}
+
+// replacement returns the content to store instead of the uploaded file, or
+// nil to store the upload as-is
+func (i *Inspection) replacement() io.ReadCloser {
+	if i.replaces() {
+		return i.PDF.sanitizedReader()
+	}
+	return nil
+}
+
+// UnsupportedURLError is returned by ResourceType.Resolve hooks for URLs the
+// type cannot use
+type UnsupportedURLError struct {
+	Reason string
+}
+
+func (e *UnsupportedURLError) Error() string {
+	return e.Reason
+}
+
+// acceptsMIME reports whether uploads of the type may have a detected MIME type
+func (t ResourceType) acceptsMIME(mtype *mimetype.MIME) bool {
+	if t.AcceptMIME != nil {
+		return t.AcceptMIME(mtype)
+	}
+	return slices.Contains(t.MIMETypes, mtype.String())
+}
+
+var (
+	// resourceTypes are the registered resource types, in registration order
+	resourceTypes []ResourceType
+
+	// imageType describes thumbnails, uploaded like files but not a resource type
+	imageType = ResourceType{
+		Name:   constants.ResourceTypeImage,
+		Source: SourceFile,
+		// Accept any image/* type but exclude SVG (already in
+		// blockedMIMETypes; this is a second, explicit guard).
+		AcceptMIME: func(mtype *mimetype.MIME) bool {
+			return strings.HasPrefix(mtype.String(), "image/") && !mtype.Is("image/svg+xml")
+		},
+		MIMEError:       "file type '%s' is not a supported image format",
+		FileHint:        "The uploaded file is not a supported image format",
+		ExtractMetadata: extractImageMetadata,
+		RenderThumbnail: func(ctx context.Context, file multipart.File) (image.Image, error) {
+			return decodeImage(file)
+		},
+	}
+)
+
+// RegisterResourceType adds a resource type to the registry. It must be
+// called during initialization, before the registry is read concurrently, and
+// panics on an invalid or duplicate type.
+func RegisterResourceType(resourceType ResourceType) {
+	switch {
+	case resourceType.Name == "" || resourceType.Name == constants.ResourceTypeImage || resourceType.Name == constants.FileTypeJobOutput:
+		panic(fmt.Sprintf("invalid resource type name %q", resourceType.Name))
+	case resourceType.Source != SourceFile && resourceType.Source != SourceURL:
+		panic(fmt.Sprintf("resource type %s: invalid source %q", resourceType.Name, resourceType.Source))
+	case resourceType.Source == SourceFile && len(resourceType.MIMETypes) == 0 && resourceType.AcceptMIME == nil:
+		panic(fmt.Sprintf("resource type %s: file types need accepted MIME types", resourceType.Name))
+	}
+	if _, exists := LookupResourceType(resourceType.Name); exists {
+		panic(fmt.Sprintf("resource type %s registered twice", resourceType.Name))
+	}
+
+	resourceTypes = append(resourceTypes, resourceType)
+}
+
+// LookupResourceType returns a registered resource type
+func LookupResourceType(name string) (ResourceType, bool) {
+	index := slices.IndexFunc(resourceTypes, func(t ResourceType) bool { return t.Name == name })
+	if index < 0 {
+		return ResourceType{}, false
+	}
+	return resourceTypes[index], true
+}
+
+// ResourceTypeNames returns the names of the registered resource types
+func ResourceTypeNames() []string {
+	names := make([]string, 0, len(resourceTypes))
+	for _, t := range resourceTypes {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// uploadType returns the type describing uploads of fileType: a file resource
+// type, thumbnails or restored job outputs
+func uploadType(fileType string) (ResourceType, bool) {
+	switch fileType {
+	case constants.ResourceTypeImage:
+		return imageType, true
+	case constants.FileTypeJobOutput:
+		return outputType, true
+	}
+	resourceType, exists := LookupResourceType(fileType)
+	return resourceType, exists && resourceType.Source == SourceFile
+}
+
+// FileTypeHint returns which files are accepted for uploads of fileType, a
+// file resource type or image, see ResourceType.FileHint
+func FileTypeHint(fileType string) string {
+	resourceType, _ := uploadType(fileType)
+	return resourceType.FileHint
+}
+
+// PendingTranscode returns the transcode state of a video waiting to be transcoded
+func PendingTranscode(sourceURL string) *models.Transcode {
+	return &models.Transcode{
+		Status:    constants.TranscodeStatusPending,
+		Source:    sourceURL,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// PendingConversion returns the conversion state of a slide deck waiting to be converted
+func PendingConversion(sourceURL string) *models.Conversion {
+	return &models.Conversion{
+		Status:    constants.ConversionStatusPending,
+		Source:    sourceURL,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// The built-in resource types
+func init() {
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypeVideo,
+		Source: SourceFile,
+		// An explicit allowlist: "video/*" is too broad and would accept
+		// obscure or potentially dangerous video sub-types
+		MIMETypes: []string{
+			"video/mp4",
+			"video/webm",
+			"video/x-matroska", // WebM is a subset of Matroska; some detectors report this MIME
+		},
+		MIMEError:           "video type '%s' is not supported; accepted types: mp4, webm",
+		FileHint:            "Only MP4 and WebM video formats are supported",
+		ExtractMetadata:     probeMediaMetadata(constants.ResourceTypeVideo),
+		RenderThumbnail:     extractVideoFrame,
+		ThumbnailFromUpload: true,
+		// Uploaded videos are transcoded to HLS once the resource is saved
+		Uploaded: func(resource *models.Resource) {
+			resource.Transcode = PendingTranscode(resource.URL)
+		},
+	})
+
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypePDF,
+		Source: SourceFile,
+		// Magic bytes confirm this is a real PDF, Inspect looks inside
+		AcceptMIME: func(mtype *mimetype.MIME) bool {
+			return mtype.Is("application/pdf")
+		},
+		MIMEError:           "file content type '%s' does not match expected type 'pdf'",
+		FileHint:            "Only PDF files are supported",
+		Inspect:             inspectPDFUpload,
+		ExtractMetadata:     extractPDFMetadata,
+		RenderThumbnail:     renderPDFPage,
+		ThumbnailFromUpload: true,
+	})
+
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypeArticle,
+		Source: SourceURL,
+	})
+
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypeAudio,
+		Source: SourceFile,
+		MIMETypes: []string{
+			"audio/mpeg",
+			"audio/ogg",
+			"audio/x-m4a",
+			"audio/mp4", // MPEG-4 audio not branded as M4A
+		},
+		MIMEError:       "audio type '%s' is not supported; accepted types: mp3, ogg, m4a",
+		FileHint:        "Only MP3, Ogg and M4A audio formats are supported",
+		ExtractMetadata: probeMediaMetadata(constants.ResourceTypeAudio),
+	})
+
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypeSlides,
+		Source: SourceFile,
+		MIMETypes: []string{
+			"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+			"application/vnd.oasis.opendocument.presentation",
+		},
+		MIMEError: "slide deck type '%s' is not supported; accepted types: pptx, odp",
+		FileHint:  "Only PowerPoint (pptx) and OpenDocument (odp) slide decks are supported",
+		// Slide decks are zip archives too, opened by office suites that are
+		// as exposed to path traversal and zip bombs as a user extracting code
+		Inspect: inspectArchiveUpload,
+		// Rendered from their PDF conversion, see ConvertToPDF
+		RenderThumbnail: renderPDFPage,
+		Uploaded: func(resource *models.Resource) {
+			resource.Conversion = PendingConversion(resource.URL)
+		},
+	})
+
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypeCode,
+		Source: SourceFile,
+		// Plain zip only: zip-based formats (jar, apk, office documents) are
+		// detected as such and rejected
+		MIMETypes: []string{"application/zip"},
+		MIMEError: "archive type '%s' is not supported; accepted type: zip",
+		FileHint:  "Only zip archives without unsafe paths, links or encrypted files are supported",
+		Inspect:   inspectArchiveUpload,
+		ExtractMetadata: func(ctx context.Context, file multipart.File, size int64, inspection *Inspection, metadata *models.Metadata) {
+			if inspection != nil {
+				metadata.Archive = inspection.Archive
+			}
+		},
+	})
+
+	RegisterResourceType(ResourceType{
+		Name:   constants.ResourceTypeEmbed,
+		Source: SourceURL,
+		Resolve: func(ctx context.Context, resource *models.Resource) error {
+			embed, err := ResolveEmbed(ctx, resource.URL)
+			resource.Embed = embed
+			return err
+		},
+		DefaultThumbnail: func(resource models.Resource) string {
+			if resource.Embed == nil {
+				return ""
+			}
+			return resource.Embed.ThumbnailURL
+		},
+	})
+}
+
+// inspectPDFUpload inspects an uploaded PDF in the mode of the product's policy
+func inspectPDFUpload(file io.ReaderAt, size int64, policy config.UploadPolicy) (*Inspection, string) {
+	inspection, rejection := inspectPDF(file, size, pdfActiveContentMode(policy.PDFActiveContent))
+	if rejection != "" {
+		return nil, rejection
+	}
+	return &Inspection{PDF: inspection}, ""
+}
+
+// inspectArchiveUpload checks that extracting an uploaded archive is safe
+func inspectArchiveUpload(file io.ReaderAt, size int64, _ config.UploadPolicy) (*Inspection, string) {
+	listing, rejection := inspectArchive(file, size)
+	if rejection != "" {
+		return nil, rejection
+	}
+	return &Inspection{Archive: listing}, ""
+}
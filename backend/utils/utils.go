@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -16,7 +17,10 @@ import (
 	"learninghub/constants"
 	"learninghub/db"
 	"learninghub/firebase"
+	"learninghub/models"
 	"learninghub/pkg/logger"
+	"learninghub/products"
+	"learninghub/scanner"
 
 	"cloud.google.com/go/storage"
 	"github.com/gabriel-vasile/mimetype"
@@ -66,21 +70,58 @@ func UpdateTagUsage(ctx context.Context, product string, tags []string, delta in
 	}
 }
 
+// AdjustTagUsage applies usage count deltas to tags at once, e.g. the changes
+// of a batch of resources
+func AdjustTagUsage(ctx context.Context, product string, deltas map[string]int) {
+	database := db.New()
+	tagService := db.NewTagService(database)
+
+	if err := tagService.AdjustUsage(ctx, product, deltas); err != nil {
+		logger.Infof("Failed to update tag usage: %v", err)
+	}
+}
+
 // FileUploadResult contains the result of a file upload operation
 type FileUploadResult struct {
-	PublicURL string
-	Filename  string
-	Size      int64
+	PublicURL   string
+	Filename    string
+	Size        int64
+	ContentType string
+	SHA256      string
+
+	// Variants maps VariantKey to the URL of each responsive variant of an image
+	Variants map[string]string
+
+	// Duplicate is true when identical content was already stored for the
+	// product, see storeContentAddressed
+	Duplicate bool
+
+	// Metadata describes the stored file, see extractMetadata
+	Metadata *models.Metadata
 }
 
-// UploadFile uploads a file to Firebase Cloud Storage and returns the public URL
+// UploadFile validates a file and uploads it to Firebase Cloud Storage in a single pass.
+//
+// The MIME type is detected from the head of the file and checked against the
+// rules of its resource type (see ResourceType) and the product's upload
+// policy. The type's Inspect hook then checks the content, e.g. PDFs are
+// inspected structurally (see inspectPDF) and, depending on the configured
+// mode, rejected or replaced by a sanitized copy. The content streams through
+// an uploadPipeline (checksum) and the configured malware scanner straight
+// into the storage writer; the file is never read into memory as a whole.
+// Uploads the scanner flags are moved to quarantine and reported with
+// constants.ErrMalwareDetected.
+//
+// Uploads are staged under a unique name, then stored content-addressed by
+// their SHA-256 with a reference count (see storeContentAddressed), so the
+// same file uploaded twice is stored once. Once stored, the metadata of the
+// file (video duration and resolution, PDF page count, image dimensions...)
+// is extracted into FileUploadResult.Metadata.
 func UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, product, fileType string) (*FileUploadResult, error) {
-	// SECURITY: Validate file content using magic bytes detection.
-	// This prevents attackers from uploading malicious files by spoofing the
-	// Content-Type header. The actual file bytes are inspected, not the header.
-	validationResult := ValidateFileContent(file, fileType)
-	if !validationResult.IsValid {
-		return nil, fmt.Errorf("%s: %s", constants.ErrFileValidationFailed, validationResult.Error)
+	policy := config.UploadPolicyFor(product)
+	head, validationResult, inspection, err := checkUpload(file, policy, fileType)
+	if err != nil {
+		return nil, err
 	}
 
 	// SECURITY: Use the detected extension from magic bytes analysis, NOT the
@@ -92,38 +133,148 @@ func UploadFile(ctx context.Context, file multipart.File, header *multipart.File
 		return nil, fmt.Errorf("failed to generate filename: %w", err)
 	}
 
-	bucketHandler := firebase.StorageClient.Bucket(firebase.StorageBucket)
-	writer := bucketHandler.Object(filename).NewWriter(ctx)
+	// Replay the sniffed head in front of the rest of the file
+	source := io.MultiReader(bytes.NewReader(head), file)
+	if replacement := inspection.replacement(); replacement != nil {
+		defer replacement.Close()
+		source = replacement
+	}
 
 	// SECURITY: Use the detected MIME type from file content, not the client-provided
 	// header. This ensures the Content-Type stored in GCS (and served to browsers)
 	// reflects the actual file content.
-	writer.ContentType = validationResult.DetectedMIME
+	bucketHandler := firebase.StorageClient.Bucket(firebase.StorageBucket)
+	bytesWritten, checksum, verdict, err := writeScanned(ctx, bucketHandler, filename, validationResult.DetectedMIME, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if verdict.Infected {
+		return nil, handleInfectedUpload(ctx, filename, product, fileType, header.Filename, checksum, verdict)
+	}
+
+	metadata := &models.Metadata{
+		Size:     bytesWritten,
+		MIMEType: validationResult.DetectedMIME,
+		SHA256:   checksum,
+	}
+
+	// Move the clean upload to its content address, deduplicating it
+	objectName, duplicate, err := storeContentAddressed(ctx, bucketHandler, filename, product, fileType, metadata.SHA256, validationResult.Extension, bytesWritten, metadata.MIMEType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate public URL
+	publicURL, err := generatePublicURL(objectName, firebase.StorageBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate public URL: %w", err)
+	}
+
+	extractMetadata(ctx, file, bytesWritten, fileType, inspection, metadata)
+
+	return &FileUploadResult{
+		PublicURL:   publicURL,
+		Filename:    objectName,
+		Size:        bytesWritten,
+		ContentType: validationResult.DetectedMIME,
+		SHA256:      metadata.SHA256,
+		Duplicate:   duplicate,
+		Metadata:    metadata,
+	}, nil
+}
+
+// checkUpload runs the checks of a file that precede storing it: its MIME
+// type, detected from magic bytes, must be one fileType accepts and the
+// policy allows, and its content must pass the inspection of the type. It
+// returns the sniffed head of the file, to replay in front of the rest of it,
+// with the validation result and the inspection. Rejections are reported
+// with constants.ErrFileValidationFailed.
+func checkUpload(file multipart.File, policy config.UploadPolicy, fileType string) ([]byte, *FileValidationResult, *Inspection, error) {
+	// SECURITY: Validate file content using magic bytes detection.
+	// This prevents attackers from uploading malicious files by spoofing the
+	// Content-Type header. The actual file bytes are inspected, not the header.
+	head, mtype, err := sniffContent(file)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: failed to detect file type: %v", constants.ErrFileValidationFailed, err)
+	}
+
+	validationResult := checkDetectedType(mtype, fileType)
+	if !validationResult.IsValid {
+		return nil, nil, nil, fmt.Errorf("%s: %s", constants.ErrFileValidationFailed, validationResult.Error)
+	}
+
+	// The product's policy may accept fewer types than the built-in rules
+	if !policy.AllowsMIMEType(fileType, validationResult.DetectedMIME) {
+		return nil, nil, nil, fmt.Errorf("%s: file type '%s' is not allowed for %s resources of this product", constants.ErrFileValidationFailed, validationResult.DetectedMIME, fileType)
+	}
+
+	// Type specific checks of the content, e.g. PDFs for active content
+	var inspection *Inspection
+	if resourceType, _ := uploadType(fileType); resourceType.Inspect != nil {
+		size, err := fileSize(file)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to determine file size: %w", err)
+		}
+
+		var rejection string
+		inspection, rejection = resourceType.Inspect(file, size, policy)
+		if rejection != "" {
+			return nil, nil, nil, fmt.Errorf("%s: %s", constants.ErrFileValidationFailed, rejection)
+		}
+	}
+
+	return head, validationResult, inspection, nil
+}
+
+// writeScanned streams source to a new object, through an uploadPipeline and
+// the configured malware scanner, and returns the size and SHA-256 of what
+// was written with the scanner's verdict. A failed stream never leaves a
+// partial object, and an object the scanner could not check is deleted.
+func writeScanned(ctx context.Context, bucket *storage.BucketHandle, objectName, contentType string, source io.Reader) (int64, string, *scanner.Result, error) {
+	// Cancelling this context before the writer is closed aborts the upload,
+	// so a failed stream never leaves a partial object in the bucket.
+	uploadCtx, cancelUpload := context.WithCancel(ctx)
+	defer cancelUpload()
+
+	writer := bucket.Object(objectName).NewWriter(uploadCtx)
+	writer.ContentType = contentType
 
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Content-Disposition
 	writer.ContentDisposition = "inline"
 
-	bytesWritten, err := io.Copy(writer, file)
+	// Tee everything through the pipeline and the malware scanner on its way
+	// to storage, so the file is scanned while it uploads
+	pipeline := newUploadPipeline()
+	scan := startMalwareScan(uploadCtx, scanner.Default)
+
+	bytesWritten, err := io.Copy(writer, io.TeeReader(source, io.MultiWriter(pipeline, scan)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload file: %w", err)
+		scan.abort(err)
+		cancelUpload()
+		writer.Close()
+		return 0, "", nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
 	// Close the writer to finalize the upload
 	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to finalize upload: %w", err)
+		scan.abort(err)
+		return 0, "", nil, fmt.Errorf("failed to finalize upload: %w", err)
 	}
 
-	// Generate public URL
-	publicURL, err := generatePublicURL(filename, firebase.StorageBucket)
+	verdict, err := scan.wait()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate public URL: %w", err)
+		// Fail closed: an unscanned file must not be served
+		if deleteErr := bucket.Object(objectName).Delete(context.WithoutCancel(ctx)); deleteErr != nil {
+			logger.Errorf("Failed to delete unscanned upload %s: %v", objectName, deleteErr)
+		}
+		if errors.Is(err, scanner.ErrTooLarge) {
+			return 0, "", nil, fmt.Errorf("%s: %w", constants.ErrScanSizeLimit, err)
+		}
+		return 0, "", nil, fmt.Errorf("malware scan failed: %w", err)
 	}
 
-	return &FileUploadResult{
-		PublicURL: publicURL,
-		Filename:  filename,
-		Size:      bytesWritten,
-	}, nil
+	return bytesWritten, pipeline.Checksum(), verdict, nil
 }
 
 // generateUniqueFilename creates a unique filename with proper sanitization.
@@ -184,6 +335,12 @@ func generatePublicURL(objectName, bucketName string) (string, error) {
 	return publicURL, nil
 }
 
+// StorageURL returns the public URL of an object of our bucket, the form
+// resources store
+func StorageURL(objectName string) (string, error) {
+	return generatePublicURL(objectName, firebase.StorageBucket)
+}
+
 // GenerateSignedURL generates a signed URL for a storage object
 // This allows temporary authenticated access to private objects
 func GenerateSignedURL(ctx context.Context, storageURL string, expirationMinutes int) (string, error) {
@@ -223,6 +380,16 @@ func GenerateSignedURL(ctx context.Context, storageURL string, expirationMinutes
 	return signedURL, nil
 }
 
+// GenerateSignedObjectURL generates a signed URL for an object of our storage bucket
+func GenerateSignedObjectURL(ctx context.Context, objectName string, expirationMinutes int) (string, error) {
+	storageURL, err := generatePublicURL(objectName, firebase.StorageBucket)
+	if err != nil {
+		return "", err
+	}
+
+	return GenerateSignedURL(ctx, storageURL, expirationMinutes)
+}
+
 // ConvertResourceURLsToSigned converts a Resource's URLs to signed URLs if needed.
 // This is a helper to transform URLs before sending to frontend.
 func ConvertResourceURLsToSigned(ctx context.Context, url, thumbnailURL string, expirationMinutes int) (signedURL, signedThumbnailURL string, err error) {
@@ -243,8 +410,30 @@ func ConvertResourceURLsToSigned(ctx context.Context, url, thumbnailURL string,
 	return signedURL, signedThumbnailURL, nil
 }
 
-// DeleteFileFromURL deletes a file from Cloud Storage given its public URL
-func DeleteFileFromURL(ctx context.Context, fileURL string) error {
+// SignThumbnailVariants returns a copy of a Resource's thumbnail variants with
+// signed URLs. Variants that fail to sign keep their original URL.
+func SignThumbnailVariants(ctx context.Context, variants map[string]string, expirationMinutes int) map[string]string {
+	if len(variants) == 0 {
+		return variants
+	}
+
+	signed := make(map[string]string, len(variants))
+	for key, variantURL := range variants {
+		signedURL, err := GenerateSignedURL(ctx, variantURL, expirationMinutes)
+		if err != nil {
+			logger.Infof("Failed to generate signed URL for thumbnail variant %s: %v", key, err)
+			signedURL = variantURL
+		}
+		signed[key] = signedURL
+	}
+
+	return signed
+}
+
+// DeleteFileFromURL deletes a file from Cloud Storage given its public URL.
+// A content-addressed upload is only deleted once no resource references it,
+// and its reference is released once per non-empty releaseID.
+func DeleteFileFromURL(ctx context.Context, fileURL, releaseID string) error {
 	// Delete file if it is stored in our bucket
 	if IsValidStorageURL(fileURL) {
 		bucketName, objectName, err := parseStorageURL(fileURL)
@@ -255,6 +444,14 @@ func DeleteFileFromURL(ctx context.Context, fileURL string) error {
 		// Get the bucket handle
 		bucketHandler := firebase.StorageClient.Bucket(bucketName)
 
+		// Content-addressed uploads may back other resources
+		if product, sha256, variant, ok := ParseContentObject(objectName); ok {
+			if variant {
+				return nil // Deleted with the upload it derives from
+			}
+			return releaseContentObject(ctx, bucketHandler, product, sha256, releaseID)
+		}
+
 		// Get the object handle
 		objHandler := bucketHandler.Object(objectName)
 
@@ -268,6 +465,34 @@ func DeleteFileFromURL(ctx context.Context, fileURL string) error {
 	return nil
 }
 
+// DeleteObjects deletes stored files by URL and every object under prefixes.
+// Files that are already gone are skipped. References to content-addressed
+// uploads are released under releaseID and the position of their URL, so a
+// partial deletion can be retried with the same releaseID and URLs; without
+// a releaseID, a retry would release them again.
+func DeleteObjects(ctx context.Context, releaseID string, fileURLs, prefixes []string) error {
+	var errs []error
+
+	for i, fileURL := range fileURLs {
+		// The same URL is listed once per reference to release
+		fileReleaseID := ""
+		if releaseID != "" {
+			fileReleaseID = fmt.Sprintf("%s/%d", releaseID, i)
+		}
+		if err := DeleteFileFromURL(ctx, fileURL, fileReleaseID); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, prefix := range prefixes {
+		if err := DeleteFolder(ctx, prefix); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func parseStorageURL(fileURL string) (bucketName, objectName string, err error) {
 	parsedURL, err := url.Parse(fileURL)
 	if err != nil {
@@ -302,14 +527,10 @@ func parseStorageURL(fileURL string) (bucketName, objectName string, err error)
 
 // Validations
 
-// IsValidResourceType check if resource type is valid
+// IsValidResourceType check if resource type is valid, see RegisterResourceType
 func IsValidResourceType(t string) bool {
-	for _, v := range constants.ResourceTypes {
-		if t == v {
-			return true
-		}
-	}
-	return false
+	_, exists := LookupResourceType(t)
+	return exists
 }
 
 // IsValidStorageURL checks if url points to resource stored in storage
@@ -317,14 +538,9 @@ func IsValidStorageURL(fileURL string) bool {
 	return strings.Contains(fileURL, firebase.StorageBucket)
 }
 
-// IsValidProduct checks if product name is valid
+// IsValidProduct checks if product name is an active product of the registry
 func IsValidProduct(product string) bool {
-	for _, v := range config.AppConfig.VALID_PRODUCTS {
-		if product == v {
-			return true
-		}
-	}
-	return false
+	return products.IsActive(product)
 }
 
 // File content validation
@@ -335,6 +551,9 @@ type FileValidationResult struct {
 	DetectedMIME string
 	Extension    string
 	Error        string
+
+	// PDF holds the structural inspection of a valid PDF, nil for other types
+	PDF *PDFInspection
 }
 
 // validationError creates a failed FileValidationResult with the given error message
@@ -370,153 +589,79 @@ var blockedMIMETypes = []string{
 	"application/x-executable", // Linux ELF executables
 }
 
-// allowedVideoMIMEs is an explicit allowlist of video MIME types the application
-// accepts. Using an allowlist (rather than strings.HasPrefix("video/")) ensures
-// that unusual or dangerous "video/*" sub-types are rejected by default.
-var allowedVideoMIMEs = map[string]bool{
-	"video/mp4":        true,
-	"video/webm":       true,
-	"video/x-matroska": true, // WebM is a subset of Matroska; some detectors report this MIME
-}
-
-// pdfSuspiciousPatterns is a comprehensive list of PDF dictionary keys and
-// JavaScript patterns associated with active content, auto-actions, and known
-// PDF exploit techniques.
-//
-// Categories:
-//   - JavaScript execution: /JavaScript, /JS
-//   - Automatic trigger actions: /AA (Additional Actions), /OpenAction
-//   - External interaction: /Launch, /SubmitForm, /ImportData, /URI, /GoToR, /GoToE
-//   - Rich media / multimedia: /RichMedia, /Sound, /Movie, /Rendition, /3D, /GoTo3DView
-//   - Embedded content: /EmbeddedFile, /FileAttachment
-//   - XFA forms (can execute JS): /XFA
-//   - Obfuscation / hiding: /Hide, /SetOCGState
-//   - Navigation abuse: /Named
-//   - Common JS API calls found in PDF exploits: app.alert, this.exportDataObject,
-//     util.printf, app.launchURL, this.submitForm, getAnnots, getField,
-//     app.setTimeOut (used for delayed execution), event.value
-//   - Malformed stream markers used in heap-spray exploits: %eval, %execute
-var pdfSuspiciousPatterns = [][]byte{
-	// ---- JavaScript execution ----
-	[]byte("/JavaScript"),
-	[]byte("/JS"),
-
-	// ---- Automatic trigger actions ----
-	[]byte("/AA"),         // Additional Actions — runs JS on field/page events
-	[]byte("/OpenAction"), // Runs an action when the PDF is opened
-
-	// ---- External interaction ----
-	[]byte("/Launch"),     // Launches an external application
-	[]byte("/SubmitForm"), // Submits form data to a remote URL
-	[]byte("/ImportData"), // Imports FDF data from an external source
-	[]byte("/URI"),        // URI action — can silently load external URLs
-	[]byte("/GoToR"),      // GoTo remote — opens another PDF or file
-	[]byte("/GoToE"),      // GoTo embedded — navigates into an embedded file
-
-	// ---- Rich media / multimedia ----
-	[]byte("/RichMedia"),  // Embeds Flash/video — historic attack surface
-	[]byte("/Sound"),      // Sound action
-	[]byte("/Movie"),      // Movie action
-	[]byte("/Rendition"),  // Media rendition action
-	[]byte("/3D"),         // 3D annotation (U3D/PRC — has a JS API)
-	[]byte("/GoTo3DView"), // Navigates to a named 3D view
-
-	// ---- Embedded content ----
-	[]byte("/EmbeddedFile"),   // Attached/embedded files
-	[]byte("/FileAttachment"), // File attachment annotation
-
-	// ---- XFA forms ----
-	[]byte("/XFA"), // XML Forms Architecture — executes JS during render
-
-	// ---- Obfuscation / hiding ----
-	[]byte("/Hide"),        // Hides/shows annotations — used in social engineering
-	[]byte("/SetOCGState"), // Controls Optional Content Group visibility
-
-	// ---- Navigation abuse ----
-	[]byte("/Named"), // Named action (e.g. NextPage) — can be chained maliciously
-
-	// ---- Common JS API calls seen in PDF exploits ----
-	[]byte("app.alert"),             // Classic PoC and phishing lure
-	[]byte("app.launchURL"),         // Opens external URL silently
-	[]byte("app.setTimeOut"),        // Delayed execution — evasion technique
-	[]byte("this.exportDataObject"), // Data exfiltration via embedded files
-	[]byte("this.submitForm"),       // Exfiltrates data to attacker server
-	[]byte("util.printf"),           // Used in format-string exploits (CVE-2008-2992)
-	[]byte("getAnnots"),             // Annotation enumeration — recon step
-	[]byte("getField"),              // Form field access — used in JS-based attacks
-	[]byte("event.value"),           // Field event handler — common JS entry point
-
-	// ---- Heap-spray / exploit markers ----
-	[]byte("%eval"),    // Obfuscated eval patterns in malformed PDFs
-	[]byte("%execute"), // Obfuscated execute patterns in malformed PDFs
-}
-
-// scanPDFForSuspiciousContent reads the entire PDF into memory and checks for
-// known dangerous patterns (embedded JavaScript, auto-actions, exploit markers).
-//
-// The file seek position is reset to the start after scanning so the caller can
-// still read the file normally (e.g. for upload).
+// ValidateFileContent validates file content using magic bytes detection.
+// It uses the gabriel-vasile/mimetype package for accurate MIME type detection
+// based on file signatures (magic numbers), then applies the checks of the
+// resource type (see ResourceType.Inspect, e.g. the structural inspection of
+// PDFs, see inspectPDF, and of archives, see inspectArchive).
 //
-// Note: This is a heuristic scan — it catches the overwhelming majority of
-// real-world malicious PDFs. A determined attacker can obfuscate content beyond
-// what byte-scanning detects; for higher-assurance environments consider a
-// sandboxed PDF renderer.
+// The file is never read into memory as a whole. Its seek position is always
+// reset to the beginning before returning so the caller can subsequently read
+// the full file again. UploadFile performs the same checks inline while
+// uploading, so this is only needed when validating without storing.
 //
 // Parameters:
-//   - file: multipart.File — the uploaded file, already validated as PDF by magic bytes
+//   - file:         multipart.File — the uploaded file to validate
+//   - expectedType: string         — the expected resource type, a file resource type or image
 //
 // Returns:
-//   - bool   — true if a suspicious pattern was found
-//   - []byte — the matched pattern (for logging/debugging)
-//   - error  — non-nil if reading or seeking failed
-func scanPDFForSuspiciousContent(file multipart.File) (bool, []byte, error) {
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return false, nil, fmt.Errorf("failed to read PDF for scanning: %w", err)
-	}
+//   - *FileValidationResult — contains IsValid, DetectedMIME, Extension, PDF and Error
+func ValidateFileContent(file multipart.File, expectedType string) *FileValidationResult {
+	result := validateFile(file, expectedType)
 
-	// Reset so the caller (UploadFile) can still read the complete file
+	// Reset file position to the beginning for all subsequent reads
 	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return false, nil, fmt.Errorf("failed to reset file position after PDF scan: %w", err)
+		return validationError(result.DetectedMIME, fmt.Sprintf("failed to reset file position: %v", err))
 	}
 
-	for _, pattern := range pdfSuspiciousPatterns {
-		if bytes.Contains(content, pattern) {
-			return true, pattern, nil
-		}
-	}
-
-	return false, nil, nil
+	return result
 }
 
-// ValidateFileContent validates file content using magic bytes detection.
-// It uses the gabriel-vasile/mimetype package for accurate MIME type detection
-// based on file signatures (magic numbers), then applies additional type-specific
-// checks (e.g. embedded JS scanning for PDFs).
-//
-// The file seek position is always reset to the beginning before returning so
-// the caller can subsequently read the full file for upload.
-//
-// Parameters:
-//   - file:         multipart.File — the uploaded file to validate
-//   - expectedType: string         — the expected resource type (video, pdf, image)
-//
-// Returns:
-//   - *FileValidationResult — contains IsValid, DetectedMIME, Extension, and Error
-func ValidateFileContent(file multipart.File, expectedType string) *FileValidationResult {
+// validateFile runs every content check of ValidateFileContent
+func validateFile(file multipart.File, expectedType string) *FileValidationResult {
 	// --- Step 1: Magic bytes detection ---
-	// mimetype.DetectReader reads file signatures (magic numbers) to determine
-	// the true MIME type, completely ignoring the client-supplied Content-Type.
-	mtype, err := mimetype.DetectReader(file)
+	// Only the head of the file is needed to determine the true MIME type,
+	// completely ignoring the client-supplied Content-Type.
+	_, mtype, err := sniffContent(file)
 	if err != nil {
 		return validationError("", fmt.Sprintf("failed to detect file type: %v", err))
 	}
 
-	// Reset file position to the beginning for all subsequent reads
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return validationError("", fmt.Sprintf("failed to reset file position: %v", err))
+	result := checkDetectedType(mtype, expectedType)
+	if !result.IsValid {
+		return result
+	}
+
+	resourceType, _ := uploadType(expectedType)
+	if resourceType.Inspect == nil {
+		return result
+	}
+
+	// --- Step 4: Inspect the content of the file ---
+	// Magic bytes only confirm what the file IS. They say nothing about what
+	// is inside it: PDFs can contain JavaScript, automatic open-actions and
+	// other active content that can be weaponised for RCE or data
+	// exfiltration, archives can escape the folder they are extracted to.
+	size, err := fileSize(file)
+	if err != nil {
+		return validationError(result.DetectedMIME, fmt.Sprintf("failed to determine file size: %v", err))
+	}
+
+	inspection, rejection := resourceType.Inspect(file, size, config.UploadPolicy{})
+	if rejection != "" {
+		return validationError(result.DetectedMIME, rejection)
+	}
+	if inspection != nil {
+		result.PDF = inspection.PDF
 	}
 
+	return result
+}
+
+// checkDetectedType applies the MIME blocklist and the allowlist of the
+// resource type to a MIME type detected from magic bytes (steps 2 and 3 of
+// content validation).
+func checkDetectedType(mtype *mimetype.MIME, expectedType string) *FileValidationResult {
 	detectedMIME := mtype.String()
 
 	// --- Step 2: Block explicitly dangerous MIME types ---
@@ -529,57 +674,13 @@ func ValidateFileContent(file multipart.File, expectedType string) *FileValidati
 	}
 
 	// --- Step 3: Type-specific validation ---
-	var isValid bool
-	switch expectedType {
-
-	case constants.ResourceTypeVideo:
-		// Use an explicit allowlist — "video/*" is too broad and would accept
-		// obscure or potentially dangerous video sub-types.
-		isValid = allowedVideoMIMEs[detectedMIME]
-		if !isValid {
-			return validationError(detectedMIME, fmt.Sprintf(
-				"video type '%s' is not supported; accepted types: mp4, webm", detectedMIME,
-			))
-		}
-
-	case constants.ResourceTypePDF:
-		// First confirm magic bytes identify this as a real PDF
-		isValid = mtype.Is("application/pdf")
-		if !isValid {
-			return validationError(detectedMIME, fmt.Sprintf(
-				"file content type '%s' does not match expected type '%s'", detectedMIME, expectedType,
-			))
-		}
-
-		// --- Step 4 (PDF only): Scan for embedded JavaScript and exploit patterns ---
-		// Magic bytes only confirm the file IS a PDF. They say nothing about what
-		// is inside it. PDFs can contain JavaScript (/JS, /JavaScript), automatic
-		// open-actions (/OpenAction), XFA forms, and many other active-content
-		// features that can be weaponised for RCE or data exfiltration.
-		found, matchedPattern, err := scanPDFForSuspiciousContent(file)
-		if err != nil {
-			return validationError(detectedMIME, fmt.Sprintf("PDF content scan failed: %v", err))
-		}
-		if found {
-			// Log the matched pattern (helpful for incident response) but do not
-			// expose the raw pattern string to the end user.
-			logger.Infof("PDF upload rejected: suspicious pattern detected: %q", matchedPattern)
-			return validationError(detectedMIME, "PDF contains potentially malicious embedded content and cannot be uploaded")
-		}
-
-	case constants.ResourceTypeImage:
-		// Accept any image/* type but exclude SVG (already in blockedMIMETypes;
-		// this is a second, explicit guard).
-		isValid = strings.HasPrefix(detectedMIME, "image/") && !mtype.Is("image/svg+xml")
-		if !isValid {
-			return validationError(detectedMIME, fmt.Sprintf(
-				"file type '%s' is not a supported image format", detectedMIME,
-			))
-		}
-
-	default:
+	resourceType, exists := uploadType(expectedType)
+	if !exists {
 		return validationError(detectedMIME, fmt.Sprintf("unknown resource type: %s", expectedType))
 	}
+	if !resourceType.acceptsMIME(mtype) {
+		return validationError(detectedMIME, fmt.Sprintf(resourceType.MIMEError, detectedMIME))
+	}
 
 	return validationSuccess(detectedMIME, mtype.Extension())
 }
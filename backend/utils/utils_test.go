@@ -1,8 +1,24 @@
 package utils
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -10,11 +26,17 @@ import (
 
 	"reflect"
 
+	"cloud.google.com/go/storage"
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
 
 	"learninghub/config"
 	"learninghub/constants"
 	"learninghub/firebase"
+	"learninghub/models"
+	"learninghub/scanner"
+	"learninghub/validation"
 )
 
 // mockFile implements multipart.File interface for testing
@@ -679,3 +701,1085 @@ func TestValidateFileContent(t *testing.T) {
 		assert.NotEmpty(t, buf, "Should be able to read from file start after validation")
 	})
 }
+
+func TestUploadPipeline(t *testing.T) {
+	pipeline := newUploadPipeline()
+
+	_, err := io.Copy(io.Discard, io.TeeReader(strings.NewReader("hello world"), pipeline))
+	assert.NoError(t, err)
+	// sha256("hello world")
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", pipeline.Checksum())
+}
+
+// stubScanner reads a fixed number of bytes, then returns its verdict
+type stubScanner struct {
+	readLimit int64
+	result    *scanner.Result
+	err       error
+	scanned   []byte
+}
+
+func (s *stubScanner) Name() string { return "stub" }
+
+func (s *stubScanner) Scan(ctx context.Context, r io.Reader) (*scanner.Result, error) {
+	s.scanned, _ = io.ReadAll(io.LimitReader(r, s.readLimit))
+	return s.result, s.err
+}
+
+func TestMalwareScan(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	tests := []struct {
+		name    string
+		scanner *stubScanner
+	}{
+		{
+			name:    "scanner reads everything",
+			scanner: &stubScanner{readLimit: int64(len(content)) + 1, result: &scanner.Result{}},
+		},
+		{
+			name:    "scanner stops early with a verdict",
+			scanner: &stubScanner{readLimit: 10, result: &scanner.Result{Infected: true, Signature: "Test.Signature"}},
+		},
+		{
+			name:    "scanner fails",
+			scanner: &stubScanner{readLimit: 0, err: io.ErrUnexpectedEOF},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := startMalwareScan(context.Background(), tt.scanner)
+
+			// The upload itself is never disturbed by the scan
+			var uploaded bytes.Buffer
+			_, err := io.Copy(&uploaded, io.TeeReader(bytes.NewReader(content), scan))
+			assert.NoError(t, err)
+			assert.Equal(t, content, uploaded.Bytes())
+
+			result, err := scan.wait()
+			assert.Equal(t, tt.scanner.err, err)
+			assert.Equal(t, tt.scanner.result, result)
+			assert.Equal(t, content[:min(len(content), int(tt.scanner.readLimit))], tt.scanner.scanned)
+		})
+	}
+}
+
+// testPDF builds a minimal single-page PDF whose catalog carries extra entries
+func testPDF(catalogExtra string) []byte {
+	return []byte("%PDF-1.4\n" +
+		"1 0 obj << /Type /Catalog /Pages 2 0 R " + catalogExtra + " >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R /Annots [<< /Subtype /Link /A << /S /URI /URI (https://example.com) >> >>] >> endobj\n" +
+		"trailer << /Root 1 0 R >>\n%%EOF\n")
+}
+
+func TestValidateFileContentPDFInspection(t *testing.T) {
+	originalMode := config.AppConfig.PDF_ACTIVE_CONTENT_MODE
+	defer func() {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = originalMode
+	}()
+
+	withJavaScript := testPDF("/OpenAction << /S /JavaScript /JS (app.alert(1)) >>")
+
+	t.Run("links are allowed", func(t *testing.T) {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = constants.PDFActiveContentReject
+
+		result := ValidateFileContent(newMockFile(testPDF("")), constants.ResourceTypePDF)
+		assert.True(t, result.IsValid, result.Error)
+		assert.False(t, result.PDF.Sanitize)
+		assert.True(t, result.PDF.Report.Has("uri"))
+	})
+
+	t.Run("active content rejected in reject mode", func(t *testing.T) {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = constants.PDFActiveContentReject
+		file := newMockFile(withJavaScript)
+
+		result := ValidateFileContent(file, constants.ResourceTypePDF)
+		assert.False(t, result.IsValid)
+		assert.Equal(t, "application/pdf", result.DetectedMIME)
+		assert.Equal(t, pdfRejectedMessage, result.Error)
+
+		// Position must still be reset after a rejected inspection
+		pos, err := file.Seek(0, io.SeekCurrent)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), pos)
+	})
+
+	t.Run("active content flagged for sanitizing in sanitize mode", func(t *testing.T) {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = constants.PDFActiveContentSanitize
+
+		result := ValidateFileContent(newMockFile(withJavaScript), constants.ResourceTypePDF)
+		assert.True(t, result.IsValid, result.Error)
+		assert.True(t, result.PDF.Sanitize)
+
+		sanitized, err := io.ReadAll(result.PDF.sanitizedReader())
+		assert.NoError(t, err)
+		assert.NotContains(t, string(sanitized), "OpenAction")
+		assert.True(t, bytes.HasPrefix(sanitized, []byte("%PDF-1.4")))
+	})
+
+	t.Run("unparseable PDF rejected", func(t *testing.T) {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = constants.PDFActiveContentSanitize
+
+		result := ValidateFileContent(newMockFile([]byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")), constants.ResourceTypePDF)
+		assert.False(t, result.IsValid)
+		assert.Equal(t, pdfUninspectableMessage, result.Error)
+	})
+
+	t.Run("malicious sample file rejected", func(t *testing.T) {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = constants.PDFActiveContentReject
+
+		file, err := os.Open("../httpClientTest/pdfs/malicious_test.pdf")
+		if err != nil {
+			t.Skipf("Test file not found: %v", err)
+		}
+		defer file.Close()
+
+		result := ValidateFileContent(file, constants.ResourceTypePDF)
+		assert.False(t, result.IsValid)
+		assert.Equal(t, pdfRejectedMessage, result.Error)
+	})
+}
+
+func TestPDFActiveContentMode(t *testing.T) {
+	originalMode := config.AppConfig.PDF_ACTIVE_CONTENT_MODE
+	defer func() {
+		config.AppConfig.PDF_ACTIVE_CONTENT_MODE = originalMode
+	}()
+
+	tests := []struct {
+		name       string
+		globalMode string
+		policyMode string
+		expected   string
+	}{
+		{name: "global mode", globalMode: constants.PDFActiveContentSanitize, expected: constants.PDFActiveContentSanitize},
+		{name: "policy overrides global sanitize", globalMode: constants.PDFActiveContentSanitize, policyMode: constants.PDFActiveContentReject, expected: constants.PDFActiveContentReject},
+		{name: "policy overrides global reject", globalMode: constants.PDFActiveContentReject, policyMode: constants.PDFActiveContentSanitize, expected: constants.PDFActiveContentSanitize},
+		{name: "unknown mode rejects", globalMode: "strip", expected: constants.PDFActiveContentReject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig.PDF_ACTIVE_CONTENT_MODE = tt.globalMode
+			assert.Equal(t, tt.expected, pdfActiveContentMode(tt.policyMode))
+		})
+	}
+}
+
+// pngOf encodes a blank image of the given size
+func pngOf(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestResizeToWidth(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{name: "landscape is scaled down", width: 1920, height: 1080, wantW: constants.ThumbnailMaxWidth, wantH: 360},
+		{name: "portrait is scaled down", width: 1000, height: 2000, wantW: constants.ThumbnailMaxWidth, wantH: 1280},
+		{name: "small image is kept", width: 320, height: 240, wantW: 320, wantH: 240},
+		{name: "height never collapses to zero", width: 10000, height: 1, wantW: constants.ThumbnailMaxWidth, wantH: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resized := resizeToWidth(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), constants.ThumbnailMaxWidth)
+			assert.Equal(t, tt.wantW, resized.Bounds().Dx())
+			assert.Equal(t, tt.wantH, resized.Bounds().Dy())
+		})
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	img, err := decodeImage(bytes.NewReader(pngOf(t, 40, 30)))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 30), img.Bounds())
+
+	// A PNG header declaring 100000x100000 pixels is refused before decoding
+	bomb := pngOf(t, 1, 1)
+	binary.BigEndian.PutUint32(bomb[16:], 100000)
+	binary.BigEndian.PutUint32(bomb[20:], 100000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+	_, err = decodeImage(bytes.NewReader(bomb))
+	assert.ErrorContains(t, err, "too large")
+
+	_, err = decodeImage(bytes.NewReader([]byte("not an image")))
+	assert.Error(t, err)
+}
+
+func TestLocalCopy(t *testing.T) {
+	content := []byte("video content")
+
+	t.Run("in-memory file is copied", func(t *testing.T) {
+		file := newMockFile(content)
+		file.Seek(5, io.SeekStart)
+
+		path, cleanup, err := localCopy(file)
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, content, data, "the whole file is copied, whatever its position")
+
+		cleanup()
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("file on disk is used in place", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "upload")
+		assert.NoError(t, os.WriteFile(path, content, 0o600))
+		file, err := os.Open(path)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		copyPath, cleanup, err := localCopy(file)
+		assert.NoError(t, err)
+		cleanup()
+
+		assert.Equal(t, path, copyPath)
+		_, err = os.Stat(path)
+		assert.NoError(t, err, "cleanup leaves the upload alone")
+	})
+}
+
+func TestExtractVideoFrame(t *testing.T) {
+	originalPath := config.AppConfig.FFMPEG_PATH
+	defer func() {
+		config.AppConfig.FFMPEG_PATH = originalPath
+	}()
+
+	frame := filepath.Join(t.TempDir(), "frame.png")
+	assert.NoError(t, os.WriteFile(frame, pngOf(t, 1280, 720), 0o600))
+
+	// Stand-in for ffmpeg: no frame at 1s (a short video), the first frame at 0s
+	ffmpeg := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\ncase \"$*\" in *\"-ss 0 \"*) cat " + frame + " ;; esac\n"
+	assert.NoError(t, os.WriteFile(ffmpeg, []byte(script), 0o755))
+	config.AppConfig.FFMPEG_PATH = ffmpeg
+
+	img, err := extractVideoFrame(context.Background(), newMockFile([]byte("video")))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 1280, 720), img.Bounds())
+
+	config.AppConfig.FFMPEG_PATH = filepath.Join(t.TempDir(), "missing-ffmpeg")
+	_, err = extractVideoFrame(context.Background(), newMockFile([]byte("video")))
+	assert.ErrorContains(t, err, "missing-ffmpeg failed")
+}
+
+func TestSnapVariantWidth(t *testing.T) {
+	tests := []struct {
+		width int
+		want  int
+	}{
+		{width: 0, want: 640},
+		{width: 1, want: 160},
+		{width: 160, want: 160},
+		{width: 161, want: 320},
+		{width: 500, want: 640},
+		{width: 4000, want: 640},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.width), func(t *testing.T) {
+			assert.Equal(t, tt.want, SnapVariantWidth(tt.width))
+		})
+	}
+}
+
+func TestVariantObjectName(t *testing.T) {
+	assert.Equal(t, "ecomm/image/1_cover_320w.webp", variantObjectName("ecomm/image/1_cover.png", 320, constants.ImageFormatWebP))
+	assert.Equal(t, "ecomm/image/1_cover_160w.jpg", variantObjectName("ecomm/image/1_cover.png", 160, constants.ImageFormatJPEG))
+	assert.Equal(t, "ecomm/image/1_cover_640w.avif", variantObjectName("ecomm/image/1_cover", 640, constants.ImageFormatAVIF))
+	assert.Equal(t, "320.webp", VariantKey(320, constants.ImageFormatWebP))
+}
+
+func TestEncodeImageVariant(t *testing.T) {
+	originalPath := config.AppConfig.FFMPEG_PATH
+	defer func() {
+		config.AppConfig.FFMPEG_PATH = originalPath
+	}()
+
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+
+	encoded, err := encodeImageVariant(context.Background(), img, constants.ImageFormatJPEG)
+	assert.NoError(t, err)
+	decoded, format, err := image.Decode(bytes.NewReader(encoded))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	// Stand-in for ffmpeg that records its arguments and echoes its input
+	dir := t.TempDir()
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\necho \"$*\" > " + filepath.Join(dir, "args") + "\ncat\n"
+	assert.NoError(t, os.WriteFile(ffmpeg, []byte(script), 0o755))
+	config.AppConfig.FFMPEG_PATH = ffmpeg
+
+	encoded, err = encodeImageVariant(context.Background(), img, constants.ImageFormatWebP)
+	assert.NoError(t, err)
+	_, format, err = image.Decode(bytes.NewReader(encoded))
+	assert.NoError(t, err)
+	assert.Equal(t, "png", format, "ffmpeg is fed a lossless PNG")
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(args), "-c:v libwebp")
+
+	_, err = encodeImageVariant(context.Background(), img, "bmp")
+	assert.Error(t, err)
+}
+
+func TestMediaToken(t *testing.T) {
+	originalSecret := config.AppConfig.MEDIA_TOKEN_SECRET
+	defer func() {
+		config.AppConfig.MEDIA_TOKEN_SECRET = originalSecret
+	}()
+	config.AppConfig.MEDIA_TOKEN_SECRET = "test-secret"
+
+	token := SignMediaToken("ecomm", "abc", time.Now().Add(time.Hour))
+
+	assert.NoError(t, VerifyMediaToken(token, "ecomm", "abc"))
+	assert.Error(t, VerifyMediaToken(token, "ecomm", "other"), "token is bound to the resource")
+	assert.Error(t, VerifyMediaToken(token, "other", "abc"), "token is bound to the product")
+	assert.Error(t, VerifyMediaToken("", "ecomm", "abc"))
+	assert.Error(t, VerifyMediaToken("garbage", "ecomm", "abc"))
+
+	// Extending the expiry invalidates the signature
+	_, signature, _ := strings.Cut(token, ".")
+	forged := strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10) + "." + signature
+	assert.ErrorContains(t, VerifyMediaToken(forged, "ecomm", "abc"), "signature")
+
+	expired := SignMediaToken("ecomm", "abc", time.Now().Add(-time.Minute))
+	assert.ErrorContains(t, VerifyMediaToken(expired, "ecomm", "abc"), "expired")
+
+	config.AppConfig.MEDIA_TOKEN_SECRET = "rotated-secret"
+	assert.Error(t, VerifyMediaToken(token, "ecomm", "abc"), "rotating the secret revokes tokens")
+}
+
+func TestRewritePlaylist(t *testing.T) {
+	playlist := "#EXTM3U\n" +
+		"#EXT-X-VERSION:7\n" +
+		"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+		"#EXTINF:6.0,\n" +
+		"segment_0000.ts\n" +
+		"\n" +
+		"#EXTINF:4.2,\n" +
+		"segment_0001.ts\n" +
+		"#EXT-X-ENDLIST\n"
+
+	rewritten, err := RewritePlaylist([]byte(playlist), func(uri string) (string, error) {
+		return "https://cdn.example/" + uri + "?sig=1", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-MAP:URI=\"https://cdn.example/init.mp4?sig=1\"\n"+
+		"#EXTINF:6.0,\n"+
+		"https://cdn.example/segment_0000.ts?sig=1\n"+
+		"\n"+
+		"#EXTINF:4.2,\n"+
+		"https://cdn.example/segment_0001.ts?sig=1\n"+
+		"#EXT-X-ENDLIST\n", string(rewritten))
+
+	_, err = RewritePlaylist([]byte(playlist), func(uri string) (string, error) {
+		return "", io.ErrUnexpectedEOF
+	})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestSelectRenditions(t *testing.T) {
+	names := func(renditions []hlsRendition) []string {
+		var result []string
+		for _, rendition := range renditions {
+			result = append(result, rendition.Name)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"360p", "720p", "1080p"}, names(selectRenditions(2160)))
+	assert.Equal(t, []string{"360p", "720p", "1080p"}, names(selectRenditions(1080)))
+	assert.Equal(t, []string{"360p", "720p"}, names(selectRenditions(1079)))
+
+	small := selectRenditions(241)
+	assert.Len(t, small, 1, "small videos are not upscaled")
+	assert.Equal(t, 240, small[0].Height, "height is rounded down to an even number")
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	playlist := string(masterPlaylist(selectRenditions(720), 1280, 720))
+
+	assert.Equal(t, "#EXTM3U\n#EXT-X-VERSION:3\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=985600,RESOLUTION=640x360\n"+
+		"360p/index.m3u8\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=3220800,RESOLUTION=1280x720\n"+
+		"720p/index.m3u8\n", playlist)
+}
+
+func TestTranscodeToDir(t *testing.T) {
+	originalPath := config.AppConfig.FFMPEG_PATH
+	defer func() {
+		config.AppConfig.FFMPEG_PATH = originalPath
+	}()
+
+	dir := t.TempDir()
+	frame := filepath.Join(dir, "frame.png")
+	assert.NoError(t, os.WriteFile(frame, pngOf(t, 1280, 720), 0o600))
+
+	// Stand-in for ffmpeg: prints a 720p frame for frame grabs, and writes a
+	// playlist with one segment to its last argument for HLS encodes
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\n" +
+		"case \"$*\" in *image2pipe*) cat " + frame + "; exit 0 ;; esac\n" +
+		"for last; do :; done\n" +
+		"printf '#EXTM3U\\n#EXTINF:6.0,\\nsegment_0000.ts\\n' > \"$last\"\n" +
+		"touch \"$(dirname \"$last\")/segment_0000.ts\"\n"
+	assert.NoError(t, os.WriteFile(ffmpeg, []byte(script), 0o755))
+	config.AppConfig.FFMPEG_PATH = ffmpeg
+
+	output := filepath.Join(dir, "hls")
+	assert.NoError(t, transcodeToDir(context.Background(), filepath.Join(dir, "source"), output))
+
+	for _, name := range []string{"master.m3u8", "360p/index.m3u8", "360p/segment_0000.ts", "720p/index.m3u8", "720p/segment_0000.ts"} {
+		_, err := os.Stat(filepath.Join(output, name))
+		assert.NoError(t, err, name)
+	}
+	_, err := os.Stat(filepath.Join(output, "1080p"))
+	assert.True(t, os.IsNotExist(err), "720p sources are not upscaled to 1080p")
+
+	assert.Equal(t, constants.HLSContentType, hlsContentType("master.m3u8"))
+	assert.Equal(t, "video/mp2t", hlsContentType("360p/segment_0000.ts"))
+}
+
+func TestUploadedFilename(t *testing.T) {
+	tests := []struct {
+		objectName string
+		want       string
+	}{
+		{objectName: "ecomm/pdf/1759303167962121049_intro_guide.pdf", want: "intro_guide.pdf"},
+		{objectName: "ecomm/video/demo.mp4", want: "demo.mp4"},
+		{objectName: "ecomm/video/v2_demo.mp4", want: "v2_demo.mp4"},
+		{objectName: "ecomm/video/1759303167962121049_", want: "1759303167962121049_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.objectName, func(t *testing.T) {
+			assert.Equal(t, tt.want, uploadedFilename(tt.objectName))
+		})
+	}
+}
+
+func TestExtractMetadataVideo(t *testing.T) {
+	originalPath := config.AppConfig.FFPROBE_PATH
+	defer func() {
+		config.AppConfig.FFPROBE_PATH = originalPath
+	}()
+
+	tests := []struct {
+		name  string
+		probe string
+		want  models.Metadata
+	}{
+		{
+			name: "landscape video with cover art",
+			probe: `{"format": {"duration": "734.1204"}, "streams": [
+				{"codec_type": "video", "codec_name": "mjpeg", "width": 600, "height": 600, "disposition": {"attached_pic": 1}},
+				{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080},
+				{"codec_type": "audio", "codec_name": "aac"}]}`,
+			want: models.Metadata{Size: 5, Duration: 734.12, Width: 1920, Height: 1080, VideoCodec: "h264", AudioCodec: "aac"},
+		},
+		{
+			name: "rotated phone video",
+			probe: `{"format": {"duration": "12.5"}, "streams": [
+				{"codec_type": "video", "codec_name": "hevc", "width": 1920, "height": 1080, "side_data_list": [{"rotation": -90}]}]}`,
+			want: models.Metadata{Size: 5, Duration: 12.5, Width: 1080, Height: 1920, VideoCodec: "hevc"},
+		},
+		{
+			name:  "unreadable video",
+			probe: `{"format": {"duration": "N/A"}, "streams": []}`,
+			want:  models.Metadata{Size: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "probe.json")
+			assert.NoError(t, os.WriteFile(output, []byte(tt.probe), 0o600))
+
+			// Stand-in for ffprobe that prints canned output
+			ffprobe := filepath.Join(t.TempDir(), "ffprobe")
+			assert.NoError(t, os.WriteFile(ffprobe, []byte("#!/bin/sh\ncat "+output+"\n"), 0o755))
+			config.AppConfig.FFPROBE_PATH = ffprobe
+
+			metadata := models.Metadata{Size: 5}
+			extractMetadata(context.Background(), newMockFile([]byte("video")), 5, constants.ResourceTypeVideo, nil, &metadata)
+			assert.Equal(t, tt.want, metadata)
+		})
+	}
+
+	t.Run("missing ffprobe", func(t *testing.T) {
+		config.AppConfig.FFPROBE_PATH = filepath.Join(t.TempDir(), "missing-ffprobe")
+
+		metadata := models.Metadata{Size: 5}
+		extractMetadata(context.Background(), newMockFile([]byte("video")), 5, constants.ResourceTypeVideo, nil, &metadata)
+		assert.Equal(t, models.Metadata{Size: 5}, metadata, "metadata is best effort")
+	})
+}
+
+func TestExtractMetadataImage(t *testing.T) {
+	data := pngOf(t, 800, 450)
+
+	var metadata models.Metadata
+	extractMetadata(context.Background(), newMockFile(data), int64(len(data)), constants.ResourceTypeImage, nil, &metadata)
+
+	assert.Equal(t, 800, metadata.Width)
+	assert.Equal(t, 450, metadata.Height)
+}
+
+func TestExtractMetadataPDF(t *testing.T) {
+	data := []byte("%PDF-1.7\n" +
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 34 >>\nendobj\n" +
+		"3 0 obj\n<< /Title (Onboarding guide) /Author (Jane Doe) >>\nendobj\n" +
+		"trailer\n<< /Root 1 0 R /Info 3 0 R >>\n%%EOF\n")
+
+	inspection, rejection := inspectPDF(bytes.NewReader(data), int64(len(data)), constants.PDFActiveContentReject)
+	assert.Empty(t, rejection)
+
+	var metadata models.Metadata
+	extractMetadata(context.Background(), newMockFile(data), int64(len(data)), constants.ResourceTypePDF, &Inspection{PDF: inspection}, &metadata)
+
+	assert.Equal(t, models.Metadata{Pages: 34, Title: "Onboarding guide", Author: "Jane Doe"}, metadata)
+}
+
+func TestParseContentObject(t *testing.T) {
+	sha := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name        string
+		objectName  string
+		wantProduct string
+		wantSHA     string
+		wantVariant bool
+		wantOK      bool
+	}{
+		{name: "upload", objectName: contentObjectName("ecomm", "video", sha, ".mp4"), wantProduct: "ecomm", wantSHA: sha, wantOK: true},
+		{name: "variant", objectName: variantObjectName(contentObjectName("ecomm", "image", sha, ".png"), 320, "webp"), wantProduct: "ecomm", wantSHA: sha, wantVariant: true, wantOK: true},
+		{name: "timestamped upload", objectName: "ecomm/pdf/1700000000_intro.pdf"},
+		{name: "generated thumbnail", objectName: "ecomm/image/1700000000_" + sha + "_thumbnail.jpg"},
+		{name: "short hash", objectName: "ecomm/pdf/abcdef.pdf"},
+		{name: "nested prefix", objectName: "ecomm/hls/" + sha + "/index.m3u8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, sha256, variant, ok := ParseContentObject(tt.objectName)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantProduct, product)
+			assert.Equal(t, tt.wantSHA, sha256)
+			assert.Equal(t, tt.wantVariant, variant)
+		})
+	}
+}
+
+func TestDeleteObjectsReleasesOncePerPurge(t *testing.T) {
+	sha := strings.Repeat("cd", 32)
+	objectName := contentObjectName("ecomm", "video", sha, ".mp4")
+	fileURL := "https://firebasestorage.googleapis.com/v0/b/test-bucket/o/" + strings.ReplaceAll(objectName, "/", "%2F") + "?alt=media"
+
+	tests := []struct {
+		name       string
+		releaseIDs []string // one purge run each
+		fileURLs   []string
+		wantRefs   int
+	}{
+		{name: "retried purge", releaseIDs: []string{"job-1", "job-1"}, fileURLs: []string{fileURL}, wantRefs: 2},
+		{name: "separate purges", releaseIDs: []string{"job-1", "job-2"}, fileURLs: []string{fileURL}, wantRefs: 1},
+		{name: "retried purge of two references", releaseIDs: []string{"job-1", "job-1"}, fileURLs: []string{fileURL, fileURL}, wantRefs: 1},
+		{name: "no release ID", releaseIDs: []string{"", ""}, fileURLs: []string{fileURL}, wantRefs: 1},
+	}
+
+	client, err := storage.NewClient(context.Background(), option.WithoutAuthentication())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	originalClient, originalBucket, originalRelease := firebase.StorageClient, firebase.StorageBucket, releaseObject
+	defer func() {
+		firebase.StorageClient, firebase.StorageBucket, releaseObject = originalClient, originalBucket, originalRelease
+	}()
+	firebase.StorageClient, firebase.StorageBucket = client, "test-bucket"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := &models.StoredObject{Object: objectName, Refs: 3}
+			releaseObject = func(_ context.Context, product, sha256, releaseID string) (*models.StoredObject, error) {
+				assert.Equal(t, "ecomm", product)
+				assert.Equal(t, sha, sha256)
+				if !object.Release(releaseID, time.Now()) || object.Refs > 0 {
+					return nil, nil
+				}
+				return object, nil
+			}
+
+			for _, releaseID := range tt.releaseIDs {
+				assert.NoError(t, DeleteObjects(context.Background(), releaseID, tt.fileURLs, nil))
+			}
+			assert.Equal(t, tt.wantRefs, object.Refs)
+		})
+	}
+}
+
+func TestRestoreFileRejections(t *testing.T) {
+	originalPolicies := config.AppConfig.UPLOAD_POLICIES
+	defer func() {
+		config.AppConfig.UPLOAD_POLICIES = originalPolicies
+	}()
+	config.AppConfig.UPLOAD_POLICIES = map[string]config.UploadPolicy{
+		"ecomm": {MaxFileSizeMB: map[string]int64{constants.ResourceTypeImage: 1}, PDFActiveContent: constants.PDFActiveContentSanitize},
+	}
+
+	playlist := []byte("#EXTM3U\n#EXT-X-VERSION:3\n")
+	playlistSum := sha256.Sum256(playlist)
+
+	tests := []struct {
+		name       string
+		content    []byte
+		fileType   string
+		objectName string
+		wantError  string
+	}{
+		{name: "content of another hash", content: testPDF(""), fileType: constants.ResourceTypePDF, objectName: contentObjectName("ecomm", "pdf", strings.Repeat("ab", 32), ".pdf"), wantError: "does not match its SHA-256"},
+		{name: "content of another type", content: playlist, fileType: constants.ResourceTypePDF, objectName: contentObjectName("ecomm", "pdf", hex.EncodeToString(playlistSum[:]), ".pdf"), wantError: "does not match expected type 'pdf'"},
+		{name: "size limit of the product", content: bytes.Repeat([]byte{0}, 1<<20+1), fileType: constants.ResourceTypeImage, objectName: "ecomm/image/1700000000_thumbnail.png", wantError: "larger than the 1 MB"},
+		{name: "active content to sanitize", content: testPDF("/OpenAction << /S /JavaScript /JS (app.alert(1)) >>"), fileType: constants.ResourceTypePDF, objectName: "ecomm/pdf/1700000000_intro.pdf", wantError: "active content"},
+		{name: "segment that is not MPEG-TS", content: []byte{0x00, 0x01, 0xfe, 0xff}, fileType: constants.FileTypeJobOutput, objectName: "ecomm/hls/abc/job/720p/segment_000.ts", wantError: "not an MPEG-TS segment"},
+		{name: "playlist named as a segment", content: playlist, fileType: constants.FileTypeJobOutput, objectName: "ecomm/hls/abc/job/720p/segment_000.ts", wantError: "does not match its name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rejected before anything is written, there is no bucket
+			_, err := RestoreFile(context.Background(), newMockFile(tt.content), "ecomm", tt.fileType, tt.objectName)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), constants.ErrFileValidationFailed)
+				assert.Contains(t, err.Error(), tt.wantError)
+			}
+		})
+	}
+}
+
+func TestInspectJobOutput(t *testing.T) {
+	segment := make([]byte, 3*mpegTSPacketSize)
+	for offset := 0; offset < len(segment); offset += mpegTSPacketSize {
+		segment[offset] = mpegTSSyncByte
+	}
+	corrupt := bytes.Clone(segment)
+	corrupt[mpegTSPacketSize] = 0
+
+	tests := []struct {
+		name    string
+		content []byte
+		valid   bool
+	}{
+		{name: "playlist", content: []byte("#EXTM3U\n#EXT-X-TARGETDURATION:6\n"), valid: true},
+		{name: "segment", content: segment, valid: true},
+		{name: "packet without sync byte", content: corrupt},
+		{name: "partial packet", content: segment[:mpegTSPacketSize+1]},
+		{name: "empty", content: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rejection := inspectJobOutput(bytes.NewReader(tt.content), int64(len(tt.content)), config.UploadPolicy{})
+			assert.Equal(t, tt.valid, rejection == "", rejection)
+		})
+	}
+}
+
+// buildZip returns a zip archive of entries added by add
+func buildZip(t *testing.T, add func(w *zip.Writer)) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	add(w)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func writeZipEntry(t *testing.T, w *zip.Writer, header *zip.FileHeader, content []byte) {
+	t.Helper()
+	entry, err := w.CreateHeader(header)
+	assert.NoError(t, err)
+	_, err = entry.Write(content)
+	assert.NoError(t, err)
+}
+
+func TestInspectArchive(t *testing.T) {
+	t.Run("listing", func(t *testing.T) {
+		data := buildZip(t, func(w *zip.Writer) {
+			writeZipEntry(t, w, &zip.FileHeader{Name: "src/"}, nil)
+			writeZipEntry(t, w, &zip.FileHeader{Name: "src/main.go", Method: zip.Deflate}, []byte("package main\n"))
+			writeZipEntry(t, w, &zip.FileHeader{Name: "README.md"}, []byte("# Demo\n"))
+		})
+
+		listing, rejection := inspectArchive(bytes.NewReader(data), int64(len(data)))
+		assert.Empty(t, rejection)
+		assert.Equal(t, 2, listing.Files, "folders are not counted")
+		assert.Equal(t, int64(20), listing.UncompressedSize)
+		assert.Equal(t, []models.ArchiveEntry{{Path: "src/main.go", Size: 13}, {Path: "README.md", Size: 7}}, listing.Entries)
+		assert.False(t, listing.Truncated)
+	})
+
+	t.Run("truncated listing", func(t *testing.T) {
+		data := buildZip(t, func(w *zip.Writer) {
+			for i := 0; i <= constants.ArchiveListingLimit; i++ {
+				writeZipEntry(t, w, &zip.FileHeader{Name: "file" + strconv.Itoa(i) + ".txt"}, []byte("x"))
+			}
+		})
+
+		listing, rejection := inspectArchive(bytes.NewReader(data), int64(len(data)))
+		assert.Empty(t, rejection)
+		assert.Equal(t, constants.ArchiveListingLimit+1, listing.Files)
+		assert.Len(t, listing.Entries, constants.ArchiveListingLimit)
+		assert.True(t, listing.Truncated)
+	})
+
+	symlink := &zip.FileHeader{Name: "link"}
+	symlink.SetMode(fs.ModeSymlink | 0o777)
+
+	tests := []struct {
+		name          string
+		header        *zip.FileHeader
+		content       []byte
+		wantRejection string
+	}{
+		{name: "path traversal", header: &zip.FileHeader{Name: "../etc/passwd"}, wantRejection: "outside of its folder"},
+		{name: "nested path traversal", header: &zip.FileHeader{Name: "src/../../evil.sh"}, wantRejection: "outside of its folder"},
+		{name: "absolute path", header: &zip.FileHeader{Name: "/etc/passwd"}, wantRejection: "absolute path"},
+		{name: "drive letter", header: &zip.FileHeader{Name: "C:/Windows/evil.exe"}, wantRejection: "absolute path"},
+		{name: "backslashes", header: &zip.FileHeader{Name: `..\evil.sh`}, wantRejection: "absolute path"},
+		{name: "symbolic link", header: symlink, content: []byte("/etc/passwd"), wantRejection: "symbolic link"},
+		{name: "encrypted", header: &zip.FileHeader{Name: "secret.txt", Flags: 0x1}, wantRejection: "encrypted"},
+		{name: "compression ratio", header: &zip.FileHeader{Name: "bomb.txt", Method: zip.Deflate}, content: make([]byte, 4<<20), wantRejection: "compression ratio"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildZip(t, func(w *zip.Writer) {
+				writeZipEntry(t, w, tt.header, tt.content)
+			})
+
+			listing, rejection := inspectArchive(bytes.NewReader(data), int64(len(data)))
+			assert.Nil(t, listing)
+			assert.Contains(t, rejection, tt.wantRejection)
+		})
+	}
+
+	t.Run("not an archive", func(t *testing.T) {
+		data := []byte("%PDF-1.4 not a zip")
+		listing, rejection := inspectArchive(bytes.NewReader(data), int64(len(data)))
+		assert.Nil(t, listing)
+		assert.Equal(t, archiveUninspectableMessage, rejection)
+	})
+}
+
+func TestResolveEmbed(t *testing.T) {
+	var html string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("url") == "https://www.youtube.com/watch?v=missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(oembedResponse{
+			Type:         "video",
+			Title:        "Intro",
+			AuthorName:   "LearningHub",
+			ThumbnailURL: "https://i.ytimg.com/vi/abc/hqdefault.jpg",
+			HTML:         html,
+			Width:        640,
+			Height:       360,
+		})
+	}))
+	defer server.Close()
+
+	originalProviders, originalClient := embedProviders, embedClient
+	defer func() { embedProviders, embedClient = originalProviders, originalClient }()
+	embedProviders = []embedProvider{{
+		Name:        "youtube",
+		Hosts:       []string{"www.youtube.com", "youtu.be"},
+		Endpoint:    server.URL,
+		PlayerHosts: []string{"www.youtube.com"},
+	}}
+	embedClient = server.Client()
+
+	tests := []struct {
+		name      string
+		pageURL   string
+		html      string
+		wantEmbed string
+		wantErr   string
+	}{
+		{
+			name:      "player",
+			pageURL:   "https://youtu.be/abc",
+			html:      `<iframe width="640" height="360" src="https://www.youtube.com/embed/abc?feature=oembed&amp;start=10" allowfullscreen></iframe>`,
+			wantEmbed: "https://www.youtube.com/embed/abc?feature=oembed&start=10",
+		},
+		{name: "unknown provider", pageURL: "https://example.com/video", wantErr: ErrUnsupportedEmbed.Error()},
+		{name: "not a web page", pageURL: "file:///etc/passwd", wantErr: ErrUnsupportedEmbed.Error()},
+		{name: "not found", pageURL: "https://www.youtube.com/watch?v=missing", wantErr: "not found"},
+		{name: "no player", pageURL: "https://youtu.be/abc", html: `<div>unavailable</div>`, wantErr: "no player"},
+		{
+			name:    "player on another host",
+			pageURL: "https://youtu.be/abc",
+			html:    `<iframe src="https://evil.example/embed/abc"></iframe>`,
+			wantErr: "unexpected player URL",
+		},
+		{
+			name:    "insecure player",
+			pageURL: "https://youtu.be/abc",
+			html:    `<iframe src="http://www.youtube.com/embed/abc"></iframe>`,
+			wantErr: "unexpected player URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html = tt.html
+			embed, err := ResolveEmbed(context.Background(), tt.pageURL)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "youtube", embed.Provider)
+			assert.Equal(t, tt.wantEmbed, embed.EmbedURL)
+			assert.Equal(t, "Intro", embed.Title)
+			assert.Equal(t, "https://i.ytimg.com/vi/abc/hqdefault.jpg", embed.ThumbnailURL)
+			assert.Equal(t, 640, embed.Width)
+		})
+	}
+}
+
+func TestResourceTypeRegistry(t *testing.T) {
+	assert.Equal(t, constants.ResourceTypes, ResourceTypeNames(), "the built-in types are registered in order")
+
+	video, exists := LookupResourceType(constants.ResourceTypeVideo)
+	assert.True(t, exists)
+	assert.Equal(t, SourceFile, video.Source)
+	assert.True(t, video.ThumbnailFromUpload)
+
+	embed, exists := LookupResourceType(constants.ResourceTypeEmbed)
+	assert.True(t, exists)
+	assert.Equal(t, SourceURL, embed.Source)
+	assert.NotNil(t, embed.Resolve)
+
+	_, exists = LookupResourceType(constants.ResourceTypeImage)
+	assert.False(t, exists, "thumbnails are not a resource type")
+	assert.NotEmpty(t, FileTypeHint(constants.ResourceTypeImage))
+	assert.Empty(t, FileTypeHint(constants.ResourceTypeArticle), "links are not uploaded")
+
+	t.Run("invalid registrations", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			resourceType ResourceType
+		}{
+			{name: "no name", resourceType: ResourceType{Source: SourceURL}},
+			{name: "image", resourceType: ResourceType{Name: constants.ResourceTypeImage, Source: SourceURL}},
+			{name: "no source", resourceType: ResourceType{Name: "podcast"}},
+			{name: "file without MIME types", resourceType: ResourceType{Name: "podcast", Source: SourceFile}},
+			{name: "duplicate", resourceType: ResourceType{Name: constants.ResourceTypeVideo, Source: SourceURL}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Panics(t, func() { RegisterResourceType(tt.resourceType) })
+			})
+		}
+	})
+
+	t.Run("custom type", func(t *testing.T) {
+		original := resourceTypes
+		defer func() { resourceTypes = original }()
+		resourceTypes = slices.Clone(original)
+
+		RegisterResourceType(ResourceType{
+			Name:   "markdown",
+			Source: SourceFile,
+			// Detected as "text/plain; charset=utf-8"
+			AcceptMIME: func(mtype *mimetype.MIME) bool { return mtype.Is("text/plain") },
+			MIMEError:  "file type '%s' is not markdown",
+		})
+
+		assert.True(t, IsValidResourceType("markdown"))
+		assert.Contains(t, ResourceTypeNames(), "markdown")
+
+		result := ValidateFileContent(newMockFile([]byte("# Title\n\nSome text.\n")), "markdown")
+		assert.True(t, result.IsValid, result.Error)
+
+		result = ValidateFileContent(newMockFile([]byte("%PDF-1.4\n")), "markdown")
+		assert.False(t, result.IsValid)
+		assert.Equal(t, "file type 'application/pdf' is not markdown", result.Error)
+	})
+}
+
+func TestCheckDetectedType(t *testing.T) {
+	zipData := buildZip(t, func(w *zip.Writer) {
+		writeZipEntry(t, w, &zip.FileHeader{Name: "main.go"}, []byte("package main\n"))
+	})
+
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedType string
+		wantError    string
+	}{
+		{name: "mp3 audio", data: append([]byte("ID3\x03\x00\x00\x00\x00\x00\x00"), make([]byte, 64)...), expectedType: constants.ResourceTypeAudio},
+		{name: "zip code archive", data: zipData, expectedType: constants.ResourceTypeCode},
+		{name: "zip as slides", data: zipData, expectedType: constants.ResourceTypeSlides, wantError: "slide deck type 'application/zip' is not supported"},
+		{name: "PDF as audio", data: []byte("%PDF-1.4\n"), expectedType: constants.ResourceTypeAudio, wantError: "audio type 'application/pdf' is not supported"},
+		{name: "link type", data: []byte("%PDF-1.4\n"), expectedType: constants.ResourceTypeArticle, wantError: "unknown resource type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkDetectedType(mimetype.Detect(tt.data), tt.expectedType)
+			if tt.wantError != "" {
+				assert.False(t, result.IsValid)
+				assert.Contains(t, result.Error, tt.wantError)
+				return
+			}
+			assert.True(t, result.IsValid, result.Error)
+		})
+	}
+}
+
+func TestValidateFieldDefinition(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition models.FieldDefinition
+		wantError  string
+	}{
+		{name: "string", definition: models.FieldDefinition{Name: "audience", Type: constants.FieldTypeString}},
+		{name: "enum", definition: models.FieldDefinition{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{" beginner ", "advanced"}}},
+		{name: "invalid name", definition: models.FieldDefinition{Name: "product-version", Type: constants.FieldTypeString}, wantError: "must start with a letter"},
+		{name: "reserved name", definition: models.FieldDefinition{Name: constants.QueryParamTags, Type: constants.FieldTypeString}, wantError: "reserved query parameter"},
+		{name: "unsupported type", definition: models.FieldDefinition{Name: "due", Type: "date"}, wantError: "unsupported type 'date'"},
+		{name: "enum without values", definition: models.FieldDefinition{Name: "difficulty", Type: constants.FieldTypeEnum}, wantError: "must list 1 to 100 values"},
+		{name: "duplicate enum value", definition: models.FieldDefinition{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"easy", " easy"}}, wantError: "lists 'easy' twice"},
+		{name: "values of a non-enum", definition: models.FieldDefinition{Name: "durationMinutes", Type: constants.FieldTypeNumber, Values: []string{"5"}}, wantError: "not an enum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFieldDefinition(&tt.definition)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			for _, value := range tt.definition.Values {
+				assert.Equal(t, strings.TrimSpace(value), value)
+			}
+		})
+	}
+}
+
+func TestMergeCustomFields(t *testing.T) {
+	definitions := []models.FieldDefinition{
+		{Name: "audience", Type: constants.FieldTypeString},
+		{Name: "difficulty", Type: constants.FieldTypeEnum, Required: true, Values: []string{"beginner", "advanced"}},
+		{Name: "durationMinutes", Type: constants.FieldTypeNumber},
+		{Name: "featured", Type: constants.FieldTypeBoolean},
+	}
+
+	tests := []struct {
+		name      string
+		existing  map[string]any
+		values    string
+		expected  map[string]any
+		wantError string
+		wantCode  string
+	}{
+		{
+			name:     "all types",
+			values:   `{"audience": "developers", "difficulty": "beginner", "durationMinutes": 15, "featured": true}`,
+			expected: map[string]any{"audience": "developers", "difficulty": "beginner", "durationMinutes": 15.0, "featured": true},
+		},
+		{
+			name:     "merged into existing values",
+			existing: map[string]any{"difficulty": "beginner", "audience": "developers", "retired": "kept"},
+			values:   `{"difficulty": "advanced", "audience": null}`,
+			expected: map[string]any{"difficulty": "advanced", "retired": "kept"},
+		},
+		{name: "required field missing", values: `{"audience": "developers"}`, wantError: "'difficulty' is required", wantCode: validation.CodeRequired},
+		{name: "required field unset", existing: map[string]any{"difficulty": "beginner"}, values: `{"difficulty": ""}`, wantError: "'difficulty' is required", wantCode: validation.CodeRequired},
+		{name: "unknown field", values: `{"difficulty": "beginner", "level": 3}`, wantError: "'level' is not defined", wantCode: validation.CodeUnknownField},
+		{name: "value not in enum", values: `{"difficulty": "expert"}`, wantError: "must be one of: beginner, advanced", wantCode: validation.CodeInvalidChoice},
+		{name: "string for a number", values: `{"difficulty": "beginner", "durationMinutes": "15"}`, wantError: "must be a number", wantCode: validation.CodeInvalidType},
+		{name: "number for a boolean", values: `{"difficulty": "beginner", "featured": 1}`, wantError: "must be true or false", wantCode: validation.CodeInvalidType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ParseCustomFields(tt.values)
+			assert.NoError(t, err)
+
+			merged, err := MergeCustomFields(definitions, tt.existing, values)
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				var fieldErr *CustomFieldError
+				assert.True(t, errors.As(err, &fieldErr))
+				assert.Equal(t, tt.wantCode, fieldErr.Code)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, merged)
+		})
+	}
+
+	t.Run("not an object", func(t *testing.T) {
+		for _, raw := range []string{`["beginner"]`, `null`, `difficulty=beginner`} {
+			_, err := ParseCustomFields(raw)
+			assert.Error(t, err, raw)
+		}
+	})
+}
+
+func TestParseCustomFieldFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition models.FieldDefinition
+		raw        string
+		expected   any
+		wantError  bool
+	}{
+		{name: "string", definition: models.FieldDefinition{Name: "audience", Type: constants.FieldTypeString}, raw: "developers", expected: "developers"},
+		{name: "number", definition: models.FieldDefinition{Name: "durationMinutes", Type: constants.FieldTypeNumber}, raw: "15", expected: 15.0},
+		{name: "invalid number", definition: models.FieldDefinition{Name: "durationMinutes", Type: constants.FieldTypeNumber}, raw: "NaN", wantError: true},
+		{name: "boolean", definition: models.FieldDefinition{Name: "featured", Type: constants.FieldTypeBoolean}, raw: "true", expected: true},
+		{name: "invalid boolean", definition: models.FieldDefinition{Name: "featured", Type: constants.FieldTypeBoolean}, raw: "yes", wantError: true},
+		{name: "enum", definition: models.FieldDefinition{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"beginner"}}, raw: "beginner", expected: "beginner"},
+		{name: "value not in enum", definition: models.FieldDefinition{Name: "difficulty", Type: constants.FieldTypeEnum, Values: []string{"beginner"}}, raw: "expert", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ParseCustomFieldFilter(tt.definition, tt.raw)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
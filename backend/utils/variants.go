@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/firebase"
+	"learninghub/pkg/logger"
+
+	"cloud.google.com/go/storage"
+)
+
+// maxVariantSourceSize bounds the source images downloaded to create a variant
+const maxVariantSourceSize = 25 << 20 // 25MB
+
+// variantContentTypes maps image variant formats to their MIME type
+var variantContentTypes = map[string]string{
+	constants.ImageFormatJPEG: "image/jpeg",
+	constants.ImageFormatWebP: "image/webp",
+	constants.ImageFormatAVIF: "image/avif",
+}
+
+// VariantKey identifies an image variant in models.Resource.Thumbnails, e.g. "320.webp"
+func VariantKey(width int, format string) string {
+	return fmt.Sprintf("%d.%s", width, format)
+}
+
+// IsValidImageFormat checks if format is one of constants.ImageVariantFormats
+func IsValidImageFormat(format string) bool {
+	return slices.Contains(constants.ImageVariantFormats, format)
+}
+
+// SnapVariantWidth returns the smallest variant width that is at least width,
+// or the largest variant width if none is. A width of 0 or less selects the
+// largest variant.
+func SnapVariantWidth(width int) int {
+	largest := constants.ImageVariantWidths[len(constants.ImageVariantWidths)-1]
+	if width <= 0 {
+		return largest
+	}
+
+	for _, w := range constants.ImageVariantWidths {
+		if w >= width {
+			return w
+		}
+	}
+	return largest
+}
+
+// variantObjectName derives the storage object of a variant from its source
+// object, e.g. "ecomm/image/1_cover.png" -> "ecomm/image/1_cover_320w.webp".
+// The name is deterministic, so a variant is only ever created once.
+func variantObjectName(sourceObject string, width int, format string) string {
+	extension := format
+	if format == constants.ImageFormatJPEG {
+		extension = "jpg"
+	}
+
+	base := sourceObject[:len(sourceObject)-len(filepath.Ext(sourceObject))]
+	return fmt.Sprintf("%s_%dw.%s", base, width, extension)
+}
+
+// GenerateImageVariants stores the responsive variants of an image and returns
+// their URLs keyed by VariantKey.
+//
+// Images are never upscaled: widths beyond the source width are skipped,
+// except the smallest one so every image has at least one variant per format.
+// A format that fails to encode (e.g. ffmpeg is missing) is logged and skipped;
+// it can still be created on demand later by CreateImageVariant.
+func GenerateImageVariants(ctx context.Context, img image.Image, sourceObject string) map[string]string {
+	variants := make(map[string]string)
+	sourceWidth := img.Bounds().Dx()
+
+	for i, width := range constants.ImageVariantWidths {
+		if i > 0 && width > sourceWidth {
+			break
+		}
+
+		resized := resizeToWidth(img, width)
+		for _, format := range constants.ImageVariantFormats {
+			objectName := variantObjectName(sourceObject, width, format)
+
+			variantURL, err := storeImageVariant(ctx, resized, objectName, format)
+			if err != nil {
+				logger.Infof("Failed to create %s variant of %s: %v", VariantKey(width, format), sourceObject, err)
+				continue
+			}
+
+			variants[VariantKey(width, format)] = variantURL
+		}
+	}
+
+	return variants
+}
+
+// GenerateImageVariantsFromObject stores the responsive variants of an image
+// stored in our bucket, see GenerateImageVariants
+func GenerateImageVariantsFromObject(ctx context.Context, imageURL string) (map[string]string, error) {
+	bucketName, objectName, err := parseStorageURL(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse storage URL: %w", err)
+	}
+
+	img, err := readStoredImage(ctx, firebase.StorageClient.Bucket(bucketName), objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ThumbnailTimeout*time.Second)
+	defer cancel()
+
+	return GenerateImageVariants(ctx, img, objectName), nil
+}
+
+// CreateImageVariant returns the URL of a variant of an image stored in our
+// bucket, creating it from the source image if it does not exist yet.
+func CreateImageVariant(ctx context.Context, sourceURL string, width int, format string) (string, error) {
+	bucketName, sourceObject, err := parseStorageURL(sourceURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse storage URL: %w", err)
+	}
+
+	bucket := firebase.StorageClient.Bucket(bucketName)
+	objectName := variantObjectName(sourceObject, width, format)
+
+	// A variant created by an earlier request
+	if _, err := bucket.Object(objectName).Attrs(ctx); err == nil {
+		return generatePublicURL(objectName, bucketName)
+	} else if !errors.Is(err, storage.ErrObjectNotExist) {
+		return "", fmt.Errorf("failed to look up variant: %w", err)
+	}
+
+	img, err := readStoredImage(ctx, bucket, sourceObject)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.ThumbnailTimeout*time.Second)
+	defer cancel()
+
+	return storeImageVariant(ctx, resizeToWidth(img, width), objectName, format)
+}
+
+// readStoredImage downloads and decodes an image from the bucket
+func readStoredImage(ctx context.Context, bucket *storage.BucketHandle, objectName string) (image.Image, error) {
+	reader, err := bucket.Object(objectName).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source image: %w", err)
+	}
+	defer reader.Close()
+
+	if reader.Attrs.Size > maxVariantSourceSize {
+		return nil, fmt.Errorf("source image is too large: %d bytes", reader.Attrs.Size)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source image: %w", err)
+	}
+
+	return decodeImage(bytes.NewReader(data))
+}
+
+// storeImageVariant encodes an already resized image and stores it
+func storeImageVariant(ctx context.Context, img image.Image, objectName, format string) (string, error) {
+	encoded, err := encodeImageVariant(ctx, img, format)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeObject(ctx, objectName, variantContentTypes[format], bytes.NewReader(encoded)); err != nil {
+		return "", err
+	}
+
+	return generatePublicURL(objectName, firebase.StorageBucket)
+}
+
+// encodeImageVariant encodes img in one of constants.ImageVariantFormats.
+// JPEG is encoded natively; WebP and AVIF are encoded by ffmpeg, since the
+// standard library and x/image only decode them.
+func encodeImageVariant(ctx context.Context, img image.Image, format string) ([]byte, error) {
+	var encoded bytes.Buffer
+
+	switch format {
+	case constants.ImageFormatJPEG:
+		if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: constants.ThumbnailJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+		return encoded.Bytes(), nil
+
+	case constants.ImageFormatWebP, constants.ImageFormatAVIF:
+		// Hand the pixels over losslessly and let ffmpeg do the lossy encoding
+		if err := png.Encode(&encoded, img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+
+		args := []string{"-hide_banner", "-loglevel", "error", "-f", "png_pipe", "-i", "pipe:0"}
+		if format == constants.ImageFormatWebP {
+			args = append(args, "-c:v", "libwebp", "-quality", "80", "-f", "webp")
+		} else {
+			args = append(args, "-c:v", "libaom-av1", "-still-picture", "1", "-crf", "32", "-cpu-used", "6", "-f", "avif")
+		}
+		args = append(args, "pipe:1")
+
+		return runToolWithInput(ctx, &encoded, config.AppConfig.FFMPEG_PATH, args...)
+
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+// collectionRules are the rules of the editable fields of a collection.
+// Whether its resources exist is checked against the database instead.
+var collectionRules = []Rule[models.Collection]{
+	{
+		Field:  constants.FormFieldTitle,
+		Value:  func(c models.Collection) any { return c.Title },
+		Checks: []Check{Required, MaxLength(constants.MaxTitleLength)},
+	},
+	{
+		Field:  constants.FormFieldDescription,
+		Value:  func(c models.Collection) any { return c.Description },
+		Checks: []Check{MaxLength(constants.MaxDescriptionLength)},
+	},
+	{
+		Field:  constants.FormFieldCoverImageURL,
+		Value:  func(c models.Collection) any { return c.CoverImageURL },
+		Checks: []Check{MaxLength(constants.MaxURLLength), URL},
+	},
+	{
+		Field:  constants.FormFieldItems,
+		Value:  func(c models.Collection) any { return c.Items },
+		Checks: []Check{collectionItems},
+	},
+}
+
+// Collection checks the editable fields of a collection and returns the
+// errors of every rejected field
+func Collection(collection models.Collection) []errors.FieldError {
+	return Validate(collection, collectionRules)
+}
+
+// collectionItems rejects lists of too many items, items that are neither or
+// both a resource and a heading, headings that are too long and resources
+// listed twice. It names the first rejected item.
+func collectionItems(value any) *errors.FieldError {
+	items, _ := value.([]models.CollectionItem)
+	if len(items) > constants.MaxCollectionItems {
+		return &errors.FieldError{
+			Code:    CodeTooMany,
+			Message: fmt.Sprintf("must have at most %d items", constants.MaxCollectionItems),
+			Params:  map[string]any{"max": constants.MaxCollectionItems},
+		}
+	}
+
+	listed := make(map[string]int, len(items))
+	for i, item := range items {
+		index := strconv.Itoa(i)
+		switch {
+		case (item.ResourceID == "") == (item.Heading == ""):
+			return &errors.FieldError{Field: index, Code: CodeInvalid, Message: "must have either a resourceId or a heading"}
+		case utf8.RuneCountInString(item.Heading) > constants.MaxTitleLength:
+			return &errors.FieldError{
+				Field:   index + ".heading",
+				Code:    CodeTooLong,
+				Message: fmt.Sprintf("must be at most %d characters", constants.MaxTitleLength),
+				Params:  map[string]any{"max": constants.MaxTitleLength},
+			}
+		case item.ResourceID != "":
+			if j, exists := listed[item.ResourceID]; exists {
+				return &errors.FieldError{
+					Field:   index + ".resourceId",
+					Code:    CodeConflict,
+					Message: fmt.Sprintf("is listed already by item %d", j),
+					Params:  map[string]any{"with": j},
+				}
+			}
+			listed[item.ResourceID] = i
+		}
+	}
+	return nil
+}
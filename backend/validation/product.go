@@ -0,0 +1,93 @@
+package validation
+
+import (
+	"net/mail"
+	"regexp"
+	"slices"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+// productNamePattern keeps product names usable as a path segment and as the
+// prefix of collection names, whose suffixes start with an underscore
+var productNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// productRules are the rules of the fields of a new product
+var productRules = []Rule[models.Product]{
+	{
+		Field:  constants.FormFieldName,
+		Value:  func(p models.Product) any { return p.Name },
+		Checks: []Check{Required, MaxLength(constants.MaxProductNameLength), ProductName},
+	},
+	{
+		Field:  constants.FormFieldDisplayName,
+		Value:  func(p models.Product) any { return p.DisplayName },
+		Checks: []Check{MaxLength(constants.MaxTitleLength)},
+	},
+	{
+		Field:  constants.FormFieldDescription,
+		Value:  func(p models.Product) any { return p.Description },
+		Checks: []Check{MaxLength(constants.MaxFieldStringLength)},
+	},
+	{
+		Field:  constants.FormFieldOwners,
+		Value:  func(p models.Product) any { return p.Owners },
+		Checks: []Check{MaxItems(constants.MaxProductOwners), Each(Email)},
+	},
+	{
+		Field:  constants.FormFieldDefaultTags,
+		Value:  func(p models.Product) any { return p.DefaultTags },
+		Checks: []Check{MaxItems(constants.MaxTags), Each(MaxLength(constants.MaxTagLength))},
+	},
+	{
+		Field:  constants.FormFieldUploadPolicy,
+		Value:  func(p models.Product) any { return p.UploadPolicy },
+		Checks: []Check{uploadPolicy},
+	},
+}
+
+// Product checks the fields of a new product and returns the errors of every
+// rejected field
+func Product(product models.Product) []errors.FieldError {
+	return Validate(product, productRules)
+}
+
+// ProductName rejects names that cannot name a product. Empty strings are
+// left to Required.
+func ProductName(value any) *errors.FieldError {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return nil
+	}
+	if !productNamePattern.MatchString(s) {
+		return &errors.FieldError{Code: CodeInvalid, Message: "must be lowercase letters and digits, optionally separated by single dashes, starting with a letter"}
+	}
+	if slices.Contains(constants.ReservedProductNames, s) {
+		return &errors.FieldError{Code: CodeNotAllowed, Message: "is reserved"}
+	}
+	return nil
+}
+
+// Email rejects strings that are not a bare email address
+func Email(value any) *errors.FieldError {
+	s, _ := value.(string)
+	if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+		return &errors.FieldError{Code: CodeInvalid, Message: "must be an email address"}
+	}
+	return nil
+}
+
+// uploadPolicy rejects upload policies that cannot be enforced
+func uploadPolicy(value any) *errors.FieldError {
+	policy, _ := value.(*config.UploadPolicy)
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Validate(); err != nil {
+		return &errors.FieldError{Code: CodeInvalid, Message: "is invalid: " + err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+// resourceRules are the rules of the editable fields of a resource. The type
+// and custom fields are checked against the type registry and the product's
+// field definitions instead.
+var resourceRules = []Rule[models.Resource]{
+	{
+		Field:  constants.FormFieldTitle,
+		Value:  func(r models.Resource) any { return r.Title },
+		Checks: []Check{Required, MaxLength(constants.MaxTitleLength)},
+	},
+	{
+		Field:  constants.FormFieldDescription,
+		Value:  func(r models.Resource) any { return r.Description },
+		Checks: []Check{Required, MaxLength(constants.MaxDescriptionLength)},
+	},
+	{
+		Field:  constants.FormFieldType,
+		Value:  func(r models.Resource) any { return r.Type },
+		Checks: []Check{Required},
+	},
+	{
+		Field:  constants.FormFieldURL,
+		Value:  func(r models.Resource) any { return r.URL },
+		Checks: []Check{MaxLength(constants.MaxURLLength), URL},
+	},
+	{
+		Field:  constants.FormFieldThumbnailURL,
+		Value:  func(r models.Resource) any { return r.ThumbnailURL },
+		Checks: []Check{MaxLength(constants.MaxURLLength), URL},
+	},
+	{
+		Field:  constants.FormFieldTags,
+		Value:  func(r models.Resource) any { return r.Tags },
+		Checks: []Check{MaxItems(constants.MaxTags), Each(MaxLength(constants.MaxTagLength))},
+	},
+}
+
+// Resource checks the editable fields of a resource, or only the given
+// fields, and returns the errors of every rejected field
+func Resource(resource models.Resource, fields ...string) []errors.FieldError {
+	return Validate(resource, resourceRules, fields...)
+}
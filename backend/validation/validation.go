@@ -0,0 +1,158 @@
+// Package validation checks request values against declarative rules and
+// reports every rejected field, see errors.FieldError.
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"learninghub/errors"
+)
+
+// Codes of field errors, which the frontend translates. Params of each code
+// are listed with it.
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long" // max
+	CodeTooMany       = "too_many" // max
+	CodeInvalidURL    = "invalid_url"
+	CodeInvalidType   = "invalid_type"   // expected
+	CodeInvalidChoice = "invalid_choice" // values
+	CodeUnknownField  = "unknown_field"
+	CodeNotSupported  = "not_supported" // supported
+	CodeNotAllowed    = "not_allowed"
+	CodeImmutable     = "immutable"
+	CodeConflict      = "conflict" // with
+	CodeNotFound      = "not_found"
+	CodeInvalid       = "invalid"
+)
+
+// Check checks a value and returns why it is rejected, or nil. Messages
+// leave out the subject, e.g. "is required": Validate prefixes the field. A
+// Field names the element of the value that is rejected, e.g. "2" for the
+// third item of a list.
+type Check func(value any) *errors.FieldError
+
+// Rule lists the checks of a field of T
+type Rule[T any] struct {
+	Field  string
+	Value  func(T) any
+	Checks []Check
+}
+
+// Validate checks v against rules and returns the errors of every rejected
+// field, at most one per field. If fields are given, only rules of those
+// fields are checked, e.g. the fields an update changes.
+func Validate[T any](v T, rules []Rule[T], fields ...string) []errors.FieldError {
+	var fieldErrors []errors.FieldError
+	for _, rule := range rules {
+		if len(fields) > 0 && !slices.Contains(fields, rule.Field) {
+			continue
+		}
+
+		value := rule.Value(v)
+		for _, check := range rule.Checks {
+			fieldErr := check(value)
+			if fieldErr == nil {
+				continue
+			}
+
+			field := rule.Field
+			if fieldErr.Field != "" {
+				field += "." + fieldErr.Field
+			}
+			fieldErr.Field = field
+			fieldErr.Message = field + " " + fieldErr.Message
+			fieldErrors = append(fieldErrors, *fieldErr)
+			break
+		}
+	}
+	return fieldErrors
+}
+
+// OnlyRequired reports whether every field error is a missing value
+func OnlyRequired(fieldErrors []errors.FieldError) bool {
+	for _, fieldErr := range fieldErrors {
+		if fieldErr.Code != CodeRequired {
+			return false
+		}
+	}
+	return len(fieldErrors) > 0
+}
+
+// Required rejects empty strings and lists
+func Required(value any) *errors.FieldError {
+	empty := false
+	switch v := value.(type) {
+	case string:
+		empty = v == ""
+	case []string:
+		empty = len(v) == 0
+	case nil:
+		empty = true
+	}
+	if empty {
+		return &errors.FieldError{Code: CodeRequired, Message: "is required"}
+	}
+	return nil
+}
+
+// MaxLength rejects strings longer than max characters
+func MaxLength(max int) Check {
+	return func(value any) *errors.FieldError {
+		if s, ok := value.(string); ok && utf8.RuneCountInString(s) > max {
+			return &errors.FieldError{
+				Code:    CodeTooLong,
+				Message: fmt.Sprintf("must be at most %d characters", max),
+				Params:  map[string]any{"max": max},
+			}
+		}
+		return nil
+	}
+}
+
+// MaxItems rejects lists of more than max items
+func MaxItems(max int) Check {
+	return func(value any) *errors.FieldError {
+		if items, ok := value.([]string); ok && len(items) > max {
+			return &errors.FieldError{
+				Code:    CodeTooMany,
+				Message: fmt.Sprintf("must have at most %d items", max),
+				Params:  map[string]any{"max": max},
+			}
+		}
+		return nil
+	}
+}
+
+// URL rejects strings that are not absolute http or https URLs. Empty
+// strings are left to Required.
+func URL(value any) *errors.FieldError {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return nil
+	}
+	if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &errors.FieldError{Code: CodeInvalidURL, Message: "must be an http or https URL"}
+	}
+	return nil
+}
+
+// Each checks every item of a list, and names the first rejected item
+func Each(checks ...Check) Check {
+	return func(value any) *errors.FieldError {
+		items, _ := value.([]string)
+		for i, item := range items {
+			for _, check := range checks {
+				if fieldErr := check(item); fieldErr != nil {
+					fieldErr.Field = strconv.Itoa(i)
+					return fieldErr
+				}
+			}
+		}
+		return nil
+	}
+}
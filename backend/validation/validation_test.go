@@ -0,0 +1,237 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"learninghub/config"
+	"learninghub/constants"
+	"learninghub/errors"
+	"learninghub/models"
+)
+
+func TestResource(t *testing.T) {
+	valid := models.Resource{
+		Title:        "Intro",
+		Description:  "Getting started",
+		Type:         constants.ResourceTypeArticle,
+		URL:          "https://example.com/intro",
+		ThumbnailURL: "https://example.com/intro.png",
+		Tags:         []string{"onboarding", "basics"},
+	}
+
+	tooManyTags := make([]string, constants.MaxTags+1)
+	for i := range tooManyTags {
+		tooManyTags[i] = "tag"
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(r *models.Resource)
+		fields   []string
+		expected []errors.FieldError
+	}{
+		{name: "valid", modify: func(r *models.Resource) {}},
+		{
+			name:   "missing fields",
+			modify: func(r *models.Resource) { r.Title, r.Description = "", "" },
+			expected: []errors.FieldError{
+				{Field: "title", Code: CodeRequired, Message: "title is required"},
+				{Field: "description", Code: CodeRequired, Message: "description is required"},
+			},
+		},
+		{
+			name:   "title too long",
+			modify: func(r *models.Resource) { r.Title = strings.Repeat("é", constants.MaxTitleLength+1) },
+			expected: []errors.FieldError{
+				{Field: "title", Code: CodeTooLong, Message: "title must be at most 200 characters", Params: map[string]any{"max": constants.MaxTitleLength}},
+			},
+		},
+		{
+			name:   "title at the limit",
+			modify: func(r *models.Resource) { r.Title = strings.Repeat("é", constants.MaxTitleLength) },
+		},
+		{
+			name:   "URLs",
+			modify: func(r *models.Resource) { r.URL, r.ThumbnailURL = "javascript:alert(1)", "/intro.png" },
+			expected: []errors.FieldError{
+				{Field: "url", Code: CodeInvalidURL, Message: "url must be an http or https URL"},
+				{Field: "thumbnailUrl", Code: CodeInvalidURL, Message: "thumbnailUrl must be an http or https URL"},
+			},
+		},
+		{
+			name:   "empty URLs",
+			modify: func(r *models.Resource) { r.URL, r.ThumbnailURL = "", "" },
+		},
+		{
+			name:   "too many tags",
+			modify: func(r *models.Resource) { r.Tags = tooManyTags },
+			expected: []errors.FieldError{
+				{Field: "tags", Code: CodeTooMany, Message: "tags must have at most 20 items", Params: map[string]any{"max": constants.MaxTags}},
+			},
+		},
+		{
+			name:   "tag too long",
+			modify: func(r *models.Resource) { r.Tags = []string{"ok", strings.Repeat("x", constants.MaxTagLength+1)} },
+			expected: []errors.FieldError{
+				{Field: "tags.1", Code: CodeTooLong, Message: "tags.1 must be at most 50 characters", Params: map[string]any{"max": constants.MaxTagLength}},
+			},
+		},
+		{
+			name:   "only the given fields",
+			modify: func(r *models.Resource) { r.Title, r.Description = "", "" },
+			fields: []string{"description", "tags"},
+			expected: []errors.FieldError{
+				{Field: "description", Code: CodeRequired, Message: "description is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := valid
+			tt.modify(&resource)
+			assert.Equal(t, tt.expected, Resource(resource, tt.fields...))
+		})
+	}
+}
+
+func TestOnlyRequired(t *testing.T) {
+	assert.False(t, OnlyRequired(nil))
+	assert.True(t, OnlyRequired([]errors.FieldError{{Code: CodeRequired}, {Code: CodeRequired}}))
+	assert.False(t, OnlyRequired([]errors.FieldError{{Code: CodeRequired}, {Code: CodeTooLong}}))
+}
+
+func TestProduct(t *testing.T) {
+	valid := models.Product{
+		Name:        "docs-v2",
+		DisplayName: "Documentation",
+		Owners:      []string{"docs@example.com"},
+		DefaultTags: []string{"docs"},
+		UploadPolicy: &config.UploadPolicy{
+			MaxFileSizeMB: map[string]int64{constants.ResourceTypePDF: 50},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(p *models.Product)
+		expected []errors.FieldError
+	}{
+		{name: "valid", modify: func(p *models.Product) {}},
+		{
+			name:   "missing name",
+			modify: func(p *models.Product) { p.Name = "" },
+			expected: []errors.FieldError{
+				{Field: "name", Code: CodeRequired, Message: "name is required"},
+			},
+		},
+		{
+			name:   "name that cannot prefix collections",
+			modify: func(p *models.Product) { p.Name = "Docs_v2" },
+			expected: []errors.FieldError{
+				{Field: "name", Code: CodeInvalid, Message: "name must be lowercase letters and digits, optionally separated by single dashes, starting with a letter"},
+			},
+		},
+		{
+			name:   "reserved name",
+			modify: func(p *models.Product) { p.Name = "products" },
+			expected: []errors.FieldError{
+				{Field: "name", Code: CodeNotAllowed, Message: "name is reserved"},
+			},
+		},
+		{
+			name:   "owners",
+			modify: func(p *models.Product) { p.Owners = []string{"docs@example.com", "Docs <docs@example.com>"} },
+			expected: []errors.FieldError{
+				{Field: "owners.1", Code: CodeInvalid, Message: "owners.1 must be an email address"},
+			},
+		},
+		{
+			name: "upload policy",
+			modify: func(p *models.Product) {
+				p.UploadPolicy = &config.UploadPolicy{MaxFileSizeMB: map[string]int64{constants.ResourceTypePDF: 0}}
+			},
+			expected: []errors.FieldError{
+				{Field: "uploadPolicy", Code: CodeInvalid, Message: "uploadPolicy is invalid: maxFileSizeMB: pdf must be between 1 and 500"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := valid
+			tt.modify(&product)
+			assert.Equal(t, tt.expected, Product(product))
+		})
+	}
+}
+
+func TestCollection(t *testing.T) {
+	valid := models.Collection{
+		Title:         "Onboarding",
+		CoverImageURL: "https://example.com/cover.png",
+		Items: []models.CollectionItem{
+			{Heading: "Week 1"},
+			{ResourceID: "intro"},
+			{ResourceID: "setup"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(c *models.Collection)
+		expected []errors.FieldError
+	}{
+		{name: "valid", modify: func(c *models.Collection) {}},
+		{name: "no items", modify: func(c *models.Collection) { c.Items = nil }},
+		{
+			name:   "missing title and invalid cover",
+			modify: func(c *models.Collection) { c.Title, c.CoverImageURL = "", "cover.png" },
+			expected: []errors.FieldError{
+				{Field: "title", Code: CodeRequired, Message: "title is required"},
+				{Field: "coverImageUrl", Code: CodeInvalidURL, Message: "coverImageUrl must be an http or https URL"},
+			},
+		},
+		{
+			name:   "item with both a resource and a heading",
+			modify: func(c *models.Collection) { c.Items[2].Heading = "Week 2" },
+			expected: []errors.FieldError{
+				{Field: "items.2", Code: CodeInvalid, Message: "items.2 must have either a resourceId or a heading"},
+			},
+		},
+		{
+			name:   "heading too long",
+			modify: func(c *models.Collection) { c.Items[0].Heading = strings.Repeat("x", constants.MaxTitleLength+1) },
+			expected: []errors.FieldError{
+				{Field: "items.0.heading", Code: CodeTooLong, Message: "items.0.heading must be at most 200 characters", Params: map[string]any{"max": constants.MaxTitleLength}},
+			},
+		},
+		{
+			name:   "resource listed twice",
+			modify: func(c *models.Collection) { c.Items = append(c.Items, models.CollectionItem{ResourceID: "intro"}) },
+			expected: []errors.FieldError{
+				{Field: "items.3.resourceId", Code: CodeConflict, Message: "items.3.resourceId is listed already by item 1", Params: map[string]any{"with": 1}},
+			},
+		},
+		{
+			name:   "too many items",
+			modify: func(c *models.Collection) { c.Items = make([]models.CollectionItem, constants.MaxCollectionItems+1) },
+			expected: []errors.FieldError{
+				{Field: "items", Code: CodeTooMany, Message: "items must have at most 500 items", Params: map[string]any{"max": constants.MaxCollectionItems}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collection := valid
+			collection.Items = slices.Clone(valid.Items)
+			tt.modify(&collection)
+			assert.Equal(t, tt.expected, Collection(collection))
+		})
+	}
+}
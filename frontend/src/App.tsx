@@ -10,13 +10,14 @@ import { ErrorFallback } from "./pages/ErrorFallback";
 import { NotFound } from "./pages/NotFound";
 
 import { ReactQueryFlashProvider } from "./components/Flash";
+import { productsApi, productsKeys } from "./services/products";
 import { VALID_PRODUCTS, type Product, DEFAULT_PRODUCT } from "./types";
 
 import styles from "./App.module.scss";
 
 const queryClient = new QueryClient();
 
-const productResourceLoader = ({
+const productResourceLoader = async ({
   params,
 }: {
   params: {
@@ -25,8 +26,14 @@ const productResourceLoader = ({
 }) => {
   const { product } = params;
 
-  // Check if the 'product' parameter is in our list of valid product names
-  if (!VALID_PRODUCTS.includes(product as Product)) {
+  // Check the 'product' parameter against the product registry, or against
+  // VITE_VALID_PRODUCTS when the registry cannot be read
+  const productNames = await queryClient
+    .ensureQueryData({ queryKey: productsKeys.lists(), queryFn: () => productsApi.getAll() })
+    .then((products) => products.map(({ name }) => name))
+    .catch(() => VALID_PRODUCTS);
+
+  if (!productNames.includes(product as Product)) {
     throw new Response("Invalid Product Resource", { status: 404 });
   }
   return null;
@@ -1,6 +1,23 @@
 import { http, HttpResponse } from "msw";
 
-import { DEFAULT_PRODUCT, type Resource } from "../types";
+import {
+  DEFAULT_PRODUCT,
+  VALID_PRODUCTS,
+  type Bookmark,
+  type BulkResourcesPayload,
+  type BulkResult,
+  type Collection,
+  type CollectionPayload,
+  type CollectionMove,
+  type CopyResourceResponse,
+  type ImportCatalogResponse,
+  type ImportResourcesCsvResponse,
+  type ImportStrategy,
+  type ProductDetails,
+  type Progress,
+  type Resource,
+  type UpdateProgressPayload,
+} from "../types";
 
 import { withDelay } from "./middleware";
 import type { TDb } from "./db";
@@ -60,8 +77,49 @@ const applyPagination = (resourcesToPaginate: Resource[], requestUrl: string) =>
   };
 };
 
+// Removes deleted resources from the collections listing them, like the backend
+const removeFromCollections = (collections: Map<string, Collection>, ids: string[]) => {
+  collections.forEach((collection) => {
+    collection.items = collection.items.filter((item) => !item.resourceId || !ids.includes(item.resourceId));
+  });
+};
+
 export const setupHandlers = (db: TDb) => {
+  // Collections are not persisted in the mock database
+  const collections = new Map<string, Collection>();
+  // Progress and bookmarks of the single mock user, by resource
+  const progress = new Map<string, Progress>();
+  const bookmarks = new Map<string, Bookmark>();
+
+  const withBookmarked = (resource: Resource): Resource => ({ ...resource, bookmarked: bookmarks.has(resource.id) });
+
+  // Deleted resources leave collections, progress and bookmarks, like the backend
+  const forgetResources = (ids: string[]) => {
+    removeFromCollections(collections, ids);
+    ids.forEach((id) => {
+      progress.delete(id);
+      bookmarks.delete(id);
+    });
+  };
+
+  const collectionNotFound = () =>
+    HttpResponse.json({ error: "COLLECTION_NOT_FOUND", message: "Collection not found" }, { status: 404 });
+
   return [
+    // The products of VITE_VALID_PRODUCTS, none archived
+    http.get("/api/v1/products", () => {
+      const now = new Date().toISOString();
+      const products: ProductDetails[] = VALID_PRODUCTS.map((name) => ({
+        name,
+        displayName: name,
+        archived: false,
+        createdAt: now,
+        updatedAt: now,
+      }));
+
+      return HttpResponse.json(products);
+    }),
+
     http.get(BASE_URL + "/resources", ({ request }) => {
       const resources = db.resource.getAll();
 
@@ -69,7 +127,7 @@ export const setupHandlers = (db: TDb) => {
       const paginatedResponse = applyPagination(filteredResources, request.url);
 
       return HttpResponse.json({
-        data: paginatedResponse.data,
+        data: paginatedResponse.data.map(withBookmarked),
         hasMore: paginatedResponse.hasMore,
         nextCursor: paginatedResponse.nextCursor,
       });
@@ -94,7 +152,7 @@ export const setupHandlers = (db: TDb) => {
         );
       }
 
-      return HttpResponse.json(resource);
+      return HttpResponse.json(withBookmarked(resource as Resource));
     }),
 
     http.post(
@@ -195,14 +253,298 @@ export const setupHandlers = (db: TDb) => {
           },
         },
       });
+      forgetResources([String(params.id)]);
 
       return HttpResponse.json({}, { status: 200 });
     }),
 
+    http.post(BASE_URL + "/resources/bulk", async ({ request }) => {
+      const { operations } = (await request.json()) as BulkResourcesPayload;
+
+      const results: BulkResult[] = operations.map((operation, index) => {
+        const where = { id: { equals: operation.id } };
+        const resource = db.resource.findFirst({ where });
+
+        if (!resource) {
+          return {
+            index,
+            id: operation.id,
+            op: operation.op,
+            status: "failed",
+            error: { error: "RESOURCE_NOT_FOUND", message: "Resource not found" },
+          };
+        }
+
+        switch (operation.op) {
+          case "addTags":
+            db.resource.update({ where, data: { tags: [...new Set([...resource.tags, ...operation.tags])] } });
+            break;
+          case "removeTags":
+            db.resource.update({ where, data: { tags: resource.tags.filter((tag) => !operation.tags.includes(tag)) } });
+            break;
+          case "update":
+            db.resource.update({
+              where,
+              data: {
+                ...(operation.fields.title ? { title: operation.fields.title } : {}),
+                ...(operation.fields.description ? { description: operation.fields.description } : {}),
+              },
+            });
+            break;
+          case "delete":
+            db.resource.delete({ where });
+            forgetResources([operation.id]);
+            break;
+        }
+
+        return { index, id: operation.id, op: operation.op, status: "succeeded" };
+      });
+
+      const failed = results.filter((result) => result.status === "failed").length;
+      return HttpResponse.json({ results, succeeded: results.length - failed, failed });
+    }),
+
+    // Mocks hold a single product: copies are reported, moves delete the resource
+    ...(["copy", "move"] as const).map((action) =>
+      http.post(BASE_URL + `/resources/:id/${action}`, async ({ params, request }) => {
+        const where = { id: { equals: String(params.id) } };
+        if (!db.resource.findFirst({ where })) {
+          return HttpResponse.json({ error: "RESOURCE_NOT_FOUND", message: "Resource not found" }, { status: 404 });
+        }
+
+        const { product } = (await request.json()) as { product: string };
+        if (action === "move") {
+          db.resource.delete({ where });
+          forgetResources([String(params.id)]);
+        }
+
+        const response: CopyResourceResponse = {
+          source: DEFAULT_PRODUCT,
+          sourceId: String(params.id),
+          product,
+          id: String(params.id),
+          files: 0,
+          bytes: 0,
+        };
+        return HttpResponse.json(response, { status: 201 });
+      })
+    ),
+
+    // Rows are not parsed in mocks
+    http.post(BASE_URL + "/resources/import", ({ request }) => {
+      const response: ImportResourcesCsvResponse = {
+        results: [],
+        created: 0,
+        failed: 0,
+        dryRun: new URL(request.url).searchParams.get("dryRun") === "true",
+      };
+
+      return HttpResponse.json(response);
+    }),
+
     http.get(BASE_URL + "/tags", () => {
       const tags = db.tag.getAll();
 
       return HttpResponse.json(tags);
     }),
+
+    // No custom fields are defined in mocks
+    http.get(BASE_URL + "/fields", () => {
+      return HttpResponse.json([]);
+    }),
+
+    http.get(BASE_URL + "/collections", () => {
+      const list = [...collections.values()].sort((a, b) => b.createdAt.localeCompare(a.createdAt));
+
+      return HttpResponse.json(list);
+    }),
+
+    http.get(BASE_URL + "/collections/:id", ({ params }) => {
+      const collection = collections.get(String(params.id));
+      if (!collection) {
+        return collectionNotFound();
+      }
+
+      // Items of missing resources are left out, like the backend does
+      const items = collection.items.flatMap((item) => {
+        if (!item.resourceId) {
+          return [item];
+        }
+        const resource = db.resource.findFirst({ where: { id: { equals: item.resourceId } } });
+        return resource ? [{ ...item, resource: resource as Resource }] : [];
+      });
+
+      return HttpResponse.json({ ...collection, items });
+    }),
+
+    http.post(BASE_URL + "/collections", async ({ request }) => {
+      const payload = (await request.json()) as CollectionPayload;
+      const now = new Date().toISOString();
+      const collection: Collection = {
+        id: crypto.randomUUID(),
+        title: payload.title,
+        description: payload.description || "",
+        coverImageUrl: payload.coverImageUrl,
+        items: payload.items || [],
+        createdAt: now,
+        updatedAt: now,
+      };
+      collections.set(collection.id, collection);
+
+      return HttpResponse.json(collection, { status: 201 });
+    }),
+
+    http.put(BASE_URL + "/collections/:id", async ({ params, request }) => {
+      const existing = collections.get(String(params.id));
+      if (!existing) {
+        return collectionNotFound();
+      }
+
+      const payload = (await request.json()) as CollectionPayload;
+      const collection: Collection = {
+        ...existing,
+        title: payload.title,
+        description: payload.description || "",
+        coverImageUrl: payload.coverImageUrl,
+        items: payload.items || [],
+        updatedAt: new Date().toISOString(),
+      };
+      collections.set(collection.id, collection);
+
+      return HttpResponse.json(collection);
+    }),
+
+    // Moves out of the list are not rejected in mocks
+    http.post(BASE_URL + "/collections/:id/reorder", async ({ params, request }) => {
+      const collection = collections.get(String(params.id));
+      if (!collection) {
+        return collectionNotFound();
+      }
+
+      const { moves } = (await request.json()) as { moves: CollectionMove[] };
+      const items = [...collection.items];
+      moves.forEach(({ from, to }) => {
+        const [item] = items.splice(from, 1);
+        items.splice(to, 0, item);
+      });
+      collection.items = items;
+      collection.updatedAt = new Date().toISOString();
+
+      return HttpResponse.json(collection);
+    }),
+
+    http.delete(BASE_URL + "/collections/:id", ({ params }) => {
+      if (!collections.delete(String(params.id))) {
+        return collectionNotFound();
+      }
+
+      return HttpResponse.json({ message: "Collection deleted successfully" });
+    }),
+
+    // Every request is signed in, and positions and pages are not checked in mocks
+    http.put(BASE_URL + "/progress/:resourceId", async ({ params, request }) => {
+      const resourceId = String(params.resourceId);
+      if (!db.resource.findFirst({ where: { id: { equals: resourceId } } })) {
+        return HttpResponse.json({ error: "RESOURCE_NOT_FOUND", message: "Resource not found" }, { status: 404 });
+      }
+
+      const { status, position, page } = (await request.json()) as Omit<UpdateProgressPayload, "resourceId">;
+      const now = new Date().toISOString();
+      const existing = progress.get(resourceId);
+      const updated: Progress = {
+        resourceId,
+        status: status || existing?.status || "started",
+        position: position ?? existing?.position,
+        page: page ?? existing?.page,
+        startedAt: existing?.startedAt || now,
+        completedAt: status === "started" ? undefined : existing?.completedAt,
+        updatedAt: now,
+      };
+      if (updated.status === "completed" && !updated.completedAt) {
+        updated.completedAt = now;
+      }
+      progress.set(resourceId, updated);
+
+      return HttpResponse.json(updated);
+    }),
+
+    http.get(BASE_URL + "/me/progress", () => {
+      const resources = [...progress.values()].sort((a, b) => b.updatedAt.localeCompare(a.updatedAt));
+      const collectionsProgress = [...collections.values()]
+        .sort((a, b) => b.createdAt.localeCompare(a.createdAt))
+        .flatMap((collection) => {
+          const ids = collection.items.flatMap((item) => (item.resourceId ? [item.resourceId] : []));
+          if (!ids.some((id) => progress.has(id))) {
+            return [];
+          }
+          const completed = ids.filter((id) => progress.get(id)?.status === "completed").length;
+          return [
+            {
+              collectionId: collection.id,
+              title: collection.title,
+              completed,
+              total: ids.length,
+              percentage: Math.floor((completed * 100) / ids.length),
+            },
+          ];
+        });
+
+      return HttpResponse.json({ resources, collections: collectionsProgress });
+    }),
+
+    http.get(BASE_URL + "/me/bookmarks", ({ request }) => {
+      const bookmarked = [...bookmarks.values()]
+        .sort((a, b) => b.createdAt.localeCompare(a.createdAt))
+        .flatMap(({ resourceId }) => {
+          const resource = db.resource.findFirst({ where: { id: { equals: resourceId } } });
+          return resource ? [withBookmarked(resource as Resource)] : [];
+        });
+
+      return HttpResponse.json({ ...applyPagination(bookmarked, request.url), total: bookmarks.size });
+    }),
+
+    http.post(BASE_URL + "/me/bookmarks/:resourceId", ({ params }) => {
+      const resourceId = String(params.resourceId);
+      if (!db.resource.findFirst({ where: { id: { equals: resourceId } } })) {
+        return HttpResponse.json({ error: "RESOURCE_NOT_FOUND", message: "Resource not found" }, { status: 404 });
+      }
+
+      const existing = bookmarks.get(resourceId);
+      if (existing) {
+        return HttpResponse.json(existing);
+      }
+      const bookmark: Bookmark = { resourceId, createdAt: new Date().toISOString() };
+      bookmarks.set(resourceId, bookmark);
+
+      return HttpResponse.json(bookmark, { status: 201 });
+    }),
+
+    http.delete(BASE_URL + "/me/bookmarks/:resourceId", ({ params }) => {
+      bookmarks.delete(String(params.resourceId));
+
+      return HttpResponse.json({ message: "Bookmark removed successfully" });
+    }),
+
+    // Archives are not read in mocks: every import is empty
+    http.post(BASE_URL + "/import", ({ request }) => {
+      const params = new URL(request.url).searchParams;
+      const response: ImportCatalogResponse = {
+        product: DEFAULT_PRODUCT,
+        source: DEFAULT_PRODUCT,
+        strategy: (params.get("strategy") as ImportStrategy | null) || "skip",
+        dryRun: params.get("dryRun") === "true",
+        resources: [],
+        created: 0,
+        overwritten: 0,
+        duplicated: 0,
+        skipped: 0,
+        failed: 0,
+        fields: 0,
+        files: 0,
+        bytes: 0,
+      };
+
+      return HttpResponse.json(response);
+    }),
   ];
 };
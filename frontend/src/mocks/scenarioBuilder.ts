@@ -7,7 +7,12 @@ export const buildScenarios = (db: TDb) => {
   const builder = {
     withResources(n = 10) {
       for (let i = 0; i < n; i++) {
-        const type = faker.helpers.arrayElement(Object.values(RESOURCE_TYPES)) as Resource["type"];
+        // Seed fixtures only exist for these types
+        const type = faker.helpers.arrayElement([
+          RESOURCE_TYPES.video,
+          RESOURCE_TYPES.pdf,
+          RESOURCE_TYPES.article,
+        ]) as Resource["type"];
         const url =
           type === RESOURCE_TYPES.video
             ? faker.helpers.arrayElement(seedVideos)
@@ -156,6 +156,10 @@ export const Resources = () => {
                     <option value="video">Videos</option>
                     <option value="pdf">PDFs</option>
                     <option value="article">Articles</option>
+                    <option value="audio">Audio</option>
+                    <option value="slides">Slide Decks</option>
+                    <option value="code">Code</option>
+                    <option value="embed">Embeds</option>
                   </select>
                 </div>
 
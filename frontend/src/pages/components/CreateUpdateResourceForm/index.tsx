@@ -1,21 +1,43 @@
 import React, { useState, useCallback, type JSX, useEffect, useRef } from "react";
-import { Upload, Plus, Edit3, Save, Eye, X, Video, File, ExternalLink, FileText, Trash2 } from "lucide-react";
+import {
+  Upload,
+  Plus,
+  Edit3,
+  Save,
+  Eye,
+  X,
+  Video,
+  File,
+  ExternalLink,
+  FileText,
+  Trash2,
+  Music,
+  Presentation,
+  FileArchive,
+  MonitorPlay,
+} from "lucide-react";
 
 import {
+  FILE_RESOURCE_TYPES,
   RESOURCE_TYPES,
+  type CustomFieldValue,
   type CreateResourcePayload,
+  type FieldDefinition,
   type Resource,
   type ResourceType,
   type UpdateResourcePayload,
 } from "../../../types";
 
 import { SearchSelectInput, type Item } from "../../../components/SearchSelectInput";
+import { useReactQueryFlash } from "../../../components/Flash/useReactQueryFlash";
 import { RichTextEditor } from "../RichText";
 
 import { ResourceDetails } from "../ResourceDetails";
 
+import { ApiError } from "../../../services/httpClient";
 import { useCreateResource, useUpdateResource } from "../../../services/resources";
 import { useTags } from "../../../services/tags";
+import { useFields } from "../../../services/fields";
 
 import { usePrevious } from "../../../hooks";
 
@@ -34,6 +56,18 @@ interface CreateUpdateResourceProps {
 
 const defaultType = "video";
 
+/** Label and accepted extensions of the file input, per file resource type */
+const fileInputs: Partial<Record<ResourceType, { label: string; accept: string }>> = {
+  video: { label: "Video File", accept: "video/*" },
+  pdf: { label: "PDF File", accept: ".pdf" },
+  audio: { label: "Audio File", accept: ".mp3,.ogg,.m4a" },
+  slides: { label: "Slide Deck", accept: ".pptx,.odp" },
+  code: { label: "Code Archive", accept: ".zip" },
+};
+
+/** Resource types given by URL only */
+const urlResourceTypes: ResourceType[] = [RESOURCE_TYPES.article, RESOURCE_TYPES.embed];
+
 export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({ resource, onCancel, onSuccess }) => {
   const [formData, setFormData] = useState<TFormData>({
     title: resource?.title || "",
@@ -42,6 +76,7 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
     url: resource?.url || "",
     thumbnailUrl: resource?.thumbnailUrl || "",
     tags: resource?.tags || [],
+    customFields: resource?.customFields || {},
     file: null,
     thumbnail: null,
   });
@@ -57,22 +92,36 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
   const prevType = usePrevious(formData.type, defaultType);
 
   const { data: tags = [], isFetching: isTagsFetching } = useTags();
+  const { data: fields = [], isFetching: isFieldsFetching } = useFields();
+
+  const { showWarning } = useReactQueryFlash();
+
+  // Highlight the fields the server rejected
+  const handleMutationError = useCallback((error: Error) => {
+    if (error instanceof ApiError && error.fieldErrors.length) {
+      setValidationErrors(fieldErrorMessages(error));
+    }
+  }, []);
 
   const { mutate: createResource, isPending: isCreatingResource } = useCreateResource({
-    onSuccess: () => {
+    onSuccess: (data) => {
+      data.warnings?.forEach((warning) => showWarning(warning.message));
       onCancel();
       onSuccess?.();
     },
+    onError: handleMutationError,
   });
 
   const { mutate: updateResource, isPending: isUpdatingResource } = useUpdateResource({
-    onSuccess: () => {
+    onSuccess: (data) => {
+      data.warnings?.forEach((warning) => showWarning(warning.message));
       onCancel();
       onSuccess?.();
     },
+    onError: handleMutationError,
   });
 
-  const isDisabled = isTagsFetching || isCreatingResource || isUpdatingResource;
+  const isDisabled = isTagsFetching || isFieldsFetching || isCreatingResource || isUpdatingResource;
 
   const handleRemoveFile = useCallback((fileType: "file" | "thumbnail") => {
     setFormData((prev) => ({ ...prev, [fileType]: null }));
@@ -113,6 +162,22 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
     [validationErrors]
   );
 
+  // An empty value unsets the field
+  const handleCustomFieldChange = useCallback((name: string, value: CustomFieldValue | "") => {
+    setFormData((prev) => {
+      const customFields = { ...prev.customFields };
+      if (value === "") {
+        delete customFields[name];
+      } else {
+        customFields[name] = value;
+      }
+      return { ...prev, customFields };
+    });
+
+    const errorKey = customFieldErrorKey(name);
+    setValidationErrors((prev) => (prev[errorKey] ? { ...prev, [errorKey]: "" } : prev));
+  }, []);
+
   const handleDescriptionChange = (value: string) => {
     setFormData((prev) => ({ ...prev, description: value }));
   };
@@ -179,7 +244,7 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
       errors.type = "Type is required";
     }
 
-    if ((formData.type === "video" || formData.type === "pdf") && !formData.file) {
+    if (formData.type && FILE_RESOURCE_TYPES.includes(formData.type) && !formData.file) {
       if (!resource) {
         errors.file = `Please select a ${formData.type} file`;
       } else if (resource && !formData.url) {
@@ -191,10 +256,20 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
       errors.url = "URL is required for articles";
     }
 
+    if (formData.type === "embed" && !formData.url?.trim()) {
+      errors.url = "A YouTube, Vimeo or Loom URL is required for embeds";
+    }
+
     if (formData.tags?.length === 0) {
       errors.tags = "At least one tag is required";
     }
 
+    fields.forEach((field) => {
+      if (field.required && formData.customFields?.[field.name] === undefined) {
+        errors[customFieldErrorKey(field.name)] = `${field.label || field.name} is required`;
+      }
+    });
+
     setValidationErrors(errors);
     return Object.keys(errors).length === 0;
   };
@@ -211,7 +286,7 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
       createResource(payload as CreateResourcePayload);
     }
     // eslint-disable-next-line
-  }, [formData, resource, createResource]);
+  }, [formData, fields, resource, createResource]);
 
   const handleTagsChange = useCallback((selectedItems: Item[]) => {
     setFormData((prev) => ({
@@ -228,6 +303,14 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
         return <File />;
       case "article":
         return <ExternalLink />;
+      case "audio":
+        return <Music />;
+      case "slides":
+        return <Presentation />;
+      case "code":
+        return <FileArchive />;
+      case "embed":
+        return <MonitorPlay />;
       default:
         return <FileText />;
     }
@@ -238,8 +321,8 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
       formData.title &&
       formData.description &&
       !isRichTextContentEmpty(formData.description) &&
-      ((formData.type === "article" && formData.url) ||
-        (["pdf", "video"].includes(formData?.type || "") && formData.file))
+      (urlResourceTypes.includes(formData.type!) && formData.url) ||
+      (FILE_RESOURCE_TYPES.includes(formData.type!) && formData.file)
     );
   };
 
@@ -323,11 +406,39 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
               {validationErrors.tags && <span className="form-field-error">{validationErrors.tags}</span>}
             </div>
 
-            {/* File Upload (for video/pdf) */}
-            {(formData.type === "video" || formData.type === "pdf") && (
+            {/* Custom fields of the product */}
+            {fields.map((field) => {
+              const errorKey = customFieldErrorKey(field.name);
+              return (
+                <div
+                  className="form-field"
+                  key={field.name}
+                >
+                  <label
+                    className="form-field-label"
+                    htmlFor={errorKey}
+                    title={field.description}
+                  >
+                    {field.label || field.name} {field.required && "*"}
+                  </label>
+                  <CustomFieldInput
+                    id={errorKey}
+                    field={field}
+                    value={formData.customFields?.[field.name]}
+                    onChange={(value) => handleCustomFieldChange(field.name, value)}
+                    hasError={!!validationErrors[errorKey]}
+                    disabled={isDisabled}
+                  />
+                  {validationErrors[errorKey] && <span className="form-field-error">{validationErrors[errorKey]}</span>}
+                </div>
+              );
+            })}
+
+            {/* File Upload (for file resource types) */}
+            {formData.type && fileInputs[formData.type] && (
               <div className="form-field">
                 <label className="form-field-label">
-                  {formData.type === "video" ? "Video File" : "PDF File"} {!resource && "*"}
+                  {fileInputs[formData.type]!.label} {!resource && "*"}
                 </label>
                 <div
                   className={`file-upload ${dragOver ? "file-upload-drag-over" : ""}`}
@@ -339,7 +450,7 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
                   <p className="file-upload-text">Drag & drop your {formData.type} file here, or click to browse</p>
                   <input
                     type="file"
-                    accept={formData.type === "video" ? "video/*" : ".pdf"}
+                    accept={fileInputs[formData.type]!.accept}
                     onChange={(e) => handleFileChange(e, "file")}
                     className="file-upload-input"
                     id="file-upload"
@@ -372,8 +483,8 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
               </div>
             )}
 
-            {/* URL (for articles or as fallback) */}
-            {(formData.type === "article" || (resource && resource.url && !formData.file)) && (
+            {/* URL (for articles and embeds, or as fallback) */}
+            {(urlResourceTypes.includes(formData.type!) || (resource && resource.url && !formData.file)) && (
               <div className="form-field">
                 <label className="form-field-label">Resource URL *</label>
                 <input
@@ -382,8 +493,8 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
                   value={formData.url}
                   onChange={handleInputChange}
                   className={`form-field-input ${validationErrors.url ? "form-field-input-error" : ""}`}
-                  placeholder="https://example.com/article"
-                  disabled={isDisabled || (resource && resource.url ? resource.type !== "article" : false)}
+                  placeholder={formData.type === "embed" ? "https://www.youtube.com/watch?v=..." : "https://example.com/article"}
+                  disabled={isDisabled || (resource && resource.url ? !urlResourceTypes.includes(resource.type) : false)}
                 />
                 {validationErrors.url && <span className="form-field-error">{validationErrors.url}</span>}
               </div>
@@ -423,6 +534,7 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
                   </div>
                 )}
               </div>
+              {validationErrors.thumbnail && <span className="form-field-error">{validationErrors.thumbnail}</span>}
             </div>
           </div>
 
@@ -475,6 +587,112 @@ export const CreateUpdateResourceForm: React.FC<CreateUpdateResourceProps> = ({
   );
 };
 
+function customFieldErrorKey(name: string): string {
+  return `customFields.${name}`;
+}
+
+/**
+ * Messages of the fields an ApiError rejects, keyed like validationErrors:
+ * errors of list items ("tags.2") go to the list, thumbnail URLs to the
+ * thumbnail input
+ */
+function fieldErrorMessages(error: ApiError): Record<string, string> {
+  const errors: Record<string, string> = {};
+  error.fieldErrors.forEach(({ field, message }) => {
+    let key = field.startsWith("customFields.") ? field : field.split(".")[0];
+    if (key === "thumbnailUrl") {
+      key = "thumbnail";
+    }
+    errors[key] ??= message;
+  });
+  return errors;
+}
+
+interface CustomFieldInputProps {
+  id: string;
+  field: FieldDefinition;
+  value?: CustomFieldValue;
+  onChange: (value: CustomFieldValue | "") => void;
+  hasError: boolean;
+  disabled: boolean;
+}
+
+/** Input matching the type of a custom field */
+const CustomFieldInput: React.FC<CustomFieldInputProps> = ({ id, field, value, onChange, hasError, disabled }) => {
+  const className = `form-field-input ${hasError ? "form-field-input-error" : ""}`;
+
+  switch (field.type) {
+    case "boolean":
+      return (
+        <input
+          id={id}
+          type="checkbox"
+          checked={value === true}
+          onChange={(e) => onChange(e.target.checked)}
+          disabled={disabled}
+        />
+      );
+    case "enum":
+      return (
+        <select
+          id={id}
+          value={typeof value === "string" ? value : ""}
+          onChange={(e) => onChange(e.target.value)}
+          className={className}
+          disabled={disabled}
+        >
+          <option value="">Select...</option>
+          {field.values?.map((option) => (
+            <option
+              key={option}
+              value={option}
+            >
+              {option}
+            </option>
+          ))}
+        </select>
+      );
+    case "number":
+      return (
+        <input
+          id={id}
+          type="number"
+          value={typeof value === "number" ? value : ""}
+          onChange={(e) => onChange(e.target.value === "" ? "" : Number(e.target.value))}
+          className={className}
+          placeholder={field.description}
+          disabled={disabled}
+        />
+      );
+    default:
+      return (
+        <input
+          id={id}
+          type="text"
+          value={typeof value === "string" ? value : ""}
+          onChange={(e) => onChange(e.target.value)}
+          className={className}
+          placeholder={field.description}
+          disabled={disabled}
+        />
+      );
+  }
+};
+
+/** Custom fields whose value changed, null when unset */
+function modifiedCustomFields(
+  original: Record<string, CustomFieldValue> = {},
+  current: Record<string, CustomFieldValue> = {}
+): Record<string, CustomFieldValue | null> | undefined {
+  const modified: Record<string, CustomFieldValue | null> = {};
+  for (const name of new Set([...Object.keys(original), ...Object.keys(current)])) {
+    if (original[name] !== current[name]) {
+      modified[name] = current[name] ?? null;
+    }
+  }
+  return Object.keys(modified).length > 0 ? modified : undefined;
+}
+
 function areTagsModified(originalTags: string[] = [], currentTags: string[] = []): boolean {
   if (originalTags.length !== currentTags.length) return true;
   const originalSet = new Set(originalTags);
@@ -497,6 +715,8 @@ function getResourcePayload(resource: Resource | undefined, formData: TFormData)
       ...(formData.thumbnailUrl && { thumbnailUrl: formData.thumbnailUrl }),
       ...(formData.file && { file: formData.file }),
       ...(formData.thumbnail && { thumbnail: formData.thumbnail }),
+      ...(formData.customFields &&
+        Object.keys(formData.customFields).length > 0 && { customFields: formData.customFields }),
     };
   }
 
@@ -509,6 +729,8 @@ function getResourcePayload(resource: Resource | undefined, formData: TFormData)
   if (formData.url !== resource.url) delta.url = formData.url!;
   if (formData.thumbnailUrl !== resource.thumbnailUrl) delta.thumbnailUrl = formData.thumbnailUrl!;
   if (areTagsModified(resource.tags, formData.tags)) delta.tags = formData.tags!.join(",");
+  const customFields = modifiedCustomFields(resource.customFields, formData.customFields);
+  if (customFields) delta.customFields = customFields;
   if (formData.file) delta.file = formData.file;
   if (formData.thumbnail) delta.thumbnail = formData.thumbnail;
 
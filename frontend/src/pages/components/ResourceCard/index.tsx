@@ -1,11 +1,63 @@
 import { useState, useCallback } from "react";
-import { Video, File, ExternalLink, FileText, Eye, Edit3, Trash2, Tag } from "lucide-react";
+import {
+  Video,
+  File,
+  ExternalLink,
+  FileText,
+  Eye,
+  Edit3,
+  Trash2,
+  Tag,
+  Music,
+  Presentation,
+  FileArchive,
+  MonitorPlay,
+} from "lucide-react";
 
 import { type Resource, RESOURCE_TYPES, type ResourceType } from "../../../types";
 import { ResourceDetails } from "../ResourceDetails";
 
 import "./ResourceCard.scss";
 
+// Most efficient first: the browser picks the first <source> it supports
+const THUMBNAIL_FORMATS = [
+  { format: "avif", type: "image/avif" },
+  { format: "webp", type: "image/webp" },
+] as const;
+
+// Cards are at most ~320px wide, full width on small screens
+const THUMBNAIL_SIZES = "(max-width: 640px) 100vw, 320px";
+
+/** Builds a srcset from the thumbnail variants of one format */
+const getThumbnailSrcSet = (thumbnails: Record<string, string>, format: string) =>
+  Object.entries(thumbnails)
+    .filter(([key]) => key.endsWith(`.${format}`))
+    .map(([key, url]) => `${url} ${parseInt(key, 10)}w`)
+    .join(", ");
+
+/** Summarizes an uploaded file, e.g. "12 min · 1080p" or "34 pages" */
+const getMetadataSummary = (metadata: Resource["metadata"]) => {
+  if (!metadata) return "";
+
+  const parts: string[] = [];
+  if (metadata.duration) {
+    parts.push(
+      metadata.duration < 60 ? `${Math.round(metadata.duration)} s` : `${Math.round(metadata.duration / 60)} min`
+    );
+  }
+  if (metadata.width && metadata.height) {
+    // Named after the short side, so portrait videos read "1080p" too
+    parts.push(`${Math.min(metadata.width, metadata.height)}p`);
+  }
+  if (metadata.pages) {
+    parts.push(metadata.pages === 1 ? "1 page" : `${metadata.pages} pages`);
+  }
+  if (metadata.archive) {
+    parts.push(metadata.archive.files === 1 ? "1 file" : `${metadata.archive.files} files`);
+  }
+  return parts.join(" · ");
+};
+
 interface ResourceCardProps {
   resource: Resource;
   onEdit: (resource: Resource) => void;
@@ -14,6 +66,7 @@ interface ResourceCardProps {
 
 export const ResourceCard = ({ resource, onEdit, onDelete }: ResourceCardProps) => {
   const [showDetails, setShowDetails] = useState<boolean>(false);
+  const metadataSummary = getMetadataSummary(resource.metadata);
 
   const getTypeIcon = useCallback((type: ResourceType) => {
     switch (type) {
@@ -23,6 +76,14 @@ export const ResourceCard = ({ resource, onEdit, onDelete }: ResourceCardProps)
         return <File className="resource-card-type-icon resource-card-type-icon-pdf" />;
       case RESOURCE_TYPES.article:
         return <ExternalLink className="resource-card-type-icon resource-card-type-icon-article" />;
+      case RESOURCE_TYPES.audio:
+        return <Music className="resource-card-type-icon resource-card-type-icon-audio" />;
+      case RESOURCE_TYPES.slides:
+        return <Presentation className="resource-card-type-icon resource-card-type-icon-slides" />;
+      case RESOURCE_TYPES.code:
+        return <FileArchive className="resource-card-type-icon resource-card-type-icon-code" />;
+      case RESOURCE_TYPES.embed:
+        return <MonitorPlay className="resource-card-type-icon resource-card-type-icon-embed" />;
       default:
         return <FileText className="resource-card-type-icon" />;
     }
@@ -53,6 +114,7 @@ export const ResourceCard = ({ resource, onEdit, onDelete }: ResourceCardProps)
           <div className="resource-card-type">
             {getTypeIcon(resource.type)}
             <span className="resource-card-type-label">{resource.type}</span>
+            {metadataSummary && <span className="resource-card-type-meta">{metadataSummary}</span>}
           </div>
           <div className="resource-card-actions">
             <button
@@ -84,13 +146,31 @@ export const ResourceCard = ({ resource, onEdit, onDelete }: ResourceCardProps)
 
         {resource.thumbnailUrl && (
           <div className="resource-card-thumbnail">
-            <img
-              src={resource.thumbnailUrl}
-              alt="Thumbnail"
-              className="resource-card-thumbnail-image"
-              loading="lazy"
-              decoding="async"
-            />
+            <picture>
+              {resource.thumbnails &&
+                THUMBNAIL_FORMATS.map(({ format, type }) => {
+                  const srcSet = getThumbnailSrcSet(resource.thumbnails!, format);
+                  return (
+                    srcSet && (
+                      <source
+                        key={format}
+                        type={type}
+                        srcSet={srcSet}
+                        sizes={THUMBNAIL_SIZES}
+                      />
+                    )
+                  );
+                })}
+              <img
+                src={resource.thumbnails?.["320.jpeg"] || resource.thumbnailUrl}
+                srcSet={resource.thumbnails ? getThumbnailSrcSet(resource.thumbnails, "jpeg") || undefined : undefined}
+                sizes={THUMBNAIL_SIZES}
+                alt="Thumbnail"
+                className="resource-card-thumbnail-image"
+                loading="lazy"
+                decoding="async"
+              />
+            </picture>
           </div>
         )}
 
@@ -1,4 +1,4 @@
-import { X, Video, File, ExternalLink, FileText } from "lucide-react";
+import { X, Video, File, ExternalLink, FileText, Music, Presentation, FileArchive, MonitorPlay } from "lucide-react";
 
 import { type Resource } from "../../../types";
 
@@ -111,6 +111,93 @@ export const ResourceDetails: React.FC<ResourceDetailsProps> = ({ resource, onCl
           </div>
         );
 
+      case "audio": {
+        const audioUrl = isPreview && resource.file ? URL.createObjectURL(resource.file) : resource.url;
+        if (audioUrl) {
+          return (
+            <audio
+              controls
+              className="resource-details-audio"
+              src={audioUrl}
+              onLoadedData={() => isPreview && resource.file && URL.revokeObjectURL(audioUrl)}
+            >
+              Your browser does not support the audio tag.
+            </audio>
+          );
+        }
+        return (
+          <div className="resource-details-placeholder">
+            <Music className="resource-details-placeholder-icon" />
+            <p>Audio file not provided</p>
+          </div>
+        );
+      }
+
+      case "slides":
+        if (!isPreview && resource.previewUrl) {
+          return (
+            <object
+              data={resource.previewUrl}
+              type="application/pdf"
+              className="resource-details-pdf"
+            />
+          );
+        }
+        return (
+          <div className="resource-details-placeholder">
+            <Presentation className="resource-details-placeholder-icon" />
+            <p>
+              {resource.conversion?.status === "failed"
+                ? "The slide deck could not be converted"
+                : "The slide deck preview is not available yet"}
+            </p>
+          </div>
+        );
+
+      case "code":
+        if (resource.metadata?.archive) {
+          const { archive } = resource.metadata;
+          return (
+            <div className="resource-details-archive">
+              <p className="resource-details-archive-summary">
+                {archive.files} files, {Math.ceil(archive.uncompressedSize / 1024)} KB once extracted
+              </p>
+              <ul className="resource-details-archive-entries">
+                {archive.entries.map((entry) => (
+                  <li key={entry.path}>{entry.path}</li>
+                ))}
+              </ul>
+              {archive.truncated && <p className="resource-details-archive-summary">…and more</p>}
+            </div>
+          );
+        }
+        return (
+          <div className="resource-details-placeholder">
+            <FileArchive className="resource-details-placeholder-icon" />
+            <p>{isPreview ? "The archive is listed once uploaded" : "Archive listing not available"}</p>
+          </div>
+        );
+
+      case "embed":
+        if (resource.embed) {
+          return (
+            <iframe
+              src={resource.embed.embedUrl}
+              title={resource.embed.title || resource.title || "Embedded video"}
+              className="resource-details-embed"
+              sandbox="allow-scripts allow-same-origin allow-presentation"
+              allow="fullscreen; picture-in-picture"
+              referrerPolicy="strict-origin-when-cross-origin"
+            />
+          );
+        }
+        return (
+          <div className="resource-details-placeholder">
+            <MonitorPlay className="resource-details-placeholder-icon" />
+            <p>{isPreview ? "The player is resolved once saved" : "Player not available"}</p>
+          </div>
+        );
+
       case "article":
         if (resource.url) {
           return (
@@ -165,6 +252,19 @@ export const ResourceDetails: React.FC<ResourceDetailsProps> = ({ resource, onCl
           <RichTextViewer content={resource.description} />
         </div>
       )}
+      {resource.customFields && Object.keys(resource.customFields).length > 0 && (
+        <dl className="resource-details-fields">
+          {Object.entries(resource.customFields).map(([name, value]) => (
+            <div
+              key={name}
+              className="resource-details-field"
+            >
+              <dt>{name}</dt>
+              <dd>{typeof value === "boolean" ? (value ? "Yes" : "No") : String(value)}</dd>
+            </div>
+          ))}
+        </dl>
+      )}
     </div>
   );
 };
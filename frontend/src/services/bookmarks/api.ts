@@ -0,0 +1,29 @@
+import { httpClient } from "../httpClient";
+import { getProductFromUrl } from "../utils";
+
+import {
+  type Bookmark,
+  type BookmarkPayload,
+  type GetMyBookmarksParams,
+  type GetMyBookmarksResponse,
+} from "../../types";
+
+// Bookmark endpoints require the ID token of the signed in user, see httpClient.setAuthToken
+export const bookmarksApi = {
+  // Get the resources the signed in user bookmarked, most recently bookmarked first
+  getMine: async (params?: GetMyBookmarksParams, options?: RequestInit): Promise<GetMyBookmarksResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.get<GetMyBookmarksResponse>(`/${product}/me/bookmarks`, params, options);
+  },
+
+  // Bookmarking a resource again keeps the first bookmark
+  add: async ({ resourceId }: BookmarkPayload): Promise<Bookmark> => {
+    const product = getProductFromUrl();
+    return httpClient.post<Bookmark>(`/${product}/me/bookmarks/${resourceId}`);
+  },
+
+  remove: async ({ resourceId }: BookmarkPayload): Promise<void> => {
+    const product = getProductFromUrl();
+    return httpClient.delete<void>(`/${product}/me/bookmarks/${resourceId}`);
+  },
+};
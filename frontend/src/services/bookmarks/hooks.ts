@@ -0,0 +1,75 @@
+import { useQueryClient, type UseQueryOptions, type UseMutationOptions, type QueryKey } from "@tanstack/react-query";
+
+import { bookmarksApi } from "./api";
+import {
+  type Bookmark,
+  type BookmarkPayload,
+  type GetMyBookmarksParams,
+  type GetMyBookmarksResponse,
+} from "../../types";
+import { useMutationWithFlash, useQueryWithFlash } from "../../hooks";
+import { resourcesKeys } from "../resources/hooks";
+
+// Query Keys
+export const bookmarksKeys = {
+  all: ["bookmarks"] as const,
+  mine: (params?: GetMyBookmarksParams) => [...bookmarksKeys.all, "me", JSON.stringify(params)] as const,
+} as const;
+
+// Custom hook for getting the resources the signed in user bookmarked
+export function useMyBookmarks(
+  params?: GetMyBookmarksParams,
+  options?: Omit<UseQueryOptions<GetMyBookmarksResponse, Error, GetMyBookmarksResponse, QueryKey>, "queryKey" | "queryFn">
+) {
+  return useQueryWithFlash({
+    queryKey: bookmarksKeys.mine(params),
+    queryFn: () => bookmarksApi.getMine(params),
+    errorMessage: "Failed to load bookmarks",
+    ...options,
+  });
+}
+
+// Custom hook for bookmarking a resource
+export function useAddBookmark(
+  options?: Omit<UseMutationOptions<Bookmark, Error, BookmarkPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: bookmarksApi.add,
+    onSuccess: (data, variables, context) => {
+      // Resources carry their bookmarked flag
+      queryClient.invalidateQueries({ queryKey: bookmarksKeys.all });
+      queryClient.invalidateQueries({ queryKey: resourcesKeys.all });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to bookmark resource",
+    successMessage: "Bookmarked resource successfully",
+    ...restOptions,
+  });
+}
+
+// Custom hook for removing a bookmark
+export function useRemoveBookmark(
+  options?: Omit<UseMutationOptions<void, Error, BookmarkPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: bookmarksApi.remove,
+    onSuccess: (data, variables, context) => {
+      queryClient.invalidateQueries({ queryKey: bookmarksKeys.all });
+      queryClient.invalidateQueries({ queryKey: resourcesKeys.all });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to remove bookmark",
+    successMessage: "Removed bookmark successfully",
+    ...restOptions,
+  });
+}
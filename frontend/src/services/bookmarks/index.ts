@@ -0,0 +1,3 @@
+export { bookmarksApi } from "./api";
+
+export { bookmarksKeys, useAddBookmark, useMyBookmarks, useRemoveBookmark } from "./hooks";
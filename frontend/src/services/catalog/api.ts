@@ -0,0 +1,37 @@
+import { ApiError, httpClient } from "../httpClient";
+import { getProductFromUrl } from "../utils";
+
+import { type ImportCatalogPayload, type ImportCatalogResponse } from "../../types";
+
+export const catalogApi = {
+  // Download the product's catalog archive, which needs the admin key
+  export: async (adminKey: string): Promise<Blob> => {
+    const product = getProductFromUrl();
+    const baseURL = (import.meta.env["VITE_API_BASE_URL"] || "/api/v1").replace(/\/$/, "");
+    const response = await fetch(`${baseURL}/${product}/export`, {
+      headers: { "X-Admin-Key": adminKey },
+    });
+    if (!response.ok) {
+      throw new ApiError(response.status, await response.json().catch(() => ({})));
+    }
+    return response.blob();
+  },
+
+  // Import a catalog archive, see ImportedResource for per-resource failures
+  import: async (payload: ImportCatalogPayload): Promise<ImportCatalogResponse> => {
+    const product = getProductFromUrl();
+
+    const params = new URLSearchParams();
+    if (payload.strategy) params.set("strategy", payload.strategy);
+    if (payload.dryRun) params.set("dryRun", "true");
+    const query = params.toString() ? `?${params}` : "";
+
+    const formData = new FormData();
+    formData.append("archive", payload.archive);
+
+    return httpClient.postFormData<ImportCatalogResponse>(`/${product}/import${query}`, {
+      body: formData,
+      headers: { "X-Admin-Key": payload.adminKey },
+    });
+  },
+};
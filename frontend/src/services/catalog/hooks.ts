@@ -0,0 +1,35 @@
+import { useQueryClient, type UseMutationOptions } from "@tanstack/react-query";
+
+import { catalogApi } from "./api";
+import { type ImportCatalogPayload, type ImportCatalogResponse } from "../../types";
+
+import { useMutationWithFlash } from "../../hooks";
+import { fieldsKeys } from "../fields/hooks";
+import { resourcesKeys } from "../resources/hooks";
+import { tagsKeys } from "../tags/hooks";
+
+// Custom hook for importing a catalog archive
+export function useImportCatalog(
+  options?: Omit<UseMutationOptions<ImportCatalogResponse, Error, ImportCatalogPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: catalogApi.import,
+    onSuccess: (data, variables, context) => {
+      if (!data.dryRun) {
+        queryClient.invalidateQueries({ queryKey: resourcesKeys.all });
+        queryClient.invalidateQueries({ queryKey: tagsKeys.lists() });
+        queryClient.invalidateQueries({ queryKey: fieldsKeys.lists() });
+      }
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to import catalog",
+    successMessage: (data: ImportCatalogResponse) =>
+      `${data.dryRun ? "Dry run: " : ""}${data.created} created, ${data.overwritten} overwritten, ${data.duplicated} duplicated, ${data.skipped} skipped, ${data.failed} failed`,
+    ...restOptions,
+  });
+}
@@ -0,0 +1,3 @@
+export { catalogApi } from "./api";
+
+export { useImportCatalog } from "./hooks";
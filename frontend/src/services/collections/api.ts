@@ -0,0 +1,49 @@
+import { httpClient } from "../httpClient";
+import { getProductFromUrl } from "../utils";
+
+import {
+  type Collection,
+  type CollectionPayload,
+  type DeleteCollectionPayload,
+  type GetCollectionsResponse,
+  type ReorderCollectionPayload,
+  type UpdateCollectionPayload,
+} from "../../types";
+
+const jsonHeaders = { "Content-Type": "application/json" };
+
+export const collectionsApi = {
+  // Get the collections of the product, newest first
+  getAll: async (options?: RequestInit): Promise<GetCollectionsResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.get<GetCollectionsResponse>(`/${product}/collections`, undefined, options);
+  },
+
+  // Get a collection with the resources of its items
+  getById: async (id: string, options?: RequestInit): Promise<Collection> => {
+    const product = getProductFromUrl();
+    return httpClient.get<Collection>(`/${product}/collections/${id}`, undefined, options);
+  },
+
+  create: async (payload: CollectionPayload): Promise<Collection> => {
+    const product = getProductFromUrl();
+    return httpClient.post<Collection>(`/${product}/collections`, payload, { headers: jsonHeaders });
+  },
+
+  // Replace the title, description, cover image and items of a collection
+  update: async ({ id, ...payload }: UpdateCollectionPayload): Promise<Collection> => {
+    const product = getProductFromUrl();
+    return httpClient.put<Collection>(`/${product}/collections/${id}`, payload, { headers: jsonHeaders });
+  },
+
+  // Move items of a collection, all moves or none
+  reorder: async ({ id, moves }: ReorderCollectionPayload): Promise<Collection> => {
+    const product = getProductFromUrl();
+    return httpClient.post<Collection>(`/${product}/collections/${id}/reorder`, { moves }, { headers: jsonHeaders });
+  },
+
+  delete: async (payload: DeleteCollectionPayload): Promise<void> => {
+    const product = getProductFromUrl();
+    return httpClient.delete<void>(`/${product}/collections/${payload.id}`);
+  },
+};
@@ -0,0 +1,131 @@
+import { useQueryClient, type UseQueryOptions, type UseMutationOptions, type QueryKey } from "@tanstack/react-query";
+
+import { collectionsApi } from "./api";
+import {
+  type Collection,
+  type CollectionPayload,
+  type DeleteCollectionPayload,
+  type GetCollectionsResponse,
+  type ReorderCollectionPayload,
+  type UpdateCollectionPayload,
+} from "../../types";
+import { useMutationWithFlash, useQueryWithFlash } from "../../hooks";
+
+// Query Keys
+export const collectionsKeys = {
+  all: ["collections"] as const,
+  lists: () => [...collectionsKeys.all, "list"] as const,
+  details: () => [...collectionsKeys.all, "detail"] as const,
+  detail: (id: string) => [...collectionsKeys.details(), id] as const,
+} as const;
+
+// Custom hook for getting the collections of the product
+export function useCollections(
+  options?: Omit<UseQueryOptions<GetCollectionsResponse, Error, GetCollectionsResponse, QueryKey>, "queryKey" | "queryFn">
+) {
+  return useQueryWithFlash({
+    queryKey: collectionsKeys.lists(),
+    queryFn: () => collectionsApi.getAll(),
+    errorMessage: "Failed to load collections",
+    ...options,
+  });
+}
+
+// Custom hook for getting a collection with its resources
+export function useCollection(
+  id: string,
+  options?: Omit<UseQueryOptions<Collection, Error, Collection, QueryKey>, "queryKey" | "queryFn">
+) {
+  return useQueryWithFlash({
+    queryKey: collectionsKeys.detail(id),
+    queryFn: () => collectionsApi.getById(id),
+    enabled: !!id,
+    errorMessage: "Failed to load collection",
+    ...options,
+  });
+}
+
+// Custom hook for creating a collection
+export function useCreateCollection(
+  options?: Omit<UseMutationOptions<Collection, Error, CollectionPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: collectionsApi.create,
+    onSuccess: (data, variables, context) => {
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.lists() });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to create collection",
+    successMessage: "Created collection successfully",
+    ...restOptions,
+  });
+}
+
+// Custom hook for replacing a collection
+export function useUpdateCollection(
+  options?: Omit<UseMutationOptions<Collection, Error, UpdateCollectionPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: collectionsApi.update,
+    onSuccess: (data, variables, context) => {
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.detail(variables.id) });
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.lists() });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to update collection",
+    successMessage: "Updated collection successfully",
+    ...restOptions,
+  });
+}
+
+// Custom hook for moving items of a collection
+export function useReorderCollection(
+  options?: Omit<UseMutationOptions<Collection, Error, ReorderCollectionPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: collectionsApi.reorder,
+    onSuccess: (data, variables, context) => {
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.detail(variables.id) });
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.lists() });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to reorder collection",
+    ...restOptions,
+  });
+}
+
+// Custom hook for deleting a collection
+export function useDeleteCollection(
+  options?: Omit<UseMutationOptions<void, Error, DeleteCollectionPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: collectionsApi.delete,
+    onSuccess: (data, variables, context) => {
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+      queryClient.removeQueries({ queryKey: collectionsKeys.detail(variables.id) });
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.lists() });
+    },
+    errorMessage: "Failed to delete collection",
+    successMessage: "Deleted collection successfully",
+    ...restOptions,
+  });
+}
@@ -0,0 +1,11 @@
+export { collectionsApi } from "./api";
+
+export {
+  collectionsKeys,
+  useCollection,
+  useCollections,
+  useCreateCollection,
+  useDeleteCollection,
+  useReorderCollection,
+  useUpdateCollection,
+} from "./hooks";
@@ -0,0 +1,12 @@
+import { httpClient } from "../httpClient";
+import { getProductFromUrl } from "../utils";
+
+import { type GetFieldsResponse } from "../../types";
+
+export const fieldsApi = {
+  // Get the custom field definitions of the product
+  getAll: async (options?: RequestInit): Promise<GetFieldsResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.get<GetFieldsResponse>(`/${product}/fields`, undefined, options);
+  },
+};
@@ -0,0 +1,25 @@
+import { type UseQueryOptions, type QueryKey } from "@tanstack/react-query";
+import { fieldsApi } from "./api";
+import { type GetFieldsResponse } from "../../types";
+import { useQueryWithFlash } from "../../hooks";
+
+// Query Keys
+export const fieldsKeys = {
+  all: ["fields"] as const,
+  lists: () => [...fieldsKeys.all, "list"] as const,
+} as const;
+
+// Custom hook for getting the custom field definitions
+export function useFields(
+  options?: Omit<UseQueryOptions<GetFieldsResponse, Error, GetFieldsResponse, QueryKey>, "queryKey" | "queryFn">
+) {
+  return useQueryWithFlash({
+    queryKey: fieldsKeys.lists(),
+    queryFn: () => fieldsApi.getAll(),
+    retry: false,
+    staleTime: Infinity,
+    refetchOnWindowFocus: false,
+    errorMessage: "Failed to load custom fields",
+    ...options,
+  });
+}
@@ -0,0 +1,3 @@
+export { fieldsApi } from "./api";
+
+export { useFields } from "./hooks";
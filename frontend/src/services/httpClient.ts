@@ -1,3 +1,20 @@
+import type { ErrorResponse, FieldError } from "../types";
+
+/** Error of a failed request, with the rejected fields if any */
+export class ApiError extends Error {
+  status: number;
+  code?: string;
+  fieldErrors: FieldError[];
+
+  constructor(status: number, data: Partial<ErrorResponse>) {
+    super(data.message || `HTTP error! status: ${status}`);
+    this.name = "ApiError";
+    this.status = status;
+    this.code = data.error;
+    this.fieldErrors = data.fieldErrors || [];
+  }
+}
+
 export class HttpClient {
   private baseURL: string;
   private defaultHeaders: Record<string, string>;
@@ -23,7 +40,7 @@ export class HttpClient {
 
       if (!response.ok) {
         const errorData = await response.json().catch(() => ({}));
-        throw new Error(errorData.message || `HTTP error! status: ${response.status}`);
+        throw new ApiError(response.status, errorData);
       }
 
       // Handle empty responses (like 204 No Content)
@@ -0,0 +1,10 @@
+import { httpClient } from "../httpClient";
+
+import { type GetProductsResponse } from "../../types";
+
+export const productsApi = {
+  // Get the products that are not archived, not scoped to the current product
+  getAll: async (options?: RequestInit): Promise<GetProductsResponse> => {
+    return httpClient.get<GetProductsResponse>("/products", undefined, options);
+  },
+};
@@ -0,0 +1,25 @@
+import { type UseQueryOptions, type QueryKey } from "@tanstack/react-query";
+import { productsApi } from "./api";
+import { type GetProductsResponse } from "../../types";
+import { useQueryWithFlash } from "../../hooks";
+
+// Query Keys
+export const productsKeys = {
+  all: ["products"] as const,
+  lists: () => [...productsKeys.all, "list"] as const,
+} as const;
+
+// Custom hook for getting the products of the registry
+export function useProducts(
+  options?: Omit<UseQueryOptions<GetProductsResponse, Error, GetProductsResponse, QueryKey>, "queryKey" | "queryFn">
+) {
+  return useQueryWithFlash({
+    queryKey: productsKeys.lists(),
+    queryFn: () => productsApi.getAll(),
+    retry: false,
+    staleTime: 60 * 1000,
+    refetchOnWindowFocus: false,
+    errorMessage: "Failed to load products",
+    ...options,
+  });
+}
@@ -0,0 +1,3 @@
+export { productsApi } from "./api";
+
+export { productsKeys, useProducts } from "./hooks";
@@ -0,0 +1,21 @@
+import { httpClient } from "../httpClient";
+import { getProductFromUrl } from "../utils";
+
+import { type GetMyProgressResponse, type Progress, type UpdateProgressPayload } from "../../types";
+
+const jsonHeaders = { "Content-Type": "application/json" };
+
+// Progress endpoints require the ID token of the signed in user, see httpClient.setAuthToken
+export const progressApi = {
+  // Get the progress of the signed in user through resources and collections
+  getMine: async (options?: RequestInit): Promise<GetMyProgressResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.get<GetMyProgressResponse>(`/${product}/me/progress`, undefined, options);
+  },
+
+  // Record the progress of the signed in user through a resource
+  update: async ({ resourceId, ...payload }: UpdateProgressPayload): Promise<Progress> => {
+    const product = getProductFromUrl();
+    return httpClient.put<Progress>(`/${product}/progress/${resourceId}`, payload, { headers: jsonHeaders });
+  },
+};
@@ -0,0 +1,44 @@
+import { useQueryClient, type UseQueryOptions, type UseMutationOptions, type QueryKey } from "@tanstack/react-query";
+
+import { progressApi } from "./api";
+import { type GetMyProgressResponse, type Progress, type UpdateProgressPayload } from "../../types";
+import { useMutationWithFlash, useQueryWithFlash } from "../../hooks";
+
+// Query Keys
+export const progressKeys = {
+  all: ["progress"] as const,
+  mine: () => [...progressKeys.all, "me"] as const,
+} as const;
+
+// Custom hook for getting the progress of the signed in user
+export function useMyProgress(
+  options?: Omit<UseQueryOptions<GetMyProgressResponse, Error, GetMyProgressResponse, QueryKey>, "queryKey" | "queryFn">
+) {
+  return useQueryWithFlash({
+    queryKey: progressKeys.mine(),
+    queryFn: () => progressApi.getMine(),
+    errorMessage: "Failed to load progress",
+    ...options,
+  });
+}
+
+// Custom hook for recording progress through a resource, without a success
+// message as players save positions as they go
+export function useUpdateProgress(
+  options?: Omit<UseMutationOptions<Progress, Error, UpdateProgressPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: progressApi.update,
+    onSuccess: (data, variables, context) => {
+      queryClient.invalidateQueries({ queryKey: progressKeys.mine() });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to save progress",
+    ...restOptions,
+  });
+}
@@ -0,0 +1,3 @@
+export { progressApi } from "./api";
+
+export { progressKeys, useMyProgress, useUpdateProgress } from "./hooks";
@@ -12,6 +12,12 @@ import {
   type UpdateResourcePayload,
   type UpdateResourceResponse,
   type DeleteResourcePayload,
+  type CopyResourcePayload,
+  type CopyResourceResponse,
+  type BulkResourcesPayload,
+  type BulkResourcesResponse,
+  type ImportResourcesCsvPayload,
+  type ImportResourcesCsvResponse,
 } from "../../types";
 
 const toFormData = (payload: Partial<CreateResourcePayload>): FormData => {
@@ -23,6 +29,7 @@ const toFormData = (payload: Partial<CreateResourcePayload>): FormData => {
   if (payload.tags) formData.append("tags", payload.tags);
   if (payload.url) formData.append("url", payload.url);
   if (payload.thumbnailUrl) formData.append("thumbnailUrl", payload.thumbnailUrl);
+  if (payload.customFields) formData.append("customFields", JSON.stringify(payload.customFields));
   if (payload.file) formData.append("file", payload.file);
   if (payload.thumbnail) formData.append("thumbnail", payload.thumbnail);
 
@@ -65,4 +72,41 @@ export const resourcesApi = {
     const product = getProductFromUrl();
     return httpClient.delete<void>(`/${product}/resources/${payload.id}`);
   },
+
+  // Copy resource to another product, as an admin or an owner of it signed in, see httpClient.setAuthToken
+  copy: async ({ id, product: target, adminKey }: CopyResourcePayload): Promise<CopyResourceResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.post<CopyResourceResponse>(`/${product}/resources/${id}/copy`, { product: target }, {
+      headers: { "Content-Type": "application/json", ...(adminKey ? { "X-Admin-Key": adminKey } : {}) },
+    });
+  },
+
+  // Move resource to another product
+  move: async ({ id, product: target, adminKey }: CopyResourcePayload): Promise<CopyResourceResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.post<CopyResourceResponse>(`/${product}/resources/${id}/move`, { product: target }, {
+      headers: { "Content-Type": "application/json", ...(adminKey ? { "X-Admin-Key": adminKey } : {}) },
+    });
+  },
+
+  // Run bulk operations, see BulkResult for per-operation failures
+  bulk: async (payload: BulkResourcesPayload): Promise<BulkResourcesResponse> => {
+    const product = getProductFromUrl();
+    return httpClient.post<BulkResourcesResponse>(`/${product}/resources/bulk`, payload, {
+      headers: { "Content-Type": "application/json" },
+    });
+  },
+
+  // Import resources from a CSV, see CsvImportResult for per-row failures
+  importCsv: async (payload: ImportResourcesCsvPayload): Promise<ImportResourcesCsvResponse> => {
+    const product = getProductFromUrl();
+    const query = payload.dryRun ? "?dryRun=true" : "";
+
+    const formData = new FormData();
+    formData.append("file", payload.file);
+
+    return httpClient.postFormData<ImportResourcesCsvResponse>(`/${product}/resources/import${query}`, {
+      body: formData,
+    });
+  },
 };
@@ -12,10 +12,19 @@ import {
   type UpdateResourcePayload,
   type UpdateResourceResponse,
   type DeleteResourcePayload,
+  type CopyResourcePayload,
+  type CopyResourceResponse,
+  type BulkResourcesPayload,
+  type BulkResourcesResponse,
+  type ImportResourcesCsvPayload,
+  type ImportResourcesCsvResponse,
 } from "../../types";
 
 import { useMutationWithFlash, useQueryWithFlash } from "../../hooks";
 import { tagsKeys } from "../tags/hooks";
+import { collectionsKeys } from "../collections/hooks";
+import { progressKeys } from "../progress/hooks";
+import { bookmarksKeys } from "../bookmarks/hooks";
 
 // Query Keys
 export const resourcesKeys = {
@@ -127,9 +136,105 @@ export function useDeleteResource(
       });
       queryClient.invalidateQueries({ queryKey: resourcesKeys.lists() });
       queryClient.invalidateQueries({ queryKey: tagsKeys.lists() });
+      // Deleted resources are removed from collections
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.all });
+      queryClient.invalidateQueries({ queryKey: progressKeys.all });
+      queryClient.invalidateQueries({ queryKey: bookmarksKeys.all });
     },
     errorMessage: "Failed to delete resource",
     successMessage: "Deleted resource successfully",
     ...restOptions,
   });
 }
+
+// Custom hook for copying a resource to another product
+export function useCopyResource(
+  options?: Omit<UseMutationOptions<CopyResourceResponse, Error, CopyResourcePayload, unknown>, "mutationFn">
+) {
+  return useMutationWithFlash({
+    mutationFn: resourcesApi.copy,
+    errorMessage: "Failed to copy resource",
+    successMessage: (data: CopyResourceResponse) => `Copied resource to ${data.product}`,
+    ...options,
+  });
+}
+
+// Custom hook for moving a resource to another product
+export function useMoveResource(
+  options?: Omit<UseMutationOptions<CopyResourceResponse, Error, CopyResourcePayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: resourcesApi.move,
+    onSuccess: (data, variables, context) => {
+      // The resource left this product
+      queryClient.removeQueries({ queryKey: resourcesKeys.detail(variables.id) });
+      queryClient.invalidateQueries({ queryKey: resourcesKeys.lists() });
+      queryClient.invalidateQueries({ queryKey: tagsKeys.lists() });
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.all });
+      queryClient.invalidateQueries({ queryKey: progressKeys.all });
+      queryClient.invalidateQueries({ queryKey: bookmarksKeys.all });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to move resource",
+    successMessage: (data: CopyResourceResponse) => `Moved resource to ${data.product}`,
+    ...restOptions,
+  });
+}
+
+// Custom hook for running bulk operations on resources
+export function useBulkResources(
+  options?: Omit<UseMutationOptions<BulkResourcesResponse, Error, BulkResourcesPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: resourcesApi.bulk,
+    onSuccess: (data, variables, context) => {
+      // Operations may have succeeded even if others failed
+      queryClient.invalidateQueries({ queryKey: resourcesKeys.all });
+      queryClient.invalidateQueries({ queryKey: tagsKeys.lists() });
+      queryClient.invalidateQueries({ queryKey: collectionsKeys.all });
+      queryClient.invalidateQueries({ queryKey: progressKeys.all });
+      queryClient.invalidateQueries({ queryKey: bookmarksKeys.all });
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to run bulk operations",
+    successMessage: (data: BulkResourcesResponse) =>
+      data.failed ? `${data.succeeded} operations succeeded, ${data.failed} failed` : `${data.succeeded} operations succeeded`,
+    ...restOptions,
+  });
+}
+
+// Custom hook for importing resources from a CSV
+export function useImportResourcesCsv(
+  options?: Omit<UseMutationOptions<ImportResourcesCsvResponse, Error, ImportResourcesCsvPayload, unknown>, "mutationFn">
+) {
+  const queryClient = useQueryClient();
+  const { onSuccess, ...restOptions } = options || {};
+
+  return useMutationWithFlash({
+    mutationFn: resourcesApi.importCsv,
+    onSuccess: (data, variables, context) => {
+      // Rows may have been imported even if others failed
+      if (!data.dryRun) {
+        queryClient.invalidateQueries({ queryKey: resourcesKeys.lists() });
+        queryClient.invalidateQueries({ queryKey: tagsKeys.lists() });
+      }
+
+      // Call user-provided onSuccess if exists
+      onSuccess?.(data, variables, context);
+    },
+    errorMessage: "Failed to import CSV",
+    successMessage: (data: ImportResourcesCsvResponse) =>
+      `${data.dryRun ? "Dry run: " : ""}${data.created} resources created, ${data.failed} rows failed`,
+    ...restOptions,
+  });
+}
@@ -1,3 +1,13 @@
 export { resourcesApi } from "./api";
 
-export { useCreateResource, useDeleteResource, useResource, useResources, useUpdateResource } from "./hooks";
+export {
+  useBulkResources,
+  useCopyResource,
+  useCreateResource,
+  useDeleteResource,
+  useImportResourcesCsv,
+  useMoveResource,
+  useResource,
+  useResources,
+  useUpdateResource,
+} from "./hooks";
@@ -1,12 +1,27 @@
+/** Why a request field was rejected */
+export type FieldError = {
+  /** e.g. "title", "tags.2" or "customFields.difficulty" */
+  field: string;
+  /** e.g. "required", "too_long" or "invalid_url", see the API contract */
+  code: string;
+  /** English fallback */
+  message: string;
+  /** Values the message refers to, e.g. { max: 200 } */
+  params?: Record<string, unknown>;
+};
+
 export type ErrorResponse = {
   error: string;
   message?: string;
+  fieldErrors?: FieldError[];
 };
 
 export type PaginatedResponse<T> = {
   data: T[];
   hasMore: boolean;
   nextCursor?: string;
+  /** Number of items of every page, when the endpoint counts them */
+  total?: number;
 };
 
 // Products
@@ -29,15 +44,98 @@ export type Product = (typeof VALID_PRODUCTS)[number];
 
 export const DEFAULT_PRODUCT: Product = VALID_PRODUCTS[0];
 
+/** Restricts what a product accepts in uploads and links, see UPLOAD_POLICY_FILE */
+export type UploadPolicy = {
+  /** Per resource type, "image" for thumbnails */
+  maxFileSizeMB?: Record<string, number>;
+  allowedMimeTypes?: Record<string, string[]>;
+  allowExternalUrls?: boolean;
+  pdfActiveContent?: "reject" | "sanitize";
+};
+
+/** A product of the registry, see GET /products */
+export type ProductDetails = {
+  name: Product;
+  displayName: string;
+  description?: string;
+  /** Emails of the people responsible for the product */
+  owners?: string[];
+  /** Suggested for new resources */
+  defaultTags?: string[];
+  uploadPolicy?: UploadPolicy;
+  archived: boolean;
+  archivedAt?: string;
+  createdAt: string;
+  updatedAt: string;
+};
+
+export type GetProductsResponse = ProductDetails[];
+
 // Resource
 export const RESOURCE_TYPES = {
   video: "video",
   pdf: "pdf",
   article: "article",
+  audio: "audio",
+  slides: "slides",
+  code: "code",
+  embed: "embed",
 } as const;
 
 export type ResourceType = (typeof RESOURCE_TYPES)[keyof typeof RESOURCE_TYPES];
 
+/** Resource types backed by a stored file, uploaded or linked */
+export const FILE_RESOURCE_TYPES: ResourceType[] = [
+  RESOURCE_TYPES.video,
+  RESOURCE_TYPES.pdf,
+  RESOURCE_TYPES.audio,
+  RESOURCE_TYPES.slides,
+  RESOURCE_TYPES.code,
+];
+
+/** Describes an uploaded file; fields that do not apply to its type are omitted */
+export type FileMetadata = {
+  /** Bytes */
+  size: number;
+  mimeType: string;
+  sha256?: string;
+  /** Seconds, videos and audio */
+  duration?: number;
+  /** Pixels as displayed, videos and images */
+  width?: number;
+  height?: number;
+  videoCodec?: string;
+  audioCodec?: string;
+  /** PDFs */
+  pages?: number;
+  title?: string;
+  author?: string;
+  /** Code archives */
+  archive?: {
+    files: number;
+    /** Bytes */
+    uncompressedSize: number;
+    /** The first files of the archive */
+    entries: { path: string; size: number }[];
+    /** More files than entries */
+    truncated?: boolean;
+  };
+};
+
+/** Player of an embedded YouTube, Vimeo or Loom video */
+export type Embed = {
+  provider: "youtube" | "vimeo" | "loom";
+  /** iframe source, always on the provider's player host */
+  embedUrl: string;
+  title?: string;
+  authorName?: string;
+  thumbnailUrl?: string;
+  width?: number;
+  height?: number;
+  /** Seconds, when the provider reports it */
+  duration?: number;
+};
+
 export type Resource = {
   id: string;
   title: string;
@@ -45,7 +143,39 @@ export type Resource = {
   type: ResourceType;
   url: string;
   thumbnailUrl?: string;
+  /** Responsive variants of the thumbnail, keyed by "<width>.<format>" (e.g. "320.webp") */
+  thumbnails?: Record<string, string>;
+  /** HLS transcoding of an uploaded video */
+  transcode?: {
+    status: "pending" | "processing" | "ready" | "failed";
+    error?: string;
+    updatedAt: string;
+  };
+  /** Tokenized HLS master playlist, relative to the API host, once transcoding is ready */
+  streamUrl?: string;
+  /** PDF conversion of an uploaded slide deck */
+  conversion?: {
+    status: "pending" | "processing" | "ready" | "failed";
+    error?: string;
+    updatedAt: string;
+  };
+  /** Signed URL of the converted PDF, once conversion is ready */
+  previewUrl?: string;
+  /** Player of an embed */
+  embed?: Embed;
+  /** Background jobs started by a create or update, keyed by job type */
+  jobs?: Record<string, string>;
+  /** Uploaded file, absent for linked URLs */
+  metadata?: FileMetadata;
+  /** Uploaded or generated thumbnail */
+  thumbnailMetadata?: FileMetadata;
+  /** Create and update responses only, e.g. when the uploaded file already backs another resource */
+  warnings?: { code: "DUPLICATE_FILE"; message: string; resourceId?: string }[];
   tags: string[];
+  /** Values of the product's custom fields, keyed by field name */
+  customFields?: Record<string, CustomFieldValue>;
+  /** Whether the signed in user bookmarked it, signed in requests only */
+  bookmarked?: boolean;
   createdAt: string;
   updatedAt: string;
 };
@@ -74,6 +204,8 @@ export type CreateResourcePayload = {
   tags: string;
   url?: string;
   thumbnailUrl?: string;
+  /** JSON object of custom field values; on update, null unsets a field */
+  customFields?: Record<string, CustomFieldValue | null>;
   file?: File;
   thumbnail?: File;
 };
@@ -86,6 +218,89 @@ export type UpdateResourceResponse = Resource;
 
 export type DeleteResourcePayload = Pick<Resource, "id">;
 
+// Copy or move to another product
+export type CopyResourcePayload = Pick<Resource, "id"> & {
+  /** Target product */
+  product: string;
+  /** Key of the admin endpoints, sent as X-Admin-Key; owners of the target product send their ID token instead */
+  adminKey?: string;
+};
+
+export type CopyResourceResponse = {
+  /** Product copied from */
+  source: string;
+  sourceId: string;
+  /** Product copied to */
+  product: string;
+  /** Of the copy, the source ID unless the target product uses it */
+  id: string;
+  /** Stored files copied */
+  files: number;
+  bytes: number;
+  /** What was left out, e.g. unfinished transcoding */
+  warnings?: string[];
+};
+
+// Bulk operations
+export type BulkOperation =
+  | { op: "addTags" | "removeTags"; id: string; tags: string[] }
+  | {
+      op: "update";
+      id: string;
+      fields: Partial<Pick<Resource, "title" | "description">> & {
+        /** Merged into the existing values, null unsets a field */
+        customFields?: Record<string, CustomFieldValue | null>;
+      };
+    }
+  | { op: "delete"; id: string };
+
+export type BulkResourcesPayload = {
+  operations: BulkOperation[];
+};
+
+export type BulkResult = {
+  /** Of the operation in the request */
+  index: number;
+  id: string;
+  op: BulkOperation["op"];
+  status: "succeeded" | "failed";
+  /** Field errors name fields of the resource */
+  error?: ErrorResponse;
+};
+
+export type BulkResourcesResponse = {
+  results: BulkResult[];
+  succeeded: number;
+  failed: number;
+};
+
+// CSV import of article and embed resources
+export type ImportResourcesCsvPayload = {
+  /** CSV with title, description and url columns, optionally type, tags and custom fields */
+  file: File;
+  /** Report what would be created without writing anything */
+  dryRun?: boolean;
+};
+
+export type CsvImportResult = {
+  /** Line of the row in the CSV, the header being line 1 */
+  row: number;
+  /** Created resources */
+  id?: string;
+  title: string;
+  status: "succeeded" | "failed";
+  /** Field errors name fields of the resource */
+  error?: ErrorResponse;
+};
+
+export type ImportResourcesCsvResponse = {
+  results: CsvImportResult[];
+  /** Or would be, in a dry run */
+  created: number;
+  failed: number;
+  dryRun: boolean;
+};
+
 // Tag
 export type Tag = {
   name: string;
@@ -93,3 +308,176 @@ export type Tag = {
 };
 
 export type GetTagsResponse = Tag[];
+
+// Custom fields
+export const FIELD_TYPES = {
+  string: "string",
+  number: "number",
+  boolean: "boolean",
+  enum: "enum",
+} as const;
+
+export type FieldType = (typeof FIELD_TYPES)[keyof typeof FIELD_TYPES];
+
+export type CustomFieldValue = string | number | boolean;
+
+export type FieldDefinition = {
+  name: string;
+  /** Shown in forms instead of the name */
+  label?: string;
+  /** Help text */
+  description?: string;
+  type: FieldType;
+  /** Whether every created resource must set it */
+  required: boolean;
+  /** Allowed values of enum fields */
+  values?: string[];
+};
+
+export type GetFieldsResponse = FieldDefinition[];
+
+// Collections (learning paths)
+export type CollectionItem = {
+  /** Set for resources */
+  resourceId?: string;
+  /** Set for section headings, which group the resources following them */
+  heading?: string;
+  /** Set by the detail endpoint */
+  resource?: Resource;
+};
+
+export type Collection = {
+  id: string;
+  title: string;
+  description: string;
+  coverImageUrl?: string;
+  items: CollectionItem[];
+  createdAt: string;
+  updatedAt: string;
+};
+
+export type GetCollectionsResponse = Collection[];
+
+export type CollectionPayload = {
+  title: string;
+  description?: string;
+  coverImageUrl?: string;
+  items?: Pick<CollectionItem, "resourceId" | "heading">[];
+};
+
+export type UpdateCollectionPayload = Pick<Collection, "id"> & CollectionPayload;
+
+export type CollectionMove = {
+  /** Index of the item to move */
+  from: number;
+  /** Index it is inserted at, once taken out of the list */
+  to: number;
+};
+
+export type ReorderCollectionPayload = Pick<Collection, "id"> & {
+  /** Applied in order */
+  moves: CollectionMove[];
+};
+
+export type DeleteCollectionPayload = Pick<Collection, "id">;
+
+// Learning progress of the signed in user
+export type ProgressStatus = "started" | "completed";
+
+export type Progress = {
+  resourceId: string;
+  status: ProgressStatus;
+  /** Playback position in seconds, videos, audio and embeds */
+  position?: number;
+  /** Last page read, PDFs and slide decks */
+  page?: number;
+  startedAt: string;
+  /** Until the resource is started again */
+  completedAt?: string;
+  updatedAt: string;
+};
+
+export type CollectionProgress = {
+  collectionId: string;
+  title: string;
+  /** Resources completed */
+  completed: number;
+  /** Resources of the collection */
+  total: number;
+  /** Of the resources completed, rounded down */
+  percentage: number;
+};
+
+export type GetMyProgressResponse = {
+  /** Most recently updated first */
+  resources: Progress[];
+  /** Collections listing a resource started or completed */
+  collections: CollectionProgress[];
+};
+
+/** Members left out are kept */
+export type UpdateProgressPayload = {
+  resourceId: string;
+  status?: ProgressStatus;
+  position?: number;
+  page?: number;
+};
+
+// Bookmarks of the signed in user
+export type Bookmark = {
+  resourceId: string;
+  createdAt: string;
+};
+
+export type GetMyBookmarksParams = {
+  limit?: string;
+  cursor?: string;
+};
+
+/** Most recently bookmarked first, with the number of bookmarks in total */
+export type GetMyBookmarksResponse = PaginatedResponse<Resource>;
+
+export type BookmarkPayload = Pick<Bookmark, "resourceId">;
+
+// Catalog import/export
+export type ImportStrategy = "skip" | "overwrite" | "duplicate";
+
+export type ImportCatalogPayload = {
+  /** Zip archive made by the catalog export */
+  archive: File;
+  /** What to do with resources whose ID exists, skip by default */
+  strategy?: ImportStrategy;
+  /** Report what the import would do without writing anything */
+  dryRun?: boolean;
+  /** Key of the admin endpoints, sent as X-Admin-Key */
+  adminKey: string;
+};
+
+export type ImportedResource = {
+  /** In the archive */
+  id: string;
+  /** Of a duplicate, unless in a dry run */
+  newId?: string;
+  action: "created" | "overwritten" | "duplicated" | "skipped" | "failed";
+  error?: string;
+  warnings?: string[];
+};
+
+export type ImportCatalogResponse = {
+  product: string;
+  /** Product the archive was exported from */
+  source: string;
+  strategy: ImportStrategy;
+  dryRun: boolean;
+  resources: ImportedResource[];
+  created: number;
+  overwritten: number;
+  duplicated: number;
+  skipped: number;
+  failed: number;
+  /** Custom field definitions created or replaced */
+  fields: number;
+  /** Stored files written, or missing from storage in a dry run */
+  files: number;
+  bytes: number;
+};